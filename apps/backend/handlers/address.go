@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/application"
+	"backend/internal/domain/address"
+	"backend/internal/domain/user"
+	"backend/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type AddressHandler struct {
+	UC *application.OrderService
+}
+
+func NewAddressHandler(uc *application.OrderService) *AddressHandler {
+	return &AddressHandler{UC: uc}
+}
+
+// CreateAddress godoc
+// @Summary Save an address
+// @Security BearerAuth
+// @Description Adds a labelled delivery address with its coordinates to a user's address book. Send the address text, the coordinates or both; the missing part is looked up. The first address, or one sent with is_default, becomes the default. Users manage their own address book; admins any.
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address body address.CreateAddressRequest true "Address"
+// @Success 201 {object} address.AddressDoc
+// @Failure 400 {object} handlers.ErrorResponse "Invalid address"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not your address book"
+// @Failure 422 {object} handlers.ErrorResponse "Address could not be found on the map"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/{id}/addresses [post]
+func (h *AddressHandler) CreateAddress(w http.ResponseWriter, r *http.Request) {
+	callerID, isAdmin, userID, ok := addressScope(w, r)
+	if !ok {
+		return
+	}
+
+	var req address.CreateAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	a := req.ToAddress(userID)
+	if err := h.UC.Addresses.UseCase.CreateAddress(r.Context(), callerID, isAdmin, a); err != nil {
+		writeAddressError(w, err, "Could not save address")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, a)
+}
+
+// ListAddresses godoc
+// @Summary List saved addresses
+// @Security BearerAuth
+// @Description Returns a user's address book, default first
+// @Tags addresses
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {array} address.AddressDoc
+// @Failure 400 {object} handlers.ErrorResponse "Invalid user ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not your address book"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/{id}/addresses [get]
+func (h *AddressHandler) ListAddresses(w http.ResponseWriter, r *http.Request) {
+	callerID, isAdmin, userID, ok := addressScope(w, r)
+	if !ok {
+		return
+	}
+
+	addresses, err := h.UC.Addresses.UseCase.ListAddresses(r.Context(), callerID, isAdmin, userID)
+	if err != nil {
+		writeAddressError(w, err, "Could not fetch addresses")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, addresses)
+}
+
+// GetAddress godoc
+// @Summary Get a saved address
+// @Security BearerAuth
+// @Tags addresses
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Success 200 {object} address.AddressDoc
+// @Failure 400 {object} handlers.ErrorResponse "Invalid ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not your address book"
+// @Failure 404 {object} handlers.ErrorResponse "Address not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/{id}/addresses/{address_id} [get]
+func (h *AddressHandler) GetAddress(w http.ResponseWriter, r *http.Request) {
+	callerID, isAdmin, userID, ok := addressScope(w, r)
+	if !ok {
+		return
+	}
+	addressID, err := uuid.Parse(chi.URLParam(r, "address_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid address ID", err)
+		return
+	}
+
+	a, err := h.UC.Addresses.UseCase.GetAddress(r.Context(), callerID, isAdmin, userID, addressID)
+	if err != nil {
+		writeAddressError(w, err, "Could not fetch address")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a)
+}
+
+// UpdateAddress godoc
+// @Summary Update a saved address
+// @Security BearerAuth
+// @Description Changes the fields that are sent; lat and lng go together. Sending is_default true makes this the default address.
+// @Tags addresses
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Param address body address.UpdateAddressRequest true "Changes"
+// @Success 200 {object} address.AddressDoc
+// @Failure 400 {object} handlers.ErrorResponse "Invalid address"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not your address book"
+// @Failure 404 {object} handlers.ErrorResponse "Address not found"
+// @Failure 422 {object} handlers.ErrorResponse "Address could not be found on the map"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/{id}/addresses/{address_id} [patch]
+func (h *AddressHandler) UpdateAddress(w http.ResponseWriter, r *http.Request) {
+	callerID, isAdmin, userID, ok := addressScope(w, r)
+	if !ok {
+		return
+	}
+	addressID, err := uuid.Parse(chi.URLParam(r, "address_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid address ID", err)
+		return
+	}
+
+	var req address.UpdateAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	a, err := h.UC.Addresses.UseCase.UpdateAddress(r.Context(), callerID, isAdmin, userID, addressID, &req)
+	if err != nil {
+		writeAddressError(w, err, "Could not update address")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a)
+}
+
+// DeleteAddress godoc
+// @Summary Delete a saved address
+// @Security BearerAuth
+// @Description Removes an address. Orders placed to it keep their copy of the address. If it was the default, the most recently added remaining address becomes the default.
+// @Tags addresses
+// @Produce json
+// @Param id path string true "User ID"
+// @Param address_id path string true "Address ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} handlers.ErrorResponse "Invalid ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not your address book"
+// @Failure 404 {object} handlers.ErrorResponse "Address not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/{id}/addresses/{address_id} [delete]
+func (h *AddressHandler) DeleteAddress(w http.ResponseWriter, r *http.Request) {
+	callerID, isAdmin, userID, ok := addressScope(w, r)
+	if !ok {
+		return
+	}
+	addressID, err := uuid.Parse(chi.URLParam(r, "address_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid address ID", err)
+		return
+	}
+
+	if err := h.UC.Addresses.UseCase.DeleteAddress(r.Context(), callerID, isAdmin, userID, addressID); err != nil {
+		writeAddressError(w, err, "Could not delete address")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "address deleted"})
+}
+
+// addressScope reads the caller and the user whose address book is
+// addressed, writing the error response if either is missing.
+func addressScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool, uuid.UUID, bool) {
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return uuid.Nil, false, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID", err)
+		return uuid.Nil, false, uuid.Nil, false
+	}
+
+	return callerID, role == string(user.Admin), userID, true
+}
+
+func writeAddressError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, address.ErrorInvalidLabel),
+		errors.Is(err, address.ErrorInvalidAddress),
+		errors.Is(err, address.ErrorInvalidCoordinates):
+		writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, address.ErrorLocationNotFound):
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error(), err)
+	case errors.Is(err, address.ErrorForbidden):
+		writeJSONError(w, http.StatusForbidden, "Not allowed to manage this user's addresses", err)
+	case errors.Is(err, address.ErrorAddressNotFound):
+		writeJSONError(w, http.StatusNotFound, "Address not found", err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
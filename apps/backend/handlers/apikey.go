@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"backend/internal/domain/apikey"
+	"backend/internal/domain/user"
+	"backend/internal/middleware"
+	usecase "backend/internal/usecase/apikey"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	UC *usecase.UseCase
+}
+
+func NewAPIKeyHandler(uc *usecase.UseCase) *APIKeyHandler {
+	return &APIKeyHandler{UC: uc}
+}
+
+// writeAPIKeyError maps API key errors to responses.
+func writeAPIKeyError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, apikey.ErrorKeyNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, apikey.ErrorNotMerchant),
+		errors.Is(err, apikey.ErrorStoreNotAllowed):
+		writeJSONError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, apikey.ErrorMissingName),
+		errors.Is(err, apikey.ErrorNoScopes),
+		errors.Is(err, apikey.ErrorInvalidScope):
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Issues a key for the merchant's own systems, e.g. a POS syncing the catalog. Send it in the X-API-Key header. The key is returned only in this response. Scopes: stores:read, catalog:read, catalog:write, orders:read, orders:write. A key with store_id only acts on that store.
+// @Tags API keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body apikey.CreateAPIKeyRequest true "Key name, scopes and optional store"
+// @Success 201 {object} apikey.APIKey
+// @Failure 400 {object} handlers.ErrorResponse "Missing name or invalid scopes"
+// @Failure 403 {object} handlers.ErrorResponse "Not staff of this store"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /api-keys/create [post]
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	var req apikey.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid request body", err)
+		return
+	}
+
+	k := req.ToAPIKey(callerID)
+
+	if err := h.UC.CreateKey(r.Context(), user.Role(role), k); err != nil {
+		writeAPIKeyError(w, err, "Failed to create api key")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, k)
+}
+
+// ListAPIKeys godoc
+// @Summary List my API keys
+// @Description Returns the caller's API keys, newest first, including revoked ones. Secrets are never returned again.
+// @Tags API keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} apikey.APIKey
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /api-keys/me [get]
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	keys, err := h.UC.ListKeys(r.Context(), callerID)
+	if err != nil {
+		writeAPIKeyError(w, err, "Failed to list api keys")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Stops one of the caller's API keys from working, keeping it on record.
+// @Tags API keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} handlers.ErrorResponse "Invalid key ID"
+// @Failure 404 {object} handlers.ErrorResponse "Key not found or already revoked"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /api-keys/{id}/revoke [post]
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	keyID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	if err := h.UC.RevokeKey(r.Context(), callerID, keyID); err != nil {
+		writeAPIKeyError(w, err, "Failed to revoke api key")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "API key revoked"})
+}
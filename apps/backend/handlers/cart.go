@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/application"
+	"backend/internal/domain/address"
+	"backend/internal/domain/cart"
+	"backend/internal/domain/geocoding"
+	"backend/internal/domain/order"
+	"backend/internal/domain/pricing"
+	"backend/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type CartHandler struct {
+	UC *application.OrderService
+}
+
+func NewCartHandler(uc *application.OrderService) *CartHandler {
+	return &CartHandler{UC: uc}
+}
+
+// ListCarts godoc
+// @Summary List my carts
+// @Security BearerAuth
+// @Description Returns every cart of the calling customer, one per store, priced against the current catalog
+// @Tags carts
+// @Produce json
+// @Success 200 {array} cart.View
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/me [get]
+func (h *CartHandler) ListCarts(w http.ResponseWriter, r *http.Request) {
+	customerID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	carts, err := h.UC.Carts.UseCase.ListCarts(r.Context(), customerID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not fetch carts", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, carts)
+}
+
+// GetCart godoc
+// @Summary Get my cart for a store
+// @Security BearerAuth
+// @Description Returns the calling customer's cart for a store. Lines are re-priced from the catalog on every read; lines short of stock are flagged out_of_stock and lines whose product or variant is gone are flagged unavailable.
+// @Tags carts
+// @Produce json
+// @Param store_id path string true "Store ID"
+// @Success 200 {object} cart.View
+// @Failure 400 {object} handlers.ErrorResponse "Invalid store ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/{store_id} [get]
+func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
+	customerID, storeID, ok := cartScope(w, r)
+	if !ok {
+		return
+	}
+
+	v, err := h.UC.Carts.UseCase.GetCart(r.Context(), customerID, storeID)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not fetch cart", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+// AddCartItem godoc
+// @Summary Add an item to my cart
+// @Security BearerAuth
+// @Description Adds a product (or variant) to the calling customer's cart for the store. Adding an item already in the cart adds to its quantity.
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param store_id path string true "Store ID"
+// @Param item body cart.AddItemRequest true "Item"
+// @Success 200 {object} cart.View
+// @Failure 400 {object} handlers.ErrorResponse "Invalid item"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Product not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/{store_id}/items [post]
+func (h *CartHandler) AddCartItem(w http.ResponseWriter, r *http.Request) {
+	customerID, storeID, ok := cartScope(w, r)
+	if !ok {
+		return
+	}
+
+	var req cart.AddItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	v, err := h.UC.Carts.UseCase.AddItem(r.Context(), customerID, storeID, req)
+	if err != nil {
+		writeCartError(w, err, "Could not add item to cart")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+// UpdateCartItem godoc
+// @Summary Change the quantity of a cart item
+// @Security BearerAuth
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param store_id path string true "Store ID"
+// @Param item_id path string true "Cart item ID"
+// @Param item body cart.UpdateItemRequest true "Quantity"
+// @Success 200 {object} cart.View
+// @Failure 400 {object} handlers.ErrorResponse "Invalid quantity"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Cart item not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/{store_id}/items/{item_id} [put]
+func (h *CartHandler) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	customerID, storeID, ok := cartScope(w, r)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(chi.URLParam(r, "item_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid cart item ID", err)
+		return
+	}
+
+	var req cart.UpdateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	v, err := h.UC.Carts.UseCase.UpdateItem(r.Context(), customerID, storeID, itemID, req.Quantity)
+	if err != nil {
+		writeCartError(w, err, "Could not update cart item")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+// RemoveCartItem godoc
+// @Summary Remove an item from my cart
+// @Security BearerAuth
+// @Tags carts
+// @Produce json
+// @Param store_id path string true "Store ID"
+// @Param item_id path string true "Cart item ID"
+// @Success 200 {object} cart.View
+// @Failure 400 {object} handlers.ErrorResponse "Invalid cart item ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Cart item not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/{store_id}/items/{item_id} [delete]
+func (h *CartHandler) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	customerID, storeID, ok := cartScope(w, r)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(chi.URLParam(r, "item_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid cart item ID", err)
+		return
+	}
+
+	v, err := h.UC.Carts.UseCase.RemoveItem(r.Context(), customerID, storeID, itemID)
+	if err != nil {
+		writeCartError(w, err, "Could not remove cart item")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, v)
+}
+
+// ClearCart godoc
+// @Summary Empty my cart for a store
+// @Security BearerAuth
+// @Tags carts
+// @Produce json
+// @Param store_id path string true "Store ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} handlers.ErrorResponse "Invalid store ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/{store_id} [delete]
+func (h *CartHandler) ClearCart(w http.ResponseWriter, r *http.Request) {
+	customerID, storeID, ok := cartScope(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.UC.Carts.UseCase.ClearCart(r.Context(), customerID, storeID); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not clear cart", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "cart cleared"})
+}
+
+// CheckoutCart godoc
+// @Summary Check out my cart
+// @Security BearerAuth
+// @Description Places an order for every line of the calling customer's cart for the store and empties the cart. Carts with out-of-stock or unavailable lines are refused; fix or remove those lines first. Send an Idempotency-Key header to make retries safe.
+// @Tags carts
+// @Accept json
+// @Produce json
+// @Param store_id path string true "Store ID"
+// @Param Idempotency-Key header string false "Unique key for safely retrying the request"
+// @Param checkout body cart.CheckoutRequest true "Saved address_id or inline delivery address, and optional promo code"
+// @Success 201 {object} order.OrderDoc "Created order"
+// @Failure 400 {object} handlers.ErrorResponse "Cart is empty"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Saved address not found"
+// @Failure 409 {object} handlers.ErrorResponse "Cart has out-of-stock or unavailable items"
+// @Failure 422 {object} handlers.ErrorResponse "Address not on the map, delivery out of range or promo code rejected"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /carts/{store_id}/checkout [post]
+func (h *CartHandler) CheckoutCart(w http.ResponseWriter, r *http.Request) {
+	customerID, storeID, ok := cartScope(w, r)
+	if !ok {
+		return
+	}
+
+	var req cart.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	o, err := h.UC.Carts.UseCase.Checkout(r.Context(), customerID, storeID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, cart.ErrorEmptyCart):
+			writeJSONError(w, http.StatusBadRequest, "Cart is empty", err)
+		case errors.Is(err, order.ErrorInvalidLocation):
+			writeJSONError(w, http.StatusBadRequest, "Invalid pickup or delivery coordinates", err)
+		case errors.Is(err, address.ErrorAddressNotFound):
+			writeJSONError(w, http.StatusNotFound, "Saved address not found", err)
+		case errors.Is(err, geocoding.ErrorNoMatch):
+			writeJSONError(w, http.StatusUnprocessableEntity, "Address could not be found on the map", err)
+		case errors.Is(err, cart.ErrorCartNotOrderable), errors.Is(err, order.ErrorOutOfStock):
+			writeJSONError(w, http.StatusConflict, "Cart has out-of-stock or unavailable items", err)
+		case errors.Is(err, pricing.ErrorOutOfDeliveryRange):
+			writeJSONError(w, http.StatusUnprocessableEntity, "Delivery address is out of range", err)
+		case errors.Is(err, pricing.ErrorNoTariff):
+			writeJSONError(w, http.StatusUnprocessableEntity, "No delivery tariff configured", err)
+		case isPromotionRejection(err):
+			writeJSONError(w, http.StatusUnprocessableEntity, err.Error(), err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Checkout failed", err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, o)
+}
+
+// cartScope reads the calling customer and the store of the cart, writing
+// the error response if either is missing.
+func cartScope(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	customerID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	storeID, err := uuid.Parse(chi.URLParam(r, "store_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid store ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return customerID, storeID, true
+}
+
+func writeCartError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, cart.ErrorInvalidQuantity),
+		errors.Is(err, cart.ErrorProductNotInStore),
+		errors.Is(err, cart.ErrorVariantRequired),
+		errors.Is(err, cart.ErrorVariantNotAllowed),
+		errors.Is(err, cart.ErrorVariantNotFound):
+		writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, cart.ErrorItemNotFound), errors.Is(err, sql.ErrNoRows):
+		writeJSONError(w, http.StatusNotFound, "Not found", err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
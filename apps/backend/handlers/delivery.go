@@ -3,13 +3,13 @@ package handlers
 import (
 	"backend/internal/application"
 	"backend/internal/domain/delivery"
+	"backend/internal/domain/order"
 	middleware "backend/internal/middleware"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"strings"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -54,17 +54,17 @@ func (h *DeliveryHandler) GetDeliveryByID(w http.ResponseWriter, r *http.Request
 // UpdateDelivery godoc
 // @Summary Update Delivery
 // @Security BearerAuth
-// @Description Update any delivery struct field of an existing delivery
+// @Description Corrects the assigned, picked up and/or delivered time of an existing delivery. A time can only be corrected once it is set, and they must stay in that order. Other fields are refused, the status only changes through /deliveries/{id}/status.
 // @Tags deliveries
 // @Accept json
 // @Produce json
 // @Param delivery_id path string true "Delivery ID"
-// @Param update body delivery.UpdateDeliveryRequest true "Field and value to update"
+// @Param update body delivery.UpdateDeliveryRequest true "Timestamps to correct"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} handlers.ErrorResponse "Invalid delivery ID or request body"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid delivery ID, request body or field"
 // @Failure 404 {object} handlers.ErrorResponse "Not found"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
-// @Router /deliveries/{id}/update [put]
+// @Router /deliveries/{id}/update [patch]
 func (h *DeliveryHandler) UpdateDelivery(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	deliveryID, err := uuid.Parse(idStr)
@@ -74,25 +74,86 @@ func (h *DeliveryHandler) UpdateDelivery(w http.ResponseWriter, r *http.Request)
 	}
 
 	var req delivery.UpdateDeliveryRequest
+	if err := decodePatch(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := h.UC.Deliveries.UseCase.UpdateDelivery(r.Context(), deliveryID, &req); err != nil {
+		switch {
+		case errors.Is(err, delivery.ErrorStatusNotUpdatable):
+			writeJSONError(w, http.StatusBadRequest, "Use /deliveries/{id}/status to change the delivery status", err)
+		case errors.Is(err, delivery.ErrorNothingToUpdate), errors.Is(err, delivery.ErrorInvalidTimestamps):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, sql.ErrNoRows):
+			writeJSONError(w, http.StatusNotFound, "Not found", err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Failed to update delivery", err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "delivery updated successfully",
+	})
+}
+
+// UpdateDeliveryStatus godoc
+// @Summary Change delivery status
+// @Security BearerAuth
+// @Description Marks a delivery picked_up, delivered or failed. The change goes through the order state machine, so the order status, driver availability and delivery timestamps move together. Drivers may only update their own deliveries.
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param id path string true "Delivery ID"
+// @Param status body delivery.UpdateDeliveryStatusRequest true "Target status"
+// @Success 200 {object} delivery.Delivery "Updated delivery"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid delivery ID or status"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Transition not allowed for caller"
+// @Failure 404 {object} handlers.ErrorResponse "Delivery not found"
+// @Failure 409 {object} handlers.ErrorResponse "Illegal status transition"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /deliveries/{id}/status [put]
+func (h *DeliveryHandler) UpdateDeliveryStatus(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid delivery ID", nil)
+		return
+	}
+
+	var req delivery.UpdateDeliveryStatusRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	column := strings.TrimSpace(strings.ToLower(req.Column))
-	if column == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing or invalid column name", err)
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
 		return
 	}
 
-	if err := h.UC.Deliveries.UseCase.UpdateDelivery(r.Context(), deliveryID, column, req.Value); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to update delivery", err)
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to change delivery status", err)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{
-		"message": fmt.Sprintf("delivery %s updated successfully", column),
-	})
+	d, err := h.UC.Deliveries.UseCase.UpdateDeliveryStatus(r.Context(), deliveryID, callerID, actor, req.Status)
+	if err != nil {
+		switch {
+		case errors.Is(err, delivery.ErrorInvalidStatus):
+			writeJSONError(w, http.StatusBadRequest, "Invalid delivery status", err)
+		case errors.Is(err, delivery.ErrorNotAssignedDriver):
+			writeJSONError(w, http.StatusForbidden, "Delivery is assigned to another driver", err)
+		default:
+			writeTransitionError(w, err, "Failed to update delivery status")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
 }
 
 // ListDeliveries godoc
@@ -145,26 +206,27 @@ func (h *DeliveryHandler) DeleteDelivery(w http.ResponseWriter, r *http.Request)
 
 // AcceptDelivery godoc
 // @Summary Accept order assignment and create delivery
-// @Description When a driver accepts an order assignment, this endpoint creates the delivery record, marks the order as in-transit, sets the pickup timestamp, and marks the driver as unavailable. Only callable by authenticated drivers.
+// @Description When a driver accepts an order assignment, this endpoint creates the delivery record, moves the order from assigned to in_transit, sets the pickup timestamp, and marks the driver as unavailable. Only callable by authenticated drivers.
 // @Tags deliveries
 // @Security BearerAuth
 // @Produce json
-// @Param id path string true "Delivery ID (corresponds to the order assignment)"
+// @Param id path string true "Order ID of the assignment"
 // @Success 200 {object} delivery.Delivery "Created and accepted delivery"
-// @Failure 400 {object} handlers.ErrorResponse "Missing or invalid delivery ID"
+// @Failure 400 {object} handlers.ErrorResponse "Missing or invalid order ID"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 409 {object} handlers.ErrorResponse "Order is not awaiting pickup"
 // @Failure 500 {object} handlers.ErrorResponse "Failed to accept delivery"
 // @Router /deliveries/{id}/accept [put]
 func (h *DeliveryHandler) AcceptDelivery(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	if idStr == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing delivery ID", nil)
+		writeJSONError(w, http.StatusBadRequest, "Missing order ID", nil)
 		return
 	}
 
-	deliveryID, err := uuid.Parse(idStr)
+	orderID, err := uuid.Parse(idStr)
 	if err != nil {
-		writeJSONError(w, http.StatusBadRequest, "Invalid delivery ID", err)
+		writeJSONError(w, http.StatusBadRequest, "Invalid order ID", err)
 		return
 	}
 
@@ -175,24 +237,17 @@ func (h *DeliveryHandler) AcceptDelivery(w http.ResponseWriter, r *http.Request)
 	}
 
 	d := &delivery.Delivery{
-		ID:         deliveryID,
-		DriverID:   driverID,
-		PickedUpAt: ptrTime(time.Now()),
-		Status:     delivery.PickedUp,
+		OrderID:  orderID,
+		DriverID: driverID,
 	}
 
-	log.Printf("delivery d: %+v", d)
 	if err := h.UC.Deliveries.UseCase.AcceptDelivery(r.Context(), d); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to accept delivery", err)
+		writeTransitionError(w, err, "Failed to accept delivery")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]any{
-		"message":  fmt.Sprintf("delivery %s accepted", deliveryID),
+		"message":  fmt.Sprintf("delivery %s accepted", d.ID),
 		"delivery": d,
 	})
 }
-
-func ptrTime(t time.Time) *time.Time {
-	return &t
-}
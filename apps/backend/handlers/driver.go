@@ -3,7 +3,9 @@ package handlers
 import (
 	"backend/internal/application"
 	"backend/internal/domain/driver"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -28,7 +30,7 @@ func NewDriverHandler(uc *application.OrderService) *DriverHandler {
 // @Accept json
 // @Produce json
 // @Param driver_id path string true "Driver ID"
-// @Param body body driver.UpdateDriverRequest true "Driver profile fields to update"
+// @Param body body driver.UpdateDriverProfileRequest true "Driver profile fields to update"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} handlers.ErrorResponse
 // @Failure 404 {object} handlers.ErrorResponse
@@ -57,18 +59,19 @@ func (h *DriverHandler) UpdateDriverProfile(w http.ResponseWriter, r *http.Reque
 }
 
 // UpdateDriver godoc
-// @Summary Update a specific driver field
-// @Description Updates a driver's specific field (e.g., VehicleInfo, CurrentLocation) based on driver ID
+// @Summary Update a driver
+// @Description Changes a driver's name, vehicle and/or location. Availability follows the driver's deliveries and can't be set here. Only the fields sent are changed, other fields are refused. lat and lng go together.
 // @Tags drivers
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "Driver ID"
-// @Param data body driver.UpdateDriverRequest true "Field and value to update"
+// @Param data body driver.UpdateDriverRequest true "Fields to update"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} handlers.ErrorResponse "Invalid driver ID or request body"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid driver ID, request body or field"
+// @Failure 404 {object} handlers.ErrorResponse "Driver not found"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
-// @Router /drivers/{id}/update [put]
+// @Router /drivers/{id}/update [patch]
 func (h *DriverHandler) UpdateDriver(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	driverID, err := uuid.Parse(idStr)
@@ -78,18 +81,26 @@ func (h *DriverHandler) UpdateDriver(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req driver.UpdateDriverRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+	if err := decodePatch(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
 	if err := h.UC.Drivers.UseCase.UpdateDriver(r.Context(), driverID, &req); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to update driver", err)
+		switch {
+		case errors.Is(err, driver.ErrNothingToUpdate), errors.Is(err, driver.ErrInvalidName),
+			errors.Is(err, driver.ErrInvalidVehicleInfo), errors.Is(err, driver.ErrInvalidLocation):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, driver.ErrDriverNotFound), errors.Is(err, sql.ErrNoRows):
+			writeJSONError(w, http.StatusNotFound, "Driver not found", err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Failed to update driver", err)
+		}
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{
-		"message": fmt.Sprintf("driver %s updated successfully", req.Column),
+		"message": "driver updated successfully",
 	})
 }
 
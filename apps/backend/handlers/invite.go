@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"backend/internal/domain/auth"
 	"backend/internal/domain/invite"
+	"backend/internal/domain/user"
+	"backend/internal/middleware"
 	usecase "backend/internal/usecase/invite"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -18,39 +22,64 @@ func NewInviteHandler(uc *usecase.UseCase) *InviteHandler {
 	return &InviteHandler{UC: uc}
 }
 
+// writeInviteError maps invite errors to responses.
+func writeInviteError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, invite.ErrorInviteNotFound):
+		writeJSONError(w, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, invite.ErrorInviteExpired),
+		errors.Is(err, invite.ErrorInviteAccepted),
+		errors.Is(err, invite.ErrorInviteRevoked):
+		writeJSONError(w, http.StatusGone, err.Error(), nil)
+	case errors.Is(err, invite.ErrorInvalidRole),
+		errors.Is(err, invite.ErrorStoreInviteRole),
+		errors.Is(err, invite.ErrorInvalidStoreRole),
+		errors.Is(err, invite.ErrorInvalidEmail),
+		errors.Is(err, invite.ErrorInvalidExpiry),
+		errors.Is(err, invite.ErrorMissingFields),
+		errors.Is(err, auth.ErrorWeakPassword):
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, user.ErrUserAlreadyExists):
+		writeJSONError(w, http.StatusConflict, err.Error(), nil)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
+
 // CreateMember godoc
 // @Summary Create a new invite
-// @Description Create a new invite for a user
+// @Description Invites someone by email. The server generates the token, returned only in this response and in the invite email. Invites naming a store add the invitee to its staff with store_role (manager, catalog_editor or fulfilment, default manager) and must use the merchant role; merchants may only invite to stores whose staff they manage.
 // @Tags Invites
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param invite body invite.CreateInviteRequest true "Invite payload"
-// @Success 201 {object} map[string]interface{}
+// @Success 201 {object} invite.Invite
 // @Failure 400 {object} handlers.ErrorResponse "Bad request"
+// @Failure 403 {object} handlers.ErrorResponse "Not allowed to invite to this store"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /invites/create [post]
 func (h *InviteHandler) CreateMember(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
 	var req invite.CreateInviteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		writeJSONError(w, http.StatusBadRequest, "invalid request body", err)
 		return
 	}
 
-	i := req.ToInvite()
+	i := req.ToInvite(callerID)
 
 	if err := h.UC.InviteMember(r.Context(), i); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to create invite", err)
+		writeInviteError(w, err, "Failed to create invite")
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]any{
-		"id":         i.ID,
-		"email":      i.Email,
-		"role":       i.Role,
-		"token":      i.Token,
-		"expires_at": i.Token,
-		"invited_by": i.InvitedBy,
-	})
+	writeJSON(w, http.StatusCreated, i)
 }
 
 // GetMemberByToken godoc
@@ -62,6 +91,7 @@ func (h *InviteHandler) CreateMember(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} invite.Invite
 // @Failure 400 {string} handlers.ErrorResponse "Invalid Token"
 // @Failure 404 {string} handlers.ErrorResponse "Invite not found"
+// @Failure 410 {string} handlers.ErrorResponse "Invite expired, accepted or revoked"
 // @Router /invites/by-token [get]
 func (h *InviteHandler) GetMemberByToken(w http.ResponseWriter, r *http.Request) {
 	token := r.URL.Query().Get("token")
@@ -72,7 +102,7 @@ func (h *InviteHandler) GetMemberByToken(w http.ResponseWriter, r *http.Request)
 
 	invite, err := h.UC.GetMemberByToken(r.Context(), token)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Invite not found", err)
+		writeInviteError(w, err, "Could not fetch invite")
 		return
 	}
 
@@ -123,3 +153,90 @@ func (h *InviteHandler) DeleteMember(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, map[string]string{"message": "New Invite deleted"})
 }
+
+// AcceptInvite godoc
+// @Summary Accept an invite
+// @Description Creates the invited account with the invite's email and role, adds it to the invite's store and consumes the invite.
+// @Tags public
+// @Accept json
+// @Produce json
+// @Param body body invite.AcceptInviteRequest true "Invite token and account details"
+// @Success 201 {object} user.User
+// @Failure 400 {object} handlers.ErrorResponse "Bad request"
+// @Failure 404 {object} handlers.ErrorResponse "Invite not found"
+// @Failure 409 {object} handlers.ErrorResponse "Email already has an account"
+// @Failure 410 {object} handlers.ErrorResponse "Invite expired, accepted or revoked"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/invites/accept [post]
+func (h *InviteHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	var req invite.AcceptInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", nil)
+		return
+	}
+
+	u, err := h.UC.AcceptInvite(r.Context(), &req)
+	if err != nil {
+		writeInviteError(w, err, "Could not accept invite")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, u)
+}
+
+// ResendInvite godoc
+// @Summary Resend an invite
+// @Description Replaces the token of a pending or expired invite, restarts its expiry and emails the new link.
+// @Tags Invites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invite ID"
+// @Success 200 {object} invite.Invite
+// @Failure 400 {object} handlers.ErrorResponse "Invalid invite ID"
+// @Failure 404 {object} handlers.ErrorResponse "Invite not found"
+// @Failure 410 {object} handlers.ErrorResponse "Invite accepted or revoked"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /invites/{id}/resend [post]
+func (h *InviteHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	i, err := h.UC.ResendInvite(r.Context(), inviteID)
+	if err != nil {
+		writeInviteError(w, err, "Failed to resend invite")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, i)
+}
+
+// RevokeInvite godoc
+// @Summary Revoke an invite
+// @Description Stops a pending invite from being accepted, keeping it on record.
+// @Tags Invites
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invite ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} handlers.ErrorResponse "Invalid invite ID"
+// @Failure 404 {object} handlers.ErrorResponse "Invite not found"
+// @Failure 410 {object} handlers.ErrorResponse "Invite already accepted"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /invites/{id}/revoke [post]
+func (h *InviteHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	inviteID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid ID", nil)
+		return
+	}
+
+	if err := h.UC.RevokeInvite(r.Context(), inviteID); err != nil {
+		writeInviteError(w, err, "Failed to revoke invite")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Invite revoked"})
+}
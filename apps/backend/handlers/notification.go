@@ -3,7 +3,10 @@ package handlers
 import (
 	"backend/internal/application"
 	"backend/internal/domain/notification"
+	"backend/internal/middleware"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -152,13 +155,15 @@ func (h *NotificationHandler) ListUserNotifications(w http.ResponseWriter, r *ht
 // MarkAsRead godoc
 // @Summary Mark a single notification as read
 // @Security BearerAuth
-// @Description Update notification status to "read" by ID
+// @Description Update the status of one of the caller's notifications to "read" by ID
 // @Tags notifications
 // @Accept json
 // @Produce json
 // @Param id path string true "Notification ID"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} handlers.ErrorResponse "Invalid ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Notification not found"
 // @Failure 500 {object} handlers.ErrorResponse "Failed to update notification"
 // @Router /notifications/{id}/read [patch]
 func (h *NotificationHandler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
@@ -169,7 +174,17 @@ func (h *NotificationHandler) MarkAsRead(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.UC.Notifications.UseCase.MarkAsRead(r.Context(), id); err != nil {
+	userID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	if err := h.UC.Notifications.UseCase.MarkAsRead(r.Context(), id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSONError(w, http.StatusNotFound, "notification not found", err)
+			return
+		}
 		writeJSONError(w, http.StatusInternalServerError, "could not mark notification as read", err)
 		return
 	}
@@ -1,14 +1,21 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"backend/internal/application"
+	"backend/internal/domain/address"
+	"backend/internal/domain/geocoding"
 	"backend/internal/domain/order"
+	"backend/internal/domain/pricing"
 	"backend/internal/middleware"
 
 	"github.com/go-chi/chi/v5"
@@ -26,15 +33,18 @@ func NewOrderHandler(uc *application.OrderService) *OrderHandler {
 // CreateOrder godoc
 // @Summary Create a new order
 // @Security BearerAuth
-// @Description Creates an order and returns IDs of created orders
+// @Description Creates one order holding a line per requested item and returns it with its pricing breakdown. An optional promo_code is validated and redeemed in the same transaction. Deliver to a saved address with address_id, or send delivery_address with its coordinates.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body order.CreateOrderRequest true "Order payload"
-// @Success 201 {object} map[string][]uuid.UUID "Created order IDs"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 201 {object} order.OrderDoc "Created order"
 // @Failure 400 {object} handlers.ErrorResponse "Bad request"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Saved address not found"
 // @Failure 409 {object} handlers.ErrorResponse "Conflict"
+// @Failure 422 {object} handlers.ErrorResponse "Idempotency key reused, address not on the map, delivery out of range or promo code rejected"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /orders/create [post]
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
@@ -53,46 +63,117 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create orders
-	orders, err := h.UC.Orders.UseCase.CreateOrder(ctx, customerID, &req)
+	// Create order
+	o, err := h.UC.Orders.UseCase.CreateOrder(ctx, customerID, &req)
+	if err != nil {
+		writeCreateOrderError(w, err, "Failed to create order")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, o)
+}
+
+// writeCreateOrderError maps the errors of placing an order to responses.
+func writeCreateOrderError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, order.ErrorOutOfStock):
+		writeJSONError(w, http.StatusConflict, "Product is out of stock", err)
+	case errors.Is(err, order.ErrorInvalidQuantity):
+		writeJSONError(w, http.StatusConflict, "Invalid product quantity", err)
+	case errors.Is(err, order.ErrorVariantRequired):
+		writeJSONError(w, http.StatusConflict, "Variant required", err)
+	case errors.Is(err, order.ErrorVariantNotAllowed):
+		writeJSONError(w, http.StatusConflict, "Variant not allowed for this product", err)
+	case errors.Is(err, order.ErrorEmptyOrder):
+		writeJSONError(w, http.StatusBadRequest, "Order has no items", err)
+	case errors.Is(err, order.ErrorProductNotInStore):
+		writeJSONError(w, http.StatusBadRequest, "Product does not belong to this store", err)
+	case errors.Is(err, order.ErrorInvalidLocation):
+		writeJSONError(w, http.StatusBadRequest, "Invalid pickup or delivery coordinates", err)
+	case errors.Is(err, address.ErrorAddressNotFound):
+		writeJSONError(w, http.StatusNotFound, "Saved address not found", err)
+	case errors.Is(err, geocoding.ErrorNoMatch):
+		writeJSONError(w, http.StatusUnprocessableEntity, "Address could not be found on the map", err)
+	case errors.Is(err, pricing.ErrorOutOfDeliveryRange):
+		writeJSONError(w, http.StatusUnprocessableEntity, "Delivery address is out of range", err)
+	case errors.Is(err, pricing.ErrorNoTariff):
+		writeJSONError(w, http.StatusUnprocessableEntity, "No delivery tariff configured", err)
+	case isPromotionRejection(err):
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error(), err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
+
+// QuoteOrder godoc
+// @Summary Quote an order
+// @Security BearerAuth
+// @Description Prices the requested items, delivery and optional promo_code without placing the order or reserving stock. The delivery fee comes from the distance between pickup and delivery and the store's tariff bands.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param order body order.CreateOrderRequest true "Order payload"
+// @Success 200 {object} pricing.Breakdown "Pricing breakdown"
+// @Failure 400 {object} handlers.ErrorResponse "Bad request"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Saved address not found"
+// @Failure 422 {object} handlers.ErrorResponse "Address not on the map, delivery out of range, no tariff or promo code rejected"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /orders/quote [post]
+func (h *OrderHandler) QuoteOrder(w http.ResponseWriter, r *http.Request) {
+	var req order.CreateOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	customerID, err := middleware.GetUserIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	b, err := h.UC.Orders.UseCase.QuoteOrder(r.Context(), customerID, &req)
 	if err != nil {
 		switch {
-		case errors.Is(err, order.ErrorOutOfStock):
-			writeJSONError(w, http.StatusConflict, "Product is out of stock", err)
-		case errors.Is(err, order.ErrorInvalidQuantity):
-			writeJSONError(w, http.StatusConflict, "Invalid product quantity", err)
-		case errors.Is(err, order.ErrorVariantRequired):
-			writeJSONError(w, http.StatusConflict, "Variant required", err)
-		case errors.Is(err, order.ErrorVariantNotAllowed):
-			writeJSONError(w, http.StatusConflict, "Variant not allowed for this product", err)
+		case isPromotionRejection(err):
+			writeJSONError(w, http.StatusUnprocessableEntity, err.Error(), err)
+		case errors.Is(err, pricing.ErrorOutOfDeliveryRange), errors.Is(err, pricing.ErrorNoTariff):
+			writeJSONError(w, http.StatusUnprocessableEntity, err.Error(), err)
+		case errors.Is(err, order.ErrorEmptyOrder),
+			errors.Is(err, order.ErrorInvalidQuantity),
+			errors.Is(err, order.ErrorVariantRequired),
+			errors.Is(err, order.ErrorVariantNotAllowed),
+			errors.Is(err, order.ErrorProductNotInStore),
+			errors.Is(err, order.ErrorInvalidLocation):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, address.ErrorAddressNotFound):
+			writeJSONError(w, http.StatusNotFound, "Saved address not found", err)
+		case errors.Is(err, geocoding.ErrorNoMatch):
+			writeJSONError(w, http.StatusUnprocessableEntity, "Address could not be found on the map", err)
 		default:
-			writeJSONError(w, http.StatusInternalServerError, "Failed to create order", err)
+			writeJSONError(w, http.StatusInternalServerError, "Could not quote order", err)
 		}
 		return
 	}
 
-	// Respond with all created order IDs
-	orderIDs := make([]uuid.UUID, len(orders))
-	for i, o := range orders {
-		orderIDs[i] = o.ID
-	}
-
-	writeJSON(w, http.StatusCreated, map[string]any{
-		"order_ids": orderIDs,
-	})
+	writeJSON(w, http.StatusOK, b)
 }
 
 // CreatePending godoc
-// @Summary Create pending orders (cart)
+// @Summary Create a pending order (cart)
 // @Security BearerAuth
-// @Description Creates pending orders and returns IDs
+// @Description Creates a pending order holding a line per requested item and returns it
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order body order.CreateOrderRequest true "Order payload"
-// @Success 200 {object} map[string][]uuid.UUID "Pending order IDs"
+// @Success 200 {object} order.OrderDoc "Pending order"
 // @Failure 400 {object} handlers.ErrorResponse "Bad request"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 404 {object} handlers.ErrorResponse "Saved address not found"
+// @Failure 409 {object} handlers.ErrorResponse "Conflict"
+// @Failure 422 {object} handlers.ErrorResponse "Address not on the map, delivery out of range or promo code rejected"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /orders/pending [post]
 func (h *OrderHandler) CreatePending(w http.ResponseWriter, r *http.Request) {
@@ -110,20 +191,13 @@ func (h *OrderHandler) CreatePending(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	orders, err := h.UC.Orders.UseCase.CreatePendingOrders(ctx, customerID, &req)
+	o, err := h.UC.Orders.UseCase.CreatePendingOrder(ctx, customerID, &req)
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to create pending orders", err)
+		writeCreateOrderError(w, err, "Failed to create pending order")
 		return
 	}
 
-	orderIDs := make([]uuid.UUID, len(orders))
-	for i, o := range orders {
-		orderIDs[i] = o.ID
-	}
-
-	writeJSON(w, http.StatusOK, map[string]any{
-		"order_ids": orderIDs,
-	})
+	writeJSON(w, http.StatusOK, o)
 }
 
 // GetOrderByID godoc
@@ -184,17 +258,19 @@ func (h *OrderHandler) GetOrderByCustomer(w http.ResponseWriter, r *http.Request
 // UpdateOrder godoc
 // @Summary Update Order
 // @Security BearerAuth
-// @Description Update any order struct field of an existing order
+// @Description Changes the pickup and/or delivery address of a pending order. Only the fields sent are changed, each change is recorded in the order timeline. Changed addresses are geocoded again and the delivery fee re-quoted, the discount is kept. Other fields are refused, the status only changes through /orders/{id}/status.
 // @Tags orders
 // @Accept json
 // @Produce json
 // @Param order_id path string true "Order ID"
-// @Param update body order.UpdateOrderRequest true "Field and value to update"
+// @Param update body order.UpdateOrderRequest true "Fields to update"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} handlers.ErrorResponse "Invalid order ID or request body"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid order ID, request body or field"
 // @Failure 404 {object} handlers.ErrorResponse "Not found"
+// @Failure 409 {object} handlers.ErrorResponse "Order is no longer pending"
+// @Failure 422 {object} handlers.ErrorResponse "Address not on the map or delivery out of range"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
-// @Router /orders/{id}/update [put]
+// @Router /orders/{id}/update [patch]
 func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	orderID, err := uuid.Parse(idStr)
@@ -204,44 +280,348 @@ func (h *OrderHandler) UpdateOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req order.UpdateOrderRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+	if err := decodePatch(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
-	column := strings.TrimSpace(strings.ToLower(req.Column))
-	if column == "" {
-		writeJSONError(w, http.StatusBadRequest, "Missing or invalid column name", err)
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
 		return
 	}
 
-	if err := h.UC.Orders.UseCase.UpdateOrder(r.Context(), orderID, column, req.Value); err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to update order", err)
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to update orders", err)
+		return
+	}
+
+	if err := h.UC.Orders.UseCase.UpdateOrder(r.Context(), orderID, callerID, actor, &req); err != nil {
+		switch {
+		case errors.Is(err, order.ErrorStatusNotUpdatable):
+			writeJSONError(w, http.StatusBadRequest, "Use /orders/{id}/status to change the order status", err)
+		case errors.Is(err, order.ErrorNothingToUpdate), errors.Is(err, order.ErrorInvalidAddress):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, order.ErrorOrderNotEditable), errors.Is(err, order.ErrorStatusConflict):
+			writeJSONError(w, http.StatusConflict, "Only pending orders can be edited", err)
+		case errors.Is(err, order.ErrorInvalidLocation), errors.Is(err, geocoding.ErrorNoMatch):
+			writeJSONError(w, http.StatusUnprocessableEntity, "Address could not be found on the map", err)
+		case errors.Is(err, pricing.ErrorOutOfDeliveryRange):
+			writeJSONError(w, http.StatusUnprocessableEntity, "Delivery address is out of range", err)
+		case errors.Is(err, pricing.ErrorNoTariff):
+			writeJSONError(w, http.StatusUnprocessableEntity, "No delivery tariff configured", err)
+		case errors.Is(err, sql.ErrNoRows):
+			writeJSONError(w, http.StatusNotFound, "Not found", err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Failed to update order", err)
+		}
 		return
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{
-		"message": fmt.Sprintf("order %s updated successfully", column),
+		"message": "order updated successfully",
 	})
 }
 
+// GetOrderTimeline godoc
+// @Summary Get order timeline
+// @Security BearerAuth
+// @Description Returns the audit history of an order, oldest first: every status change, driver assignment, payment event and edit with who made it and when. Customers and merchants may only read their own orders.
+// @Tags orders
+// @Produce json
+// @Param id path string true "Order ID"
+// @Success 200 {array} order.Event
+// @Failure 400 {object} handlers.ErrorResponse "Invalid order ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not a participant of this order"
+// @Failure 404 {object} handlers.ErrorResponse "Order not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /orders/{id}/timeline [get]
+func (h *OrderHandler) GetOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid order ID", nil)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to read order history", err)
+		return
+	}
+
+	events, err := h.UC.Orders.UseCase.GetOrderTimeline(r.Context(), orderID, callerID, actor)
+	if err != nil {
+		writeTransitionError(w, err, "Could not fetch order timeline")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// UpdateOrderStatus godoc
+// @Summary Change order status
+// @Security BearerAuth
+// @Description Moves an order along its lifecycle (pending, assigned, in_transit, delivered, cancelled). Only legal transitions for the caller's role are accepted; customers and merchants may only change their own orders.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param status body order.UpdateOrderStatusRequest true "Target status"
+// @Success 200 {object} order.OrderDoc "Updated order"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid order ID or status"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Transition not allowed for caller"
+// @Failure 404 {object} handlers.ErrorResponse "Order not found"
+// @Failure 409 {object} handlers.ErrorResponse "Illegal status transition"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /orders/{id}/status [put]
+func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid order ID", nil)
+		return
+	}
+
+	var req order.UpdateOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to change order status", err)
+		return
+	}
+
+	o, err := h.UC.Orders.UseCase.UpdateOrderStatus(r.Context(), orderID, callerID, actor, req.Status)
+	if err != nil {
+		writeTransitionError(w, err, "Failed to update order status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, o)
+}
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Security BearerAuth
+// @Description Cancels an order with a reason. In one transaction it restores stock, releases any assigned driver, marks the open delivery failed and opens a refund for a completed payment. Customers may cancel until the order is in transit, merchants their own store's orders until then, admins until delivery.
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param id path string true "Order ID"
+// @Param cancel body order.CancelOrderRequest true "Cancellation reason"
+// @Success 200 {object} order.OrderDoc "Cancelled order"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid order ID or missing reason"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Caller may not cancel this order"
+// @Failure 404 {object} handlers.ErrorResponse "Order not found"
+// @Failure 409 {object} handlers.ErrorResponse "Order can no longer be cancelled"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /orders/{id}/cancel [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid order ID", nil)
+		return
+	}
+
+	var req order.CancelOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to cancel orders", err)
+		return
+	}
+
+	o, err := h.UC.Orders.UseCase.CancelOrder(r.Context(), orderID, callerID, actor, req.Reason)
+	if err != nil {
+		if errors.Is(err, order.ErrorCancelReasonMissing) {
+			writeJSONError(w, http.StatusBadRequest, "Cancellation reason is required", err)
+			return
+		}
+		writeTransitionError(w, err, "Failed to cancel order")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, o)
+}
+
+// writeTransitionError maps order state machine errors to HTTP responses.
+func writeTransitionError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, order.ErrorIllegalTransition):
+		writeJSONError(w, http.StatusConflict, "Illegal order status transition", err)
+	case errors.Is(err, order.ErrorStatusConflict):
+		writeJSONError(w, http.StatusConflict, "Order status changed, please retry", err)
+	case errors.Is(err, order.ErrorTransitionForbidden):
+		writeJSONError(w, http.StatusForbidden, "Not allowed to perform this status transition", err)
+	case errors.Is(err, order.ErrorNotOrderParticipant):
+		writeJSONError(w, http.StatusForbidden, "Not a participant of this order", err)
+	case errors.Is(err, order.ErrorInvalidStatus):
+		writeJSONError(w, http.StatusBadRequest, "Invalid order status", err)
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSONError(w, http.StatusNotFound, "Not found", err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
+
 // ListOrders godoc
-// @Summary List all orders
+// @Summary List orders
 // @Security BearerAuth
-// @Description Get a list of all orders
+// @Description Returns one page of orders matching the filters, newest first by default. Customers only see their own orders and merchants only those of stores they own. Pass next_cursor back as cursor to get the following page.
 // @Tags orders
 // @Produce  json
-// @Success 200 {array} order.OrderDoc
+// @Param store_id query string false "Store ID"
+// @Param merchant_id query string false "Merchant ID"
+// @Param customer_id query string false "Customer ID"
+// @Param status query string false "Comma-separated statuses, e.g. pending,assigned"
+// @Param from query string false "Created at or after (RFC3339)"
+// @Param to query string false "Created before (RFC3339)"
+// @Param min_total query int false "Minimum total in cents"
+// @Param max_total query int false "Maximum total in cents"
+// @Param sort query string false "created_at or total" default(created_at)
+// @Param order query string false "asc or desc" default(desc)
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Success 200 {object} order.PageDoc
+// @Failure 400 {object} handlers.ErrorResponse "Invalid filter"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Caller may not list these orders"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /orders/all_orders [get]
 func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.UC.Orders.UseCase.ListOrders(r.Context())
+	filter, err := parseListFilter(r.URL.Query())
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid order filter", err)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Could not fetch orders", err)
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to list orders", err)
+		return
+	}
+
+	page, err := h.UC.Orders.UseCase.ListOrdersFiltered(r.Context(), callerID, actor, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, order.ErrorListForbidden):
+			writeJSONError(w, http.StatusForbidden, "Not allowed to list these orders", err)
+		case errors.Is(err, order.ErrorInvalidSort),
+			errors.Is(err, order.ErrorInvalidCursor),
+			errors.Is(err, order.ErrorInvalidFilter),
+			errors.Is(err, order.ErrorInvalidStatus):
+			writeJSONError(w, http.StatusBadRequest, "Invalid order filter", err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Could not fetch orders", err)
+		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, orders)
+	writeJSON(w, http.StatusOK, page)
+}
+
+// parseListFilter reads the order listing query parameters.
+func parseListFilter(q url.Values) (order.ListFilter, error) {
+	f := order.ListFilter{Desc: true}
+	var err error
+
+	for key, dst := range map[string]**uuid.UUID{
+		"store_id":    &f.StoreID,
+		"merchant_id": &f.MerchantID,
+		"customer_id": &f.CustomerID,
+	} {
+		if v := q.Get(key); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return f, fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = &id
+		}
+	}
+
+	if v := q.Get("status"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			f.Statuses = append(f.Statuses, order.OrderStatus(strings.TrimSpace(s)))
+		}
+	}
+
+	for key, dst := range map[string]**time.Time{"from": &f.CreatedFrom, "to": &f.CreatedTo} {
+		if v := q.Get(key); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return f, fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = &t
+		}
+	}
+
+	for key, dst := range map[string]**int64{"min_total": &f.MinTotal, "max_total": &f.MaxTotal} {
+		if v := q.Get(key); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return f, fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = &n
+		}
+	}
+
+	f.Sort = order.SortField(q.Get("sort"))
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		f.Desc = false
+	default:
+		return f, fmt.Errorf("order must be asc or desc")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return f, fmt.Errorf("limit: %w", err)
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		if f.Cursor, err = order.DecodeCursor(v); err != nil {
+			return f, err
+		}
+	}
+
+	return f, nil
 }
 
 // DeleteOrder godoc
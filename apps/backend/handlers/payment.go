@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"backend/internal/domain/mpesa"
+	"backend/internal/domain/order"
 	"backend/internal/domain/payment"
+	"backend/internal/middleware"
 	usecase "backend/internal/usecase/payment"
 	"encoding/json"
 	"net/http"
@@ -34,6 +36,8 @@ func NewPaymentHandler(ph *usecase.UseCase, mpesa *mpesa.MpesaService) *PaymentH
 // @Param user body payment.CreatePaymentRequest true "User Input"
 // @Success 201 {object} payment.Payment
 // @Failure 400 {string} handlers.ErrorResponse "Invalid request"
+// @Failure 401 {string} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {string} handlers.ErrorResponse "Not allowed to create payments"
 // @Failure 500 {string} handlers.ErrorResponse "Failed to create payment"
 // @Router /payments/create [post]
 func (ph *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request) {
@@ -45,9 +49,21 @@ func (ph *PaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	actor, err := order.ActorFromRole(role)
+	if err != nil {
+		writeJSONError(w, http.StatusForbidden, "Not allowed to create payments", err)
+		return
+	}
+
 	p := req.ToPayment()
 
-	if err := ph.PH.CreatePayment(r.Context(), p); err != nil {
+	if err := ph.PH.CreatePayment(r.Context(), p, callerID, actor); err != nil {
 		writeJSONError(w, http.StatusInternalServerError, "Could not create payment", err)
 		return
 	}
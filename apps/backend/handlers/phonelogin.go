@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// RequestPhoneCode godoc
+// @Summary Text a sign-in code
+// @Description Texts a 6-digit sign-in code to a Kenyan mobile number. Only the last code sent is valid, for 5 minutes. A number gets a code at most once a minute and 5 times an hour, a client 20 times an hour.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.PhoneCodeRequest true "Phone number"
+// @Success 202 {object} map[string]string "Code sent"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid phone number"
+// @Failure 429 {object} handlers.ErrorResponse "Too many codes requested, see Retry-After"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/login/phone [post]
+func (h *UserHandler) RequestPhoneCode(w http.ResponseWriter, r *http.Request) {
+	var req auth.PhoneCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	err := h.UC.Auth.UseCase.RequestPhoneCode(r.Context(), req.Phone, clientIP(r))
+	if writeThrottled(w, err) {
+		return
+	}
+	if errors.Is(err, user.ErrInvalidPhone) {
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not send code, try again later.", err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "code sent",
+	})
+}
+
+// VerifyPhoneCode godoc
+// @Summary Sign in with a texted code
+// @Description Exchanges the code texted by /public/login/phone for an access token and a refresh token. The first sign-in of a number creates a guest customer without email address or password, the only account the number then signs into. Accounts that merely list the number in their profile are never signed into. A code works once and takes 5 wrong tries.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.VerifyPhoneCodeRequest true "Phone number and code"
+// @Success 200 {object} user.LoginResponse
+// @Failure 400 {object} handlers.ErrorResponse "Invalid phone number"
+// @Failure 401 {object} handlers.ErrorResponse "Invalid or expired code"
+// @Failure 403 {object} handlers.ErrorResponse "Account suspended or inactive"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/login/phone/verify [post]
+func (h *UserHandler) VerifyPhoneCode(w http.ResponseWriter, r *http.Request) {
+	var req auth.VerifyPhoneCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	u, tokens, err := h.UC.Auth.UseCase.VerifyPhoneCode(r.Context(), req.Phone, req.Code)
+	switch {
+	case errors.Is(err, user.ErrInvalidPhone):
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, auth.ErrorInvalidPhoneCode):
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+	case errors.Is(err, auth.ErrorAccountDisabled):
+		writeJSONError(w, http.StatusForbidden, err.Error(), nil)
+	case err != nil:
+		writeJSONError(w, http.StatusInternalServerError, "Could not start session", err)
+	default:
+		h.writeLogin(w, r, u, tokens, false)
+	}
+}
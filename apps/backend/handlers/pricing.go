@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/application"
+	"backend/internal/domain/pricing"
+	"backend/internal/domain/user"
+	"backend/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+type PricingHandler struct {
+	UC *application.OrderService
+}
+
+func NewPricingHandler(uc *application.OrderService) *PricingHandler {
+	return &PricingHandler{UC: uc}
+}
+
+// GetTariff godoc
+// @Summary Get delivery tariff
+// @Security BearerAuth
+// @Description Returns the delivery fee bands that apply to a store, falling back to the global bands when the store has none. Without store_id the global bands are returned.
+// @Tags pricing
+// @Produce json
+// @Param store_id query string false "Store ID"
+// @Success 200 {array} pricing.TariffBand
+// @Failure 400 {object} handlers.ErrorResponse "Invalid store ID"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /pricing/tariffs [get]
+func (h *PricingHandler) GetTariff(w http.ResponseWriter, r *http.Request) {
+	var (
+		bands pricing.Tariff
+		err   error
+	)
+
+	if v := r.URL.Query().Get("store_id"); v != "" {
+		storeID, perr := uuid.Parse(v)
+		if perr != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid store ID", perr)
+			return
+		}
+		bands, err = h.UC.Pricing.UseCase.GetTariff(r.Context(), storeID)
+	} else {
+		bands, err = h.UC.Pricing.UseCase.GetGlobalTariff(r.Context())
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not fetch tariff", err)
+		return
+	}
+	if bands == nil {
+		bands = pricing.Tariff{}
+	}
+
+	writeJSON(w, http.StatusOK, bands)
+}
+
+// ReplaceTariff godoc
+// @Summary Replace delivery tariff
+// @Security BearerAuth
+// @Description Replaces the delivery fee bands of a store, or the global bands when store_id is omitted. Bands must grow in distance. Merchants may set the tariff of stores they own; only admins may set the global tariff.
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Param tariff body pricing.ReplaceTariffRequest true "Tariff bands"
+// @Success 200 {array} pricing.TariffBand
+// @Failure 400 {object} handlers.ErrorResponse "Invalid tariff"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not the owner of this store"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /pricing/tariffs [put]
+func (h *PricingHandler) ReplaceTariff(w http.ResponseWriter, r *http.Request) {
+	var req pricing.ReplaceTariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	bands := req.ToTariff()
+	if err := h.UC.Pricing.UseCase.ReplaceTariff(r.Context(), callerID, role == string(user.Admin), req.StoreID, bands); err != nil {
+		switch {
+		case errors.Is(err, pricing.ErrorInvalidTariff), errors.Is(err, pricing.ErrorNoTariff):
+			writeJSONError(w, http.StatusBadRequest, "Invalid tariff", err)
+		case errors.Is(err, pricing.ErrorNotStoreOwner):
+			writeJSONError(w, http.StatusForbidden, "Not the owner of this store", err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Could not save tariff", err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bands)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/application"
+	"backend/internal/domain/promotion"
+	"backend/internal/domain/user"
+	"backend/internal/middleware"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type PromotionHandler struct {
+	UC *application.OrderService
+}
+
+func NewPromotionHandler(uc *application.OrderService) *PromotionHandler {
+	return &PromotionHandler{UC: uc}
+}
+
+// CreatePromotion godoc
+// @Summary Create a promotion
+// @Security BearerAuth
+// @Description Creates a discount code: percentage or fixed off the basket (or only the listed products), or free delivery. Optional minimum basket, global and per-customer usage limits and validity window. Merchants create codes for stores they own; codes without a store are platform-wide and reserved to admins.
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param promotion body promotion.CreatePromotionRequest true "Promotion"
+// @Success 201 {object} promotion.Promotion
+// @Failure 400 {object} handlers.ErrorResponse "Invalid promotion"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not the owner of this store"
+// @Failure 409 {object} handlers.ErrorResponse "Code already exists"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /promotions/create [post]
+func (h *PromotionHandler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req promotion.CreatePromotionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	p := req.ToPromotion(callerID)
+	if err := h.UC.Promotions.UseCase.CreatePromotion(r.Context(), callerID, role == string(user.Admin), p); err != nil {
+		writePromotionError(w, err, "Could not create promotion")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, p)
+}
+
+// ListPromotions godoc
+// @Summary List promotions
+// @Security BearerAuth
+// @Description Lists the promotions of a store to its owner and managers, or the platform-wide ones to admins without store_id
+// @Tags promotions
+// @Produce json
+// @Param store_id query string false "Store ID"
+// @Success 200 {array} promotion.Promotion
+// @Failure 400 {object} handlers.ErrorResponse "Invalid store ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not the owner of this store"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /promotions/all_promotions [get]
+func (h *PromotionHandler) ListPromotions(w http.ResponseWriter, r *http.Request) {
+	var storeID *uuid.UUID
+	if v := r.URL.Query().Get("store_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid store ID", err)
+			return
+		}
+		storeID = &id
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	promotions, err := h.UC.Promotions.UseCase.ListPromotions(r.Context(), callerID, role == string(user.Admin), storeID)
+	if err != nil {
+		writePromotionError(w, err, "Could not fetch promotions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, promotions)
+}
+
+// DeactivatePromotion godoc
+// @Summary Deactivate a promotion
+// @Security BearerAuth
+// @Description Stops a promotion from being redeemed. Past redemptions are kept.
+// @Tags promotions
+// @Produce json
+// @Param id path string true "Promotion ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} handlers.ErrorResponse "Invalid promotion ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not the owner of this store"
+// @Failure 404 {object} handlers.ErrorResponse "Promotion not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /promotions/{id}/deactivate [put]
+func (h *PromotionHandler) DeactivatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid promotion ID", nil)
+		return
+	}
+
+	callerID, role, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	if err := h.UC.Promotions.UseCase.SetPromotionActive(r.Context(), callerID, role == string(user.Admin), id, false); err != nil {
+		writePromotionError(w, err, "Could not deactivate promotion")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "promotion deactivated"})
+}
+
+func writePromotionError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, promotion.ErrorInvalidPromotion), errors.Is(err, promotion.ErrorProductNotInStore):
+		writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, promotion.ErrorNotStoreOwner):
+		writeJSONError(w, http.StatusForbidden, "Not the owner of this store", err)
+	case errors.Is(err, promotion.ErrorPromotionCodeConflict):
+		writeJSONError(w, http.StatusConflict, "Promotion code already exists", err)
+	case errors.Is(err, sql.ErrNoRows):
+		writeJSONError(w, http.StatusNotFound, "Not found", err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
+
+// isPromotionRejection reports whether a checkout failed because its promo
+// code can't be used, which the customer can fix by changing the code.
+func isPromotionRejection(err error) bool {
+	for _, target := range []error{
+		promotion.ErrorPromotionNotFound,
+		promotion.ErrorPromotionInactive,
+		promotion.ErrorPromotionNotStarted,
+		promotion.ErrorPromotionExpired,
+		promotion.ErrorMinimumNotMet,
+		promotion.ErrorNotApplicable,
+		promotion.ErrorPromotionExhausted,
+		promotion.ErrorCustomerLimitReached,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
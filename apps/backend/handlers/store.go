@@ -66,6 +66,8 @@ func (h *StoreHandler) CreateStore(w http.ResponseWriter, r *http.Request) {
 			writeJSONError(w, http.StatusConflict, "Name already exists", err)
 		case errors.Is(err, store.ErrInvalidStoreInput):
 			writeJSONError(w, http.StatusBadRequest, "Input data is invalid. Try again.", err)
+		case errors.Is(err, store.ErrInvalidServiceRadius):
+			writeJSONError(w, http.StatusBadRequest, store.ErrInvalidServiceRadius.Error(), err)
 		default:
 			writeJSONError(w, http.StatusInternalServerError, store.ErrCreateStore.Error(), err)
 		}
@@ -73,13 +75,16 @@ func (h *StoreHandler) CreateStore(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusCreated, map[string]any{
-		"id":         s.ID,
-		"owner_id":   s.OwnerID,
-		"name":       s.Name,
-		"logo_url":   s.LogoURL,
-		"location":   s.Location,
-		"created_at": s.CreatedAt,
-		"updated_at": s.UpdatedAt,
+		"id":               s.ID,
+		"owner_id":         s.OwnerID,
+		"name":             s.Name,
+		"slug":             s.Slug,
+		"logo_url":         s.LogoURL,
+		"location":         s.Location,
+		"point":            s.Point,
+		"service_radius_m": s.ServiceRadiusM,
+		"created_at":       s.CreatedAt,
+		"updated_at":       s.UpdatedAt,
 	})
 }
 
@@ -140,7 +145,7 @@ func (h *StoreHandler) GetStoreSummary(w http.ResponseWriter, r *http.Request) {
 // UpdateStore godoc
 // @Summary Update a store
 // @Security BearerAuth
-// @Description Update details of a store owned by the authenticated user
+// @Description Update details of a store the authenticated user owns or manages
 // @Tags stores
 // @Accept json
 // @Produce json
@@ -149,6 +154,7 @@ func (h *StoreHandler) GetStoreSummary(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} map[string]string "Update message"
 // @Failure 400 {object} handlers.ErrorResponse "Invalid request"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not allowed to manage this store"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /stores/{id}/update [put]
 func (h *StoreHandler) UpdateStore(w http.ResponseWriter, r *http.Request) {
@@ -179,6 +185,10 @@ func (h *StoreHandler) UpdateStore(w http.ResponseWriter, r *http.Request) {
 			writeJSONError(w, http.StatusConflict, "Store name already exists.", err)
 		case errors.Is(err, store.ErrInvalidStoreInput):
 			writeJSONError(w, http.StatusBadRequest, "Invalid data input", err)
+		case errors.Is(err, store.ErrInvalidServiceRadius):
+			writeJSONError(w, http.StatusBadRequest, store.ErrInvalidServiceRadius.Error(), err)
+		case errors.Is(err, store.ErrNotPermitted):
+			writeJSONError(w, http.StatusForbidden, "Access denied.", err)
 		default:
 			writeJSONError(w, http.StatusInternalServerError, "Update failed, try again later.", err)
 		}
@@ -211,10 +221,10 @@ func (h *StoreHandler) ListStores(w http.ResponseWriter, r *http.Request) {
 // ListOwnerStores godoc
 // @Summary List authenticated owner's stores
 // @Security BearerAuth
-// @Description Returns all stores owned by the current user
+// @Description Returns the stores the current user owns or is staff of, with their role in each
 // @Tags stores
 // @Produce json
-// @Success 200 {array} store.Store
+// @Success 200 {array} store.MyStores
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /stores/me [get]
@@ -284,6 +294,65 @@ func (h *StoreHandler) ListStoresPaged(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, stores)
 }
 
+// ListNearbyStores godoc
+// @Summary Stores near me
+// @Description Returns the stores that deliver to the given location, closest first, with their distance in metres
+// @Tags stores
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lng query number true "Longitude"
+// @Param radius query int false "Search radius in metres (default 5000, max 50000)"
+// @Param limit query int false "Maximum number of items to return"
+// @Success 200 {array} store.StoreSummary
+// @Failure 400 {object} handlers.ErrorResponse "Invalid location or radius"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/stores/nearby [get]
+func (h *StoreHandler) ListNearbyStores(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	lat, latErr := strconv.ParseFloat(q.Get("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(q.Get("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		writeJSONError(w, http.StatusBadRequest, "lat and lng are required", nil)
+		return
+	}
+
+	var radius int
+	if v := q.Get("radius"); v != "" {
+		var err error
+		if radius, err = strconv.Atoi(v); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid radius", err)
+			return
+		}
+	}
+
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20 // default
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	stores, err := h.UC.Stores.UseCase.ListNearbyStores(r.Context(), store.NearbyFilter{
+		Lat:     lat,
+		Lng:     lng,
+		RadiusM: radius,
+		Limit:   limit,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidCoordinates), errors.Is(err, store.ErrInvalidSearchRadius):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Failed to list stores", err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stores)
+}
+
 // DeleteStore godoc
 // @Summary Delete a store
 // @Security BearerAuth
@@ -294,6 +363,7 @@ func (h *StoreHandler) ListStoresPaged(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} map[string]string "Deletion message"
 // @Failure 400 {object} handlers.ErrorResponse "Invalid store ID"
 // @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Only the owner deletes a store"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /stores/{id}/delete [delete]
 func (h *StoreHandler) DeleteStore(w http.ResponseWriter, r *http.Request) {
@@ -318,7 +388,7 @@ func (h *StoreHandler) DeleteStore(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, store.ErrStoreHasReferences):
 			writeJSONError(w, http.StatusConflict, "Store cannot be deleted. Remove dependent records first.", err)
 		case errors.Is(err, store.ErrNotOwner):
-			writeJSONError(w, http.StatusInternalServerError, "Access denied.", err)
+			writeJSONError(w, http.StatusForbidden, "Access denied.", err)
 		default:
 			writeJSONError(w, http.StatusInternalServerError, "Delete failed, try again later", err)
 		}
@@ -329,3 +399,145 @@ func (h *StoreHandler) DeleteStore(w http.ResponseWriter, r *http.Request) {
 		"message": fmt.Sprintf("Store '%q' deleted successfully", name),
 	})
 }
+
+// ListMembers godoc
+// @Summary List store staff
+// @Security BearerAuth
+// @Description Returns the staff of a store with their roles. Only the store owner may list them
+// @Tags stores
+// @Produce json
+// @Param id path string true "Store ID"
+// @Success 200 {array} store.Member
+// @Failure 400 {object} handlers.ErrorResponse "Invalid store ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not allowed to manage staff"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /stores/{id}/members [get]
+func (h *StoreHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	storeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid store ID", nil)
+		return
+	}
+
+	callerID, err := middleware.GetOwnerIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	members, err := h.UC.Stores.UseCase.ListMembers(r.Context(), storeID, callerID)
+	if err != nil {
+		writeMemberError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+// UpdateMemberRole godoc
+// @Summary Change a staff member's role
+// @Security BearerAuth
+// @Description Gives a staff member of a store another role: manager, catalog_editor or fulfilment
+// @Tags stores
+// @Accept json
+// @Produce json
+// @Param id path string true "Store ID"
+// @Param user_id path string true "User ID of the staff member"
+// @Param request body store.UpdateMemberRoleRequest true "New role"
+// @Success 200 {object} map[string]string "Update message"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request or role"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not allowed to manage staff"
+// @Failure 404 {object} handlers.ErrorResponse "Staff member not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /stores/{id}/members/{user_id} [patch]
+func (h *StoreHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	storeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid store ID", nil)
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	var req store.UpdateMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	callerID, err := middleware.GetOwnerIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	if err := h.UC.Stores.UseCase.UpdateMemberRole(r.Context(), storeID, callerID, userID, req.Role); err != nil {
+		writeMemberError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message": "staff role updated successfully",
+	})
+}
+
+// RemoveMember godoc
+// @Summary Remove a staff member
+// @Security BearerAuth
+// @Description Takes a user off the staff of a store. Their account is kept
+// @Tags stores
+// @Produce json
+// @Param id path string true "Store ID"
+// @Param user_id path string true "User ID of the staff member"
+// @Success 200 {object} map[string]string "Removal message"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid ID"
+// @Failure 401 {object} handlers.ErrorResponse "Unauthorized"
+// @Failure 403 {object} handlers.ErrorResponse "Not allowed to manage staff"
+// @Failure 404 {object} handlers.ErrorResponse "Staff member not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /stores/{id}/members/{user_id} [delete]
+func (h *StoreHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	storeID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid store ID", nil)
+		return
+	}
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	callerID, err := middleware.GetOwnerIDFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, "Unauthorized", err)
+		return
+	}
+
+	if err := h.UC.Stores.UseCase.RemoveMember(r.Context(), storeID, callerID, userID); err != nil {
+		writeMemberError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"message": "staff member removed successfully",
+	})
+}
+
+func writeMemberError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotPermitted):
+		writeJSONError(w, http.StatusForbidden, "Access denied.", err)
+	case errors.Is(err, store.ErrMemberNotFound):
+		writeJSONError(w, http.StatusNotFound, store.ErrMemberNotFound.Error(), err)
+	case errors.Is(err, store.ErrInvalidMemberRole):
+		writeJSONError(w, http.StatusBadRequest, store.ErrInvalidMemberRole.Error(), err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, "Staff update failed, try again later.", err)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend/internal/application"
+	"backend/internal/domain/storefront"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// StorefrontHandler serves the public store pages; none of its routes
+// require authentication.
+type StorefrontHandler struct {
+	UC *application.OrderService
+}
+
+func NewStorefrontHandler(uc *application.OrderService) *StorefrontHandler {
+	return &StorefrontHandler{UC: uc}
+}
+
+// GetStorefront godoc
+// @Summary Public store page
+// @Description Returns the public details of a store by its slug
+// @Tags storefront
+// @Produce json
+// @Param slug path string true "Store slug"
+// @Success 200 {object} storefront.Store
+// @Failure 404 {object} handlers.ErrorResponse "Store not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/stores/{slug} [get]
+func (h *StorefrontHandler) GetStorefront(w http.ResponseWriter, r *http.Request) {
+	s, err := h.UC.Storefront.UseCase.GetStore(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		writeStorefrontError(w, err, "Could not load store")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s)
+}
+
+// ListStorefrontProducts godoc
+// @Summary Public product list of a store
+// @Description Returns one page of the products of a store, by name
+// @Tags storefront
+// @Produce json
+// @Param slug path string true "Store slug"
+// @Param limit query int false "Maximum number of items to return (default 20, max 100)"
+// @Param offset query int false "Number of items to skip"
+// @Success 200 {object} storefront.ProductPage
+// @Failure 404 {object} handlers.ErrorResponse "Store not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/stores/{slug}/products [get]
+func (h *StorefrontHandler) ListStorefrontProducts(w http.ResponseWriter, r *http.Request) {
+	// Bad values fall back to the defaults, like the other paged lists
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	page, err := h.UC.Storefront.UseCase.ListProducts(r.Context(), chi.URLParam(r, "slug"), limit, offset)
+	if err != nil {
+		writeStorefrontError(w, err, "Could not list products")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// GetStorefrontProduct godoc
+// @Summary Public product detail
+// @Description Returns a product of a store with its images, options and variants
+// @Tags storefront
+// @Produce json
+// @Param slug path string true "Store slug"
+// @Param product_id path string true "Product ID"
+// @Success 200 {object} storefront.ProductDetail
+// @Failure 400 {object} handlers.ErrorResponse "Invalid product ID"
+// @Failure 404 {object} handlers.ErrorResponse "Store or product not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/stores/{slug}/products/{product_id} [get]
+func (h *StorefrontHandler) GetStorefrontProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(chi.URLParam(r, "product_id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	p, err := h.UC.Storefront.UseCase.GetProduct(r.Context(), chi.URLParam(r, "slug"), productID)
+	if err != nil {
+		writeStorefrontError(w, err, "Could not load product")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// GetStorefrontRating godoc
+// @Summary Store rating summary
+// @Description Returns the average rating of a store, the number of ratings and how many ratings gave each star
+// @Tags storefront
+// @Produce json
+// @Param slug path string true "Store slug"
+// @Success 200 {object} storefront.RatingSummary
+// @Failure 404 {object} handlers.ErrorResponse "Store not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/stores/{slug}/rating [get]
+func (h *StorefrontHandler) GetStorefrontRating(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.UC.Storefront.UseCase.GetRatingSummary(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		writeStorefrontError(w, err, "Could not load ratings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func writeStorefrontError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, storefront.ErrorStoreNotFound):
+		writeJSONError(w, http.StatusNotFound, "Store not found", err)
+	case errors.Is(err, storefront.ErrorProductNotFound):
+		writeJSONError(w, http.StatusNotFound, "Product not found", err)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
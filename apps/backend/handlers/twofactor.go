@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"backend/internal/middleware"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// writeTwoFactorError maps two-factor errors to responses.
+func writeTwoFactorError(w http.ResponseWriter, err error, fallback string) {
+	if writeThrottled(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, auth.ErrorInvalidChallenge),
+		errors.Is(err, auth.ErrorInvalidTwoFactorCode):
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+	case errors.Is(err, auth.ErrorAccountDisabled),
+		errors.Is(err, auth.ErrorTwoFactorNotAllowed):
+		writeJSONError(w, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, auth.ErrorTwoFactorAlreadyEnabled),
+		errors.Is(err, auth.ErrorTwoFactorNotEnrolled),
+		errors.Is(err, auth.ErrorTwoFactorNotEnabled),
+		errors.Is(err, auth.ErrorTwoFactorRequired):
+		writeJSONError(w, http.StatusConflict, err.Error(), nil)
+	default:
+		writeJSONError(w, http.StatusInternalServerError, fallback, err)
+	}
+}
+
+// VerifyLogin godoc
+// @Summary Complete a two-factor login
+// @Description Exchanges the challenge token from /public/login and a code from the authenticator app, or an unused recovery code, for an access token and a refresh token. A challenge expires after 5 minutes or 5 wrong codes.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.VerifyLoginRequest true "Challenge token and code"
+// @Success 200 {object} user.LoginResponse
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 401 {object} handlers.ErrorResponse "Invalid or expired challenge, or wrong code"
+// @Failure 403 {object} handlers.ErrorResponse "Account suspended or inactive"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/login/2fa [post]
+func (h *UserHandler) VerifyLogin(w http.ResponseWriter, r *http.Request) {
+	var req auth.VerifyLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	u, tokens, err := h.UC.Auth.UseCase.CompleteLogin(r.Context(), req.ChallengeToken, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Could not start session")
+		return
+	}
+
+	h.writeLogin(w, r, u, tokens, false)
+}
+
+// GetTwoFactorStatus godoc
+// @Summary Get my two-factor status
+// @Description Tells whether the caller has two-factor authentication on, how many recovery codes are left and whether their role requires it.
+// @Tags two-factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth.TwoFactorStatus
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa [get]
+func (h *UserHandler) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	st, err := h.UC.Auth.UseCase.TwoFactorStatus(r.Context(), callerID)
+	if err != nil {
+		writeTwoFactorError(w, err, "Could not get two-factor status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, st)
+}
+
+// EnrolTwoFactor godoc
+// @Summary Start two-factor enrolment
+// @Description Generates an authenticator secret for the caller and its otpauth:// URI, to show as a QR code. Nothing changes at sign-in until the enrolment is confirmed with a first code; enrolling again before that replaces the secret. Admins and merchants only.
+// @Tags two-factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} auth.TwoFactorEnrolment
+// @Failure 403 {object} handlers.ErrorResponse "Role can't use two-factor authentication"
+// @Failure 409 {object} handlers.ErrorResponse "Already enabled"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa/enrol [post]
+func (h *UserHandler) EnrolTwoFactor(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	enrolment, err := h.UC.Auth.UseCase.EnrolTwoFactor(r.Context(), callerID)
+	if err != nil {
+		writeTwoFactorError(w, err, "Could not start two-factor enrolment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, enrolment)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor enrolment
+// @Description Turns two-factor authentication on with a first code from the newly enrolled app. Returns the recovery codes, only in this response.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body auth.TwoFactorCodeRequest true "Code from the authenticator app"
+// @Success 200 {object} auth.RecoveryCodes
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 401 {object} handlers.ErrorResponse "Wrong code"
+// @Failure 409 {object} handlers.ErrorResponse "Not enrolled or already enabled"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa/confirm [post]
+func (h *UserHandler) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	var req auth.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	codes, err := h.UC.Auth.UseCase.ConfirmTwoFactor(r.Context(), callerID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Could not confirm two-factor enrolment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, auth.RecoveryCodes{Codes: codes})
+}
+
+// DisableTwoFactor godoc
+// @Summary Turn two-factor authentication off
+// @Description Turns two-factor authentication off, proven with a code from the app or a recovery code. Refused while the caller's role requires it.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body auth.TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} map[string]string "Two-factor authentication disabled"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 401 {object} handlers.ErrorResponse "Wrong code"
+// @Failure 409 {object} handlers.ErrorResponse "Not enabled, or required for the role"
+// @Failure 429 {object} handlers.ErrorResponse "Too many wrong codes, see Retry-After"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	var req auth.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	if err := h.UC.Auth.UseCase.DisableTwoFactor(r.Context(), callerID, req.Code); err != nil {
+		writeTwoFactorError(w, err, "Could not disable two-factor authentication")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace my recovery codes
+// @Description Issues new recovery codes, proven with a code from the app or a recovery code. The old ones stop working; the new ones are returned only in this response.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body auth.TwoFactorCodeRequest true "Code from the authenticator app or a recovery code"
+// @Success 200 {object} auth.RecoveryCodes
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 401 {object} handlers.ErrorResponse "Wrong code"
+// @Failure 409 {object} handlers.ErrorResponse "Not enabled"
+// @Failure 429 {object} handlers.ErrorResponse "Too many wrong codes, see Retry-After"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa/recovery-codes [post]
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	var req auth.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	codes, err := h.UC.Auth.UseCase.RegenerateRecoveryCodes(r.Context(), callerID, req.Code)
+	if err != nil {
+		writeTwoFactorError(w, err, "Could not replace recovery codes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, auth.RecoveryCodes{Codes: codes})
+}
+
+// ListTwoFactorRequirements godoc
+// @Summary List roles requiring two-factor authentication
+// @Tags two-factor
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} auth.TwoFactorRequirement
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa/requirements [get]
+func (h *UserHandler) ListTwoFactorRequirements(w http.ResponseWriter, r *http.Request) {
+	reqs, err := h.UC.Auth.UseCase.ListTwoFactorRequirements(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not list two-factor requirements", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reqs)
+}
+
+// SetTwoFactorRequirement godoc
+// @Summary Require two-factor authentication for a role
+// @Description Makes two-factor authentication mandatory for every admin or every merchant, or optional again. Users of the role without it can only set it up until they do.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role path string true "Role (admin or merchant)"
+// @Param body body auth.TwoFactorRequirementRequest true "Whether it is required"
+// @Success 200 {object} map[string]string "Requirement updated"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request or role"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/2fa/requirements/{role} [put]
+func (h *UserHandler) SetTwoFactorRequirement(w http.ResponseWriter, r *http.Request) {
+	adminID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+
+	var req auth.TwoFactorRequirementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	role := user.Role(chi.URLParam(r, "role"))
+	if err := h.UC.Auth.UseCase.SetTwoFactorRequired(r.Context(), adminID, role, req.Required); err != nil {
+		if errors.Is(err, auth.ErrorTwoFactorNotAllowed) {
+			writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Could not update two-factor requirement", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Two-factor requirement updated"})
+}
@@ -5,16 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"net/url"
-	"os"
-	"time"
+	"strconv"
+	"strings"
 
 	"backend/internal/application"
+	"backend/internal/domain/auth"
 	"backend/internal/domain/user"
+	"backend/internal/middleware"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 )
 
@@ -51,6 +54,22 @@ func writeJSONError(w http.ResponseWriter, status int, message string, internalE
 	}
 }
 
+// decodePatch decodes a PATCH body into req. Fields req doesn't have are
+// refused rather than ignored, so immutable fields like the role or password
+// hash are never silently dropped.
+func decodePatch(r *http.Request, req any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(req)
+	if field, ok := strings.CutPrefix(fmt.Sprint(err), "json: unknown field "); ok {
+		return fmt.Errorf("field %s can't be updated", field)
+	}
+	if err != nil {
+		return errors.New("Invalid request body")
+	}
+	return nil
+}
+
 // CreateUser godoc
 // @Summary Create a new user
 // @Description Register a new user with name, email, etc.
@@ -90,6 +109,12 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The account works without a verified address, so a failed mail
+	// doesn't fail the sign-up; the user can ask for a new one
+	if err := h.UC.Auth.UseCase.SendEmailVerification(r.Context(), u); err != nil {
+		log.Printf("send email verification to user %s: %v", u.ID, err)
+	}
+
 	writeJSON(w, http.StatusCreated, map[string]any{
 		"id":         u.ID,
 		"fullName":   u.FullName,
@@ -233,18 +258,20 @@ func (h *UserHandler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateUser godoc
-// @Summary Update a specific user field
-// @Description Updates a user's specific field (e.g., FullName, Email) based on user ID
+// @Summary Update a user
+// @Description Changes a user's name, email address and/or phone number. Only the fields sent are changed. Other fields, such as the role or password, are refused. A new email address has to be verified again.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "User ID"
-// @Param data body user.UpdateUserRequest true "Field and value to update"
+// @Param data body user.UpdateUserRequest true "Fields to update"
 // @Success 200 {object} map[string]string
-// @Failure 400 {object} handlers.ErrorResponse "Invalid user ID or request body"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid user ID, request body or field"
+// @Failure 404 {object} handlers.ErrorResponse "User not found"
+// @Failure 409 {object} handlers.ErrorResponse "Email address already in use"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
-// @Router /users/{id}/update [put]
+// @Router /users/{id}/update [patch]
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	userID, err := uuid.Parse(idStr)
@@ -254,13 +281,19 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req user.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSONError(w, http.StatusBadRequest, "Invalid request body", err)
+	if err := decodePatch(r, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
 		return
 	}
 
 	if err := h.UC.Users.UseCase.UpdateUser(r.Context(), userID, &req); err != nil {
 		switch {
+		case errors.Is(err, user.ErrNothingToUpdate), errors.Is(err, user.ErrInvalidName),
+			errors.Is(err, user.ErrInvalidEmail), errors.Is(err, user.ErrInvalidPhone),
+			errors.Is(err, user.ErrInvalidDataInput):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
+		case errors.Is(err, user.ErrUserAlreadyExists):
+			writeJSONError(w, http.StatusConflict, err.Error(), err)
 		case errors.Is(err, user.ErrUserNotFound):
 			writeJSONError(w, http.StatusNotFound, "User does not exist.", err)
 		default:
@@ -270,7 +303,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusOK, map[string]string{
-		"message": fmt.Sprintf("user %s updated successfully", req.Column),
+		"message": "user updated successfully",
 	})
 }
 
@@ -284,8 +317,10 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 // @Param id path string true "User ID"
 // @Param data body user.ChangePasswordRequest true "Current and new passwords"
 // @Success 200 {object} map[string]string "Password updated successfully"
-// @Failure 400 {object} handlers.ErrorResponse "Invalid user ID or request body"
-// @Failure 401 {object} handlers.ErrorResponse "Current password incorrect"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid user ID or request body, new password too short or unchanged"
+// @Failure 404 {object} handlers.ErrorResponse "User not found"
+// @Failure 409 {object} handlers.ErrorResponse "Current password incorrect"
+// @Failure 429 {object} handlers.ErrorResponse "Too many failed attempts or account locked, see Retry-After"
 // @Failure 500 {object} handlers.ErrorResponse "Internal server error"
 // @Router /users/{id}/password [put]
 func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
@@ -302,10 +337,17 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.UC.Users.UseCase.ChangePassword(r.Context(), userID, &req); err != nil {
+	if err := h.UC.Auth.UseCase.ChangePassword(r.Context(), userID, &req, clientIP(r)); err != nil {
+		if writeThrottled(w, err) {
+			return
+		}
 		switch {
+		case errors.Is(err, user.ErrUserNotFound):
+			writeJSONError(w, http.StatusNotFound, "User does not exist.", err)
 		case errors.Is(err, user.ErrInvalidCurrentPassword):
 			writeJSONError(w, http.StatusConflict, "Current password is incorrect, try again.", err)
+		case errors.Is(err, auth.ErrorWeakPassword), errors.Is(err, user.ErrPasswordUnchanged):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), err)
 		default:
 			writeJSONError(w, http.StatusInternalServerError, "Change password failed, try again later.", err)
 		}
@@ -317,6 +359,39 @@ func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// UnlockUser godoc
+// @Summary Unlock a user's account
+// @Description Lifts a lockout after too many failed sign-in attempts and forgets the account's failures
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} map[string]string "Account unlocked"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid user ID"
+// @Failure 404 {object} handlers.ErrorResponse "User not found"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid user ID", nil)
+		return
+	}
+
+	if err := h.UC.Auth.UseCase.UnlockAccount(r.Context(), userID); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			writeJSONError(w, http.StatusNotFound, "User does not exist.", err)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Unlock account failed, try again later.", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"message": "account unlocked",
+	})
+}
+
 // GetUserByID godoc
 // @Summary Get user by ID
 // @Security BearerAuth
@@ -394,14 +469,17 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 
 // LoginUser godoc
 // @Summary Login user
-// @Description Authenticates a user using email and password and returns a JWT token.
+// @Description Authenticates a user using email and password and returns a short-lived JWT access token and a refresh token. Users with two-factor authentication get a challenge token instead, to exchange for the tokens at /public/login/2fa.
 // @Tags public
 // @Accept  json
 // @Produce  json
 // @Param user body user.LoginRequest true "User login credentials"
 // @Success 200 {object} user.LoginResponse
+// @Success 202 {object} auth.ChallengeResponse "Two-factor code required"
 // @Failure 400 {string} handlers.ErrorResponse "Invalid request"
 // @Failure 401 {string} handlers.ErrorResponse "Invalid credentials"
+// @Failure 403 {string} handlers.ErrorResponse "Account suspended or inactive"
+// @Failure 429 {string} handlers.ErrorResponse "Too many failed attempts or account locked, see Retry-After"
 // @Failure 500 {string} handlers.ErrorResponse "Internal server error"
 // @Router /public/login [post]
 func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
@@ -412,64 +490,261 @@ func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	u, err := h.UC.Users.UseCase.GetUserByEmail(r.Context(), req.Email)
-	if err != nil || !u.ComparePassword(req.Password) {
-		writeJSONError(w, http.StatusUnauthorized, "Invalid credentials", err)
+	// Repeated failures are slowed down and eventually lock the account.
+	// Suspended and inactive users are refused a session, users with
+	// two-factor authentication get a challenge first
+	u, res, err := h.UC.Auth.UseCase.Login(r.Context(), req.Email, req.Password, clientIP(r))
+	if writeThrottled(w, err) {
+		return
+	}
+	if errors.Is(err, auth.ErrorInvalidCredentials) {
+		writeJSONError(w, http.StatusUnauthorized, "Invalid credentials", nil)
+		return
+	}
+	if errors.Is(err, auth.ErrorAccountDisabled) {
+		writeJSONError(w, http.StatusForbidden, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not start session", err)
+		return
+	}
+	if res.Challenge != nil {
+		writeJSON(w, http.StatusAccepted, res.Challenge)
 		return
 	}
 
-	// Update last login
-	reqUpdate := &user.UpdateUserRequest{
-		Column: "last_login",
-		Value:  time.Now(),
+	h.writeLogin(w, r, u, res.Tokens, res.MustEnrolTwoFactor)
+}
+
+// writeThrottled answers a throttled password attempt with 429 and when to
+// retry. It reports whether err was one.
+func writeThrottled(w http.ResponseWriter, err error) bool {
+	var te *auth.ThrottledError
+	if !errors.As(err, &te) {
+		return false
 	}
-	if err := h.UC.Users.UseCase.UpdateUser(r.Context(), u.ID, reqUpdate); err != nil {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(te.RetryAfter.Seconds()))))
+	writeJSONError(w, http.StatusTooManyRequests, te.Error(), nil)
+	return true
+}
+
+// clientIP is the address a request came from. The RealIP middleware puts
+// the proxy's forwarded address there.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// writeLogin records the sign-in and hands the client its tokens.
+func (h *UserHandler) writeLogin(w http.ResponseWriter, r *http.Request, u *user.User, tokens *auth.TokenPair, mustEnrolTwoFactor bool) {
+	// Update last login
+	if err := h.UC.Users.UseCase.RecordLogin(r.Context(), u.ID); err != nil {
 		log.Printf("failed to update last login for user %s: %v", u.ID, err)
 		writeJSONError(w, http.StatusInternalServerError, "Update last login failed.", err)
 		return
 	}
 
-	// Load the JWT secret from env
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		writeJSONError(w, http.StatusInternalServerError, "JWT secret not configured", err)
+	// Return the tokens in the response
+	response := user.LoginResponse{
+		ID:                    u.ID.String(),
+		FullName:              u.FullName,
+		Email:                 u.Email,
+		Role:                  string(u.Role),
+		Token:                 tokens.AccessToken,
+		TokenExpiresAt:        tokens.AccessTokenExpiresAt,
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt,
+		MustChangePassword:    u.Must_change_password,
+		MustEnrolTwoFactor:    mustEnrolTwoFactor,
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchanges a refresh token for a new access token and refresh token. Each refresh token can be used once; reusing one signs out the whole session.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.RefreshRequest true "Refresh token"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 401 {object} handlers.ErrorResponse "Invalid, expired or reused refresh token"
+// @Failure 403 {object} handlers.ErrorResponse "Account suspended or inactive"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/refresh [post]
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req auth.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
 		return
 	}
 
-	// Create the token
-	claims := jwt.MapClaims{
-		"iss":        "my-client",   // Kong
-		"sub":        u.ID.String(), // subject
-		"email":      u.Email,
-		"role":       u.Role, // custom claim
-		"name":       u.FullName,
-		"phone":      u.Phone,
-		"slug":       u.Slug,
-		"status":     string(u.Status),
-		"last_login": u.LastLogin.Unix(),
-		"created_at": u.CreatedAt.Unix(),
-		"exp":        time.Now().Add(time.Hour * 24).Unix(), // expires in 24h
+	tokens, err := h.UC.Auth.UseCase.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidRefreshToken), errors.Is(err, auth.ErrorRefreshTokenReused):
+			writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		case errors.Is(err, auth.ErrorAccountDisabled):
+			writeJSONError(w, http.StatusForbidden, err.Error(), nil)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Could not refresh token", err)
+		}
+		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	writeJSON(w, http.StatusOK, tokens)
+}
 
-	// Sign it using the secret
-	signedToken, err := token.SignedString([]byte(jwtSecret))
+// Logout godoc
+// @Summary Logout
+// @Description Revokes the session of the calling access token, its refresh token and every other access token issued for it.
+// @Tags users
+// @Produce  json
+// @Security BearerAuth
+// @Success 200 {object} map[string]string "Logged out"
+// @Failure 401 {object} handlers.ErrorResponse "Session already revoked"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /users/logout [post]
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	callerID, _, err := middleware.GetCallerFromContext(r.Context())
+	if err != nil {
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+		return
+	}
+	sessionID, err := middleware.GetSessionIDFromContext(r.Context())
 	if err != nil {
-		writeJSONError(w, http.StatusInternalServerError, "Failed to sign token", err)
+		writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
 		return
 	}
 
-	// Return the token in the response
-	response := user.LoginResponse{
-		ID:       u.ID.String(),
-		FullName: u.FullName,
-		Email:    u.Email,
-		Role:     string(u.Role),
-		Token:    signedToken,
+	if err := h.UC.Auth.UseCase.Logout(r.Context(), callerID, sessionID); err != nil {
+		if errors.Is(err, auth.ErrorSessionRevoked) {
+			writeJSONError(w, http.StatusUnauthorized, err.Error(), nil)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Could not log out", err)
+		return
 	}
 
-	writeJSON(w, http.StatusOK, response)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Emails a single-use password reset link. The response is the same whether or not the address has an account.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.ForgotPasswordRequest true "Account email"
+// @Success 202 {object} map[string]string "Reset email sent if the account exists"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/forgot-password [post]
+func (h *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req auth.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	if err := h.UC.Auth.UseCase.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not send reset email", err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"message": "If the account exists, a reset link has been sent"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password with a token from a reset email. The token works once, and every session of the user is signed out.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} map[string]string "Password reset"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid or expired token, or weak password"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/reset-password [post]
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req auth.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	if err := h.UC.Auth.UseCase.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrorInvalidToken), errors.Is(err, auth.ErrorWeakPassword):
+			writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "Could not reset password", err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Password reset"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirms the account's email address with a token from a verification email.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} map[string]string "Email verified"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid or expired token"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/verify-email [post]
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req auth.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	if err := h.UC.Auth.UseCase.VerifyEmail(r.Context(), req.Token); err != nil {
+		if errors.Is(err, auth.ErrorInvalidToken) {
+			writeJSONError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		writeJSONError(w, http.StatusInternalServerError, "Could not verify email", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Email verified"})
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Emails a new verification link, replacing earlier ones. The response is the same whether or not the address has an account.
+// @Tags public
+// @Accept  json
+// @Produce  json
+// @Param body body auth.ResendVerificationRequest true "Account email"
+// @Success 202 {object} map[string]string "Verification email sent if needed"
+// @Failure 400 {object} handlers.ErrorResponse "Invalid request"
+// @Failure 500 {object} handlers.ErrorResponse "Internal server error"
+// @Router /public/resend-verification [post]
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req auth.ResendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		writeJSONError(w, http.StatusBadRequest, "Invalid request", nil)
+		return
+	}
+
+	if err := h.UC.Auth.UseCase.ResendEmailVerification(r.Context(), req.Email); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "Could not send verification email", err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"message": "If the address needs verifying, a link has been sent"})
 }
 
 // DeleteUser godoc
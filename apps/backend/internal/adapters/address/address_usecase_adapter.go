@@ -0,0 +1,18 @@
+package addressadapter
+
+import (
+	"context"
+
+	"backend/internal/domain/address"
+	addressusecase "backend/internal/usecase/address"
+
+	"github.com/google/uuid"
+)
+
+type UseCaseAdapter struct {
+	UseCase *addressusecase.UseCase
+}
+
+func (a *UseCaseAdapter) GetAddressForUser(ctx context.Context, userID, id uuid.UUID) (*address.Address, error) {
+	return a.UseCase.GetAddressForUser(ctx, userID, id)
+}
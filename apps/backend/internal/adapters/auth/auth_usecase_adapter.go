@@ -0,0 +1,7 @@
+package authadapter
+
+import authusecase "backend/internal/usecase/auth"
+
+type UseCaseAdapter struct {
+	UseCase *authusecase.UseCase
+}
@@ -0,0 +1,9 @@
+package cartadapter
+
+import (
+	cartusecase "backend/internal/usecase/cart"
+)
+
+type UseCaseAdapter struct {
+	UseCase *cartusecase.UseCase
+}
@@ -3,6 +3,7 @@ package delivery
 import (
 	"context"
 	"backend/internal/domain/delivery"
+	"backend/internal/domain/order"
 	deliveryusecase "backend/internal/usecase/delivery"
 
 	"github.com/google/uuid"
@@ -15,3 +16,7 @@ type UseCaseAdapter struct {
 func (a *UseCaseAdapter) GetDeliveryByID(ctx context.Context, id uuid.UUID) (*delivery.Delivery, error) {
 	return a.UseCase.GetDeliveryByID(ctx, id)
 }
+
+func (a *UseCaseAdapter) ApplyTransition(ctx context.Context, o *order.Order, t order.Transition) error {
+	return a.UseCase.ApplyTransition(ctx, o, t)
+}
@@ -31,6 +31,6 @@ func (a *UseCaseAdapter) GetDriverByID(ctx context.Context, id uuid.UUID) (*driv
 	return driver, nil
 }
 
-func (a *UseCaseAdapter) UpdateDriverAvailability(ctx context.Context, driverID uuid.UUID, column string, value bool) error {
-	return a.UseCase.UpdateDriverAvailability(ctx, driverID, column, value)
+func (a *UseCaseAdapter) UpdateDriverAvailability(ctx context.Context, driverID uuid.UUID, available bool) error {
+	return a.UseCase.UpdateDriverAvailability(ctx, driverID, available)
 }
@@ -16,6 +16,14 @@ func (a *UseCaseAdapter) GetOrderByID(ctx context.Context, id uuid.UUID) (*order
 	return a.UseCase.GetOrder(ctx, id)
 }
 
-func (a *UseCaseAdapter) UpdateOrder(ctx context.Context, orderID uuid.UUID, column string, value any) error {
-	return a.UseCase.UpdateOrder(ctx, orderID, column, value)
+func (a *UseCaseAdapter) UpdateOrder(ctx context.Context, orderID, callerID uuid.UUID, actor order.Actor, req *order.UpdateOrderRequest) error {
+	return a.UseCase.UpdateOrder(ctx, orderID, callerID, actor, req)
+}
+
+func (a *UseCaseAdapter) TransitionOrder(ctx context.Context, orderID uuid.UUID, to order.OrderStatus, actor order.Actor, actorID uuid.UUID) (*order.Order, error) {
+	return a.UseCase.TransitionOrder(ctx, orderID, to, actor, actorID)
+}
+
+func (a *UseCaseAdapter) CreateOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) (*order.Order, error) {
+	return a.UseCase.CreateOrder(ctx, customerID, req)
 }
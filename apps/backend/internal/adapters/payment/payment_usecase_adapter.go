@@ -0,0 +1,18 @@
+package paymentadapter
+
+import (
+	"context"
+
+	paymentusecase "backend/internal/usecase/payment"
+
+	"github.com/google/uuid"
+)
+
+type UseCaseAdapter struct {
+	UseCase *paymentusecase.UseCase
+}
+
+func (a *UseCaseAdapter) RequestRefund(ctx context.Context, orderID uuid.UUID, reason string) error {
+	_, err := a.UseCase.RequestRefund(ctx, orderID, reason)
+	return err
+}
@@ -0,0 +1,19 @@
+package pricingadapter
+
+import (
+	"context"
+
+	"backend/internal/domain/pricing"
+	pricingusecase "backend/internal/usecase/pricing"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+)
+
+type UseCaseAdapter struct {
+	UseCase *pricingusecase.UseCase
+}
+
+func (a *UseCaseAdapter) Quote(ctx context.Context, storeID uuid.UUID, subtotal int64, currency string, pickup, delivery postgis.PointS) (*pricing.Breakdown, error) {
+	return a.UseCase.Quote(ctx, storeID, subtotal, currency, pickup, delivery)
+}
@@ -0,0 +1,26 @@
+package promotionadapter
+
+import (
+	"context"
+
+	"backend/internal/domain/promotion"
+	promotionusecase "backend/internal/usecase/promotion"
+
+	"github.com/google/uuid"
+)
+
+type UseCaseAdapter struct {
+	UseCase *promotionusecase.UseCase
+}
+
+func (a *UseCaseAdapter) ApplyPromotion(ctx context.Context, code string, b promotion.Basket) (*promotion.Application, error) {
+	return a.UseCase.ApplyPromotion(ctx, code, b)
+}
+
+func (a *UseCaseAdapter) RecordRedemption(ctx context.Context, app *promotion.Application, orderID, customerID uuid.UUID) error {
+	return a.UseCase.RecordRedemption(ctx, app, orderID, customerID)
+}
+
+func (a *UseCaseAdapter) ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error {
+	return a.UseCase.ReleaseRedemption(ctx, orderID)
+}
@@ -0,0 +1,7 @@
+package storefrontadapter
+
+import storefrontusecase "backend/internal/usecase/storefront"
+
+type UseCaseAdapter struct {
+	UseCase *storefrontusecase.UseCase
+}
@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/internal/domain/notification"
+	order "backend/internal/domain/order"
+)
+
+// expiryBatchSize caps how many orders a single sweep cancels.
+const expiryBatchSize = 100
+
+// ExpirePendingOrders cancels unpaid pending orders older than ttl, returns
+// their stock and tells each customer. Orders that moved on while the sweep
+// ran are skipped. It returns the orders that were expired.
+func (s *OrderService) ExpirePendingOrders(ctx context.Context, ttl time.Duration) ([]*order.Order, error) {
+	candidates, err := s.Orders.UseCase.ListExpiredPendingOrders(ctx, ttl, expiryBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetch expired orders failed: %w", err)
+	}
+
+	var expired []*order.Order
+	for _, c := range candidates {
+		o, err := s.Orders.UseCase.ExpireOrder(ctx, c.ID)
+		if err != nil {
+			if errors.Is(err, order.ErrorStatusConflict) {
+				continue // paid, assigned or cancelled in the meantime
+			}
+			return expired, fmt.Errorf("expire order %s: %w", c.ID, err)
+		}
+
+		_ = s.Notifications.UseCase.CreateNotification(ctx, &notification.Notification{
+			UserID:  o.CustomerID,
+			Message: fmt.Sprintf("⌛ Your order %s was cancelled because it wasn't paid in time. The items are back in stock.", o.ID),
+			Type:    notification.System,
+			Status:  notification.Pending,
+		})
+
+		expired = append(expired, o)
+	}
+
+	return expired, nil
+}
+
+// RunOrderExpiry sweeps for expired pending orders every interval until ctx
+// is cancelled.
+func (s *OrderService) RunOrderExpiry(ctx context.Context, ttl, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := s.ExpirePendingOrders(ctx, ttl)
+			if err != nil {
+				log.Printf("order expiry: %v", err)
+			}
+			if len(expired) > 0 {
+				log.Printf("order expiry: cancelled %d unpaid orders", len(expired))
+			}
+		}
+	}
+}
@@ -1,18 +1,23 @@
 package application
 
 import (
+	addressadapter "backend/internal/adapters/address"
+	authadapter "backend/internal/adapters/auth"
+	cartadapter "backend/internal/adapters/cart"
 	deliveryadapter "backend/internal/adapters/delivery"
 	driveradapter "backend/internal/adapters/driver"
 	notificationadapter "backend/internal/adapters/notification"
 	orderadapter "backend/internal/adapters/order"
+	pricingadapter "backend/internal/adapters/pricing"
 	productadapter "backend/internal/adapters/product"
+	promotionadapter "backend/internal/adapters/promotion"
 	storeadapter "backend/internal/adapters/store"
+	storefrontadapter "backend/internal/adapters/storefront"
 	useradapter "backend/internal/adapters/user"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"sort"
 
 	"backend/internal/domain/driver"
 	"backend/internal/domain/notification"
@@ -34,6 +39,12 @@ type OrderService struct {
 	Notifications *notificationadapter.UseCaseAdapter
 	Products      *productadapter.UseCaseAdapter
 	Stores        *storeadapter.UseCaseAdapter
+	Pricing       *pricingadapter.UseCaseAdapter
+	Promotions    *promotionadapter.UseCaseAdapter
+	Carts         *cartadapter.UseCaseAdapter
+	Addresses     *addressadapter.UseCaseAdapter
+	Storefront    *storefrontadapter.UseCaseAdapter
+	Auth          *authadapter.UseCaseAdapter
 }
 
 func NewOrderService(
@@ -44,6 +55,12 @@ func NewOrderService(
 	notificationUC *notificationadapter.UseCaseAdapter,
 	productUC *productadapter.UseCaseAdapter,
 	storeUC *storeadapter.UseCaseAdapter,
+	pricingUC *pricingadapter.UseCaseAdapter,
+	promotionUC *promotionadapter.UseCaseAdapter,
+	cartUC *cartadapter.UseCaseAdapter,
+	addressUC *addressadapter.UseCaseAdapter,
+	storefrontUC *storefrontadapter.UseCaseAdapter,
+	authUC *authadapter.UseCaseAdapter,
 ) *OrderService {
 	return &OrderService{
 		Users:         userUC,
@@ -53,6 +70,12 @@ func NewOrderService(
 		Notifications: notificationUC,
 		Products:      productUC,
 		Stores:        storeUC,
+		Pricing:       pricingUC,
+		Promotions:    promotionUC,
+		Carts:         cartUC,
+		Addresses:     addressUC,
+		Storefront:    storefrontUC,
+		Auth:          authUC,
 	}
 }
 
@@ -96,22 +119,15 @@ func (s *OrderService) GetOrderWithDriver(ctx context.Context, orderID uuid.UUID
 }
 
 func (s *OrderService) OrderAssignment(ctx context.Context, maxDistance float64) ([]Assignment, error) {
-	// 1. Fetch all pending orders
-	allOrders, err := s.Orders.UseCase.ListOrders(ctx)
+	// 1. Fetch all pending orders, oldest first
+	pendingOrders, err := s.Orders.UseCase.ListOrdersByStatus(ctx, order.Pending)
 	if err != nil {
-		return nil, fmt.Errorf("fetch all orders failed: %w", err)
+		return nil, fmt.Errorf("fetch pending orders failed: %w", err)
 	}
-
-	pendingOrders := filterPendingOrders(allOrders)
 	if len(pendingOrders) == 0 {
 		return nil, fmt.Errorf("no pending orders found")
 	}
 
-	// sort by oldest first
-	sort.Slice(pendingOrders, func(i, j int) bool {
-		return pendingOrders[i].CreatedAt.Before(pendingOrders[j].CreatedAt)
-	})
-
 	// 2. Get available drivers
 	availableDrivers, err := s.Drivers.UseCase.ListAvailableDrivers(ctx, true)
 	if err != nil {
@@ -153,14 +169,13 @@ func (s *OrderService) OrderAssignment(ctx context.Context, maxDistance float64)
 
 		// 5. Perform assignment via order domain
 		if designatedDriver != nil {
-			_, err := s.Orders.UseCase.AssignOrderToDriver(ctx, o.ID, designatedDriver.ID, maxDistance)
-			if err != nil {
+			if err := s.Orders.UseCase.AssignOrderToDriver(ctx, o.ID, designatedDriver.ID); err != nil {
 				continue
 			}
 
 			// Create a notification for both driver and customer
 			msgCustomer := fmt.Sprintf("Your order %s has been assigned to driver %s", o.ID.String(), designatedDriver.FullName)
-			msgDriver := fmt.Sprintf("You have been assigned a new delivery: order %s", o.ID.String())
+			msgDriver := fmt.Sprintf("You have been assigned a new delivery: order %s (%d items)", o.ID.String(), len(o.Items))
 
 			// Customer notification
 			_ = s.Notifications.UseCase.CreateNotification(ctx, &notification.Notification{
@@ -208,13 +223,3 @@ func (s *OrderService) OrderAssignment(ctx context.Context, maxDistance float64)
 
 	return assignments, nil
 }
-
-func filterPendingOrders(orders []*order.Order) []*order.Order {
-	var pending []*order.Order
-	for _, o := range orders {
-		if o.Status == order.Pending {
-			pending = append(pending, o)
-		}
-	}
-	return pending
-}
@@ -24,6 +24,12 @@ func NewTxManager(db *sqlx.DB) *SQLTxManager {
 }
 
 func (m *SQLTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Join the surrounding transaction instead of opening a second one,
+	// which could block on rows the outer transaction already locked.
+	if GetTx(ctx) != nil {
+		return fn(ctx)
+	}
+
 	tx, err := m.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
@@ -31,7 +37,7 @@ func (m *SQLTxManager) Do(ctx context.Context, fn func(ctx context.Context) erro
 
 	txCtx := common.MarkTx(ctx)
 	// Store the transaction in a new ctx
-	txCtx = context.WithValue(ctx, txCtxKey{}, tx)
+	txCtx = context.WithValue(txCtx, txCtxKey{}, tx)
 
 	// Run the business logic
 	if err := fn(txCtx); err != nil {
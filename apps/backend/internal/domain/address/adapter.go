@@ -0,0 +1,13 @@
+package address
+
+import (
+	"backend/internal/domain/geocoding"
+	"context"
+)
+
+// Geocoder fills in the coordinates or the text of an address. It is
+// implemented by the geocoding providers.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (*geocoding.Place, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.Place, error)
+}
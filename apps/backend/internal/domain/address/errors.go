@@ -0,0 +1,12 @@
+package address
+
+import "errors"
+
+var (
+	ErrorAddressNotFound    = errors.New("address not found")
+	ErrorInvalidLabel       = errors.New("label is required and must be at most 50 characters")
+	ErrorInvalidAddress     = errors.New("address is required")
+	ErrorInvalidCoordinates = errors.New("lat must be within [-90, 90] and lng within [-180, 180]")
+	ErrorLocationNotFound   = errors.New("address could not be found on the map")
+	ErrorForbidden          = errors.New("not allowed to manage this user's addresses")
+)
@@ -0,0 +1,74 @@
+package address
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+)
+
+// MaxLabelLength caps labels such as "Home" or "Office".
+const MaxLabelLength = 50
+
+// Address is a saved delivery location of a customer. At most one address
+// per customer is the default one.
+type Address struct {
+	ID        uuid.UUID      `db:"id" json:"id"`
+	UserID    uuid.UUID      `db:"user_id" json:"user_id"`
+	Label     string         `db:"label" json:"label"`
+	Address   string         `db:"address" json:"address"`
+	Point     postgis.PointS `db:"point" json:"point"`
+	IsDefault bool           `db:"is_default" json:"is_default"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+}
+
+// NewPoint builds a WGS84 point from a latitude and longitude.
+func NewPoint(lat, lng float64) postgis.PointS {
+	return postgis.PointS{SRID: 4326, X: lng, Y: lat}
+}
+
+// ValidCoordinates reports whether lat/lng lie on the globe. (0, 0), the
+// value of unset coordinates, is refused as well: it is in the Atlantic.
+func ValidCoordinates(lat, lng float64) bool {
+	if lat == 0 && lng == 0 {
+		return false
+	}
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// Validate checks an address before it is saved.
+func (a *Address) Validate() error {
+	a.Label = strings.TrimSpace(a.Label)
+	a.Address = strings.TrimSpace(a.Address)
+
+	if a.Label == "" || len(a.Label) > MaxLabelLength {
+		return ErrorInvalidLabel
+	}
+	if a.Address == "" {
+		return ErrorInvalidAddress
+	}
+	if !ValidCoordinates(a.Point.Y, a.Point.X) {
+		return ErrorInvalidCoordinates
+	}
+	return nil
+}
+
+// Point represents a simple GeoJSON-style point for Swagger only.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// AddressDoc mirrors Address for Swagger.
+type AddressDoc struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Label     string    `json:"label"`
+	Address   string    `json:"address"`
+	Point     Point     `json:"point"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
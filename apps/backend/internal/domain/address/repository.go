@@ -0,0 +1,28 @@
+package address
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// Create inserts an address
+	Create(ctx context.Context, a *Address) error
+
+	// GetByID fetches an address of a user, failing with
+	// ErrorAddressNotFound if the user has no such address
+	GetByID(ctx context.Context, userID, id uuid.UUID) (*Address, error)
+
+	// ListByUser returns the addresses of a user, default first
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*Address, error)
+
+	// Update saves label, address, point and default flag
+	Update(ctx context.Context, a *Address) error
+
+	// Delete removes an address of a user
+	Delete(ctx context.Context, userID, id uuid.UUID) error
+
+	// ClearDefault unsets the default flag on every address of a user
+	ClearDefault(ctx context.Context, userID uuid.UUID) error
+}
@@ -0,0 +1,60 @@
+package address
+
+import (
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+)
+
+type CreateAddressRequest struct {
+	Label string `json:"label" binding:"required"` // e.g. "Home", "Office"
+
+	// Send the address, the coordinates or both: a missing address is
+	// looked up from the coordinates and missing coordinates from the address
+	Address string  `json:"address"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+
+	IsDefault bool `json:"is_default"`
+}
+
+// ToAddress maps the request onto a new address of the user.
+func (r *CreateAddressRequest) ToAddress(userID uuid.UUID) *Address {
+	return &Address{
+		UserID:    userID,
+		Label:     r.Label,
+		Address:   r.Address,
+		Point:     NewPoint(r.Lat, r.Lng),
+		IsDefault: r.IsDefault,
+	}
+}
+
+// UpdateAddressRequest changes the fields that are set. Lat and Lng go
+// together; a new address without them is geocoded again.
+type UpdateAddressRequest struct {
+	Label     *string  `json:"label,omitempty"`
+	Address   *string  `json:"address,omitempty"`
+	Lat       *float64 `json:"lat,omitempty"`
+	Lng       *float64 `json:"lng,omitempty"`
+	IsDefault *bool    `json:"is_default,omitempty"`
+}
+
+// Apply copies the set fields onto a.
+func (r *UpdateAddressRequest) Apply(a *Address) error {
+	if r.Label != nil {
+		a.Label = *r.Label
+	}
+	if r.Address != nil {
+		a.Address = *r.Address
+		a.Point = postgis.PointS{}
+	}
+	if (r.Lat == nil) != (r.Lng == nil) {
+		return ErrorInvalidCoordinates
+	}
+	if r.Lat != nil {
+		a.Point = NewPoint(*r.Lat, *r.Lng)
+	}
+	if r.IsDefault != nil {
+		a.IsDefault = *r.IsDefault
+	}
+	return nil
+}
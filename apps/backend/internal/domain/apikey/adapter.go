@@ -0,0 +1,21 @@
+package apikey
+
+import (
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UserReader loads the owner of a key, whose role and status the key's
+// requests run with. It is implemented by the user repository.
+type UserReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*user.User, error)
+}
+
+// StoreAccess tells a user's role in a store, so keys are only restricted
+// to stores their owner works in. It is implemented by the store repository.
+type StoreAccess interface {
+	GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error)
+}
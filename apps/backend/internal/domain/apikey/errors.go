@@ -0,0 +1,13 @@
+package apikey
+
+import "errors"
+
+var (
+	ErrorKeyNotFound     = errors.New("api key not found")
+	ErrorInvalidKey      = errors.New("invalid or revoked api key")
+	ErrorMissingName     = errors.New("api key name is required")
+	ErrorInvalidScope    = errors.New("unknown api key scope")
+	ErrorNoScopes        = errors.New("api key needs at least one scope")
+	ErrorNotMerchant     = errors.New("only merchants can create api keys")
+	ErrorStoreNotAllowed = errors.New("not staff of this store")
+)
@@ -0,0 +1,24 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	Create(ctx context.Context, k *APIKey) error
+
+	// GetByHash returns the key with the hash, revoked or not.
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+
+	// ListByUser returns a user's keys, newest first, revoked ones included.
+	ListByUser(ctx context.Context, userID uuid.UUID) ([]*APIKey, error)
+
+	// Revoke returns ErrorKeyNotFound unless the user has an unrevoked key
+	// with the id.
+	Revoke(ctx context.Context, id, userID uuid.UUID, revokedAt time.Time) error
+
+	TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -0,0 +1,20 @@
+package apikey
+
+import "github.com/google/uuid"
+
+// CreateAPIKeyRequest names a new key and what it may do. Without StoreID
+// the key reaches every store its owner does.
+type CreateAPIKeyRequest struct {
+	Name    string     `json:"name" binding:"required"`
+	Scopes  []Scope    `json:"scopes" binding:"required"`
+	StoreID *uuid.UUID `json:"store_id,omitempty"`
+}
+
+func (r *CreateAPIKeyRequest) ToAPIKey(userID uuid.UUID) *APIKey {
+	return &APIKey{
+		UserID:  userID,
+		StoreID: r.StoreID,
+		Name:    r.Name,
+		Scopes:  r.Scopes,
+	}
+}
@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"backend/internal/domain/user"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UserReader loads and updates the credentials of users, and creates the
+// customers who first sign in by phone. It is implemented by the user
+// repository.
+type UserReader interface {
+	Create(ctx context.Context, u *user.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*user.User, error)
+	GetByEmail(ctx context.Context, email string) (*user.User, error)
+	GetCustomerByVerifiedPhone(ctx context.Context, phone string) (*user.User, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrorInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrorRefreshTokenReused  = errors.New("refresh token was already used, sign in again")
+	ErrorSessionRevoked      = errors.New("session revoked or expired")
+	ErrorAccountDisabled     = errors.New("account is suspended or inactive")
+	ErrorInvalidToken        = errors.New("invalid or expired token")
+	ErrorWeakPassword        = errors.New("password must be at least 8 characters")
+
+	ErrorInvalidChallenge        = errors.New("invalid or expired login challenge, sign in again")
+	ErrorInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrorTwoFactorNotAllowed     = errors.New("two-factor authentication is only available to admins and merchants")
+	ErrorTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrorTwoFactorNotEnrolled    = errors.New("start two-factor enrolment first")
+	ErrorTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrorTwoFactorRequired       = errors.New("two-factor authentication is required for your role")
+
+	ErrorInvalidCredentials = errors.New("invalid email or password")
+	ErrorTooManyAttempts    = errors.New("too many failed attempts, try again later")
+	ErrorAccountLocked      = errors.New("account temporarily locked after too many failed attempts")
+
+	ErrorInvalidPhoneCode    = errors.New("invalid or expired code, request a new one")
+	ErrorTooManyCodeRequests = errors.New("too many codes requested, try again later")
+)
+
+// ThrottledError refuses a password or two-factor code attempt made too
+// soon after failed ones, or a phone code requested too soon after others. It wraps
+// ErrorTooManyAttempts, ErrorAccountLocked or ErrorTooManyCodeRequests.
+type ThrottledError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string { return e.Err.Error() }
+
+func (e *ThrottledError) Unwrap() error { return e.Err }
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"backend/internal/domain/user"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+	EmailVerificationTTL = 48 * time.Hour
+	PasswordResetTTL     = time.Hour
+
+	MinPasswordLength = 8
+
+	// LoginChallengeTTL is how long a user has to enter their second factor
+	// after their password, and MaxChallengeAttempts how many codes they
+	// may try meanwhile.
+	LoginChallengeTTL    = 5 * time.Minute
+	MaxChallengeAttempts = 5
+
+	RecoveryCodeCount = 10
+
+	DefaultTOTPIssuer = "Fastabiz"
+)
+
+// Reasons recorded when a session is revoked.
+const (
+	RevokedByLogout        = "logout"
+	RevokedByReuse         = "refresh token reused"
+	RevokedByPasswordReset = "password reset"
+)
+
+// Purpose tells what a one-time token may be used for.
+type Purpose string
+
+const (
+	PurposeEmailVerification Purpose = "email_verification"
+	PurposePasswordReset     Purpose = "password_reset"
+)
+
+// Config holds the signing secret and lifetimes of issued tokens.
+// AppBaseURL, when set, turns mailed tokens into links to the app.
+// TOTPIssuer names the service in authenticator apps.
+type Config struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	AppBaseURL      string
+	TOTPIssuer      string
+}
+
+// Session is one sign-in of a user. Each refresh replaces its refresh token
+// with a new one of the same family; revoking the session ends them all, and
+// the access tokens issued for it, at once.
+type Session struct {
+	ID            uuid.UUID  `db:"id" json:"id"`
+	UserID        uuid.UUID  `db:"user_id" json:"user_id"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	RevokedAt     *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	RevokedReason *string    `db:"revoked_reason" json:"revoked_reason,omitempty"`
+}
+
+// RefreshToken is stored by its SHA-256 hash only. UsedAt is set once the
+// token has been exchanged; presenting it again means it leaked.
+type RefreshToken struct {
+	ID        uuid.UUID  `db:"id"`
+	SessionID uuid.UUID  `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// SessionState is what requests are checked against: whether the session
+// is still open, whether its user may still sign in and whether they have
+// to change their password or set up two-factor authentication first.
+type SessionState struct {
+	UserID             uuid.UUID       `db:"user_id"`
+	Status             user.UserStatus `db:"status"`
+	MustChangePassword bool            `db:"must_change_password"`
+	MustEnrolTwoFactor bool            `db:"must_enrol_two_factor"`
+	RevokedAt          *time.Time      `db:"revoked_at"`
+}
+
+// OneTimeToken is mailed to a user to prove they own their email address.
+// Like refresh tokens it is stored hashed and can be used once.
+type OneTimeToken struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	Purpose   Purpose    `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// TwoFactor is a user's TOTP authenticator. It only guards sign-in once
+// ConfirmedAt is set, i.e. once the user proved their app produces codes.
+// LastUsedStep is the time step of the last code accepted, so a code can't
+// be replayed.
+type TwoFactor struct {
+	UserID       uuid.UUID  `db:"user_id"`
+	Secret       string     `db:"secret"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// Enabled reports whether sign-in asks for a code.
+func (t *TwoFactor) Enabled() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+// TwoFactorEnrolment is handed to the user once to set up their app with.
+type TwoFactorEnrolment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// RecoveryCodes are handed to the user once, to sign in with when they
+// lose their authenticator. Each works once.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatus tells a user where their two-factor set-up stands.
+// Required is set when their role may not sign in without it.
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+	Required          bool       `json:"required"`
+}
+
+// TwoFactorRequirement makes two-factor authentication mandatory for every
+// user of a role.
+type TwoFactorRequirement struct {
+	Role       user.Role  `db:"role" json:"role"`
+	RequiredBy *uuid.UUID `db:"required_by" json:"required_by,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+// LoginChallenge is opened when a password was right but a second factor is
+// due. Its token stands in for the password until the code is entered; like
+// other tokens it is stored hashed.
+type LoginChallenge struct {
+	ID        uuid.UUID  `db:"id"`
+	UserID    uuid.UUID  `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	Attempts  int        `db:"attempts"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// LoginResult is either a session's tokens or, for users with two-factor
+// authentication, the challenge to answer with a code.
+type LoginResult struct {
+	Tokens             *TokenPair
+	Challenge          *ChallengeResponse
+	MustEnrolTwoFactor bool
+}
+
+// ChallengeResponse is handed to the client when a second factor is due.
+type ChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required" example:"true"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// TokenPair is handed to the client on login and on every refresh.
+type TokenPair struct {
+	AccessToken           string    `json:"token"`
+	AccessTokenExpiresAt  time.Time `json:"token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// HashToken returns the form refresh tokens are stored and looked up in.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// CanUseTwoFactor reports whether users of role can set up two-factor
+// authentication, i.e. those managing payouts or other users.
+func CanUseTwoFactor(role user.Role) bool {
+	return role == user.Admin || role == user.Merchant
+}
+
+// CanSignIn reports whether users with the given status may hold a session.
+func CanSignIn(status user.UserStatus) bool {
+	return status != user.Suspended && status != user.Inactive
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customers can sign in with a code texted to their phone instead of a
+// password. A code works once, for a few minutes and a few tries, and only
+// the last one sent to a number is valid. Sending is limited per number and
+// per client IP, since every text costs money.
+const (
+	PhoneCodeLength      = 6
+	PhoneCodeTTL         = 5 * time.Minute
+	MaxPhoneCodeAttempts = 5
+
+	PhoneCodeResendInterval = time.Minute
+	PhoneCodeWindow         = time.Hour
+	MaxPhoneCodesPerPhone   = 5
+	MaxPhoneCodesPerIP      = 20
+)
+
+// GuestName is the name given to customers created by their first sign-in
+// by phone, until they fill in their profile.
+const GuestName = "Guest"
+
+// PhoneCode is a sign-in code texted to a normalised phone number. Only its
+// hash is stored.
+type PhoneCode struct {
+	ID        uuid.UUID  `db:"id"`
+	Phone     string     `db:"phone"`
+	CodeHash  string     `db:"code_hash"`
+	IP        string     `db:"ip"`
+	Attempts  int        `db:"attempts"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
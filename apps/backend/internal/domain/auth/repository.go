@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"backend/internal/domain/user"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Repository stores sessions and the hashed refresh tokens issued for them,
+// mailed one-time tokens, the second factors users sign in with, the
+// failed password attempts sign-in is throttled by and the codes texted to
+// customers signing in by phone.
+type Repository interface {
+	CreateSession(ctx context.Context, s *Session) error
+
+	// GetSessionState returns sql.ErrNoRows for unknown sessions.
+	GetSessionState(ctx context.Context, sessionID uuid.UUID) (*SessionState, error)
+
+	// RevokeSession is a no-op for sessions that are already revoked.
+	RevokeSession(ctx context.Context, sessionID uuid.UUID, reason string) error
+
+	CreateRefreshToken(ctx context.Context, t *RefreshToken) error
+
+	// GetRefreshTokenForUpdate locks the token with the given hash for the
+	// rest of the transaction, so concurrent refreshes can't both use it.
+	// Returns sql.ErrNoRows for unknown tokens.
+	GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (*RefreshToken, error)
+
+	MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+
+	// RevokeUserSessions revokes every open session of a user.
+	RevokeUserSessions(ctx context.Context, userID uuid.UUID, reason string) error
+
+	// CreateOneTimeToken stores a token and retires the unused tokens the
+	// user holds for the same purpose, so only the latest mail works.
+	CreateOneTimeToken(ctx context.Context, t *OneTimeToken) error
+
+	// GetOneTimeTokenForUpdate locks the token with the given hash and
+	// purpose. Returns sql.ErrNoRows for unknown tokens.
+	GetOneTimeTokenForUpdate(ctx context.Context, tokenHash string, purpose Purpose) (*OneTimeToken, error)
+
+	MarkOneTimeTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+
+	// GetTwoFactor returns sql.ErrNoRows for users who never enrolled.
+	GetTwoFactor(ctx context.Context, userID uuid.UUID) (*TwoFactor, error)
+
+	// SaveTwoFactorSecret starts an enrolment, replacing an unconfirmed one.
+	SaveTwoFactorSecret(ctx context.Context, userID uuid.UUID, secret string) error
+
+	ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, confirmedAt time.Time, step int64) error
+
+	// UseTOTPStep records the step of an accepted code. It returns
+	// ErrorInvalidTwoFactorCode when a code of that step or a later one was
+	// accepted meanwhile.
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error
+
+	// DeleteTwoFactor removes the authenticator and recovery codes of a user.
+	DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error
+
+	// ReplaceRecoveryCodes swaps every recovery code of a user for new ones.
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+
+	// UseRecoveryCode returns sql.ErrNoRows unless the user holds the code
+	// unused.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error
+
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+
+	ListTwoFactorRequirements(ctx context.Context) ([]*TwoFactorRequirement, error)
+
+	// SetTwoFactorRequired adds or drops the requirement of a role.
+	SetTwoFactorRequired(ctx context.Context, role user.Role, required bool, by uuid.UUID) error
+
+	TwoFactorRequired(ctx context.Context, role user.Role) (bool, error)
+
+	CreateLoginChallenge(ctx context.Context, c *LoginChallenge) error
+
+	// GetLoginChallengeForUpdate locks the challenge with the given hash.
+	// Returns sql.ErrNoRows for unknown challenges.
+	GetLoginChallengeForUpdate(ctx context.Context, tokenHash string) (*LoginChallenge, error)
+
+	AddLoginChallengeAttempt(ctx context.Context, id uuid.UUID) error
+
+	MarkLoginChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+
+	// LockThrottle locks the throttle of an account or IP for the rest of
+	// the transaction, creating it without failures when nothing failed yet
+	// so that the first attempts queue up behind each other too.
+	LockThrottle(ctx context.Context, scope ThrottleScope, key string) (*Throttle, error)
+
+	SaveThrottle(ctx context.Context, t *Throttle) error
+
+	// DeleteThrottle forgets the failures of an account or IP, lifting a
+	// lockout.
+	DeleteThrottle(ctx context.Context, scope ThrottleScope, key string) error
+
+	CreatePhoneCode(ctx context.Context, c *PhoneCode) error
+
+	// GetLatestPhoneCodeForUpdate locks the last code sent to a number, the
+	// only one that is still valid. Returns sql.ErrNoRows if none was sent.
+	GetLatestPhoneCodeForUpdate(ctx context.Context, phone string) (*PhoneCode, error)
+
+	// CountPhoneCodes counts the codes sent to a number since a time.
+	CountPhoneCodes(ctx context.Context, phone string, since time.Time) (int, error)
+
+	// CountPhoneCodesFromIP counts the codes requested from a client IP
+	// since a time.
+	CountPhoneCodesFromIP(ctx context.Context, ip string, since time.Time) (int, error)
+
+	AddPhoneCodeAttempt(ctx context.Context, id uuid.UUID) error
+
+	MarkPhoneCodeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
@@ -0,0 +1,46 @@
+package auth
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type VerifyLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	// Code is a code from the authenticator app or an unused recovery code
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type TwoFactorRequirementRequest struct {
+	Required bool `json:"required"`
+}
+
+type PhoneCodeRequest struct {
+	// Phone is a Kenyan mobile number, like "0712345678" or "+254712345678"
+	Phone string `json:"phone" binding:"required"`
+}
+
+type VerifyPhoneCodeRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
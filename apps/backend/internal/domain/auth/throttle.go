@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"strings"
+	"time"
+)
+
+// Password guessing is slowed down per account and per client IP, and
+// guessing the two-factor code of a signed-in user per user. After a few
+// free failures each further attempt has to wait twice as long as the one
+// before, and an account or user that keeps failing is locked for a while.
+const (
+	AccountFreeAttempts = 3
+	IPFreeAttempts      = 20
+	MaxAttemptDelay     = time.Minute
+
+	LockoutThreshold = 10
+	LockoutDuration  = 15 * time.Minute
+
+	// FailureWindow is how long failures are remembered without a new one.
+	FailureWindow = time.Hour
+)
+
+// ThrottleScope tells what a throttle counts the failures of.
+type ThrottleScope string
+
+const (
+	ThrottleAccount   ThrottleScope = "account"
+	ThrottleIP        ThrottleScope = "ip"
+	ThrottleTwoFactor ThrottleScope = "two_factor"
+)
+
+// Throttle counts the recent failed password attempts on an account, keyed
+// by its email address, or from a client IP, or the wrong codes a signed-in
+// user gave to change their two-factor settings, keyed by their ID.
+type Throttle struct {
+	Scope         ThrottleScope `db:"scope"`
+	Key           string        `db:"key"`
+	Failures      int           `db:"failures"`
+	LastFailureAt time.Time     `db:"last_failure_at"`
+	LockedUntil   *time.Time    `db:"locked_until"`
+}
+
+// AccountKey is the throttle key of an account's email address.
+func AccountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Locked reports whether the account or user is locked out at now.
+func (t *Throttle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
+
+// Wait returns how long the next attempt has to wait from now, 0 if it may
+// go ahead.
+func (t *Throttle) Wait(now time.Time) time.Duration {
+	if t.Locked(now) {
+		return t.LockedUntil.Sub(now)
+	}
+
+	free := AccountFreeAttempts
+	if t.Scope == ThrottleIP {
+		free = IPFreeAttempts
+	}
+	if t.Failures <= free || now.Sub(t.LastFailureAt) >= FailureWindow {
+		return 0
+	}
+
+	next := t.LastFailureAt.Add(AttemptDelay(t.Failures - free))
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+// Fail counts a failed attempt at now. A quiet FailureWindow or a lockout
+// that ran out start a new count. It reports whether this failure locked
+// the account or user. IPs are only ever slowed down.
+func (t *Throttle) Fail(now time.Time) bool {
+	lapsed := t.LockedUntil != nil && !t.Locked(now)
+	if lapsed || now.Sub(t.LastFailureAt) >= FailureWindow {
+		t.Failures, t.LockedUntil = 0, nil
+	}
+
+	t.Failures++
+	t.LastFailureAt = now
+	if t.Scope != ThrottleIP && t.Failures >= LockoutThreshold && t.LockedUntil == nil {
+		until := now.Add(LockoutDuration)
+		t.LockedUntil = &until
+		return true
+	}
+	return false
+}
+
+// AttemptDelay returns the wait after the nth failure past the free ones:
+// 1s, 2s, 4s and so on up to MaxAttemptDelay.
+func AttemptDelay(n int) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+	if n > 7 {
+		return MaxAttemptDelay
+	}
+	return min(time.Second<<(n-1), MaxAttemptDelay)
+}
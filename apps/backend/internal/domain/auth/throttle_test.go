@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttemptDelay(t *testing.T) {
+	require.Equal(t, time.Duration(0), AttemptDelay(0))
+	require.Equal(t, time.Second, AttemptDelay(1))
+	require.Equal(t, 4*time.Second, AttemptDelay(3))
+	require.Equal(t, 32*time.Second, AttemptDelay(6))
+	require.Equal(t, MaxAttemptDelay, AttemptDelay(7))
+	require.Equal(t, MaxAttemptDelay, AttemptDelay(50))
+}
+
+func TestThrottle_Account(t *testing.T) {
+	now := time.Now()
+	th := &Throttle{Scope: ThrottleAccount, Key: "wanjiru@example.com"}
+
+	for range AccountFreeAttempts {
+		require.False(t, th.Fail(now))
+		require.Zero(t, th.Wait(now), "free attempts")
+	}
+
+	require.False(t, th.Fail(now))
+	require.Equal(t, time.Second, th.Wait(now))
+	require.Zero(t, th.Wait(now.Add(time.Second)))
+
+	for th.Failures < LockoutThreshold-1 {
+		require.False(t, th.Fail(now))
+	}
+	require.True(t, th.Fail(now), "the threshold locks the account")
+	require.True(t, th.Locked(now))
+	require.Equal(t, LockoutDuration, th.Wait(now))
+
+	// Once the lockout runs out the count starts over
+	later := now.Add(LockoutDuration)
+	require.False(t, th.Locked(later))
+	require.False(t, th.Fail(later))
+	require.Equal(t, 1, th.Failures)
+	require.Nil(t, th.LockedUntil)
+}
+
+func TestThrottle_IP(t *testing.T) {
+	now := time.Now()
+	th := &Throttle{Scope: ThrottleIP, Key: "203.0.113.7"}
+
+	for range 2 * LockoutThreshold {
+		require.False(t, th.Fail(now), "IPs are slowed down, never locked")
+	}
+	require.Zero(t, th.Wait(now))
+
+	th.Fail(now)
+	require.Equal(t, time.Second, th.Wait(now))
+
+	// Failures are forgotten after a quiet window
+	require.Zero(t, th.Wait(now.Add(FailureWindow)))
+	th.Fail(now.Add(FailureWindow))
+	require.Equal(t, 1, th.Failures)
+}
+
+func TestThrottle_TwoFactor(t *testing.T) {
+	now := time.Now()
+	th := &Throttle{Scope: ThrottleTwoFactor, Key: "7f9c24e5-1b3a-4d6e-9f80-2a1c3b4d5e6f"}
+
+	for th.Failures < LockoutThreshold-1 {
+		require.False(t, th.Fail(now))
+	}
+	require.True(t, th.Fail(now), "users guessing codes are locked like accounts")
+	require.Equal(t, LockoutDuration, th.Wait(now))
+}
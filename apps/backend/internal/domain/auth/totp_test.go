@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// The RFC vectors have 8 digits, these are their last 6
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tt.want, code, tt.unix)
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	step := TOTPStep(at)
+
+	got, ok := MatchTOTP(rfcSecret, "050471", at, 0)
+	require.True(t, ok)
+	require.Equal(t, step, got)
+
+	// One period of drift either way
+	_, ok = MatchTOTP(rfcSecret, "050471", at.Add(TOTPPeriod), 0)
+	require.True(t, ok)
+	_, ok = MatchTOTP(rfcSecret, "050471", at.Add(-TOTPPeriod), 0)
+	require.True(t, ok)
+	_, ok = MatchTOTP(rfcSecret, "050471", at.Add(2*TOTPPeriod), 0)
+	require.False(t, ok)
+
+	_, ok = MatchTOTP(rfcSecret, "050471", at, step)
+	require.False(t, ok, "a used code is not accepted again")
+	_, ok = MatchTOTP(rfcSecret, "050472", at, 0)
+	require.False(t, ok)
+	_, ok = MatchTOTP(rfcSecret, "50471", at, 0)
+	require.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Fastabiz", "wanjiru@example.com", "JBSWY3DPEHPK3PXP")
+	require.Equal(t, "otpauth://totp/Fastabiz:wanjiru@example.com?algorithm=SHA1&digits=6&issuer=Fastabiz&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
@@ -0,0 +1,45 @@
+package authz
+
+import (
+	"backend/internal/domain/delivery"
+	"backend/internal/domain/invite"
+	"backend/internal/domain/order"
+	"backend/internal/domain/product"
+	"backend/internal/domain/store"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// StoreAccess tells a user's role in a store, owner or staff. It is
+// implemented by the store repository.
+type StoreAccess interface {
+	GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error)
+}
+
+// ProductReader resolves products, variants, images and options to their
+// store. It is implemented by the product repository.
+type ProductReader interface {
+	GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error)
+	GetVariantByID(ctx context.Context, variantID uuid.UUID) (*product.Variant, error)
+	GetImageProductID(ctx context.Context, imageID uuid.UUID) (uuid.UUID, error)
+	GetOptionProductID(ctx context.Context, optionID uuid.UUID) (uuid.UUID, error)
+	GetOptionValueProductID(ctx context.Context, optionValueID uuid.UUID) (uuid.UUID, error)
+}
+
+// OrderReader loads orders. It is implemented by the order repository.
+type OrderReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*order.Order, error)
+}
+
+// InviteReader loads invites. It is implemented by the invite repository.
+type InviteReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*invite.Invite, error)
+}
+
+// DeliveryReader loads deliveries. It is implemented by the delivery
+// repository.
+type DeliveryReader interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*delivery.Delivery, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*delivery.Delivery, error)
+}
@@ -0,0 +1,16 @@
+package authz
+
+import "errors"
+
+var (
+	ErrorNoPolicy            = errors.New("no policy declared for this route")
+	ErrorRoleNotAllowed      = errors.New("role not allowed on this route")
+	ErrorForbidden           = errors.New("not allowed to access this resource")
+	ErrorInvalidResourceID   = errors.New("invalid resource id")
+	ErrorAmbiguousResourceID = errors.New("resource id given more than once with different values")
+	ErrorNotFound            = errors.New("resource not found")
+
+	ErrorPasswordChangeRequired     = errors.New("password must be changed before continuing")
+	ErrorTwoFactorEnrolmentRequired = errors.New("two-factor authentication must be set up before continuing")
+	ErrorScopeNotGranted            = errors.New("api key does not grant access to this route")
+)
@@ -0,0 +1,20 @@
+package cart
+
+import (
+	"backend/internal/domain/order"
+	"backend/internal/domain/product"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// cross-domain interfaces to price carts and turn them into orders
+
+type ProductReader interface {
+	GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error)
+	GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error)
+}
+
+type OrderPlacer interface {
+	CreateOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) (*order.Order, error)
+}
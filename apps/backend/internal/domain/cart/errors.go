@@ -0,0 +1,14 @@
+package cart
+
+import "errors"
+
+var (
+	ErrorItemNotFound      = errors.New("cart item not found")
+	ErrorInvalidQuantity   = errors.New("quantity must be greater than zero")
+	ErrorEmptyCart         = errors.New("cart is empty")
+	ErrorCartNotOrderable  = errors.New("cart has out-of-stock or unavailable items")
+	ErrorProductNotInStore = errors.New("product does not belong to this store")
+	ErrorVariantRequired   = errors.New("product has variants, variant_id is required")
+	ErrorVariantNotAllowed = errors.New("product has no variants, variant_id must be empty")
+	ErrorVariantNotFound   = errors.New("variant not found for this product")
+)
@@ -0,0 +1,86 @@
+package cart
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Cart holds what a customer is about to order from one store. A customer
+// has at most one cart per store; it is created with its first line.
+type Cart struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	CustomerID uuid.UUID `db:"customer_id" json:"customer_id"`
+	StoreID    uuid.UUID `db:"store_id" json:"store_id"`
+	Items      []Item    `db:"-" json:"items"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Item is a product (or variant) and quantity in a cart. Prices are not
+// stored: they are looked up from the catalog whenever the cart is read.
+type Item struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	CartID    uuid.UUID  `db:"cart_id" json:"cart_id"`
+	ProductID uuid.UUID  `db:"product_id" json:"product_id"`
+	VariantID *uuid.UUID `db:"variant_id" json:"variant_id,omitempty"`
+	Quantity  int        `db:"quantity" json:"quantity"`
+	AddedAt   time.Time  `db:"added_at" json:"added_at"`
+}
+
+// View is a cart priced against the current catalog.
+type View struct {
+	ID         uuid.UUID `json:"id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	StoreID    uuid.UUID `json:"store_id"`
+
+	Lines    []Line `json:"lines"`
+	Currency string `json:"currency"`
+	Subtotal int64  `json:"subtotal"` // in cents, available lines only
+
+	// CanCheckout is false while any line is out of stock or unavailable
+	CanCheckout bool `json:"can_checkout"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Line is a cart item with its current price and stock.
+type Line struct {
+	ItemID    uuid.UUID  `json:"item_id"`
+	ProductID uuid.UUID  `json:"product_id"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int        `json:"quantity"`
+
+	ProductName string  `json:"product_name,omitempty"`
+	VariantName *string `json:"variant_name,omitempty"`
+	ImageURL    *string `json:"image_url,omitempty"`
+
+	UnitPrice int64 `json:"unit_price"` // in cents
+	Total     int64 `json:"total"`      // quantity * unit_price
+
+	// Stock is what is left right now; nil when the product isn't stock-tracked
+	Stock      *int `json:"stock,omitempty"`
+	OutOfStock bool `json:"out_of_stock"`
+
+	// Unavailable lines point at a product or variant that was removed
+	Unavailable bool `json:"unavailable"`
+}
+
+// Orderable reports whether the line can be checked out as it is.
+func (l Line) Orderable() bool {
+	return !l.OutOfStock && !l.Unavailable
+}
+
+// Add appends a priced line and keeps the subtotal and checkout flag in sync.
+func (v *View) Add(l Line) {
+	v.Lines = append(v.Lines, l)
+	if l.Orderable() {
+		v.Subtotal += l.Total
+	}
+	v.CanCheckout = len(v.Lines) > 0
+	for _, line := range v.Lines {
+		if !line.Orderable() {
+			v.CanCheckout = false
+		}
+	}
+}
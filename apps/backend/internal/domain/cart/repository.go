@@ -0,0 +1,34 @@
+package cart
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// GetOrCreate returns the cart of a customer for a store, creating an
+	// empty one the first time
+	GetOrCreate(ctx context.Context, customerID, storeID uuid.UUID) (*Cart, error)
+
+	// Get returns the cart of a customer for a store with its items, or
+	// sql.ErrNoRows if the customer has none
+	Get(ctx context.Context, customerID, storeID uuid.UUID) (*Cart, error)
+
+	// ListByCustomer returns every cart of a customer with its items,
+	// most recently updated first
+	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*Cart, error)
+
+	// AddItem adds quantity to the line of the same product and variant,
+	// or inserts a new line, and returns the resulting line
+	AddItem(ctx context.Context, cartID uuid.UUID, item *Item) (*Item, error)
+
+	// SetItemQuantity replaces the quantity of a line of the cart
+	SetItemQuantity(ctx context.Context, cartID, itemID uuid.UUID, quantity int) error
+
+	// RemoveItem deletes a line of the cart
+	RemoveItem(ctx context.Context, cartID, itemID uuid.UUID) error
+
+	// Clear deletes every line of the cart
+	Clear(ctx context.Context, cartID uuid.UUID) error
+}
@@ -0,0 +1,58 @@
+package cart
+
+import (
+	"backend/internal/domain/order"
+
+	"github.com/google/uuid"
+)
+
+type AddItemRequest struct {
+	ProductID uuid.UUID  `json:"product_id" binding:"required"`
+	VariantID *uuid.UUID `json:"variant_id,omitempty"`
+	Quantity  int        `json:"quantity" binding:"required,gt=0"`
+}
+
+type UpdateItemRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0"`
+}
+
+// CheckoutRequest carries what the cart doesn't know about the order.
+type CheckoutRequest struct {
+	// Optional; orders are picked up at the store by default
+	PickupAddress string  `json:"pickup_address"`
+	PickupLat     float64 `json:"pickup_lat"`
+	PickupLng     float64 `json:"pickup_lng"`
+
+	// Either a saved address of the customer, or the address and
+	// coordinates inline
+	AddressID       *uuid.UUID `json:"address_id,omitempty"`
+	DeliveryAddress string     `json:"delivery_address"`
+	DeliveryLat     float64    `json:"delivery_lat"`
+	DeliveryLng     float64    `json:"delivery_lng"`
+
+	PromoCode string `json:"promo_code,omitempty"`
+}
+
+// ToOrderRequest builds the order request for the lines of a cart.
+func (r *CheckoutRequest) ToOrderRequest(c *Cart) *order.CreateOrderRequest {
+	req := &order.CreateOrderRequest{
+		StoreID:         c.StoreID,
+		CustomerID:      c.CustomerID,
+		PickupAddress:   r.PickupAddress,
+		PickupLat:       r.PickupLat,
+		PickupLng:       r.PickupLng,
+		AddressID:       r.AddressID,
+		DeliveryAddress: r.DeliveryAddress,
+		DeliveryLat:     r.DeliveryLat,
+		DeliveryLng:     r.DeliveryLng,
+		PromoCode:       r.PromoCode,
+	}
+	for _, item := range c.Items {
+		req.Items = append(req.Items, order.CreateOrderItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		})
+	}
+	return req
+}
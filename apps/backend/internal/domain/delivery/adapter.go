@@ -13,12 +13,14 @@ import (
 
 type OrderReader interface {
 	GetOrderByID(ctx context.Context, id uuid.UUID) (*order.Order, error)
-	UpdateOrder(ctx context.Context, orderID uuid.UUID, column string, value any) error
+	// TransitionOrder runs a status change through the order state machine,
+	// which calls back into ApplyTransition for the delivery side effects.
+	TransitionOrder(ctx context.Context, orderID uuid.UUID, to order.OrderStatus, actor order.Actor, actorID uuid.UUID) (*order.Order, error)
 }
 
 type DriverReader interface {
 	GetDriverByID(ctx context.Context, id uuid.UUID) (*driver.Driver, error)
-	UpdateDriverAvailability(ctx context.Context, driverID uuid.UUID, column string, value bool) error
+	UpdateDriverAvailability(ctx context.Context, driverID uuid.UUID, available bool) error
 }
 
 type NotificationReader interface {
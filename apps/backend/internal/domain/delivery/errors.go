@@ -2,4 +2,11 @@ package delivery
 
 import "errors"
 
-var ErrorNoPendingOrder = errors.New("no pending orders")
+var (
+	ErrorNoPendingOrder     = errors.New("no pending orders")
+	ErrorInvalidStatus      = errors.New("invalid delivery status")
+	ErrorNotAssignedDriver  = errors.New("delivery is assigned to another driver")
+	ErrorStatusNotUpdatable = errors.New("delivery status can only be changed through a status transition")
+	ErrorNothingToUpdate    = errors.New("no delivery fields to update")
+	ErrorInvalidTimestamps  = errors.New("delivery timestamps can only be corrected once set, and must stay in assigned, picked up, delivered order")
+)
@@ -1,6 +1,8 @@
 package delivery
 
 import (
+	"backend/internal/domain/order"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +17,20 @@ const (
 	Failed    DeliveryStatus = "failed"
 )
 
+// OrderStatus returns the order status a delivery status corresponds to.
+// A failed delivery sends the order back to the assignment queue.
+func (s DeliveryStatus) OrderStatus() (order.OrderStatus, error) {
+	switch s {
+	case PickedUp:
+		return order.InTransit, nil
+	case Delivered:
+		return order.Delivered, nil
+	case Failed:
+		return order.Pending, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrorInvalidStatus, s)
+}
+
 type Delivery struct {
 	ID          uuid.UUID      `db:"id" json:"id"`
 	OrderID     uuid.UUID      `db:"order_id" json:"order_id"`
@@ -12,9 +12,64 @@ type CreateDeliveryRequest struct {
 	Status   DeliveryStatus `json:"status"`
 }
 
+// UpdateDeliveryRequest corrects the timestamps that are set. The order and
+// driver of a delivery are fixed, and its status only moves through a
+// status transition.
 type UpdateDeliveryRequest struct {
-	Column string      `json:"column" binding:"required"`
-	Value  interface{} `json:"value" binding:"required"`
+	AssignedAt  *time.Time `json:"assigned_at,omitempty"`
+	PickedUpAt  *time.Time `json:"picked_up_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	// Status is refused with ErrorStatusNotUpdatable
+	Status *DeliveryStatus `json:"status,omitempty" swaggerignore:"true"`
+}
+
+// Validate checks that something is set.
+func (r *UpdateDeliveryRequest) Validate() error {
+	if r.Status != nil {
+		return ErrorStatusNotUpdatable
+	}
+	if r.AssignedAt == nil && r.PickedUpAt == nil && r.DeliveredAt == nil {
+		return ErrorNothingToUpdate
+	}
+	return nil
+}
+
+// Apply copies the set timestamps onto d. A timestamp can only be corrected
+// once its transition happened, and they have to stay in order.
+func (r *UpdateDeliveryRequest) Apply(d *Delivery) error {
+	for _, f := range []struct{ from, to *time.Time }{
+		{r.AssignedAt, d.AssignedAt},
+		{r.PickedUpAt, d.PickedUpAt},
+		{r.DeliveredAt, d.DeliveredAt},
+	} {
+		if f.from != nil && f.to == nil {
+			return ErrorInvalidTimestamps
+		}
+	}
+
+	if r.AssignedAt != nil {
+		d.AssignedAt = r.AssignedAt
+	}
+	if r.PickedUpAt != nil {
+		d.PickedUpAt = r.PickedUpAt
+	}
+	if r.DeliveredAt != nil {
+		d.DeliveredAt = r.DeliveredAt
+	}
+
+	if d.PickedUpAt != nil && (d.AssignedAt == nil || d.PickedUpAt.Before(*d.AssignedAt)) {
+		return ErrorInvalidTimestamps
+	}
+	if d.DeliveredAt != nil && (d.PickedUpAt == nil || d.DeliveredAt.Before(*d.PickedUpAt)) {
+		return ErrorInvalidTimestamps
+	}
+	return nil
+}
+
+// UpdateDeliveryStatusRequest moves a delivery, and with it the order, along.
+type UpdateDeliveryStatusRequest struct {
+	Status DeliveryStatus `json:"status" binding:"required"` // "picked_up", "delivered" or "failed"
 }
 
 func (r *CreateDeliveryRequest) ToDelivery() *Delivery {
@@ -0,0 +1,30 @@
+package delivery
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateDeliveryRequest_Apply(t *testing.T) {
+	at := func(min int) *time.Time {
+		ts := time.Date(2025, 1, 1, 12, min, 0, 0, time.UTC)
+		return &ts
+	}
+	picked := func() *Delivery {
+		return &Delivery{AssignedAt: at(0), PickedUpAt: at(10)}
+	}
+
+	d := picked()
+	require.NoError(t, (&UpdateDeliveryRequest{PickedUpAt: at(5)}).Apply(d))
+	require.Equal(t, at(5), d.PickedUpAt)
+
+	require.ErrorIs(t, (&UpdateDeliveryRequest{DeliveredAt: at(20)}).Apply(picked()), ErrorInvalidTimestamps, "not delivered yet")
+	require.ErrorIs(t, (&UpdateDeliveryRequest{AssignedAt: at(15)}).Apply(picked()), ErrorInvalidTimestamps, "assigned after pick up")
+	require.ErrorIs(t, (&UpdateDeliveryRequest{PickedUpAt: at(-1)}).Apply(picked()), ErrorInvalidTimestamps, "picked up before assigned")
+
+	st := Delivered
+	require.ErrorIs(t, (&UpdateDeliveryRequest{Status: &st}).Validate(), ErrorStatusNotUpdatable)
+	require.ErrorIs(t, (&UpdateDeliveryRequest{}).Validate(), ErrorNothingToUpdate)
+}
@@ -6,4 +6,9 @@ var (
 	ErrMissingUserID       = errors.New("missing driver ID")
 	ErrDriverAlreadyExists = errors.New("An account with this email already exists.")
 	ErrRoleCheck              = errors.New("Invalid user role.")
+	ErrNothingToUpdate     = errors.New("No driver fields to update.")
+	ErrInvalidName         = errors.New("Invalid name.")
+	ErrInvalidVehicleInfo  = errors.New("Vehicle info can't be blank.")
+	ErrInvalidLocation     = errors.New("Location needs a valid lat and lng.")
+	ErrDriverNotFound      = errors.New("Driver not found.")
 )
@@ -12,9 +12,10 @@ type Repository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*Driver, error)                                                // GET
 	GetByEmail(ctx context.Context, email string) (*Driver, error)                                             // GET
 	List(ctx context.Context) ([]*Driver, error)                                                               // GET all drivers
-	UpdateColumn(ctx context.Context, driverID uuid.UUID, column string, value any) error                      // PATCH method for specific driver details update
+	Update(ctx context.Context, driverID uuid.UUID, req *UpdateDriverRequest) error                            // PATCH the fields set in req
 	UpdateProfile(ctx context.Context, id uuid.UUID, vehicleInfo string, currentLocation postgis.PointS) error // PUT method for driver details to be updated after registration
 	Delete(ctx context.Context, id uuid.UUID) error                                                            // DELETE
+	SetAvailable(ctx context.Context, driverID uuid.UUID, available bool) error                                // reserve or release for a delivery
 
 	GetNearestDriver(ctx context.Context, pickup postgis.PointS, maxDistance float64) (*Driver, error)
 	ListAvailableDrivers(ctx context.Context, available bool) ([]*Driver, error)
@@ -1,6 +1,8 @@
 package driver
 
 import (
+	"strings"
+
 	"github.com/cridenour/go-postgis"
 )
 
@@ -16,9 +18,42 @@ type UpdateDriverProfileRequest struct {
 	CurrentLocation postgis.PointS `json:"current_location" binding:"required"`
 }
 
+// UpdateDriverRequest changes the fields that are set. Lat and Lng go
+// together. The email address is the user's and changes with their profile,
+// availability follows the deliveries the driver is on.
 type UpdateDriverRequest struct {
-	Column string      `json:"column" binding:"required"`
-	Value  interface{} `json:"value" binding:"required"`
+	FullName    *string  `json:"full_name,omitempty"`
+	VehicleInfo *string  `json:"vehicle_info,omitempty"`
+	Lat         *float64 `json:"lat,omitempty"`
+	Lng         *float64 `json:"lng,omitempty"`
+}
+
+// Validate checks that something is set and that the set fields are valid.
+func (r *UpdateDriverRequest) Validate() error {
+	if r.FullName == nil && r.VehicleInfo == nil && r.Lat == nil && r.Lng == nil {
+		return ErrNothingToUpdate
+	}
+	if r.FullName != nil && len(strings.TrimSpace(*r.FullName)) < 2 {
+		return ErrInvalidName
+	}
+	if r.VehicleInfo != nil && strings.TrimSpace(*r.VehicleInfo) == "" {
+		return ErrInvalidVehicleInfo
+	}
+	if (r.Lat == nil) != (r.Lng == nil) {
+		return ErrInvalidLocation
+	}
+	if r.Lat != nil && (*r.Lat < -90 || *r.Lat > 90 || *r.Lng < -180 || *r.Lng > 180) {
+		return ErrInvalidLocation
+	}
+	return nil
+}
+
+// Location returns the set coordinates as a point, nil if unset.
+func (r *UpdateDriverRequest) Location() *postgis.PointS {
+	if r.Lat == nil || r.Lng == nil {
+		return nil
+	}
+	return &postgis.PointS{SRID: 4326, X: *r.Lng, Y: *r.Lat}
 }
 
 func (r *CreateDriverRequest) ToDriver() *Driver {
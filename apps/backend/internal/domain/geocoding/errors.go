@@ -0,0 +1,9 @@
+package geocoding
+
+import "errors"
+
+var (
+	ErrorNoMatch            = errors.New("location could not be found")
+	ErrorEmptyQuery         = errors.New("location is empty")
+	ErrorInvalidCoordinates = errors.New("invalid coordinates")
+)
@@ -0,0 +1,77 @@
+package geocoding
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Source names the provider a place came from.
+const SourceGazetteer = "gazetteer"
+
+// Provider turns free-text locations into coordinates and back.
+// Implementations return ErrorNoMatch when they can't resolve the input.
+type Provider interface {
+	Geocode(ctx context.Context, query string) (*Place, error)
+	ReverseGeocode(ctx context.Context, lat, lng float64) (*Place, error)
+}
+
+// Place is a resolved location.
+type Place struct {
+	Name    string  `db:"name" json:"name"`       // e.g. "Kilimani"
+	Address string  `db:"address" json:"address"` // e.g. "Kilimani, Nairobi"
+	Lat     float64 `db:"lat" json:"lat"`
+	Lng     float64 `db:"lng" json:"lng"`
+	Source  string  `db:"source" json:"source"`
+}
+
+// Entry is a row of the offline gazetteer: a town, or an estate within a town.
+type Entry struct {
+	Name   string  `db:"name"`
+	Kind   string  `db:"kind"` // "town" or "estate"
+	Town   *string `db:"town"` // parent town of an estate
+	County string  `db:"county"`
+	Lat    float64 `db:"lat"`
+	Lng    float64 `db:"lng"`
+}
+
+// Place formats the entry as a resolved place.
+func (e *Entry) Place() *Place {
+	address := e.Name
+	if e.Town != nil {
+		address += ", " + *e.Town
+	} else if e.County != "" && !strings.EqualFold(e.County, e.Name) {
+		address += ", " + e.County
+	}
+	return &Place{Name: e.Name, Address: address, Lat: e.Lat, Lng: e.Lng, Source: SourceGazetteer}
+}
+
+var (
+	nonWord = regexp.MustCompile(`[^a-z0-9,' ]+`)
+	spaces  = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeQuery lowercases a query, drops punctuation and a trailing
+// country, so equivalent spellings share a cache entry.
+func NormalizeQuery(query string) string {
+	q := strings.ToLower(query)
+	q = nonWord.ReplaceAllString(q, " ")
+	q = spaces.ReplaceAllString(q, " ")
+
+	var parts []string
+	for _, p := range strings.Split(q, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if n := len(parts); n > 1 && parts[n-1] == "kenya" {
+		parts = parts[:n-1]
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReverseKey rounds coordinates to about 10 metres for caching.
+func ReverseKey(lat, lng float64) string {
+	return fmt.Sprintf("%.4f,%.4f", lat, lng)
+}
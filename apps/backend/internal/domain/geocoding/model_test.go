@@ -0,0 +1,34 @@
+package geocoding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"Kilimani, Nairobi":              "kilimani, nairobi",
+		"  KILIMANI ,  Nairobi , Kenya ": "kilimani, nairobi",
+		"Murang'a":                       "murang'a",
+		"Shop 4 - Moi Ave., Nakuru":      "shop 4 moi ave, nakuru",
+		"Kenya":                          "kenya",
+		",,":                             "",
+	}
+	for in, want := range tests {
+		require.Equal(t, want, NormalizeQuery(in), in)
+	}
+}
+
+func TestEntryPlace(t *testing.T) {
+	nairobi := "Nairobi"
+	estate := &Entry{Name: "Kilimani", Kind: "estate", Town: &nairobi, County: "Nairobi", Lat: -1.29, Lng: 36.78}
+	require.Equal(t, "Kilimani, Nairobi", estate.Place().Address)
+
+	town := &Entry{Name: "Naivasha", Kind: "town", County: "Nakuru"}
+	require.Equal(t, "Naivasha, Nakuru", town.Place().Address)
+
+	capital := &Entry{Name: "Nairobi", Kind: "town", County: "Nairobi"}
+	require.Equal(t, "Nairobi", capital.Place().Address)
+	require.Equal(t, SourceGazetteer, capital.Place().Source)
+}
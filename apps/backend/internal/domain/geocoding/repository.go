@@ -0,0 +1,26 @@
+package geocoding
+
+import "context"
+
+// Lookup kinds kept apart in the cache.
+const (
+	Forward = "forward"
+	Reverse = "reverse"
+)
+
+type Repository interface {
+	// FindByName returns the gazetteer entries with the given normalized
+	// name, towns first
+	FindByName(ctx context.Context, name string) ([]*Entry, error)
+
+	// Nearest returns the closest estate within estateRadiusM, or else the
+	// closest town within townRadiusM, or ErrorNoMatch
+	Nearest(ctx context.Context, lat, lng float64, estateRadiusM, townRadiusM int) (*Entry, error)
+
+	// GetCached returns a cached lookup result, or ErrorNoMatch if the key
+	// was never cached
+	GetCached(ctx context.Context, kind, key string) (*Place, error)
+
+	// PutCached stores a lookup result, replacing an older one
+	PutCached(ctx context.Context, kind, key string, p *Place) error
+}
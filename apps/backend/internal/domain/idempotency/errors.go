@@ -0,0 +1,9 @@
+package idempotency
+
+import "errors"
+
+var (
+	ErrorKeyTooLong  = errors.New("idempotency key too long")
+	ErrorKeyReused   = errors.New("idempotency key reused with a different request")
+	ErrorKeyInFlight = errors.New("a request with this idempotency key is still in progress")
+)
@@ -0,0 +1,32 @@
+package idempotency
+
+import "time"
+
+// Header is the request header clients use to mark a retryable request.
+const Header = "Idempotency-Key"
+
+// TTL is how long a key and its stored response are honoured.
+const TTL = 24 * time.Hour
+
+// Record is a request seen under an Idempotency-Key and, once the handler
+// finished, the response to replay for retries of it.
+type Record struct {
+	Key   string `db:"key"`
+	Owner string `db:"owner"` // caller the key is scoped to, empty for anonymous requests
+
+	Method      string `db:"method"`
+	Path        string `db:"path"`
+	RequestHash string `db:"request_hash"` // sha256 of method, path and body
+
+	StatusCode  *int    `db:"status_code"` // nil while the request is in flight
+	ContentType *string `db:"content_type"`
+	Body        []byte  `db:"response_body"`
+
+	CreatedAt   time.Time  `db:"created_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+}
+
+// Completed reports whether the response has been stored.
+func (r *Record) Completed() bool {
+	return r.StatusCode != nil
+}
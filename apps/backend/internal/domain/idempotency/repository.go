@@ -0,0 +1,15 @@
+package idempotency
+
+import "context"
+
+type Repository interface {
+	// Reserve claims rec.Key for a new request. If the key is already held by
+	// an unexpired record, that record is returned with reserved == false.
+	Reserve(ctx context.Context, rec *Record) (existing *Record, reserved bool, err error)
+
+	// Complete stores the response of a reserved request for replay.
+	Complete(ctx context.Context, owner, key string, status int, contentType string, body []byte) error
+
+	// Release drops a reservation so the request can be retried, e.g. after a server error.
+	Release(ctx context.Context, owner, key string) error
+}
@@ -0,0 +1,21 @@
+package invite
+
+import (
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// UserRegistrar creates the account of an accepted invite, with the driver
+// profile for drivers. It is implemented by the user usecase.
+type UserRegistrar interface {
+	RegisterUser(ctx context.Context, u *user.User) error
+}
+
+// StoreMembership adds the invitee of a store invite to the store's staff
+// with the invited role. It is implemented by the store repository.
+type StoreMembership interface {
+	AddMember(ctx context.Context, storeID, userID, inviteID uuid.UUID, role store.MemberRole) error
+}
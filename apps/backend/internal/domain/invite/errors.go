@@ -0,0 +1,16 @@
+package invite
+
+import "errors"
+
+var (
+	ErrorInviteNotFound   = errors.New("invite not found")
+	ErrorInviteExpired    = errors.New("invite has expired")
+	ErrorInviteAccepted   = errors.New("invite was already accepted")
+	ErrorInviteRevoked    = errors.New("invite was revoked")
+	ErrorInvalidRole      = errors.New("invalid invite role")
+	ErrorStoreInviteRole  = errors.New("store staff must be invited as merchants")
+	ErrorInvalidStoreRole = errors.New("invalid store staff role")
+	ErrorInvalidEmail     = errors.New("invalid invite email")
+	ErrorInvalidExpiry    = errors.New("invite expiry must be in the future")
+	ErrorMissingFields    = errors.New("full name, phone and password are required")
+)
@@ -1,6 +1,9 @@
 package invite
 
 import (
+	"backend/internal/domain/store"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,18 +13,51 @@ type Role string
 
 const (
 	Admin    Role = "admin"
+	Merchant Role = "merchant"
 	Driver   Role = "driver"
 	Customer Role = "customer"
 	Guest    Role = "guest"
 )
 
+// DefaultTTL is how long an invite link works unless the inviter says
+// otherwise. Resending an invite starts it over.
+const DefaultTTL = 7 * 24 * time.Hour
+
 // new user invite via invite link
+//
+// Only the SHA-256 hash of the token is stored. Token holds the raw value
+// right after the invite is created or resent, so it can be shared.
 type Invite struct {
-	ID        uuid.UUID `db:"id" json:"id"`
-	Email     string    `db:"email" json:"email"`
-	Role      Role      `db:"role" json:"role"`
-	Token     string    `db:"token" json:"token"`
-	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
-	InvitedBy uuid.UUID `db:"invited_by" json:"invited_by"`
-	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	ID         uuid.UUID         `db:"id" json:"id"`
+	Email      string            `db:"email" json:"email"`
+	Role       Role              `db:"role" json:"role"`
+	StoreID    *uuid.UUID        `db:"store_id" json:"store_id,omitempty"`     // store the invitee joins as staff
+	StoreRole  *store.MemberRole `db:"store_role" json:"store_role,omitempty"` // their role in that store
+	Token      string            `db:"-" json:"token,omitempty"`
+	TokenHash  string            `db:"token_hash" json:"-"`
+	ExpiresAt  time.Time         `db:"expires_at" json:"expires_at"`
+	InvitedBy  uuid.UUID         `db:"invited_by" json:"invited_by"`
+	AcceptedAt *time.Time        `db:"accepted_at" json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID        `db:"accepted_by" json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time        `db:"revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time         `db:"created_at" json:"created_at"`
+}
+
+// Usable tells why an invite can no longer be accepted, or returns nil.
+func (i *Invite) Usable(now time.Time) error {
+	switch {
+	case i.RevokedAt != nil:
+		return ErrorInviteRevoked
+	case i.AcceptedAt != nil:
+		return ErrorInviteAccepted
+	case !now.Before(i.ExpiresAt):
+		return ErrorInviteExpired
+	}
+	return nil
+}
+
+// HashToken returns the form invite tokens are stored and looked up in.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
 }
@@ -2,6 +2,7 @@ package invite
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -9,7 +10,22 @@ import (
 // Invite = pending entry, just an email + role + token + expiration.
 type Repository interface {
 	Create(ctx context.Context, invite *Invite) error              // POST
-	GetByToken(ctx context.Context, token string) (*Invite, error) // GET
-	ListPending(ctx context.Context) ([]*Invite, error)            // GET
+	GetByToken(ctx context.Context, token string) (*Invite, error) // GET, by token hash
+	ListPending(ctx context.Context) ([]*Invite, error)            // GET, unexpired and not accepted or revoked
 	Delete(ctx context.Context, id uuid.UUID) error                // DELETE
+
+	// GetByID returns sql.ErrNoRows for unknown invites.
+	GetByID(ctx context.Context, id uuid.UUID) (*Invite, error)
+
+	// GetByTokenForUpdate locks the invite with the given token hash for
+	// the rest of the transaction, so it can't be accepted twice. Returns
+	// sql.ErrNoRows for unknown tokens.
+	GetByTokenForUpdate(ctx context.Context, tokenHash string) (*Invite, error)
+
+	MarkAccepted(ctx context.Context, id, userID uuid.UUID, acceptedAt time.Time) error
+
+	// Renew replaces the token of an invite and moves its expiry.
+	Renew(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) error
+
+	Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
 }
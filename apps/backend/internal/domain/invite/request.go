@@ -1,27 +1,42 @@
 package invite
 
 import (
+	"backend/internal/domain/store"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// CreateInviteRequest names who to invite. The token is generated by the
+// server, and the inviter is the caller. ExpiresAt defaults to DefaultTTL
+// from now. StoreRole applies to store invites and defaults to manager.
 type CreateInviteRequest struct {
-	ID        uuid.UUID `json:"id" binding:"required"`
-	Email     string    `json:"email" binding:"required"`
-	Role      Role      `json:"role" binding:"required"`
-	Token     string    `json:"token" binding:"required"`
-	ExpiresAt time.Time `json:"expires_at" binding:"required"`
-	InvitedBy uuid.UUID `json:"invited_by" binding:"required"`
+	Email     string            `json:"email" binding:"required"`
+	Role      Role              `json:"role" binding:"required"`
+	StoreID   *uuid.UUID        `json:"store_id,omitempty"`
+	StoreRole *store.MemberRole `json:"store_role,omitempty"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
 }
 
-func (r *CreateInviteRequest) ToInvite() *Invite {
-	return &Invite{
-		ID:        r.ID,
+func (r *CreateInviteRequest) ToInvite(invitedBy uuid.UUID) *Invite {
+	i := &Invite{
 		Email:     r.Email,
 		Role:      r.Role,
-		Token:     r.Token,
-		ExpiresAt: r.ExpiresAt,
-		InvitedBy: r.InvitedBy,
+		StoreID:   r.StoreID,
+		StoreRole: r.StoreRole,
+		InvitedBy: invitedBy,
 	}
+	if r.ExpiresAt != nil {
+		i.ExpiresAt = *r.ExpiresAt
+	}
+	return i
+}
+
+// AcceptInviteRequest creates the invited account. Email and role come from
+// the invite.
+type AcceptInviteRequest struct {
+	Token    string `json:"token" binding:"required"`
+	FullName string `json:"fullName" binding:"required"`
+	Phone    string `json:"phone" binding:"required"`
+	Password string `json:"password" binding:"required"`
 }
@@ -12,6 +12,9 @@ type Repository interface {
 	ListPending(ctx context.Context) ([]*Notification, error)
 	ListByUserAndStatus(ctx context.Context, userID uuid.UUID, status NotificationStatus) ([]*Notification, error)
 	UpdateAllAsRead(ctx context.Context, userID uuid.UUID) error
+	// MarkAsRead marks a notification of userID read, sql.ErrNoRows if they
+	// have no notification with that id
+	MarkAsRead(ctx context.Context, id, userID uuid.UUID) error
 }
 
 // Sender defines a generic interface for sending notifications.
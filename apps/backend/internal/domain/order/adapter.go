@@ -1,9 +1,13 @@
 package order
 
 import (
+	"backend/internal/domain/address"
 	"backend/internal/domain/driver"
+	"backend/internal/domain/geocoding"
 	"backend/internal/domain/notification"
+	"backend/internal/domain/pricing"
 	"backend/internal/domain/product"
+	"backend/internal/domain/promotion"
 	"backend/internal/domain/store"
 	"context"
 
@@ -30,10 +34,52 @@ type NotificationReader interface {
 type ProductOrVariantReader interface {
 	GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error)
 	GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error)
-	UpdateProductStock(ctx context.Context, productID uuid.UUID, newStock int) error
-	UpdateVariantStock(ctx context.Context, variantID uuid.UUID, newStock int) error
+	// Decrement* must only succeed when enough stock is left, so that
+	// concurrent checkouts cannot oversell.
+	DecrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error
+	DecrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error
+	IncrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error
+	IncrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error
 }
 
 type StoreReader interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*store.Store, error)
-}
\ No newline at end of file
+	GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error)
+}
+
+// RefundRequester opens a refund for the completed payment of an order, if any.
+type RefundRequester interface {
+	RequestRefund(ctx context.Context, orderID uuid.UUID, reason string) error
+}
+
+// Pricer prices an order: delivery fee from the pickup-delivery distance,
+// plus tax. It is implemented by the pricing domain.
+type Pricer interface {
+	Quote(ctx context.Context, storeID uuid.UUID, subtotal int64, currency string, pickup, delivery postgis.PointS) (*pricing.Breakdown, error)
+}
+
+// AddressReader resolves a saved address of a customer. It is implemented by
+// the address domain.
+type AddressReader interface {
+	GetAddressForUser(ctx context.Context, userID, id uuid.UUID) (*address.Address, error)
+}
+
+// Geocoder looks up coordinates for addresses sent without them. It is
+// implemented by the geocoding providers.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (*geocoding.Place, error)
+}
+
+// PromotionApplier validates promotion codes at checkout and records their
+// use. It is implemented by the promotion domain.
+type PromotionApplier interface {
+	ApplyPromotion(ctx context.Context, code string, b promotion.Basket) (*promotion.Application, error)
+	RecordRedemption(ctx context.Context, a *promotion.Application, orderID, customerID uuid.UUID) error
+	ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error
+}
+
+// FulfilmentWriter applies the delivery and driver side effects of a status
+// transition. It is implemented by the delivery domain.
+type FulfilmentWriter interface {
+	ApplyTransition(ctx context.Context, o *Order, t Transition) error
+}
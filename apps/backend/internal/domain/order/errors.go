@@ -8,4 +8,23 @@ var (
 	ErrorQuantityExceedsStock = errors.New("ordered quantity exceeds available stock")
 	ErrorVariantRequired      = errors.New("missing variant")
 	ErrorVariantNotAllowed    = errors.New("product does not have variants")
+	ErrorEmptyOrder           = errors.New("order has no items")
+	ErrorProductNotInStore    = errors.New("product does not belong to store")
+	ErrorInvalidLocation      = errors.New("pickup and delivery coordinates must be valid lat/lng")
+
+	ErrorInvalidStatus       = errors.New("invalid order status")
+	ErrorIllegalTransition   = errors.New("illegal order status transition")
+	ErrorTransitionForbidden = errors.New("not allowed to perform this status transition")
+	ErrorStatusConflict      = errors.New("order status changed concurrently")
+	ErrorStatusNotUpdatable  = errors.New("order status can only be changed through a status transition")
+	ErrorNothingToUpdate     = errors.New("no order fields to update")
+	ErrorInvalidAddress      = errors.New("address can't be blank")
+	ErrorOrderNotEditable    = errors.New("order can only be edited while pending")
+	ErrorNotOrderParticipant = errors.New("not a participant of this order")
+	ErrorCancelReasonMissing = errors.New("cancellation reason is required")
+
+	ErrorInvalidSort   = errors.New("invalid order sort field")
+	ErrorInvalidCursor = errors.New("invalid order cursor")
+	ErrorInvalidFilter = errors.New("invalid order filter")
+	ErrorListForbidden = errors.New("not allowed to list these orders")
 )
@@ -0,0 +1,67 @@
+package order
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType tells what happened to an order in its timeline.
+type EventType string
+
+const (
+	EventStatusChanged  EventType = "status_changed"
+	EventDriverAssigned EventType = "driver_assigned"
+	EventPayment        EventType = "payment"
+	EventRefund         EventType = "refund"
+	EventEdited         EventType = "edited"
+)
+
+// Event is one entry of the audit history of an order. Events are only ever
+// appended, so the timeline shows who changed what and when.
+type Event struct {
+	ID      uuid.UUID `db:"id" json:"id"`
+	OrderID uuid.UUID `db:"order_id" json:"order_id"`
+	Type    EventType `db:"type" json:"type"`
+
+	// Field names what changed, e.g. "status" or "delivery_address"
+	Field    string  `db:"field" json:"field"`
+	OldValue *string `db:"old_value" json:"old_value,omitempty"`
+	NewValue *string `db:"new_value" json:"new_value,omitempty"`
+	Note     *string `db:"note" json:"note,omitempty"`
+
+	// ActorID is nil for changes made by the system itself
+	ActorID   *uuid.UUID `db:"actor_id" json:"actor_id,omitempty"`
+	ActorRole Actor      `db:"actor_role" json:"actor_role"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// NewEvent starts an event for a change made by actor. A nil actorID records
+// no actor, as for system changes.
+func NewEvent(orderID uuid.UUID, typ EventType, field string, actor Actor, actorID uuid.UUID) *Event {
+	e := &Event{OrderID: orderID, Type: typ, Field: field, ActorRole: actor}
+	if actorID != uuid.Nil {
+		e.ActorID = &actorID
+	}
+	return e
+}
+
+// Change sets the old and new values of the event. Empty values are left unset.
+func (e *Event) Change(from, to string) *Event {
+	if from != "" {
+		e.OldValue = &from
+	}
+	if to != "" {
+		e.NewValue = &to
+	}
+	return e
+}
+
+// WithNote attaches a free-text note, e.g. a cancellation reason.
+func (e *Event) WithNote(note string) *Event {
+	if note != "" {
+		e.Note = &note
+	}
+	return e
+}
@@ -0,0 +1,126 @@
+package order
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SortField is a column orders can be listed by.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByTotal     SortField = "total"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListFilter narrows an order listing. Nil and empty fields don't filter.
+// Results are keyset-paginated on (sort column, id): pass the NextCursor of a
+// page as Cursor to get the one after it.
+type ListFilter struct {
+	StoreID    *uuid.UUID
+	MerchantID *uuid.UUID
+	CustomerID *uuid.UUID
+	Statuses   []OrderStatus
+
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+
+	MinTotal *int64 // in cents, inclusive
+	MaxTotal *int64 // in cents, inclusive
+
+	Sort   SortField
+	Desc   bool
+	Limit  int
+	Cursor *Cursor
+}
+
+// Normalize fills in defaults and rejects filters that can't be queried.
+func (f *ListFilter) Normalize() error {
+	switch f.Sort {
+	case "":
+		f.Sort = SortByCreatedAt
+	case SortByCreatedAt, SortByTotal:
+	default:
+		return ErrorInvalidSort
+	}
+
+	if f.Limit <= 0 {
+		f.Limit = DefaultListLimit
+	}
+	if f.Limit > MaxListLimit {
+		f.Limit = MaxListLimit
+	}
+
+	for _, s := range f.Statuses {
+		if !s.Valid() {
+			return ErrorInvalidStatus
+		}
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return ErrorInvalidFilter
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && *f.MinTotal > *f.MaxTotal {
+		return ErrorInvalidFilter
+	}
+
+	if f.Cursor != nil && (f.Cursor.Sort != f.Sort || f.Cursor.Desc != f.Desc) {
+		return ErrorInvalidCursor
+	}
+
+	return nil
+}
+
+// Cursor marks the last order of a page in the sort order it was listed in.
+type Cursor struct {
+	Sort      SortField `json:"s"`
+	Desc      bool      `json:"d"`
+	CreatedAt time.Time `json:"c"`
+	Total     int64     `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// CursorAfter builds the cursor that continues the listing of f after o.
+func CursorAfter(o *Order, f ListFilter) *Cursor {
+	return &Cursor{Sort: f.Sort, Desc: f.Desc, CreatedAt: o.CreatedAt, Total: o.Total, ID: o.ID}
+}
+
+// Encode returns the opaque form handed out to clients.
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrorInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil {
+		return nil, ErrorInvalidCursor
+	}
+	return &c, nil
+}
+
+// Page is one page of an order listing.
+type Page struct {
+	Orders     []*Order `json:"orders"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// PageDoc mirrors Page for Swagger.
+type PageDoc struct {
+	Orders     []OrderDoc `json:"orders"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -1,6 +1,7 @@
 package order
 
 import (
+	"backend/internal/domain/pricing"
 	"time"
 
 	"github.com/cridenour/go-postgis"
@@ -17,37 +18,84 @@ const (
 	Cancelled OrderStatus = "cancelled"
 )
 
+// DefaultCurrency is used for order totals until stores carry their own currency.
+const DefaultCurrency = "KES"
+
+// Order is the aggregate root of a checkout: one header holding many line items,
+// one total, one payment and one delivery.
 type Order struct {
-	ID      uuid.UUID `db:"id" json:"id"`
-	StoreID uuid.UUID `db:"store_id" json:"store_id"`
+	ID         uuid.UUID `db:"id" json:"id"`
+	StoreID    uuid.UUID `db:"store_id" json:"store_id"`
 	MerchantID uuid.UUID `db:"merchant_id" json:"merchant_id"` // owner
-	AdminID    uuid.UUID `db:"admin_id" json:"admin_id"` // manager
 	CustomerID uuid.UUID `db:"user_id" json:"customer_id"`
 
-	ProductID uuid.UUID  `db:"product_id" json:"product_id"`
-	VariantID *uuid.UUID `db:"variant_id" json:"variant_id"` // NULLABLE
-
-	Quantity int `db:"quantity" json:"quantity"`
+	Items []OrderItem `db:"-" json:"items"`
 
-	// Price snapshot
-	UnitPrice int64  `db:"unit_price" json:"unit_price"`
-	Currency  string `db:"currency" json:"currency"`
-	Total     int64  `db:"total" json:"total"` // quantity * unit_price
+	// Pricing breakdown, in cents
+	Currency    string `db:"currency" json:"currency"`
+	Subtotal    int64  `db:"subtotal" json:"subtotal"` // sum of item totals
+	DeliveryFee int64  `db:"delivery_fee" json:"delivery_fee"`
+	Discount    int64  `db:"discount" json:"discount"`
+	Tax         int64  `db:"tax" json:"tax"`
+	Total       int64  `db:"total" json:"total"` // grand total the customer pays
 
-	// Optional — snapshot of name & image at purchase time
-	ProductName string `db:"product_name" json:"product_name"`
-	VariantName string `db:"variant_name" json:"variant_name"`
-	ImageURL    string `db:"image_url" json:"image_url"`
+	PromoCode *string `db:"promo_code" json:"promo_code,omitempty"`
 
 	// Pickup/delivery
 	PickupAddress   string         `db:"pickup_address" json:"pickup_address"`
 	PickupPoint     postgis.PointS `db:"pickup_point" json:"pickup_point"`
 	DeliveryAddress string         `db:"delivery_address" json:"delivery_address"`
 	DeliveryPoint   postgis.PointS `db:"delivery_point" json:"delivery_point"`
+	AddressID       *uuid.UUID     `db:"address_id" json:"address_id,omitempty"` // saved address delivered to
 
 	Status    OrderStatus `db:"status" json:"status"`
 	CreatedAt time.Time   `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+
+	// Set once the order is cancelled
+	CancelReason *string    `db:"cancel_reason" json:"cancel_reason,omitempty"`
+	CancelledAt  *time.Time `db:"cancelled_at" json:"cancelled_at,omitempty"`
+}
+
+// OrderItem is a single line of an order, persisted from the CartItemSnapshot
+// taken at checkout so later catalog changes don't alter past orders.
+type OrderItem struct {
+	ID      uuid.UUID `db:"id" json:"id"`
+	OrderID uuid.UUID `db:"order_id" json:"order_id"`
+
+	ProductID uuid.UUID  `db:"product_id" json:"product_id"`
+	VariantID *uuid.UUID `db:"variant_id" json:"variant_id"` // NULLABLE
+
+	Quantity int `db:"quantity" json:"quantity"`
+
+	// Price snapshot
+	UnitPrice int64 `db:"unit_price" json:"unit_price"`
+	Total     int64 `db:"total" json:"total"` // quantity * unit_price
+
+	// Snapshot of name & image at purchase time
+	ProductName string  `db:"product_name" json:"product_name"`
+	VariantName *string `db:"variant_name" json:"variant_name,omitempty"`
+	ImageURL    *string `db:"image_url" json:"image_url,omitempty"`
+}
+
+// AddItem appends a line built from the snapshot and keeps the order total in sync.
+func (o *Order) AddItem(s CartItemSnapshot) {
+	o.Items = append(o.Items, s.ToOrderItem())
+	o.Subtotal += s.Total
+	o.Total += s.Total
+	if o.Currency == "" {
+		o.Currency = s.Currency
+	}
+}
+
+// ApplyPricing stores a pricing breakdown on the order; the grand total
+// becomes the order total.
+func (o *Order) ApplyPricing(b *pricing.Breakdown) {
+	o.Subtotal = b.Subtotal
+	o.DeliveryFee = b.DeliveryFee
+	o.Discount = b.Discount
+	o.Tax = b.Tax
+	o.Total = b.GrandTotal
 }
 
 // Point represents a simple GeoJSON-style point for Swagger only.
@@ -59,31 +107,33 @@ type Point struct {
 
 type OrderDoc struct {
 	ID         uuid.UUID `db:"id" json:"id"`
+	StoreID    uuid.UUID `db:"store_id" json:"store_id"`
 	MerchantID uuid.UUID `db:"merchant_id" json:"merchant_id"`
-	AdminID    uuid.UUID `db:"admin_id" json:"admin_id"`
 	CustomerID uuid.UUID `db:"user_id" json:"customer_id"`
 
-	ProductID uuid.UUID  `db:"product_id" json:"product_id"`
-	VariantID *uuid.UUID `db:"variant_id" json:"variant_id"`
-
-	Quantity int `db:"quantity" json:"quantity"`
+	Items []OrderItem `json:"items"`
 
-	UnitPrice int64  `db:"unit_price" json:"unit_price"`
-	Currency  string `db:"currency" json:"currency"`
-	Total     int64  `db:"total" json:"total"`
+	Currency    string `db:"currency" json:"currency"`
+	Subtotal    int64  `db:"subtotal" json:"subtotal"`
+	DeliveryFee int64  `db:"delivery_fee" json:"delivery_fee"`
+	Discount    int64  `db:"discount" json:"discount"`
+	Tax         int64  `db:"tax" json:"tax"`
+	Total       int64  `db:"total" json:"total"`
 
-	ProductName string `db:"product_name" json:"product_name"`
-	VariantName string `db:"variant_name" json:"variant_name"`
-	ImageURL    string `db:"image_url" json:"image_url"`
+	PromoCode *string `db:"promo_code" json:"promo_code,omitempty"`
 
-	PickupAddress   string `db:"pickup_address" json:"pickup_address"`
-	PickupPoint     Point  `db:"pickup_point" json:"pickup_point"`
-	DeliveryAddress string `db:"delivery_address" json:"delivery_address"`
-	DeliveryPoint   Point  `db:"delivery_point" json:"delivery_point"`
+	PickupAddress   string     `db:"pickup_address" json:"pickup_address"`
+	PickupPoint     Point      `db:"pickup_point" json:"pickup_point"`
+	DeliveryAddress string     `db:"delivery_address" json:"delivery_address"`
+	DeliveryPoint   Point      `db:"delivery_point" json:"delivery_point"`
+	AddressID       *uuid.UUID `db:"address_id" json:"address_id,omitempty"`
 
 	Status    OrderStatus `db:"status" json:"status"`
 	CreatedAt time.Time   `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time   `db:"updated_at" json:"updated_at"`
+
+	CancelReason *string    `db:"cancel_reason" json:"cancel_reason,omitempty"`
+	CancelledAt  *time.Time `db:"cancelled_at" json:"cancelled_at,omitempty"`
 }
 
 // created inside the usecase, never sent by client.
@@ -102,3 +152,17 @@ type CartItemSnapshot struct {
 	VariantName *string
 	ImageURL    *string
 }
+
+// ToOrderItem maps the snapshot onto a persistable order line.
+func (s CartItemSnapshot) ToOrderItem() OrderItem {
+	return OrderItem{
+		ProductID:   s.ProductID,
+		VariantID:   s.VariantID,
+		Quantity:    s.Quantity,
+		UnitPrice:   s.UnitPrice,
+		Total:       s.Total,
+		ProductName: s.ProductName,
+		VariantName: s.VariantName,
+		ImageURL:    s.ImageURL,
+	}
+}
@@ -2,14 +2,16 @@ package order
 
 import (
 	"context"
+	"time"
 
 	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 )
 
-// Repository defines CRUD and lookup operations for orders.
+// Repository defines CRUD and lookup operations for the order aggregate.
+// Every read returns the order header together with its line items.
 type Repository interface {
-	// Create inserts a new order record
+	// Create inserts a new order header and all of its line items
 	Create(ctx context.Context, order *Order) error
 
 	// GetByID fetches a single order by ID
@@ -18,21 +20,47 @@ type Repository interface {
 	// ListByCustomer returns all orders for a given customer
 	ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*Order, error)
 
-	// Update updates a single column for a given order
-	Update(ctx context.Context, orderID uuid.UUID, column string, value any) error
+	// ListByStatus returns all orders in the given status, oldest first
+	ListByStatus(ctx context.Context, status OrderStatus) ([]*Order, error)
+
+	// ListExpiredPending returns up to limit pending orders created before
+	// cutoff that have no completed payment, oldest first
+	ListExpiredPending(ctx context.Context, cutoff time.Time, limit int) ([]*Order, error)
+
+	// HasCompletedPayment locks the order for the rest of the transaction
+	// and reports whether it has a completed payment
+	HasCompletedPayment(ctx context.Context, orderID uuid.UUID) (bool, error)
+
+	// UpdateRoute stores the addresses, points and pricing of a pending
+	// order, failing with ErrorStatusConflict once it is no longer pending
+	UpdateRoute(ctx context.Context, o *Order) error
+
+	// UpdateStatus moves an order from one status to another, failing with
+	// ErrorStatusConflict if the order is no longer in the from status
+	UpdateStatus(ctx context.Context, orderID uuid.UUID, from, to OrderStatus) error
+
+	// SetCancellation records why and when an order was cancelled
+	SetCancellation(ctx context.Context, orderID uuid.UUID, reason string) error
 
 	// List returns all orders
 	List(ctx context.Context) ([]*Order, error)
 
-	// Delete removes an order by ID
+	// ListFiltered returns up to filter.Limit orders matching the filter,
+	// in its sort order and starting after its cursor
+	ListFiltered(ctx context.Context, filter ListFilter) ([]*Order, error)
+
+	// Delete removes an order and its line items by ID
 	Delete(ctx context.Context, id uuid.UUID) error
 
+	// AddEvent appends an entry to the audit history of an order
+	AddEvent(ctx context.Context, e *Event) error
+
+	// ListEvents returns the audit history of an order, oldest first
+	ListEvents(ctx context.Context, orderID uuid.UUID) ([]*Event, error)
+
 	// GetPickupPoint returns the pickup location of an order
 	GetPickupPoint(ctx context.Context, orderID uuid.UUID) (postgis.PointS, error)
 
 	// GetDeliveryPoint returns the delivery location of an order
 	GetDeliveryPoint(ctx context.Context, orderID uuid.UUID) (postgis.PointS, error)
-
-	// CreatePending inserts a pending order
-	CreatePending(ctx context.Context, o *Order) error
 }
@@ -1,6 +1,8 @@
 package order
 
 import (
+	"strings"
+
 	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 )
@@ -11,13 +13,19 @@ type CreateOrderRequest struct {
 
 	Items []CreateOrderItem `json:"items" binding:"required,min=1"`
 
-	PickupAddress string  `json:"pickup_address" binding:"required"`
-	PickupLat     float64 `json:"pickup_lat" binding:"required"`
-	PickupLng     float64 `json:"pickup_lng" binding:"required"`
+	// Optional; orders are picked up at the store by default
+	PickupAddress string  `json:"pickup_address"`
+	PickupLat     float64 `json:"pickup_lat"`
+	PickupLng     float64 `json:"pickup_lng"`
+
+	// Either a saved address of the customer, or the address and
+	// coordinates inline
+	AddressID       *uuid.UUID `json:"address_id,omitempty"`
+	DeliveryAddress string     `json:"delivery_address"`
+	DeliveryLat     float64    `json:"delivery_lat"`
+	DeliveryLng     float64    `json:"delivery_lng"`
 
-	DeliveryAddress string  `json:"delivery_address" binding:"required"`
-	DeliveryLat     float64 `json:"delivery_lat" binding:"required"`
-	DeliveryLng     float64 `json:"delivery_lng" binding:"required"`
+	PromoCode string `json:"promo_code,omitempty"`
 }
 
 type CreateOrderItem struct {
@@ -26,9 +34,42 @@ type CreateOrderItem struct {
 	Quantity  int        `json:"quantity" binding:"required,gt=0"`
 }
 
+// UpdateOrderRequest changes the addresses that are set while the order is
+// pending. Everything else about an order is fixed once placed, and its
+// status only moves through a status transition.
 type UpdateOrderRequest struct {
-	Column string      `json:"column" binding:"required"` // e.g. "status", "quantity"
-	Value  interface{} `json:"value" binding:"required"`  // Accepts string, int, etc.
+	PickupAddress   *string `json:"pickup_address,omitempty"`
+	DeliveryAddress *string `json:"delivery_address,omitempty"`
+
+	// Status is refused with ErrorStatusNotUpdatable
+	Status *OrderStatus `json:"status,omitempty" swaggerignore:"true"`
+}
+
+// Validate checks that something is set and that set addresses aren't
+// blank.
+func (r *UpdateOrderRequest) Validate() error {
+	if r.Status != nil {
+		return ErrorStatusNotUpdatable
+	}
+	if r.PickupAddress == nil && r.DeliveryAddress == nil {
+		return ErrorNothingToUpdate
+	}
+	for _, a := range []*string{r.PickupAddress, r.DeliveryAddress} {
+		if a != nil && strings.TrimSpace(*a) == "" {
+			return ErrorInvalidAddress
+		}
+	}
+	return nil
+}
+
+// UpdateOrderStatusRequest asks for a single lifecycle transition.
+type UpdateOrderStatusRequest struct {
+	Status OrderStatus `json:"status" binding:"required"` // e.g. "assigned", "cancelled"
+}
+
+// CancelOrderRequest carries the reason shown to the other parties of the order.
+type CancelOrderRequest struct {
+	Reason string `json:"reason" binding:"required"`
 }
 
 // ToOrder maps basic request info; snapshot fields filled in UseCase
@@ -48,8 +89,8 @@ func (r *CreateOrderRequest) ToOrder() *Order {
 			Y: r.DeliveryLat,
 			SRID: 4326,
 		},
-		// ProductID, VariantID, Quantity, UnitPrice, Total, ProductName, VariantName, ImageURL
-		// will be populated per item in UseCase
+		// Items, Currency and Total are populated from
+		// CartItemSnapshots in the UseCase
 	}
 }
 
@@ -72,29 +113,18 @@ type Inventory struct {
 	Category string    `db:"category" json:"category"`
 }
 
-// Point represents a simple GeoJSON-style point for Swagger only.
-// swagger:model Point
+// CreateOrderRequestDoc is used only for Swagger documentation.
 type CreateOrderRequestDoc struct {
-	MerchantID uuid.UUID `json:"merchant_id" binding:"required"`
-	AdminID    uuid.UUID `json:"admin_id" binding:"required"`
+	StoreID    uuid.UUID `json:"store_id" binding:"required"`
 	CustomerID uuid.UUID `json:"customer_id" binding:"required"`
 
-	ProductID uuid.UUID  `json:"product_id" binding:"required"`
-	VariantID *uuid.UUID `json:"variant_id" binding:"required"`
-
-	Quantity int `json:"quantity" binding:"required"`
-
-	UnitPrice int64  `json:"unit_price" binding:"required"`
-	Currency  string `json:"currency" binding:"required"`
-	Total     int64  `json:"total" binding:"required"`
+	Items []CreateOrderItem `json:"items" binding:"required"`
 
-	// snapshot at purchase time
-	ProductName string `json:"product_name" binding:"required"`
-	VariantName string `json:"variant_name" binding:"required"`
-	ImageURL    string `json:"image_url" binding:"required"`
+	PickupAddress   string     `json:"pickup_address" binding:"required"`
+	PickupPoint     Point      `json:"pickup_point" binding:"required"`
+	AddressID       *uuid.UUID `json:"address_id,omitempty"`
+	DeliveryAddress string     `json:"delivery_address"`
+	DeliveryPoint   Point      `json:"delivery_point"`
 
-	PickupAddress   string `json:"pickup_address" binding:"required"`
-	PickupPoint     Point  `json:"pickup_point" binding:"required"`
-	DeliveryAddress string `json:"delivery_address" binding:"required"`
-	DeliveryPoint   Point  `json:"delivery_point" binding:"required"`
+	PromoCode string `json:"promo_code,omitempty"`
 }
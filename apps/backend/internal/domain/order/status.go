@@ -0,0 +1,171 @@
+package order
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Actor is the kind of caller asking for an order status change.
+type Actor string
+
+const (
+	ActorCustomer Actor = "customer"
+	ActorMerchant Actor = "merchant"
+	ActorDriver   Actor = "driver"
+	ActorAdmin    Actor = "admin"
+	ActorSystem   Actor = "system" // background jobs such as auto-assignment
+)
+
+// ActorFromRole maps an authenticated user role to a status-change actor.
+func ActorFromRole(role string) (Actor, error) {
+	switch a := Actor(role); a {
+	case ActorCustomer, ActorMerchant, ActorDriver, ActorAdmin:
+		return a, nil
+	}
+	return "", fmt.Errorf("%w: role %q", ErrorTransitionForbidden, role)
+}
+
+// Effect is a side effect that has to be applied together with a status change,
+// in the same transaction.
+type Effect string
+
+const (
+	EffectRestoreStock     Effect = "restore_stock"     // put reserved stock back on the shelf
+	EffectReserveDriver    Effect = "reserve_driver"    // driver becomes unavailable
+	EffectReleaseDriver    Effect = "release_driver"    // driver becomes available again
+	EffectPickUpDelivery   Effect = "pick_up_delivery"  // delivery moves to picked_up
+	EffectCompleteDelivery Effect = "complete_delivery" // delivery moves to delivered
+	EffectFailDelivery     Effect = "fail_delivery"     // delivery moves to failed
+	EffectRefundPayment    Effect = "refund_payment"    // open a refund for a completed payment
+	EffectReleasePromotion Effect = "release_promotion" // give back the promotion redemption of the order
+	EffectNotifyCustomer   Effect = "notify_customer"
+)
+
+// Transition is one legal edge of the order lifecycle.
+type Transition struct {
+	From    OrderStatus
+	To      OrderStatus
+	Actors  []Actor
+	Effects []Effect
+}
+
+// AllowedFor reports whether the actor may trigger the transition.
+func (t Transition) AllowedFor(a Actor) bool {
+	return slices.Contains(t.Actors, a)
+}
+
+// Has reports whether the transition carries the given side effect.
+func (t Transition) Has(e Effect) bool {
+	return slices.Contains(t.Effects, e)
+}
+
+// transitions is the order lifecycle:
+//
+//	pending ──> assigned ──> in_transit ──> delivered
+//	   │  <──────┘ │              │
+//	   └───────────┴──────────────┴──> cancelled
+var transitions = []Transition{
+	{
+		From:   Pending,
+		To:     Assigned, // the assignment itself tells customer and driver
+		Actors: []Actor{ActorMerchant, ActorAdmin, ActorSystem},
+	},
+	{
+		From:    Pending,
+		To:      Cancelled,
+		Actors:  []Actor{ActorCustomer, ActorMerchant, ActorAdmin, ActorSystem},
+		Effects: []Effect{EffectRestoreStock, EffectRefundPayment, EffectReleasePromotion, EffectNotifyCustomer},
+	},
+	{
+		From:    Assigned,
+		To:      Pending, // driver declined, back to the assignment queue
+		Actors:  []Actor{ActorDriver, ActorMerchant, ActorAdmin, ActorSystem},
+		Effects: []Effect{EffectReleaseDriver, EffectFailDelivery},
+	},
+	{
+		From:    Assigned,
+		To:      InTransit,
+		Actors:  []Actor{ActorDriver, ActorAdmin},
+		Effects: []Effect{EffectReserveDriver, EffectPickUpDelivery, EffectNotifyCustomer},
+	},
+	{
+		From:    Assigned,
+		To:      Cancelled,
+		Actors:  []Actor{ActorCustomer, ActorMerchant, ActorAdmin},
+		Effects: []Effect{EffectRestoreStock, EffectReleaseDriver, EffectFailDelivery, EffectRefundPayment, EffectReleasePromotion, EffectNotifyCustomer},
+	},
+	{
+		From:    InTransit,
+		To:      Delivered,
+		Actors:  []Actor{ActorDriver, ActorAdmin},
+		Effects: []Effect{EffectReleaseDriver, EffectCompleteDelivery, EffectNotifyCustomer},
+	},
+	{
+		From:    InTransit,
+		To:      Cancelled,
+		Actors:  []Actor{ActorAdmin},
+		Effects: []Effect{EffectRestoreStock, EffectReleaseDriver, EffectFailDelivery, EffectRefundPayment, EffectReleasePromotion, EffectNotifyCustomer},
+	},
+}
+
+// Valid reports whether s is a known order status.
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case Pending, Assigned, InTransit, Delivered, Cancelled:
+		return true
+	}
+	return false
+}
+
+// Terminal reports whether no further transitions are possible from s.
+func (s OrderStatus) Terminal() bool {
+	return s == Delivered || s == Cancelled
+}
+
+// TransitionError is returned when a status change is not allowed. It wraps
+// ErrorIllegalTransition or ErrorTransitionForbidden.
+type TransitionError struct {
+	From  OrderStatus
+	To    OrderStatus
+	Actor Actor
+	Err   error
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%v: %s -> %s by %s", e.Err, e.From, e.To, e.Actor)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// NextTransition returns the transition from -> to if it exists and actor is
+// allowed to trigger it.
+func NextTransition(from, to OrderStatus, actor Actor) (Transition, error) {
+	if !to.Valid() {
+		return Transition{}, fmt.Errorf("%w: %q", ErrorInvalidStatus, to)
+	}
+
+	for _, t := range transitions {
+		if t.From != from || t.To != to {
+			continue
+		}
+		if !t.AllowedFor(actor) {
+			return Transition{}, &TransitionError{From: from, To: to, Actor: actor, Err: ErrorTransitionForbidden}
+		}
+		return t, nil
+	}
+
+	return Transition{}, &TransitionError{From: from, To: to, Actor: actor, Err: ErrorIllegalTransition}
+}
+
+// NeedsFulfilment reports whether the transition touches the delivery or driver.
+func (t Transition) NeedsFulfilment() bool {
+	for _, e := range t.Effects {
+		switch e {
+		case EffectReserveDriver, EffectReleaseDriver, EffectPickUpDelivery, EffectCompleteDelivery, EffectFailDelivery:
+			return true
+		}
+	}
+	return false
+}
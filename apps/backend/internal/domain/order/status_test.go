@@ -0,0 +1,59 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextTransition(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  OrderStatus
+		to    OrderStatus
+		actor Actor
+		err   error
+	}{
+		{"system assigns pending order", Pending, Assigned, ActorSystem, nil},
+		{"customer cancels pending order", Pending, Cancelled, ActorCustomer, nil},
+		{"driver picks up assigned order", Assigned, InTransit, ActorDriver, nil},
+		{"driver declines assigned order", Assigned, Pending, ActorDriver, nil},
+		{"merchant cancels assigned order", Assigned, Cancelled, ActorMerchant, nil},
+		{"driver delivers order", InTransit, Delivered, ActorDriver, nil},
+		{"admin cancels order in transit", InTransit, Cancelled, ActorAdmin, nil},
+
+		{"customer cannot assign", Pending, Assigned, ActorCustomer, ErrorTransitionForbidden},
+		{"merchant cannot pick up", Assigned, InTransit, ActorMerchant, ErrorTransitionForbidden},
+		{"customer cannot cancel order in transit", InTransit, Cancelled, ActorCustomer, ErrorTransitionForbidden},
+		{"pending cannot skip to delivered", Pending, Delivered, ActorAdmin, ErrorIllegalTransition},
+		{"in transit cannot go back to pending", InTransit, Pending, ActorAdmin, ErrorIllegalTransition},
+		{"delivered is terminal", Delivered, Cancelled, ActorAdmin, ErrorIllegalTransition},
+		{"cancelled is terminal", Cancelled, Pending, ActorAdmin, ErrorIllegalTransition},
+		{"same status is not a transition", Pending, Pending, ActorAdmin, ErrorIllegalTransition},
+		{"unknown status", Pending, OrderStatus("in-transit"), ActorAdmin, ErrorInvalidStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := NextTransition(tt.from, tt.to, tt.actor)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.from, tr.From)
+			require.Equal(t, tt.to, tr.To)
+		})
+	}
+}
+
+func TestTransitions_CancellationUndoesCheckout(t *testing.T) {
+	for _, tr := range transitions {
+		if tr.To == Cancelled {
+			require.True(t, tr.Has(EffectRestoreStock), "%s -> cancelled must restore stock", tr.From)
+			require.True(t, tr.Has(EffectRefundPayment), "%s -> cancelled must refund payment", tr.From)
+			require.True(t, tr.Has(EffectReleasePromotion), "%s -> cancelled must release the promotion", tr.From)
+		}
+		require.False(t, tr.From.Terminal(), "no transition may leave terminal status %s", tr.From)
+	}
+}
@@ -0,0 +1,12 @@
+package payment
+
+import (
+	"backend/internal/domain/order"
+	"context"
+)
+
+// cross-domain interface so payment events land in the order timeline
+
+type OrderEventWriter interface {
+	AddEvent(ctx context.Context, e *order.Event) error
+}
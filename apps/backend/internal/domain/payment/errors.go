@@ -0,0 +1,7 @@
+package payment
+
+import "errors"
+
+var (
+	ErrorRefundAlreadyRequested = errors.New("refund already requested for this payment")
+)
@@ -8,6 +8,7 @@ import (
 
 type PaymentMethod string
 type PaymentStatus string
+type RefundStatus string
 
 const (
 	MethodStripe         PaymentMethod = "stripe"
@@ -18,6 +19,10 @@ const (
 	StatusPending   PaymentStatus = "pending"
 	StatusCompleted PaymentStatus = "completed"
 	StatusFailed    PaymentStatus = "failed"
+
+	RefundPending   RefundStatus = "pending"
+	RefundCompleted RefundStatus = "completed"
+	RefundRejected  RefundStatus = "rejected"
 )
 
 type Payment struct {
@@ -39,3 +44,19 @@ type Payment struct {
 	CreatedAt time.Time  `db:"created_at" json:"created_at"`
 	PaidAt    *time.Time `db:"paid_at" json:"paid_at,omitempty"`
 }
+
+// Refund is a request to pay back a completed payment, e.g. after the order
+// was cancelled. It is settled with the provider outside of this service.
+type Refund struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	PaymentID uuid.UUID `db:"payment_id" json:"payment_id"`
+	OrderID   uuid.UUID `db:"order_id" json:"order_id"`
+
+	Amount   int64  `db:"amount" json:"amount"` // in cents
+	Currency string `db:"currency" json:"currency"`
+	Reason   string `db:"reason" json:"reason"`
+
+	Status      RefundStatus `db:"status" json:"status"`
+	CreatedAt   time.Time    `db:"created_at" json:"created_at"`
+	ProcessedAt *time.Time   `db:"processed_at" json:"processed_at,omitempty"`
+}
@@ -12,4 +12,6 @@ type Repository interface {
 	GetByOrder(ctx context.Context, id uuid.UUID) (*Payment, error)
 	List(ctx context.Context) ([]*Payment, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	CreateRefund(ctx context.Context, r *Refund) error
 }
@@ -0,0 +1,14 @@
+package pricing
+
+import (
+	"backend/internal/domain/store"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// cross-domain interface so merchants can only price the stores they manage
+
+type StoreReader interface {
+	GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error)
+}
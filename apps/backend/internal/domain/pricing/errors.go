@@ -0,0 +1,10 @@
+package pricing
+
+import "errors"
+
+var (
+	ErrorNoTariff           = errors.New("no delivery tariff configured")
+	ErrorOutOfDeliveryRange = errors.New("delivery address is out of range")
+	ErrorInvalidTariff      = errors.New("tariff bands must grow in distance and have non-negative fees")
+	ErrorNotStoreOwner      = errors.New("not allowed to manage this store")
+)
@@ -0,0 +1,86 @@
+package pricing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TariffBand charges a flat delivery fee for distances up to MaxDistanceM.
+// Bands without a store are the global tariff, used by stores that have
+// none of their own.
+type TariffBand struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	StoreID      *uuid.UUID `db:"store_id" json:"store_id,omitempty"`
+	MaxDistanceM int        `db:"max_distance_m" json:"max_distance_m"`
+	Fee          int64      `db:"fee" json:"fee"` // in cents
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Tariff is an ordered set of bands, shortest distance first.
+type Tariff []TariffBand
+
+// FeeFor returns the fee of the first band covering the distance.
+func (t Tariff) FeeFor(distanceM float64) (int64, error) {
+	if len(t) == 0 {
+		return 0, ErrorNoTariff
+	}
+	for _, b := range t {
+		if distanceM <= float64(b.MaxDistanceM) {
+			return b.Fee, nil
+		}
+	}
+	return 0, ErrorOutOfDeliveryRange
+}
+
+// Validate checks that bands grow strictly in distance and never charge
+// a negative fee.
+func (t Tariff) Validate() error {
+	if len(t) == 0 {
+		return ErrorNoTariff
+	}
+	prev := 0
+	for _, b := range t {
+		if b.MaxDistanceM <= prev || b.Fee < 0 {
+			return ErrorInvalidTariff
+		}
+		prev = b.MaxDistanceM
+	}
+	return nil
+}
+
+// Breakdown itemises what a customer pays for an order. All amounts are in
+// cents of Currency.
+type Breakdown struct {
+	Currency  string  `json:"currency"`
+	DistanceM float64 `json:"distance_m"`
+
+	Subtotal    int64 `json:"subtotal"`
+	DeliveryFee int64 `json:"delivery_fee"`
+	Discount    int64 `json:"discount"`
+	Tax         int64 `json:"tax"`
+	GrandTotal  int64 `json:"grand_total"`
+
+	TaxRateBps int `json:"tax_rate_bps"` // basis points, 1600 = 16%
+}
+
+// ApplyDiscount takes an amount off, never more than the subtotal plus
+// delivery fee, and recomputes tax and grand total.
+func (b *Breakdown) ApplyDiscount(amount int64) {
+	if owed := b.Subtotal + b.DeliveryFee; amount > owed {
+		amount = owed
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	b.Discount = amount
+	b.Recalculate()
+}
+
+// Recalculate derives tax and grand total from the other amounts. Tax is
+// charged on the discounted subtotal plus delivery.
+func (b *Breakdown) Recalculate() {
+	taxable := b.Subtotal + b.DeliveryFee - b.Discount
+	b.Tax = taxable * int64(b.TaxRateBps) / 10000
+	b.GrandTotal = taxable + b.Tax
+}
@@ -0,0 +1,59 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTariff_FeeFor(t *testing.T) {
+	tariff := Tariff{
+		{MaxDistanceM: 3000, Fee: 15000},
+		{MaxDistanceM: 7000, Fee: 25000},
+	}
+
+	tests := []struct {
+		name     string
+		distance float64
+		fee      int64
+		err      error
+	}{
+		{"next door", 0, 15000, nil},
+		{"band edge is inclusive", 3000, 15000, nil},
+		{"second band", 3000.5, 25000, nil},
+		{"beyond last band", 7001, 0, ErrorOutOfDeliveryRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := tariff.FeeFor(tt.distance)
+			require.ErrorIs(t, err, tt.err)
+			require.Equal(t, tt.fee, fee)
+		})
+	}
+
+	_, err := Tariff{}.FeeFor(10)
+	require.ErrorIs(t, err, ErrorNoTariff)
+}
+
+func TestTariff_Validate(t *testing.T) {
+	require.NoError(t, Tariff{{MaxDistanceM: 1000, Fee: 0}, {MaxDistanceM: 2000, Fee: 100}}.Validate())
+	require.ErrorIs(t, Tariff{{MaxDistanceM: 2000}, {MaxDistanceM: 1000}}.Validate(), ErrorInvalidTariff)
+	require.ErrorIs(t, Tariff{{MaxDistanceM: 1000, Fee: -1}}.Validate(), ErrorInvalidTariff)
+	require.ErrorIs(t, Tariff{}.Validate(), ErrorNoTariff)
+}
+
+func TestBreakdown_ApplyDiscount(t *testing.T) {
+	b := &Breakdown{Subtotal: 10000, DeliveryFee: 2000, TaxRateBps: 1600}
+	b.Recalculate()
+	require.Equal(t, int64(1920), b.Tax)
+	require.Equal(t, int64(13920), b.GrandTotal)
+
+	b.ApplyDiscount(2000)
+	require.Equal(t, int64(1600), b.Tax, "tax is charged after the discount")
+	require.Equal(t, int64(11600), b.GrandTotal)
+
+	b.ApplyDiscount(50000)
+	require.Equal(t, int64(12000), b.Discount, "discount never exceeds what is owed")
+	require.Equal(t, int64(0), b.GrandTotal)
+}
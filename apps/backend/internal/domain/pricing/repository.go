@@ -0,0 +1,20 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// ListBands returns the bands of a store, or the global bands for a
+	// nil store, shortest distance first
+	ListBands(ctx context.Context, storeID *uuid.UUID) (Tariff, error)
+
+	// ReplaceBands swaps the bands of a store (or the global ones) for new ones
+	ReplaceBands(ctx context.Context, storeID *uuid.UUID, bands Tariff) error
+
+	// Distance returns the distance in meters between two WGS84 points
+	Distance(ctx context.Context, from, to postgis.PointS) (float64, error)
+}
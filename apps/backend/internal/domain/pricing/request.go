@@ -0,0 +1,24 @@
+package pricing
+
+import "github.com/google/uuid"
+
+// ReplaceTariffRequest sets the delivery tariff of a store, or the global
+// tariff when StoreID is empty.
+type ReplaceTariffRequest struct {
+	StoreID *uuid.UUID    `json:"store_id,omitempty"`
+	Bands   []BandRequest `json:"bands" binding:"required,min=1"`
+}
+
+type BandRequest struct {
+	MaxDistanceM int   `json:"max_distance_m" binding:"required,gt=0"`
+	Fee          int64 `json:"fee" binding:"gte=0"` // in cents
+}
+
+// ToTariff maps the requested bands, keeping their order.
+func (r *ReplaceTariffRequest) ToTariff() Tariff {
+	t := make(Tariff, len(r.Bands))
+	for i, b := range r.Bands {
+		t[i] = TariffBand{StoreID: r.StoreID, MaxDistanceM: b.MaxDistanceM, Fee: b.Fee}
+	}
+	return t
+}
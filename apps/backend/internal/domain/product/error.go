@@ -25,4 +25,5 @@ var (
 	ErrInvalidVariantOptValInput = errors.New("Invalid variant option data.")
 	ErrVariantAlreadyExists      = errors.New("Variant already exists.")
 	ErrVariantNotFound           = errors.New("Variant not found.")
+	ErrInsufficientStock         = errors.New("Insufficient stock.")
 )
@@ -0,0 +1,53 @@
+package product
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// The helpers below describe a product, or one of its variants, the same way
+// on cart lines and on the order lines snapshotted at checkout.
+
+// UnitPrice returns the price of one product, or of the variant if one is
+// picked, in cents. It is rounded so prices like 19.99 don't lose a cent to
+// float error.
+func UnitPrice(p *Product, v *Variant) int64 {
+	if v != nil {
+		return int64(math.Round(v.Price * 100))
+	}
+	return int64(math.Round(p.Price * 100))
+}
+
+// VariantName returns a human-readable name for a variant: its SKU followed
+// by its options in key order, so the same variant is always named alike.
+func VariantName(v *Variant) string {
+	if len(v.Options) == 0 {
+		return v.SKU
+	}
+
+	keys := make([]string, 0, len(v.Options))
+	for k := range v.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s:%s", k, v.Options[k])
+	}
+	return fmt.Sprintf("%s (%s)", v.SKU, strings.Join(parts, ", "))
+}
+
+// PrimaryImage returns the image URL of the variant if it has one, else the
+// product's first image, "" if there is none.
+func PrimaryImage(p *Product, v *Variant) string {
+	if v != nil && v.ImageURL != "" {
+		return v.ImageURL
+	}
+	if len(p.Images) > 0 {
+		return p.Images[0]
+	}
+	return ""
+}
@@ -0,0 +1,30 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitPrice(t *testing.T) {
+	p := &Product{Price: 19.99} // 19.99 * 100 is 1998.9999999999998
+	require.Equal(t, int64(1999), UnitPrice(p, nil))
+	require.Equal(t, int64(2050), UnitPrice(p, &Variant{Price: 20.5}))
+}
+
+func TestVariantName(t *testing.T) {
+	require.Equal(t, "TS-1", VariantName(&Variant{SKU: "TS-1"}))
+
+	v := &Variant{SKU: "TS-1", Options: map[string]string{"size": "M", "colour": "red", "fit": "slim"}}
+	for range 20 {
+		require.Equal(t, "TS-1 (colour:red, fit:slim, size:M)", VariantName(v))
+	}
+}
+
+func TestPrimaryImage(t *testing.T) {
+	p := &Product{Images: []string{"front.jpg", "back.jpg"}}
+	require.Equal(t, "front.jpg", PrimaryImage(p, nil))
+	require.Equal(t, "front.jpg", PrimaryImage(p, &Variant{}))
+	require.Equal(t, "red.jpg", PrimaryImage(p, &Variant{ImageURL: "red.jpg"}))
+	require.Empty(t, PrimaryImage(&Product{}, nil))
+}
@@ -21,6 +21,15 @@ type Repository interface {
 
 	UpdateProductStock(ctx context.Context, productID uuid.UUID, stock int) error
 
+	// DecrementProductStock atomically removes quantity from the stock of a
+	// product without variants. It returns ErrInsufficientStock, and changes
+	// nothing, when less than quantity is available.
+	DecrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error
+
+	// IncrementProductStock puts quantity back on the stock of a product
+	// without variants, e.g. when an order is cancelled.
+	IncrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error
+
 	// List returns all products from a specified store accessible to the caller.
 	// Each product should be returned as a fully-hydrated aggregate.
 	ListProductsByStore(ctx context.Context, storeID uuid.UUID) ([]ProductListItem, error)
@@ -50,6 +59,14 @@ type Repository interface {
 	// for a specific variant.
 	UpdateVariantStock(ctx context.Context, variantID uuid.UUID, stock int) error
 
+	// DecrementVariantStock atomically removes quantity from the stock of a
+	// variant. It returns ErrInsufficientStock, and changes nothing, when less
+	// than quantity is available.
+	DecrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error
+
+	// IncrementVariantStock puts quantity back on the stock of a variant.
+	IncrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error
+
 	// UpdateVariantPrice updates the selling price for a specific variant.
 	UpdateVariantPrice(ctx context.Context, variantID uuid.UUID, price float64) error
 
@@ -97,6 +114,16 @@ type Repository interface {
 	// GetOptionValueID retrieves the ID of a product option value by its value.
 	GetOptionValueID(ctx context.Context, optionID uuid.UUID, value string) (uuid.UUID, error)
 
+	// GetImageProductID retrieves the ID of the product an image belongs to.
+	GetImageProductID(ctx context.Context, imageID uuid.UUID) (uuid.UUID, error)
+
+	// GetOptionProductID retrieves the ID of the product an option belongs to.
+	GetOptionProductID(ctx context.Context, optionID uuid.UUID) (uuid.UUID, error)
+
+	// GetOptionValueProductID retrieves the ID of the product an option value
+	// belongs to.
+	GetOptionValueProductID(ctx context.Context, optionValueID uuid.UUID) (uuid.UUID, error)
+
 	// ListVariantsByProductID retrieves all variants associated with a specific product.
 	ListVariantsByProductID(ctx context.Context, productID uuid.UUID) ([]Variant, error)
 
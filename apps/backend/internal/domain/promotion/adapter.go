@@ -0,0 +1,19 @@
+package promotion
+
+import (
+	"backend/internal/domain/product"
+	"backend/internal/domain/store"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// cross-domain interfaces to check who may create promotions for what
+
+type StoreReader interface {
+	GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error)
+}
+
+type ProductReader interface {
+	GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error)
+}
@@ -0,0 +1,18 @@
+package promotion
+
+import "errors"
+
+var (
+	ErrorPromotionNotFound     = errors.New("promotion code not found")
+	ErrorPromotionInactive     = errors.New("promotion is no longer active")
+	ErrorPromotionNotStarted   = errors.New("promotion has not started yet")
+	ErrorPromotionExpired      = errors.New("promotion has expired")
+	ErrorMinimumNotMet         = errors.New("basket is below the promotion minimum")
+	ErrorNotApplicable         = errors.New("promotion does not apply to this basket")
+	ErrorPromotionExhausted    = errors.New("promotion has been fully redeemed")
+	ErrorCustomerLimitReached  = errors.New("promotion already used the maximum number of times")
+	ErrorInvalidPromotion      = errors.New("invalid promotion")
+	ErrorPromotionCodeConflict = errors.New("promotion code already exists")
+	ErrorNotStoreOwner         = errors.New("not allowed to manage this store")
+	ErrorProductNotInStore     = errors.New("product does not belong to the promotion store")
+)
@@ -0,0 +1,170 @@
+package promotion
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DiscountType string
+
+const (
+	Percentage   DiscountType = "percentage"    // Value percent off the eligible items
+	Fixed        DiscountType = "fixed"         // Value cents off the eligible items
+	FreeDelivery DiscountType = "free_delivery" // the delivery fee is waived
+)
+
+// Promotion is a discount code. Without a store it is platform-wide; with
+// products it only discounts those products.
+type Promotion struct {
+	ID         uuid.UUID   `db:"id" json:"id"`
+	Code       string      `db:"code" json:"code"`
+	StoreID    *uuid.UUID  `db:"store_id" json:"store_id,omitempty"`
+	ProductIDs []uuid.UUID `db:"-" json:"product_ids,omitempty"`
+
+	Type  DiscountType `db:"type" json:"type"`
+	Value int64        `db:"value" json:"value"`
+
+	MinSubtotal    int64 `db:"min_subtotal" json:"min_subtotal"` // in cents
+	MaxRedemptions *int  `db:"max_redemptions" json:"max_redemptions,omitempty"`
+	MaxPerCustomer *int  `db:"max_per_customer" json:"max_per_customer,omitempty"`
+	Redemptions    int   `db:"redemption_count" json:"redemption_count"`
+
+	StartsAt *time.Time `db:"starts_at" json:"starts_at,omitempty"`
+	EndsAt   *time.Time `db:"ends_at" json:"ends_at,omitempty"`
+	Active   bool       `db:"active" json:"active"`
+
+	CreatedBy uuid.UUID `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// Redemption records one use of a promotion by an order.
+type Redemption struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	PromotionID uuid.UUID `db:"promotion_id" json:"promotion_id"`
+	OrderID     uuid.UUID `db:"order_id" json:"order_id"`
+	CustomerID  uuid.UUID `db:"customer_id" json:"customer_id"`
+	Amount      int64     `db:"amount" json:"amount"` // in cents
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// Basket is what a code is checked against at checkout.
+type Basket struct {
+	StoreID     uuid.UUID
+	CustomerID  uuid.UUID
+	Subtotal    int64
+	DeliveryFee int64
+	Lines       []BasketLine
+}
+
+type BasketLine struct {
+	ProductID uuid.UUID
+	Total     int64
+}
+
+// Application is a validated code with the discount it grants a basket.
+type Application struct {
+	PromotionID uuid.UUID `json:"promotion_id"`
+	Code        string    `json:"code"`
+	Discount    int64     `json:"discount"`
+}
+
+// NormalizeCode makes codes case-insensitive.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CheckWindow reports whether the promotion can be used at the given time.
+func (p *Promotion) CheckWindow(now time.Time) error {
+	switch {
+	case !p.Active:
+		return ErrorPromotionInactive
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return ErrorPromotionNotStarted
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return ErrorPromotionExpired
+	}
+	return nil
+}
+
+// DiscountFor returns the discount the promotion grants a basket, checking
+// store scope, product scope and minimum basket. Usage limits are checked by
+// the usecase, which has to count redemptions.
+func (p *Promotion) DiscountFor(b Basket) (int64, error) {
+	if p.StoreID != nil && *p.StoreID != b.StoreID {
+		return 0, ErrorNotApplicable
+	}
+	if b.Subtotal < p.MinSubtotal {
+		return 0, ErrorMinimumNotMet
+	}
+
+	eligible := b.Subtotal
+	if len(p.ProductIDs) > 0 {
+		eligible = 0
+		for _, l := range b.Lines {
+			if p.covers(l.ProductID) {
+				eligible += l.Total
+			}
+		}
+		if eligible == 0 {
+			return 0, ErrorNotApplicable
+		}
+	}
+
+	switch p.Type {
+	case Percentage:
+		return eligible * p.Value / 100, nil
+	case Fixed:
+		return min(p.Value, eligible), nil
+	case FreeDelivery:
+		return b.DeliveryFee, nil
+	}
+	return 0, ErrorInvalidPromotion
+}
+
+func (p *Promotion) covers(productID uuid.UUID) bool {
+	for _, id := range p.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a promotion before it is saved.
+func (p *Promotion) Validate() error {
+	if p.Code == "" || len(p.Code) > 32 {
+		return ErrorInvalidPromotion
+	}
+	switch p.Type {
+	case Percentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return ErrorInvalidPromotion
+		}
+	case Fixed:
+		if p.Value <= 0 {
+			return ErrorInvalidPromotion
+		}
+	case FreeDelivery:
+		p.Value = 0
+	default:
+		return ErrorInvalidPromotion
+	}
+	if p.MinSubtotal < 0 {
+		return ErrorInvalidPromotion
+	}
+	if p.MaxRedemptions != nil && *p.MaxRedemptions <= 0 {
+		return ErrorInvalidPromotion
+	}
+	if p.MaxPerCustomer != nil && *p.MaxPerCustomer <= 0 {
+		return ErrorInvalidPromotion
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.StartsAt.Before(*p.EndsAt) {
+		return ErrorInvalidPromotion
+	}
+	if len(p.ProductIDs) > 0 && p.StoreID == nil {
+		return ErrorInvalidPromotion // product scope only makes sense within a store
+	}
+	return nil
+}
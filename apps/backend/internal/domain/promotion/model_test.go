@@ -0,0 +1,74 @@
+package promotion
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscountFor(t *testing.T) {
+	storeID := uuid.New()
+	shoes, socks := uuid.New(), uuid.New()
+	basket := Basket{
+		StoreID:     storeID,
+		Subtotal:    10000,
+		DeliveryFee: 300,
+		Lines: []BasketLine{
+			{ProductID: shoes, Total: 8000},
+			{ProductID: socks, Total: 2000},
+		},
+	}
+
+	tests := []struct {
+		name string
+		p    Promotion
+		want int64
+		err  error
+	}{
+		{"percentage of basket", Promotion{Type: Percentage, Value: 10}, 1000, nil},
+		{"fixed amount", Promotion{Type: Fixed, Value: 1500}, 1500, nil},
+		{"fixed capped at eligible", Promotion{Type: Fixed, Value: 5000, StoreID: &storeID, ProductIDs: []uuid.UUID{socks}}, 2000, nil},
+		{"percentage of listed products", Promotion{Type: Percentage, Value: 50, StoreID: &storeID, ProductIDs: []uuid.UUID{shoes}}, 4000, nil},
+		{"free delivery", Promotion{Type: FreeDelivery}, 300, nil},
+		{"minimum not met", Promotion{Type: Fixed, Value: 100, MinSubtotal: 20000}, 0, ErrorMinimumNotMet},
+		{"other store", Promotion{Type: Fixed, Value: 100, StoreID: ptr(uuid.New())}, 0, ErrorNotApplicable},
+		{"no listed product in basket", Promotion{Type: Fixed, Value: 100, StoreID: &storeID, ProductIDs: []uuid.UUID{uuid.New()}}, 0, ErrorNotApplicable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.p.DiscountFor(basket)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCheckWindow(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	require.NoError(t, (&Promotion{Active: true, StartsAt: &past, EndsAt: &future}).CheckWindow(now))
+	require.ErrorIs(t, (&Promotion{Active: false}).CheckWindow(now), ErrorPromotionInactive)
+	require.ErrorIs(t, (&Promotion{Active: true, StartsAt: &future}).CheckWindow(now), ErrorPromotionNotStarted)
+	require.ErrorIs(t, (&Promotion{Active: true, EndsAt: &past}).CheckWindow(now), ErrorPromotionExpired)
+}
+
+func TestValidate(t *testing.T) {
+	storeID := uuid.New()
+
+	require.NoError(t, (&Promotion{Code: "KARIBU", Type: Percentage, Value: 15}).Validate())
+	require.ErrorIs(t, (&Promotion{Code: "KARIBU", Type: Percentage, Value: 150}).Validate(), ErrorInvalidPromotion)
+	require.ErrorIs(t, (&Promotion{Code: "", Type: Fixed, Value: 100}).Validate(), ErrorInvalidPromotion)
+	require.ErrorIs(t, (&Promotion{Code: "X", Type: Fixed, Value: 100, ProductIDs: []uuid.UUID{uuid.New()}}).Validate(), ErrorInvalidPromotion)
+	require.NoError(t, (&Promotion{Code: "X", Type: Fixed, Value: 100, StoreID: &storeID, ProductIDs: []uuid.UUID{uuid.New()}}).Validate())
+	require.ErrorIs(t, (&Promotion{Code: "X", Type: "bogo"}).Validate(), ErrorInvalidPromotion)
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -0,0 +1,37 @@
+package promotion
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	// Create inserts a promotion with its product scope
+	Create(ctx context.Context, p *Promotion) error
+
+	// GetByID fetches a promotion by ID
+	GetByID(ctx context.Context, id uuid.UUID) (*Promotion, error)
+
+	// GetByCodeForUpdate fetches a promotion by code and, inside a
+	// transaction, locks it until commit so redemptions are counted safely
+	GetByCodeForUpdate(ctx context.Context, code string) (*Promotion, error)
+
+	// ListByStore returns the promotions of a store, or the platform-wide
+	// ones for a nil store, newest first
+	ListByStore(ctx context.Context, storeID *uuid.UUID) ([]*Promotion, error)
+
+	// SetActive switches a promotion on or off
+	SetActive(ctx context.Context, id uuid.UUID, active bool) error
+
+	// CountCustomerRedemptions returns how often a customer used a promotion
+	CountCustomerRedemptions(ctx context.Context, promotionID, customerID uuid.UUID) (int, error)
+
+	// CreateRedemption records a use and bumps the redemption count of the
+	// promotion, failing with ErrorPromotionExhausted past its global limit
+	CreateRedemption(ctx context.Context, r *Redemption) error
+
+	// DeleteRedemption removes the use of a promotion by an order, if any,
+	// and gives it back to the promotion's redemption count
+	DeleteRedemption(ctx context.Context, orderID uuid.UUID) error
+}
@@ -0,0 +1,41 @@
+package promotion
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreatePromotionRequest struct {
+	Code       string      `json:"code" binding:"required"`
+	StoreID    *uuid.UUID  `json:"store_id,omitempty"`
+	ProductIDs []uuid.UUID `json:"product_ids,omitempty"`
+
+	Type  DiscountType `json:"type" binding:"required"` // percentage, fixed or free_delivery
+	Value int64        `json:"value"`                   // percent for percentage, cents for fixed
+
+	MinSubtotal    int64 `json:"min_subtotal"`
+	MaxRedemptions *int  `json:"max_redemptions,omitempty"`
+	MaxPerCustomer *int  `json:"max_per_customer,omitempty"`
+
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// ToPromotion maps the request onto an active promotion.
+func (r *CreatePromotionRequest) ToPromotion(createdBy uuid.UUID) *Promotion {
+	return &Promotion{
+		Code:           NormalizeCode(r.Code),
+		StoreID:        r.StoreID,
+		ProductIDs:     r.ProductIDs,
+		Type:           r.Type,
+		Value:          r.Value,
+		MinSubtotal:    r.MinSubtotal,
+		MaxRedemptions: r.MaxRedemptions,
+		MaxPerCustomer: r.MaxPerCustomer,
+		StartsAt:       r.StartsAt,
+		EndsAt:         r.EndsAt,
+		Active:         true,
+		CreatedBy:      createdBy,
+	}
+}
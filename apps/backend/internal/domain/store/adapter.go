@@ -0,0 +1,12 @@
+package store
+
+import (
+	"backend/internal/domain/geocoding"
+	"context"
+)
+
+// Geocoder resolves the free-text location of a store. It is implemented by
+// the geocoding providers.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (*geocoding.Place, error)
+}
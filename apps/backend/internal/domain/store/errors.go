@@ -9,4 +9,11 @@ var (
 	ErrStoreHasReferences     = errors.New("Store has dependent records.")
 	ErrInvalidStoreInput      = errors.New("Invalid store data.")
 	ErrStoreNameConflict      = errors.New("Store name already exists.")
+	ErrSlugTaken              = errors.New("Store slug already exists.")
+	ErrInvalidServiceRadius   = errors.New("Service radius is out of range.")
+	ErrInvalidSearchRadius    = errors.New("Search radius is out of range.")
+	ErrInvalidCoordinates     = errors.New("Invalid coordinates.")
+	ErrNotPermitted           = errors.New("User may not do this in the store.")
+	ErrMemberNotFound         = errors.New("Store member not found.")
+	ErrInvalidMemberRole      = errors.New("Invalid store member role.")
 )
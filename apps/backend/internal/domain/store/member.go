@@ -0,0 +1,60 @@
+package store
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemberRole is what a user may do in a store. Staff join a store with one
+// of the staff roles through a store invite; MemberOwner is the role of the
+// store's owner and is never stored.
+type MemberRole string
+
+const (
+	MemberOwner         MemberRole = "owner"
+	MemberManager       MemberRole = "manager"
+	MemberCatalogEditor MemberRole = "catalog_editor"
+	MemberFulfilment    MemberRole = "fulfilment"
+)
+
+// Permission is a kind of store action guarded by member roles.
+type Permission string
+
+const (
+	PermManageStore   Permission = "store:manage"   // details, service area, pricing and promotions
+	PermManageCatalog Permission = "catalog:manage" // products, variants, images and stock
+	PermFulfilOrders  Permission = "orders:fulfil"  // see the store's orders and move them along
+	PermManageStaff   Permission = "staff:manage"   // invite, change and remove staff
+	PermDeleteStore   Permission = "store:delete"
+)
+
+var rolePermissions = map[MemberRole][]Permission{
+	MemberOwner:         {PermManageStore, PermManageCatalog, PermFulfilOrders, PermManageStaff, PermDeleteStore},
+	MemberManager:       {PermManageStore, PermManageCatalog, PermFulfilOrders},
+	MemberCatalogEditor: {PermManageCatalog},
+	MemberFulfilment:    {PermFulfilOrders},
+}
+
+// Can reports whether the role grants p. The empty role, of users who are
+// neither owner nor staff, grants nothing.
+func (r MemberRole) Can(p Permission) bool {
+	return slices.Contains(rolePermissions[r], p)
+}
+
+// IsStaff reports whether r is a role staff can be given.
+func (r MemberRole) IsStaff() bool {
+	return r == MemberManager || r == MemberCatalogEditor || r == MemberFulfilment
+}
+
+// Member is a staff member of a store.
+type Member struct {
+	StoreID   uuid.UUID  `db:"store_id" json:"store_id"`
+	UserID    uuid.UUID  `db:"user_id" json:"user_id"`
+	Role      MemberRole `db:"role" json:"role"`
+	InviteID  *uuid.UUID `db:"invite_id" json:"invite_id,omitempty"`
+	FullName  string     `db:"full_name" json:"fullName"`
+	Email     string     `db:"email" json:"email"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+}
@@ -3,18 +3,40 @@ package store
 import (
 	"time"
 
+	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 )
 
+const (
+	// DefaultServiceRadiusM is how far a store delivers unless it says otherwise.
+	DefaultServiceRadiusM = 10_000
+	MaxServiceRadiusM     = 100_000
+
+	// Bounds of the "stores near me" search radius
+	DefaultNearbyRadiusM = 5_000
+	MaxNearbyRadiusM     = 50_000
+)
+
 type Store struct {
 	ID             uuid.UUID `db:"id" json:"id"`
 	OwnerID        uuid.UUID `db:"owner_id" json:"merchant_id"` // FK to users
 	Name           string    `db:"name" json:"name"`
 	NameNormalized string    `db:"name_normalized" json:"-"`
-	LogoURL        string    `db:"logo_url" json:"logo_url"`
-	Location       string    `db:"location" json:"location"` // optional
-	CreatedAt      time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at" json:"updated_at"`
+
+	// Slug is the unique name of the store in public URLs. It is set once,
+	// when the store is created, so links keep working after a rename.
+	Slug string `db:"slug" json:"slug"`
+
+	LogoURL  string `db:"logo_url" json:"logo_url"`
+	Location string `db:"location" json:"location"` // optional
+
+	// Point is the geocoded Location, nil for stores created before it was
+	// recorded. It is the default pickup point of orders.
+	Point          *postgis.PointS `db:"point" json:"point,omitempty"`
+	ServiceRadiusM int             `db:"service_radius_m" json:"service_radius_m"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
 // Store analytics (derived data)
@@ -28,6 +50,10 @@ type StoreSummary struct {
 	LogoURL  string    `db:"logo_url"`
 	Rating   float64   `db:"rating"`
 	Location string    `db:"location"`
+
+	// Set by nearby searches only
+	ServiceRadiusM *int     `db:"service_radius_m" json:",omitempty"`
+	DistanceM      *float64 `db:"distance_m" json:",omitempty"`
 }
 
 // GetBasicByID(ctx context.Context, storeID uuid.UUID) (*StoreBasic, error)
@@ -52,14 +78,24 @@ type StoreFilter struct {
 	Offset  int        `db:"offset" json:"offset"`
 }
 
+// NearbyFilter finds the stores within RadiusM metres of a customer that
+// also deliver that far, closest first.
+type NearbyFilter struct {
+	Lat     float64
+	Lng     float64
+	RadiusM int
+	Limit   int
+}
+
 // Existence / ownership checks (important for authorization)
 // Used for:
 // “Can this user edit this store?”
 // “Can this merchant create products under this store?”
 
 type MyStores struct {
-	ID   uuid.UUID `db:"id" json:"id"`
-	Name string    `db:"name" json:"name"`
+	ID   uuid.UUID  `db:"id" json:"id"`
+	Name string     `db:"name" json:"name"`
+	Role MemberRole `db:"role" json:"role"` // owner, or the staff role
 }
 
 // merchant_store_preferences {
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 
+	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 )
 
@@ -19,6 +20,14 @@ type Repository interface {
 	// This does not affect ownership, permissions, or derived analytics.
 	UpdateStoreDetails(ctx context.Context, storeID uuid.UUID, name, logo, location string) error
 
+	// UpdateServiceArea sets the geocoded point and service radius of a
+	// store. A nil point or a zero radius keeps the current value.
+	UpdateServiceArea(ctx context.Context, storeID uuid.UUID, point *postgis.PointS, radiusM int) error
+
+	// SetPoint replaces the geocoded point of a store after its location
+	// changed, nil when the new location could not be resolved.
+	SetPoint(ctx context.Context, storeID uuid.UUID, point *postgis.PointS) error
+
 	// Delete permanently removes a store and all associated child records,
 	// such as products, configurations, and dependent metadata.
 	Delete(ctx context.Context, storeID uuid.UUID) error
@@ -40,7 +49,8 @@ type Repository interface {
 	// multiple owners, depending on caller permissions.
 	ListStores(ctx context.Context) ([]*Store, error)
 
-	// ListStoresByOwner returns all stores owned by the specified owner.
+	// ListStoresByOwner returns all stores owned by the specified owner,
+	// followed by the stores they are staff of.
 	//
 	// This is typically used for dashboards and management views
 	// scoped to a single account.
@@ -53,6 +63,10 @@ type Repository interface {
 	// tables, search results, and administrative listings.
 	ListStoresPaged(ctx context.Context, filter StoreFilter) ([]*StoreSummary, error)
 
+	// ListNearby returns the stores around a location, closest first, with
+	// their distance in metres. Stores without a point are left out.
+	ListNearby(ctx context.Context, filter NearbyFilter) ([]*StoreSummary, error)
+
 	// GetStoreSummary retrieves an aggregated, read-optimized view
 	// of a store, including derived metrics such as product counts,
 	// order statistics, or revenue indicators.
@@ -60,6 +74,10 @@ type Repository interface {
 	// This method does not return the full store aggregate.
 	GetStoreSummary(ctx context.Context, storeID uuid.UUID) (*StoreSummary, error)
 
+	// ListSlugs returns the slugs that are base itself or base followed by
+	// a numeric suffix, such as "duka" and "duka-2" for base "duka".
+	ListSlugs(ctx context.Context, base string) ([]string, error)
+
 	// Exists checks whether a store with the given ID exists.
 	//
 	// This is a lightweight guard method and should not load
@@ -72,4 +90,21 @@ type Repository interface {
 	// This is primarily used for authorization and access control
 	// checks prior to executing write operations.
 	IsOwnedBy(ctx context.Context, storeID uuid.UUID, ownerID uuid.UUID) (bool, error)
+
+	// GetMemberRole returns what a user may do in a store: MemberOwner for
+	// its owner, the staff role of its members, or "" for anyone else and
+	// for unknown stores.
+	GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (MemberRole, error)
+
+	// AddMember adds a user to the staff of a store, recording the invite
+	// they joined through. Adding an existing member is a no-op.
+	AddMember(ctx context.Context, storeID, userID, inviteID uuid.UUID, role MemberRole) error
+
+	// ListMembers returns the staff of a store, earliest first.
+	ListMembers(ctx context.Context, storeID uuid.UUID) ([]*Member, error)
+
+	// UpdateMemberRole and RemoveMember return ErrMemberNotFound when the
+	// user is not on the store's staff.
+	UpdateMemberRole(ctx context.Context, storeID, userID uuid.UUID, role MemberRole) error
+	RemoveMember(ctx context.Context, storeID, userID uuid.UUID) error
 }
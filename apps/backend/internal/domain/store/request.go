@@ -7,19 +7,30 @@ type CreateStoreRequest struct {
 	Name     string    `json:"name" binding:"required"` // example:"Kevin's Electronics"
 	LogoURL  string    `json:"logo_url"`                // example:"https://cdn.fastabiz.com/logos/kevins.png"
 	Location string    `json:"location" binding:"required"`
+
+	// How far the store delivers, in metres; DefaultServiceRadiusM if unset
+	ServiceRadiusM int `json:"service_radius_m"`
 }
 
 type UpdateStoreRequest struct {
 	Name     string
 	Location string
 	Logo     string
+
+	ServiceRadiusM int `json:"service_radius_m"` // unchanged if unset
+}
+
+// UpdateMemberRoleRequest gives a staff member another staff role.
+type UpdateMemberRoleRequest struct {
+	Role MemberRole `json:"role" binding:"required"`
 }
 
 func (r *CreateStoreRequest) ToStore() *Store {
 	return &Store{
-		OwnerID:  r.OwnerID,
-		Name:     r.Name,
-		LogoURL:  r.LogoURL,
-		Location: r.Location,
+		OwnerID:        r.OwnerID,
+		Name:           r.Name,
+		LogoURL:        r.LogoURL,
+		Location:       r.Location,
+		ServiceRadiusM: r.ServiceRadiusM,
 	}
 }
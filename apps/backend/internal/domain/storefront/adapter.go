@@ -0,0 +1,14 @@
+package storefront
+
+import (
+	"backend/internal/domain/product"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ProductReader loads a product with its images, options and variants. It
+// is implemented by the product repository.
+type ProductReader interface {
+	GetFullProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error)
+}
@@ -0,0 +1,8 @@
+package storefront
+
+import "errors"
+
+var (
+	ErrorStoreNotFound   = errors.New("store not found")
+	ErrorProductNotFound = errors.New("product not found")
+)
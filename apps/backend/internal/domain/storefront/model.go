@@ -0,0 +1,147 @@
+package storefront
+
+import (
+	"backend/internal/domain/product"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// Paging bounds of the public product list
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// The types below are read-only views served to anonymous shoppers. They
+// are built field by field so nothing about the owner, stock levels or
+// internal bookkeeping leaks out.
+
+// Store is the public page of a store.
+type Store struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	Slug           string    `db:"slug" json:"slug"`
+	Name           string    `db:"name" json:"name"`
+	LogoURL        string    `db:"logo_url" json:"logo_url"`
+	Location       string    `db:"location" json:"location"`
+	ServiceRadiusM int       `db:"service_radius_m" json:"service_radius_m"`
+}
+
+// Product is a line of the public product list. For products with variants
+// the prices span the variants.
+type Product struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	Category    string    `db:"category" json:"category"`
+	ImageURL    string    `db:"image_url" json:"image_url,omitempty"`
+	HasVariants bool      `db:"has_variants" json:"has_variants"`
+	MinPrice    float64   `db:"min_price" json:"min_price"`
+	MaxPrice    float64   `db:"max_price" json:"max_price"`
+	InStock     bool      `db:"in_stock" json:"in_stock"`
+}
+
+// ProductPage is one page of the products of a store, by name.
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+// ProductDetail is a product with everything needed to pick a variant.
+type ProductDetail struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Images      []string  `json:"images"`
+	HasVariants bool      `json:"has_variants"`
+	Price       float64   `json:"price,omitempty"` // products without variants
+	InStock     bool      `json:"in_stock"`
+	Options     []Option  `json:"options,omitempty"`
+	Variants    []Variant `json:"variants,omitempty"`
+}
+
+// Option is a dimension a product varies on, e.g. Size: S, M, L.
+type Option struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type Variant struct {
+	ID       uuid.UUID         `json:"id"`
+	Price    float64           `json:"price"`
+	ImageURL string            `json:"image_url,omitempty"`
+	Options  map[string]string `json:"options"` // Size → Small
+	InStock  bool              `json:"in_stock"`
+}
+
+// RatingSummary aggregates the feedback customers left on orders of a store.
+type RatingSummary struct {
+	Average float64     `json:"average"` // 0 without ratings
+	Count   int         `json:"count"`
+	Stars   map[int]int `json:"stars"` // number of ratings per star, 1 to 5
+}
+
+// NewProductDetail builds the public view of a fully loaded product.
+func NewProductDetail(p *product.Product) *ProductDetail {
+	d := &ProductDetail{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Category:    p.Category,
+		Images:      p.Images,
+		HasVariants: p.HasVariants,
+		Options:     []Option{},
+	}
+	if d.Images == nil {
+		d.Images = []string{}
+	}
+
+	for _, o := range p.Options {
+		values := make([]string, len(o.Values))
+		for i, v := range o.Values {
+			values[i] = v.Value
+		}
+		d.Options = append(d.Options, Option{Name: o.Name, Values: values})
+	}
+
+	if !p.HasVariants {
+		d.Price = p.Price
+		d.InStock = p.Stock == nil || *p.Stock > 0
+		return d
+	}
+
+	d.Variants = make([]Variant, len(p.Variants))
+	for i, v := range p.Variants {
+		d.Variants[i] = Variant{
+			ID:       v.ID,
+			Price:    v.Price,
+			ImageURL: v.ImageURL,
+			Options:  v.Options,
+			InStock:  v.Stock > 0,
+		}
+		d.InStock = d.InStock || v.Stock > 0
+	}
+	sort.SliceStable(d.Variants, func(i, j int) bool { return d.Variants[i].Price < d.Variants[j].Price })
+	return d
+}
+
+// SummarizeRatings builds the summary from the number of ratings per star.
+// The average is rounded to two decimals.
+func SummarizeRatings(stars map[int]int) *RatingSummary {
+	summary := &RatingSummary{Stars: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+
+	sum := 0
+	for star, count := range stars {
+		summary.Stars[star] = count
+		summary.Count += count
+		sum += star * count
+	}
+	if summary.Count > 0 {
+		summary.Average = math.Round(float64(sum)/float64(summary.Count)*100) / 100
+	}
+	return summary
+}
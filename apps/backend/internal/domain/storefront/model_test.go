@@ -0,0 +1,62 @@
+package storefront
+
+import (
+	"backend/internal/domain/product"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeRatings(t *testing.T) {
+	empty := SummarizeRatings(nil)
+	require.Equal(t, 0, empty.Count)
+	require.Equal(t, 0.0, empty.Average)
+	require.Equal(t, map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}, empty.Stars)
+
+	s := SummarizeRatings(map[int]int{5: 2, 4: 1})
+	require.Equal(t, 3, s.Count)
+	require.Equal(t, 4.67, s.Average)
+	require.Equal(t, 2, s.Stars[5])
+	require.Equal(t, 0, s.Stars[1])
+}
+
+func TestNewProductDetail_Simple(t *testing.T) {
+	stock := 0
+	p := &product.Product{ID: uuid.New(), StoreID: uuid.New(), Name: "Sukuma wiki", Price: 30, Stock: &stock}
+
+	d := NewProductDetail(p)
+
+	require.Equal(t, 30.0, d.Price)
+	require.False(t, d.InStock)
+	require.Empty(t, d.Variants)
+	require.NotNil(t, d.Images)
+
+	p.Stock = nil
+	require.True(t, NewProductDetail(p).InStock, "untracked stock is available")
+}
+
+func TestNewProductDetail_Variants(t *testing.T) {
+	p := &product.Product{
+		ID:          uuid.New(),
+		Name:        "Kitenge",
+		HasVariants: true,
+		Options: []product.Option{
+			{Name: "Size", Values: []product.OptionValue{{Value: "M"}, {Value: "L"}}},
+		},
+		Variants: []product.Variant{
+			{ID: uuid.New(), SKU: "KIT-L", Price: 1500, Stock: 0, Options: map[string]string{"Size": "L"}},
+			{ID: uuid.New(), SKU: "KIT-M", Price: 1200, Stock: 4, Options: map[string]string{"Size": "M"}},
+		},
+	}
+
+	d := NewProductDetail(p)
+
+	require.True(t, d.InStock)
+	require.Equal(t, []Option{{Name: "Size", Values: []string{"M", "L"}}}, d.Options)
+	require.Len(t, d.Variants, 2)
+	require.Equal(t, 1200.0, d.Variants[0].Price, "cheapest first")
+	require.True(t, d.Variants[0].InStock)
+	require.False(t, d.Variants[1].InStock)
+	require.Equal(t, "L", d.Variants[1].Options["Size"])
+}
@@ -0,0 +1,19 @@
+package storefront
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Repository reads the public projections of stores and their products.
+type Repository interface {
+	// GetStoreBySlug returns ErrorStoreNotFound for unknown slugs.
+	GetStoreBySlug(ctx context.Context, slug string) (*Store, error)
+
+	// ListProducts returns a page of the products of a store, by name, and
+	// the number of products of the store.
+	ListProducts(ctx context.Context, storeID uuid.UUID, limit, offset int) ([]Product, int, error)
+
+	GetRatingSummary(ctx context.Context, storeID uuid.UUID) (*RatingSummary, error)
+}
@@ -12,9 +12,10 @@ var (
 	ErrRoleCheck              = errors.New("Invalid user role.")
 	ErrInvalidUserId          = errors.New("Invalid user id.")
 	ErrInvalidCurrentPassword = errors.New("Current Password is incorrect.")
+	ErrPasswordUnchanged      = errors.New("New password must differ from the current one.")
 	ErrInvalidStatusInput     = errors.New("Invalid user status input.")
 	ErrInvalidDataInput       = errors.New("Invalid data input.")
-	ErrInvalidColumn          = errors.New("Invalid column update.")
+	ErrNothingToUpdate        = errors.New("No user fields to update.")
 	ErrUserHasReferences      = errors.New("User has dependent records.")
 	ErrUserNotFound           = errors.New("User not found.")
 )
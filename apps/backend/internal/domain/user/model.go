@@ -34,6 +34,8 @@ type User struct {
 	Phone                string     `db:"phone" json:"phone"`
 	Slug                 *string    `db:"slug" json:"slug,omitempty"` // adminSlug used in public route
 	Must_change_password bool       `db:"must_change_password" json:"must_change_password"`
+	EmailVerifiedAt      *time.Time `db:"email_verified_at" json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt      *time.Time `db:"phone_verified_at" json:"phone_verified_at,omitempty"`
 	Status               UserStatus `db:"status" json:"status"`
 	LastLogin            *time.Time `db:"last_login" json:"last_login,omitempty"`
 	CreatedAt            time.Time  `db:"created_at" json:"created_at"`
@@ -0,0 +1,33 @@
+package user
+
+import (
+	"strings"
+)
+
+// NormalizePhone turns the ways Kenyan mobile numbers are written, like
+// "0712 345 678", "712345678" or "+254-712-345-678", into "+254712345678".
+func NormalizePhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+			return -1
+		default:
+			return 'x'
+		}
+	}, strings.TrimPrefix(strings.TrimSpace(phone), "+"))
+
+	switch {
+	case len(digits) == 12 && strings.HasPrefix(digits, "254"):
+		digits = digits[3:]
+	case len(digits) == 10 && strings.HasPrefix(digits, "0"):
+		digits = digits[1:]
+	}
+
+	// Safaricom, Airtel and Telkom numbers start with 7 or 1
+	if len(digits) != 9 || (digits[0] != '7' && digits[0] != '1') || strings.Contains(digits, "x") {
+		return "", ErrInvalidPhone
+	}
+	return "+254" + digits, nil
+}
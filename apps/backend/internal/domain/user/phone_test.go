@@ -0,0 +1,31 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	for _, in := range []string{
+		"+254712345678",
+		"+254 712 345 678",
+		"254712345678",
+		"0712345678",
+		"0712-345-678",
+		"712345678",
+	} {
+		got, err := NormalizePhone(in)
+		require.NoError(t, err, in)
+		require.Equal(t, "+254712345678", got, in)
+	}
+
+	got, err := NormalizePhone("0110 123 456")
+	require.NoError(t, err)
+	require.Equal(t, "+254110123456", got)
+
+	for _, in := range []string{"", "0712", "+255712345678", "0212345678", "07123456789", "07l2345678"} {
+		_, err := NormalizePhone(in)
+		require.ErrorIs(t, err, ErrInvalidPhone, in)
+	}
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,11 +12,15 @@ type Repository interface {
 	Create(ctx context.Context, user *User) error                                         // POST
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)                             // GET
 	GetByEmail(ctx context.Context, email string) (*User, error)                          // GET
+	GetCustomerByVerifiedPhone(ctx context.Context, phone string) (*User, error)          // GET by +254 number verified by sign-in
 	List(ctx context.Context) ([]*User, error)                                            // GET
 	GetAllCustomers(ctx context.Context) ([]AllCustomers, error)                          // GET
-	UpdateColumn(ctx context.Context, userID uuid.UUID, column string, value any) error    // PATCH update one specified column at a time
+	Update(ctx context.Context, userID uuid.UUID, req *UpdateUserRequest) error           // PATCH the fields set in req
+	UpdateLastLogin(ctx context.Context, userID uuid.UUID, at time.Time) error            // stamps a sign-in
 	UpdateUserStatus(ctx context.Context, userID uuid.UUID, status UserStatus) error      // PATCH method to update user status
 	UpdateDriverProfile(ctx context.Context, id uuid.UUID, phone string) error            // PUT update user(driver) phone number
 	UpdateUserProfile(ctx context.Context, id uuid.UUID, phone, email, name string) error // PATCH method to update user profile - name, email & phone number
 	Delete(ctx context.Context, id uuid.UUID) error                                       // DELETE
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error          // sets the hash and clears must_change_password
+	MarkEmailVerified(ctx context.Context, id uuid.UUID) error                             // stamps email_verified_at once
 }
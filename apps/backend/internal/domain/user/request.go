@@ -2,6 +2,8 @@ package user
 
 import (
 	generate "backend/internal/utils"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -19,9 +21,50 @@ type UpdateDriverUserProfileRequest struct {
 	Phone string `json:"phone" binding:"required"`
 }
 
+// UpdateUserRequest changes the fields that are set. Role and status are
+// changed by admins through their own endpoints, the password through
+// ChangePassword.
 type UpdateUserRequest struct {
-	Column string      `json:"column" binding:"required"`
-	Value  interface{} `json:"value" binding:"required"`
+	FullName *string `json:"fullName,omitempty"`
+	Email    *string `json:"email,omitempty"`
+	Phone    *string `json:"phone,omitempty"`
+}
+
+// Validate checks that something is set and that the set fields are valid,
+// normalising the phone number.
+func (r *UpdateUserRequest) Validate() error {
+	if r.FullName == nil && r.Email == nil && r.Phone == nil {
+		return ErrNothingToUpdate
+	}
+	if r.FullName != nil && len(strings.TrimSpace(*r.FullName)) < 2 {
+		return ErrInvalidName
+	}
+	if r.Email != nil && !strings.Contains(*r.Email, "@") {
+		return ErrInvalidEmail
+	}
+	if r.Phone != nil {
+		phone, err := NormalizePhone(*r.Phone)
+		if err != nil {
+			return err
+		}
+		r.Phone = &phone
+	}
+	return nil
+}
+
+// Fields names the fields that are set, for telling the user what changed.
+func (r *UpdateUserRequest) Fields() []string {
+	var fields []string
+	if r.FullName != nil {
+		fields = append(fields, "name")
+	}
+	if r.Email != nil {
+		fields = append(fields, "email")
+	}
+	if r.Phone != nil {
+		fields = append(fields, "phone")
+	}
+	return fields
 }
 
 type ChangePasswordRequest struct {
@@ -66,4 +109,14 @@ type LoginResponse struct {
 	Email    string `json:"email"`
 	Role     string `json:"role"`
 	Token    string `json:"token,omitempty"`
+
+	TokenExpiresAt        time.Time `json:"token_expires_at"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
+
+	// MustChangePassword users can only change their password until they do
+	MustChangePassword bool `json:"must_change_password"`
+	// MustEnrolTwoFactor users can only set up two-factor authentication
+	// until they do
+	MustEnrolTwoFactor bool `json:"must_enrol_two_factor"`
 }
@@ -0,0 +1,21 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserRequest_Validate(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	require.ErrorIs(t, (&UpdateUserRequest{}).Validate(), ErrNothingToUpdate)
+	require.ErrorIs(t, (&UpdateUserRequest{FullName: str(" a ")}).Validate(), ErrInvalidName)
+	require.ErrorIs(t, (&UpdateUserRequest{Email: str("jane.example.com")}).Validate(), ErrInvalidEmail)
+	require.ErrorIs(t, (&UpdateUserRequest{Phone: str("0212345678")}).Validate(), ErrInvalidPhone)
+
+	req := &UpdateUserRequest{FullName: str("Jane Wanjiru"), Phone: str("0712 345 678")}
+	require.NoError(t, req.Validate())
+	require.Equal(t, "+254712345678", *req.Phone)
+	require.Equal(t, []string{"name", "phone"}, req.Fields())
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"backend/internal/domain/apikey"
+	"backend/internal/domain/auth"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// APIKeyHeader carries the API key of server-to-server requests.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuthenticator resolves an API key to what requests made with it may
+// do.
+type APIKeyAuthenticator interface {
+	AuthenticateKey(ctx context.Context, raw string) (*apikey.Grant, error)
+}
+
+// AuthMiddleware accepts an API key in the X-API-Key header, or else an
+// access token as JWTAuthMiddleware does. Both put the same caller into the
+// context, so handlers can't tell them apart; key callers also carry the
+// key's grant, which Authorize holds them to.
+func AuthMiddleware(sessions SessionValidator, keys APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withJWT := JWTAuthMiddleware(sessions)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := r.Header.Get(APIKeyHeader)
+			if raw == "" {
+				withJWT.ServeHTTP(w, r)
+				return
+			}
+
+			g, err := keys.AuthenticateKey(r.Context(), raw)
+			if err != nil {
+				switch {
+				case errors.Is(err, apikey.ErrorInvalidKey):
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+				case errors.Is(err, auth.ErrorAccountDisabled):
+					http.Error(w, err.Error(), http.StatusForbidden)
+				default:
+					log.Printf("authenticate api key: %v", err)
+					http.Error(w, "Could not validate api key", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), ContextUserID, g.UserID.String())
+			ctx = context.WithValue(ctx, ContextRole, string(g.Role))
+			ctx = context.WithValue(ctx, ContextAPIKey, g)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// APIKeyFromContext returns the grant of the API key the request was
+// authenticated with, or nil for requests with an access token.
+func APIKeyFromContext(ctx context.Context) *apikey.Grant {
+	g, _ := ctx.Value(ContextAPIKey).(*apikey.Grant)
+	return g
+}
@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"backend/internal/domain/authz"
+	"backend/internal/domain/user"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RouteAuthorizer decides whether a caller may call a route.
+type RouteAuthorizer interface {
+	Authorize(ctx context.Context, route string, sub authz.Subject, params authz.Params) error
+}
+
+// Authorize enforces the route policy of the caller put in the context by
+// AuthMiddleware. Group middleware runs before chi has matched the final
+// route, so the request is matched against routes, the root router, to learn
+// its pattern and URL parameters.
+func Authorize(routes chi.Routes, authorizer RouteAuthorizer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, role, err := GetCallerFromContext(r.Context())
+			if err != nil {
+				writeMiddlewareError(w, http.StatusUnauthorized, "Missing caller in token")
+				return
+			}
+
+			rctx := chi.NewRouteContext()
+			if !routes.Match(rctx, r.Method, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			sub := authz.Subject{
+				ID:                 id,
+				Role:               user.Role(role),
+				MustChangePassword: MustChangePasswordFromContext(r.Context()),
+				MustEnrolTwoFactor: MustEnrolTwoFactorFromContext(r.Context()),
+				Key:                APIKeyFromContext(r.Context()),
+			}
+			params := &requestParams{r: r, route: rctx}
+			err = authorizer.Authorize(r.Context(), authz.Route(r.Method, rctx.RoutePattern()), sub, params)
+			switch {
+			case err == nil:
+				next.ServeHTTP(w, r)
+			case errors.Is(err, authz.ErrorInvalidResourceID),
+				errors.Is(err, authz.ErrorAmbiguousResourceID):
+				writeMiddlewareError(w, http.StatusBadRequest, err.Error())
+			case errors.Is(err, authz.ErrorNotFound):
+				writeMiddlewareError(w, http.StatusNotFound, err.Error())
+			case errors.Is(err, authz.ErrorNoPolicy),
+				errors.Is(err, authz.ErrorRoleNotAllowed),
+				errors.Is(err, authz.ErrorForbidden),
+				errors.Is(err, authz.ErrorPasswordChangeRequired),
+				errors.Is(err, authz.ErrorTwoFactorEnrolmentRequired),
+				errors.Is(err, authz.ErrorScopeNotGranted):
+				writeMiddlewareError(w, http.StatusForbidden, err.Error())
+			default:
+				log.Printf("authorize %s %s: %v", r.Method, r.URL.Path, err)
+				writeMiddlewareError(w, http.StatusInternalServerError, "Could not authorize request")
+			}
+		})
+	}
+}
+
+// requestParams looks identifiers up in the URL parameters and query
+// string, or in the top-level fields of a JSON body. The body is only read
+// when a check asks for it, and is put back for the handler.
+type requestParams struct {
+	r     *http.Request
+	route *chi.Context
+	body  []byte
+	read  bool
+}
+
+// Param returns the URL parameter or query value name. A name given in both,
+// or repeated in the query, must have the same value.
+func (p *requestParams) Param(name string) (string, error) {
+	return agree(append([]string{p.route.URLParam(name)}, p.r.URL.Query()[name]...))
+}
+
+// Body returns the body field name. It never falls back to the URL
+// parameters or query string, which may not name it differently.
+func (p *requestParams) Body(name string) (string, error) {
+	if !p.read {
+		p.read = true
+		if body, err := io.ReadAll(p.r.Body); err == nil {
+			p.r.Body = io.NopCloser(bytes.NewReader(body))
+			p.body = body
+		}
+	}
+
+	v, err := bodyField(p.body, name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := agree(append([]string{v, p.route.URLParam(name)}, p.r.URL.Query()[name]...)); err != nil {
+		return "", err
+	}
+	return v, nil
+}
+
+// bodyField decodes the field name of a JSON body into a struct tagged like
+// the handler's request struct, so the key is matched the same way: exactly
+// or regardless of case, the last match winning. Keys matching name must
+// all have the same value.
+func bodyField(body []byte, name string) (string, error) {
+	field := reflect.StructField{Name: "V", Type: reflect.TypeFor[string](), Tag: reflect.StructTag(`json:"` + name + `"`)}
+	v := reflect.New(reflect.StructOf([]reflect.StructField{field}))
+	if err := json.Unmarshal(body, v.Interface()); err != nil {
+		return "", nil
+	}
+
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(body, &fields)
+	values := []string{}
+	for key, raw := range fields {
+		var s string
+		if strings.EqualFold(key, name) && json.Unmarshal(raw, &s) == nil {
+			values = append(values, s)
+		}
+	}
+	if _, err := agree(values); err != nil {
+		return "", err
+	}
+	return v.Elem().Field(0).String(), nil
+}
+
+// agree returns the value of an identifier given in several places, refusing
+// different ones. Empty values count as not given.
+func agree(values []string) (string, error) {
+	var v string
+	for _, s := range values {
+		if s == "" {
+			continue
+		}
+		if v != "" && s != v {
+			return "", authz.ErrorAmbiguousResourceID
+		}
+		v = s
+	}
+	return v, nil
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"backend/internal/domain/authz"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type authorizerFunc func(ctx context.Context, route string, sub authz.Subject, params authz.Params) error
+
+func (f authorizerFunc) Authorize(ctx context.Context, route string, sub authz.Subject, params authz.Params) error {
+	return f(ctx, route, sub, params)
+}
+
+func serveAuthorized(authorizer RouteAuthorizer, target, body string) (*httptest.ResponseRecorder, string) {
+	var seen string
+	r := chi.NewRouter()
+	r.Group(func(g chi.Router) {
+		g.Use(Authorize(r, authorizer))
+		g.Post("/stores/{id}/products", func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			seen = string(b)
+			w.WriteHeader(http.StatusNoContent)
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	ctx := context.WithValue(req.Context(), ContextUserID, uuid.NewString())
+	ctx = context.WithValue(ctx, ContextRole, "merchant")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req.WithContext(ctx))
+	return rec, seen
+}
+
+func TestAuthorize_ResolvesRouteAndParams(t *testing.T) {
+	body := `{"product_id":"p1","stock":3}`
+	rec, seen := serveAuthorized(authorizerFunc(func(ctx context.Context, route string, sub authz.Subject, params authz.Params) error {
+		require.Equal(t, "POST /stores/{id}/products", route)
+		require.Equal(t, "merchant", string(sub.Role))
+		require.Equal(t, "s1", param(t, params.Param, "id"))
+		require.Equal(t, "new", param(t, params.Param, "tag"))
+		require.Empty(t, param(t, params.Param, "product_id"), "body fields aren't params")
+		require.Equal(t, "p1", param(t, params.Body, "product_id"))
+		require.Empty(t, param(t, params.Body, "tag"), "query values aren't body fields")
+		require.Empty(t, param(t, params.Body, "stock"))
+		return nil
+	}), "/stores/s1/products?tag=new", body)
+
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, body, seen, "handler must still get the body")
+}
+
+func param(t *testing.T, lookup func(string) (string, error), name string) string {
+	v, err := lookup(name)
+	require.NoError(t, err)
+	return v
+}
+
+func TestAuthorize_RefusesAmbiguousIDs(t *testing.T) {
+	tests := []struct {
+		name, path, body string
+		lookup           func(authz.Params) (string, error)
+	}{
+		{"query against path", "/stores/s1/products?id=s2", "", func(p authz.Params) (string, error) { return p.Param("id") }},
+		{"repeated query", "/stores/s1/products?tag=a&tag=b", "", func(p authz.Params) (string, error) { return p.Param("tag") }},
+		{"query against body", "/stores/s1/products?store_id=mine", `{"store_id":"theirs"}`, func(p authz.Params) (string, error) { return p.Body("store_id") }},
+		{"body keys differing in case", "/stores/s1/products", `{"product_id":"p1","PRODUCT_ID":"p2"}`, func(p authz.Params) (string, error) { return p.Body("product_id") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := serveAuthorized(authorizerFunc(func(ctx context.Context, route string, sub authz.Subject, params authz.Params) error {
+				_, err := tt.lookup(params)
+				return err
+			}), tt.path, tt.body)
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
+
+func TestAuthorize_MapsErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{authz.ErrorNoPolicy, http.StatusForbidden},
+		{authz.ErrorRoleNotAllowed, http.StatusForbidden},
+		{authz.ErrorForbidden, http.StatusForbidden},
+		{authz.ErrorInvalidResourceID, http.StatusBadRequest},
+		{authz.ErrorNotFound, http.StatusNotFound},
+		{errors.New("db down"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			rec, _ := serveAuthorized(authorizerFunc(func(context.Context, string, authz.Subject, authz.Params) error {
+				return tt.err
+			}), "/stores/s1/products", "")
+			require.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"backend/internal/domain/idempotency"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// Idempotency replays the stored response for retries that carry the same
+// Idempotency-Key header as an earlier request of the same caller. A retry
+// whose body differs from the original gets 422. Requests without the header
+// pass through untouched.
+//
+// Server errors are not stored, so the client can retry them with the same key.
+func Idempotency(store idempotency.Repository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotency.Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeMiddlewareError(w, http.StatusBadRequest, idempotency.ErrorKeyTooLong.Error())
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeMiddlewareError(w, http.StatusBadRequest, "Could not read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			owner, _ := r.Context().Value(ContextUserID).(string)
+			rec := &idempotency.Record{
+				Key:         key,
+				Owner:       owner,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: requestHash(r.Method, r.URL.Path, body),
+			}
+
+			existing, reserved, err := store.Reserve(r.Context(), rec)
+			if err != nil {
+				log.Printf("idempotency reserve failed: %v", err)
+				writeMiddlewareError(w, http.StatusInternalServerError, "Could not check idempotency key")
+				return
+			}
+
+			if !reserved {
+				replay(w, rec, existing)
+				return
+			}
+
+			// Store even if the client went away, that's when it will retry.
+			ctx := context.WithoutCancel(r.Context())
+			release := func() {
+				if err := store.Release(ctx, owner, key); err != nil {
+					log.Printf("idempotency release failed: %v", err)
+				}
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			func() {
+				// A panicking handler mustn't leave the key in flight until it
+				// expires, the retry has to be able to run
+				defer func() {
+					if p := recover(); p != nil {
+						release()
+						panic(p)
+					}
+				}()
+				next.ServeHTTP(rw, r)
+			}()
+
+			if rw.status >= http.StatusInternalServerError {
+				release()
+				return
+			}
+
+			if err := store.Complete(ctx, owner, key, rw.status, rw.Header().Get("Content-Type"), rw.body.Bytes()); err != nil {
+				log.Printf("idempotency complete failed: %v", err)
+			}
+		})
+	}
+}
+
+// replay answers a retry from the record of the original request.
+func replay(w http.ResponseWriter, rec, existing *idempotency.Record) {
+	switch {
+	case existing.RequestHash != rec.RequestHash:
+		writeMiddlewareError(w, http.StatusUnprocessableEntity, idempotency.ErrorKeyReused.Error())
+	case !existing.Completed():
+		writeMiddlewareError(w, http.StatusConflict, idempotency.ErrorKeyInFlight.Error())
+	default:
+		if existing.ContentType != nil && *existing.ContentType != "" {
+			w.Header().Set("Content-Type", *existing.ContentType)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(*existing.StatusCode)
+		_, _ = w.Write(existing.Body)
+	}
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter passes the response through while keeping a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func writeMiddlewareError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
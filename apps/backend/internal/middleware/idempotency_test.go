@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"backend/internal/domain/idempotency"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{records: map[string]*idempotency.Record{}}
+}
+
+func (f *fakeIdempotencyStore) Reserve(ctx context.Context, rec *idempotency.Record) (*idempotency.Record, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := rec.Owner + "/" + rec.Key
+	if existing, ok := f.records[id]; ok {
+		cp := *existing
+		return &cp, false, nil
+	}
+	cp := *rec
+	f.records[id] = &cp
+	return nil, true, nil
+}
+
+func (f *fakeIdempotencyStore) Complete(ctx context.Context, owner, key string, status int, contentType string, body []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rec := f.records[owner+"/"+key]
+	rec.StatusCode = &status
+	rec.ContentType = &contentType
+	rec.Body = append([]byte(nil), body...)
+	return nil
+}
+
+func (f *fakeIdempotencyStore) Release(ctx context.Context, owner, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.records, owner+"/"+key)
+	return nil
+}
+
+// countingHandler answers 201 with the call number, or status when set.
+type countingHandler struct {
+	calls  int
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+	_, _ = io.ReadAll(r.Body)
+
+	status := h.status
+	if status == 0 {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(h.calls) + `}`))
+}
+
+func send(h http.Handler, userID, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/orders/create", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), ContextUserID, userID))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyStore())(next)
+
+	first := send(h, "u1", "k1", `{"a":1}`)
+	retry := send(h, "u1", "k1", `{"a":1}`)
+
+	require.Equal(t, 1, next.calls)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Equal(t, first.Body.String(), retry.Body.String())
+	require.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	require.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_DifferentBodyIsRejected(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyStore())(next)
+
+	send(h, "u1", "k1", `{"a":1}`)
+	retry := send(h, "u1", "k1", `{"a":2}`)
+
+	require.Equal(t, 1, next.calls)
+	require.Equal(t, http.StatusUnprocessableEntity, retry.Code)
+}
+
+func TestIdempotency_KeysAreScopedPerCaller(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyStore())(next)
+
+	send(h, "u1", "k1", `{"a":1}`)
+	other := send(h, "u2", "k1", `{"a":1}`)
+
+	require.Equal(t, 2, next.calls)
+	require.Empty(t, other.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_ServerErrorsCanBeRetried(t *testing.T) {
+	next := &countingHandler{status: http.StatusInternalServerError}
+	h := Idempotency(newFakeIdempotencyStore())(next)
+
+	send(h, "u1", "k1", `{"a":1}`)
+	next.status = 0
+	retry := send(h, "u1", "k1", `{"a":1}`)
+
+	require.Equal(t, 2, next.calls)
+	require.Equal(t, http.StatusCreated, retry.Code)
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	next := &countingHandler{}
+	panicking := true
+	h := Idempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if panicking {
+			panic("boom")
+		}
+		next.ServeHTTP(w, r)
+	}))
+
+	require.PanicsWithValue(t, "boom", func() { send(h, "u1", "k1", `{"a":1}`) }, "the panic still reaches the recoverer")
+
+	panicking = false
+	retry := send(h, "u1", "k1", `{"a":1}`)
+	require.Equal(t, http.StatusCreated, retry.Code)
+	require.Equal(t, 1, next.calls)
+}
+
+func TestIdempotency_WithoutKeyPassesThrough(t *testing.T) {
+	next := &countingHandler{}
+	h := Idempotency(newFakeIdempotencyStore())(next)
+
+	send(h, "u1", "", `{"a":1}`)
+	send(h, "u1", "", `{"a":1}`)
+
+	require.Equal(t, 2, next.calls)
+}
+
+func TestIdempotency_InFlightRetryConflicts(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	var h http.Handler
+
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A retry arriving while the first request is still being handled.
+		retry := send(h, "u1", "k1", `{"a":1}`)
+		require.Equal(t, http.StatusConflict, retry.Code)
+		w.WriteHeader(http.StatusCreated)
+	})
+	h = Idempotency(store)(inner)
+
+	first := send(h, "u1", "k1", `{"a":1}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+}
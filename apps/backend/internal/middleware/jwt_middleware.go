@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"backend/internal/domain/auth"
 	"context"
 	"errors"
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -14,58 +16,99 @@ import (
 type contextKey string
 
 const (
-	ContextUserID contextKey = "userID"
-	ContextRole   contextKey = "role"
+	ContextUserID    contextKey = "userID"
+	ContextRole      contextKey = "role"
+	ContextSessionID contextKey = "sessionID"
+
+	ContextMustChangePassword contextKey = "mustChangePassword"
+	ContextMustEnrolTwoFactor contextKey = "mustEnrolTwoFactor"
+	ContextAPIKey             contextKey = "apiKey"
 )
 
-func JWTAuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer") {
-			http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			http.Error(w, "Server misconfigured (no JWT_SECRET)", http.StatusInternalServerError)
-			return
-		}
-
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			// Check the signing method is HMAC (HS256)
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+// SessionValidator tells whether the session an access token was issued for
+// is still open.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) (*auth.SessionState, error)
+}
+
+// JWTAuthMiddleware accepts signed, unexpired access tokens whose session is
+// still open and whose user is neither suspended nor inactive.
+func JWTAuthMiddleware(sessions SessionValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer") {
+				http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+			jwtSecret := os.Getenv("JWT_SECRET")
+			if jwtSecret == "" {
+				http.Error(w, "Server misconfigured (no JWT_SECRET)", http.StatusInternalServerError)
+				return
+			}
+
+			token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+				// Check the signing method is HMAC (HS256)
+				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+					return nil, jwt.ErrSignatureInvalid
+				}
+				return []byte(jwtSecret), nil
+			})
+
+			if err != nil || !token.Valid {
+				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				return
 			}
-			return []byte(jwtSecret), nil
-		})
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
-		}
-
-		// Extract user ID and role from claims
-		userID, ok1 := claims["sub"].(string)
-		role, ok2 := claims["role"].(string)
-		if !ok1 || !ok2 {
-			http.Error(w, "Missing token claims", http.StatusUnauthorized)
-			return
-		}
-
-		// Add to context
-		ctx := context.WithValue(r.Context(), ContextUserID, userID)
-		ctx = context.WithValue(ctx, ContextRole, role)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			claims, ok := token.Claims.(jwt.MapClaims)
+			if !ok {
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+
+			// Extract user ID and role from claims
+			userID, ok1 := claims["sub"].(string)
+			role, ok2 := claims["role"].(string)
+			sessionID, ok3 := claims["sid"].(string)
+			if !ok1 || !ok2 || !ok3 {
+				http.Error(w, "Missing token claims", http.StatusUnauthorized)
+				return
+			}
+
+			uid, err1 := uuid.Parse(userID)
+			sid, err2 := uuid.Parse(sessionID)
+			if err1 != nil || err2 != nil {
+				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				return
+			}
+
+			// Reject tokens of revoked sessions and of disabled users
+			state, err := sessions.ValidateSession(r.Context(), uid, sid)
+			if err != nil {
+				switch {
+				case errors.Is(err, auth.ErrorSessionRevoked):
+					http.Error(w, err.Error(), http.StatusUnauthorized)
+				case errors.Is(err, auth.ErrorAccountDisabled):
+					http.Error(w, err.Error(), http.StatusForbidden)
+				default:
+					log.Printf("validate session %s: %v", sid, err)
+					http.Error(w, "Could not validate session", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			// Add to context
+			ctx := context.WithValue(r.Context(), ContextUserID, userID)
+			ctx = context.WithValue(ctx, ContextRole, role)
+			ctx = context.WithValue(ctx, ContextSessionID, sid)
+			ctx = context.WithValue(ctx, ContextMustChangePassword, state.MustChangePassword)
+			ctx = context.WithValue(ctx, ContextMustEnrolTwoFactor, state.MustEnrolTwoFactor)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
 func GetOwnerIDFromContext(ctx context.Context) (uuid.UUID, error) {
@@ -123,3 +166,43 @@ func GetUserIDFromContext(ctx context.Context) (uuid.UUID, error) {
 
 	return uuid.Parse(idStr)
 }
+
+// GetCallerFromContext returns the authenticated user ID and role, whatever the role.
+func GetCallerFromContext(ctx context.Context) (uuid.UUID, string, error) {
+	role, ok := ctx.Value(ContextRole).(string)
+	if !ok || role == "" {
+		return uuid.Nil, "", errors.New("missing role in context")
+	}
+
+	idStr, ok := ctx.Value(ContextUserID).(string)
+	if !ok {
+		return uuid.Nil, "", errors.New("missing user ID in context")
+	}
+
+	id, err := uuid.Parse(idStr)
+	return id, role, err
+}
+
+// GetSessionIDFromContext returns the session of the access token the request
+// was authenticated with.
+func GetSessionIDFromContext(ctx context.Context) (uuid.UUID, error) {
+	sid, ok := ctx.Value(ContextSessionID).(uuid.UUID)
+	if !ok {
+		return uuid.Nil, errors.New("missing session ID in context")
+	}
+	return sid, nil
+}
+
+// MustChangePasswordFromContext reports whether the caller has to change
+// their password before using the rest of the API.
+func MustChangePasswordFromContext(ctx context.Context) bool {
+	must, _ := ctx.Value(ContextMustChangePassword).(bool)
+	return must
+}
+
+// MustEnrolTwoFactorFromContext reports whether the caller has to set up
+// two-factor authentication before using the rest of the API.
+func MustEnrolTwoFactorFromContext(ctx context.Context) bool {
+	must, _ := ctx.Value(ContextMustEnrolTwoFactor).(bool)
+	return must
+}
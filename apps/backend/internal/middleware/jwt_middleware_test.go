@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSessions map[uuid.UUID]error
+
+func (f fakeSessions) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) (*auth.SessionState, error) {
+	if err := f[sessionID]; err != nil {
+		return nil, err
+	}
+	return &auth.SessionState{UserID: userID, Status: user.Active}, nil
+}
+
+func TestJWTAuthMiddleware_ChecksSession(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	open, revoked, disabled, broken := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	sessions := fakeSessions{
+		revoked:  auth.ErrorSessionRevoked,
+		disabled: auth.ErrorAccountDisabled,
+		broken:   errors.New("db down"),
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   int
+	}{
+		{"open session", jwt.MapClaims{"sid": open.String()}, http.StatusNoContent},
+		{"revoked session", jwt.MapClaims{"sid": revoked.String()}, http.StatusUnauthorized},
+		{"disabled user", jwt.MapClaims{"sid": disabled.String()}, http.StatusForbidden},
+		{"lookup failure", jwt.MapClaims{"sid": broken.String()}, http.StatusInternalServerError},
+		{"token without session", jwt.MapClaims{}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			tt.claims["sub"] = userID.String()
+			tt.claims["role"] = "customer"
+			signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString([]byte("test-secret"))
+			require.NoError(t, err)
+
+			var gotSession uuid.UUID
+			h := JWTAuthMiddleware(sessions)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotSession, _ = GetSessionIDFromContext(r.Context())
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users/by-id/"+userID.String(), nil)
+			req.Header.Set("Authorization", "Bearer "+signed)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.want, rec.Code)
+			if tt.want == http.StatusNoContent {
+				require.Equal(t, open, gotSession)
+			}
+		})
+	}
+}
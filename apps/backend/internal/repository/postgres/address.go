@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/address"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type AddressRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewAddressRepository(db *sqlx.DB) *AddressRepository {
+	return &AddressRepository{exec: db}
+}
+
+func (r *AddressRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+const addressColumns = `id, user_id, label, address, point, is_default, created_at, updated_at`
+
+func (r *AddressRepository) Create(ctx context.Context, a *address.Address) error {
+	query := `
+		INSERT INTO customer_addresses (user_id, label, address, point, is_default)
+		VALUES (
+			:user_id, :label, :address,
+			ST_SetSRID(ST_MakePoint(:point.x, :point.y), 4326),
+			:is_default
+		)
+		RETURNING id, created_at, updated_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, a)
+	if err != nil {
+		return fmt.Errorf("insert address: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return fmt.Errorf("no id returned after insert")
+	}
+	if err := rows.Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return fmt.Errorf("scanning new address id: %w", err)
+	}
+	return nil
+}
+
+func (r *AddressRepository) GetByID(ctx context.Context, userID, id uuid.UUID) (*address.Address, error) {
+	query := `SELECT ` + addressColumns + ` FROM customer_addresses WHERE id = $1 AND user_id = $2`
+
+	var a address.Address
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &a, query, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, address.ErrorAddressNotFound
+		}
+		return nil, fmt.Errorf("get address: %w", err)
+	}
+	return &a, nil
+}
+
+func (r *AddressRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*address.Address, error) {
+	query := `
+		SELECT ` + addressColumns + `
+		FROM customer_addresses
+		WHERE user_id = $1
+		ORDER BY is_default DESC, label
+	`
+
+	addresses := []*address.Address{}
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &addresses, query, userID); err != nil {
+		return nil, fmt.Errorf("list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+func (r *AddressRepository) Update(ctx context.Context, a *address.Address) error {
+	query := `
+		UPDATE customer_addresses
+		SET label = :label,
+			address = :address,
+			point = ST_SetSRID(ST_MakePoint(:point.x, :point.y), 4326),
+			is_default = :is_default,
+			updated_at = now()
+		WHERE id = :id AND user_id = :user_id
+	`
+
+	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, a)
+	if err != nil {
+		return fmt.Errorf("update address: %w", err)
+	}
+	return addressAffected(res)
+}
+
+func (r *AddressRepository) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, `DELETE FROM customer_addresses WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete address: %w", err)
+	}
+	return addressAffected(res)
+}
+
+func (r *AddressRepository) ClearDefault(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE customer_addresses SET is_default = false, updated_at = now() WHERE user_id = $1 AND is_default`
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("clear default address: %w", err)
+	}
+	return nil
+}
+
+func addressAffected(res sql.Result) error {
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return address.ErrorAddressNotFound
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/apikey"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, user_id, store_id, name, prefix, key_hash, scopes,
+		last_used_at, revoked_at, created_at`
+
+type APIKeyRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
+	return &APIKeyRepository{exec: db}
+}
+
+func (r *APIKeyRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+// apiKeyRow scans the scopes, kept in a TEXT[] column.
+type apiKeyRow struct {
+	apikey.APIKey
+	Scopes pq.StringArray `db:"scopes"`
+}
+
+func (row *apiKeyRow) toAPIKey() *apikey.APIKey {
+	k := row.APIKey
+	k.Scopes = make([]apikey.Scope, len(row.Scopes))
+	for i, s := range row.Scopes {
+		k.Scopes[i] = apikey.Scope(s)
+	}
+	return &k
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *apikey.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, store_id, name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+
+	err := r.execFromCtx(ctx).QueryRowxContext(ctx, query,
+		k.UserID, k.StoreID, k.Name, k.Prefix, k.KeyHash, pq.Array(scopes),
+	).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*apikey.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	var row apiKeyRow
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &row, query, keyHash); err != nil {
+		return nil, err
+	}
+	return row.toAPIKey(), nil
+}
+
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID uuid.UUID) ([]*apikey.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+
+	var rows []apiKeyRow
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &rows, query, userID); err != nil {
+		return nil, err
+	}
+
+	keys := make([]*apikey.APIKey, len(rows))
+	for i := range rows {
+		keys[i] = rows[i].toAPIKey()
+	}
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id, userID uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, userID, revokedAt)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return apikey.ErrorKeyNotFound
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, usedAt)
+	return err
+}
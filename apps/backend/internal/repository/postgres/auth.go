@@ -0,0 +1,444 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type AuthRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewAuthRepository(db *sqlx.DB) *AuthRepository {
+	return &AuthRepository{exec: db}
+}
+
+func (r *AuthRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+func (r *AuthRepository) CreateSession(ctx context.Context, s *auth.Session) error {
+	query := `
+		INSERT INTO auth_sessions (user_id)
+		VALUES ($1)
+		RETURNING id, created_at
+	`
+
+	return sqlx.GetContext(ctx, r.execFromCtx(ctx), s, query, s.UserID)
+}
+
+func (r *AuthRepository) GetSessionState(ctx context.Context, sessionID uuid.UUID) (*auth.SessionState, error) {
+	query := `
+		SELECT s.user_id, u.status, u.must_change_password, s.revoked_at,
+			EXISTS (SELECT 1 FROM two_factor_requirements r WHERE r.role = u.role)
+				AND NOT EXISTS (
+					SELECT 1 FROM user_two_factor t
+					WHERE t.user_id = u.id AND t.confirmed_at IS NOT NULL
+				) AS must_enrol_two_factor
+		FROM auth_sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1
+	`
+
+	var st auth.SessionState
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &st, query, sessionID); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (r *AuthRepository) RevokeSession(ctx context.Context, sessionID uuid.UUID, reason string) error {
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = NOW(), revoked_reason = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, sessionID, reason)
+	return err
+}
+
+func (r *AuthRepository) CreateRefreshToken(ctx context.Context, t *auth.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	return sqlx.GetContext(ctx, r.execFromCtx(ctx), t, query, t.SessionID, t.TokenHash, t.ExpiresAt)
+}
+
+func (r *AuthRepository) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	query := `
+		SELECT id, session_id, token_hash, expires_at, used_at, created_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	var t auth.RefreshToken
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &t, query, tokenHash); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *AuthRepository) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE refresh_tokens SET used_at = $2 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, usedAt)
+	return err
+}
+
+func (r *AuthRepository) RevokeUserSessions(ctx context.Context, userID uuid.UUID, reason string) error {
+	query := `
+		UPDATE auth_sessions
+		SET revoked_at = NOW(), revoked_reason = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, reason)
+	return err
+}
+
+func (r *AuthRepository) CreateOneTimeToken(ctx context.Context, t *auth.OneTimeToken) error {
+	retire := `
+		UPDATE user_tokens
+		SET used_at = NOW()
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, retire, t.UserID, t.Purpose); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+
+	return sqlx.GetContext(ctx, r.execFromCtx(ctx), t, query, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt)
+}
+
+func (r *AuthRepository) GetOneTimeTokenForUpdate(ctx context.Context, tokenHash string, purpose auth.Purpose) (*auth.OneTimeToken, error) {
+	query := `
+		SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+		FROM user_tokens
+		WHERE token_hash = $1 AND purpose = $2
+		FOR UPDATE
+	`
+
+	var t auth.OneTimeToken
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &t, query, tokenHash, purpose); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *AuthRepository) MarkOneTimeTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE user_tokens SET used_at = $2 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, usedAt)
+	return err
+}
+
+func (r *AuthRepository) GetTwoFactor(ctx context.Context, userID uuid.UUID) (*auth.TwoFactor, error) {
+	query := `
+		SELECT user_id, secret, confirmed_at, last_used_step, created_at
+		FROM user_two_factor
+		WHERE user_id = $1
+	`
+
+	var t auth.TwoFactor
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &t, query, userID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *AuthRepository) SaveTwoFactorSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_two_factor (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = NOW()
+		WHERE user_two_factor.confirmed_at IS NULL
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, secret)
+	return err
+}
+
+func (r *AuthRepository) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, confirmedAt time.Time, step int64) error {
+	query := `
+		UPDATE user_two_factor
+		SET confirmed_at = $2, last_used_step = $3
+		WHERE user_id = $1
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, confirmedAt, step)
+	return err
+}
+
+func (r *AuthRepository) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `
+		UPDATE user_two_factor
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return auth.ErrorInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (r *AuthRepository) DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, `DELETE FROM user_two_factor WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *AuthRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO recovery_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, pq.Array(codeHashes))
+	return err
+}
+
+func (r *AuthRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error {
+	query := `
+		UPDATE recovery_codes
+		SET used_at = $3
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, codeHash, usedAt)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *AuthRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	var n int
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &n, query, userID)
+	return n, err
+}
+
+func (r *AuthRepository) ListTwoFactorRequirements(ctx context.Context) ([]*auth.TwoFactorRequirement, error) {
+	query := `
+		SELECT role, required_by, created_at
+		FROM two_factor_requirements
+		ORDER BY role
+	`
+
+	var reqs []*auth.TwoFactorRequirement
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &reqs, query); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+func (r *AuthRepository) SetTwoFactorRequired(ctx context.Context, role user.Role, required bool, by uuid.UUID) error {
+	if !required {
+		_, err := r.execFromCtx(ctx).ExecContext(ctx, `DELETE FROM two_factor_requirements WHERE role = $1`, role)
+		return err
+	}
+
+	query := `
+		INSERT INTO two_factor_requirements (role, required_by)
+		VALUES ($1, $2)
+		ON CONFLICT (role) DO NOTHING
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, role, by)
+	return err
+}
+
+func (r *AuthRepository) TwoFactorRequired(ctx context.Context, role user.Role) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM two_factor_requirements WHERE role = $1)`
+
+	var required bool
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &required, query, role)
+	return required, err
+}
+
+func (r *AuthRepository) CreateLoginChallenge(ctx context.Context, c *auth.LoginChallenge) error {
+	query := `
+		INSERT INTO login_challenges (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+
+	return sqlx.GetContext(ctx, r.execFromCtx(ctx), c, query, c.UserID, c.TokenHash, c.ExpiresAt)
+}
+
+func (r *AuthRepository) GetLoginChallengeForUpdate(ctx context.Context, tokenHash string) (*auth.LoginChallenge, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, attempts, used_at, created_at
+		FROM login_challenges
+		WHERE token_hash = $1
+		FOR UPDATE
+	`
+
+	var c auth.LoginChallenge
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &c, query, tokenHash); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *AuthRepository) AddLoginChallengeAttempt(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE login_challenges SET attempts = attempts + 1 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *AuthRepository) MarkLoginChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE login_challenges SET used_at = $2 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, usedAt)
+	return err
+}
+
+func (r *AuthRepository) LockThrottle(ctx context.Context, scope auth.ThrottleScope, key string) (*auth.Throttle, error) {
+	insert := `
+		INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 0, to_timestamp(0))
+		ON CONFLICT (scope, key) DO NOTHING
+	`
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, insert, scope, key); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT scope, key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE scope = $1 AND key = $2
+		FOR UPDATE
+	`
+
+	var t auth.Throttle
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &t, query, scope, key); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *AuthRepository) SaveThrottle(ctx context.Context, t *auth.Throttle) error {
+	query := `
+		INSERT INTO login_throttles (scope, key, failures, last_failure_at, locked_until)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE
+		SET failures = EXCLUDED.failures,
+			last_failure_at = EXCLUDED.last_failure_at,
+			locked_until = EXCLUDED.locked_until
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, t.Scope, t.Key, t.Failures, t.LastFailureAt, t.LockedUntil)
+	return err
+}
+
+func (r *AuthRepository) DeleteThrottle(ctx context.Context, scope auth.ThrottleScope, key string) error {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND key = $2`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, scope, key)
+	return err
+}
+
+func (r *AuthRepository) CreatePhoneCode(ctx context.Context, c *auth.PhoneCode) error {
+	query := `
+		INSERT INTO phone_codes (phone, code_hash, ip, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+
+	return sqlx.GetContext(ctx, r.execFromCtx(ctx), &c.ID, query, c.Phone, c.CodeHash, c.IP, c.ExpiresAt, c.CreatedAt)
+}
+
+func (r *AuthRepository) GetLatestPhoneCodeForUpdate(ctx context.Context, phone string) (*auth.PhoneCode, error) {
+	query := `
+		SELECT id, phone, code_hash, ip, attempts, expires_at, used_at, created_at
+		FROM phone_codes
+		WHERE phone = $1
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`
+
+	var c auth.PhoneCode
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &c, query, phone); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *AuthRepository) CountPhoneCodes(ctx context.Context, phone string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM phone_codes WHERE phone = $1 AND created_at >= $2`
+
+	var n int
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &n, query, phone, since)
+	return n, err
+}
+
+func (r *AuthRepository) CountPhoneCodesFromIP(ctx context.Context, ip string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM phone_codes WHERE ip = $1 AND created_at >= $2`
+
+	var n int
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &n, query, ip, since)
+	return n, err
+}
+
+func (r *AuthRepository) AddPhoneCodeAttempt(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE phone_codes SET attempts = attempts + 1 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *AuthRepository) MarkPhoneCodeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE phone_codes SET used_at = $2 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, usedAt)
+	return err
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/cart"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type CartRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewCartRepository(db *sqlx.DB) *CartRepository {
+	return &CartRepository{exec: db}
+}
+
+func (r *CartRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+const cartColumns = `id, customer_id, store_id, created_at, updated_at`
+
+const cartItemColumns = `id, cart_id, product_id, variant_id, quantity, added_at`
+
+func (r *CartRepository) GetOrCreate(ctx context.Context, customerID, storeID uuid.UUID) (*cart.Cart, error) {
+	// The no-op update makes RETURNING yield the existing row on conflict
+	query := `
+		INSERT INTO carts (customer_id, store_id)
+		VALUES ($1, $2)
+		ON CONFLICT (customer_id, store_id) DO UPDATE SET customer_id = EXCLUDED.customer_id
+		RETURNING ` + cartColumns
+
+	var c cart.Cart
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &c, query, customerID, storeID); err != nil {
+		return nil, fmt.Errorf("get or create cart: %w", err)
+	}
+	return &c, r.attachItems(ctx, &c)
+}
+
+func (r *CartRepository) Get(ctx context.Context, customerID, storeID uuid.UUID) (*cart.Cart, error) {
+	query := `SELECT ` + cartColumns + ` FROM carts WHERE customer_id = $1 AND store_id = $2`
+
+	var c cart.Cart
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &c, query, customerID, storeID); err != nil {
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+	return &c, r.attachItems(ctx, &c)
+}
+
+func (r *CartRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*cart.Cart, error) {
+	query := `
+		SELECT ` + cartColumns + `
+		FROM carts
+		WHERE customer_id = $1
+		ORDER BY updated_at DESC
+	`
+
+	var carts []*cart.Cart
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &carts, query, customerID); err != nil {
+		return nil, fmt.Errorf("list carts: %w", err)
+	}
+
+	for _, c := range carts {
+		if err := r.attachItems(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	return carts, nil
+}
+
+// attachItems loads the lines of a cart in the order they were added.
+func (r *CartRepository) attachItems(ctx context.Context, c *cart.Cart) error {
+	query := `SELECT ` + cartItemColumns + ` FROM cart_items WHERE cart_id = $1 ORDER BY added_at, id`
+
+	c.Items = []cart.Item{}
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &c.Items, query, c.ID); err != nil {
+		return fmt.Errorf("get cart items: %w", err)
+	}
+	return nil
+}
+
+func (r *CartRepository) AddItem(ctx context.Context, cartID uuid.UUID, item *cart.Item) (*cart.Item, error) {
+	query := `
+		INSERT INTO cart_items (cart_id, product_id, variant_id, quantity)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid))
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+		RETURNING ` + cartItemColumns
+
+	var saved cart.Item
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &saved, query, cartID, item.ProductID, item.VariantID, item.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("add cart item: %w", err)
+	}
+
+	return &saved, r.touch(ctx, cartID)
+}
+
+func (r *CartRepository) SetItemQuantity(ctx context.Context, cartID, itemID uuid.UUID, quantity int) error {
+	query := `UPDATE cart_items SET quantity = $3 WHERE id = $2 AND cart_id = $1`
+	if err := r.execLine(ctx, query, cartID, itemID, quantity); err != nil {
+		return err
+	}
+	return r.touch(ctx, cartID)
+}
+
+func (r *CartRepository) RemoveItem(ctx context.Context, cartID, itemID uuid.UUID) error {
+	query := `DELETE FROM cart_items WHERE id = $2 AND cart_id = $1`
+	if err := r.execLine(ctx, query, cartID, itemID); err != nil {
+		return err
+	}
+	return r.touch(ctx, cartID)
+}
+
+func (r *CartRepository) Clear(ctx context.Context, cartID uuid.UUID) error {
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, `DELETE FROM cart_items WHERE cart_id = $1`, cartID); err != nil {
+		return fmt.Errorf("clear cart: %w", err)
+	}
+	return r.touch(ctx, cartID)
+}
+
+// execLine runs a statement on a single cart line, which must exist.
+func (r *CartRepository) execLine(ctx context.Context, query string, args ...any) error {
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update cart item: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return cart.ErrorItemNotFound
+	}
+	return nil
+}
+
+func (r *CartRepository) touch(ctx context.Context, cartID uuid.UUID) error {
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, `UPDATE carts SET updated_at = now() WHERE id = $1`, cartID); err != nil {
+		return fmt.Errorf("touch cart: %w", err)
+	}
+	return nil
+}
@@ -1,9 +1,10 @@
 package postgres
 
 import (
+	"backend/internal/application"
+	"backend/internal/domain/delivery"
 	"context"
 	"fmt"
-	"backend/internal/domain/delivery"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -19,6 +20,13 @@ func NewDeliveryRepository(db *sqlx.DB) *DeliveryRepository {
 	//flexible — can pass in either a *sqlx.DB or a *sqlx.Tx
 }
 
+func (r *DeliveryRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
 func (r *DeliveryRepository) Create(ctx context.Context, d *delivery.Delivery) error {
 	query := `
 		INSERT INTO deliveries (order_id, driver_id, status)
@@ -26,7 +34,7 @@ func (r *DeliveryRepository) Create(ctx context.Context, d *delivery.Delivery) e
 		RETURNING id
 	`
 
-	rows, err := sqlx.NamedQueryContext(ctx, r.exec, query, d)
+	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, d)
 	if err != nil {
 		return fmt.Errorf("insert delivery: %w", err)
 	}
@@ -51,35 +59,75 @@ func (r *DeliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (*delive
 	`
 
 	var d delivery.Delivery
-	if err := sqlx.GetContext(ctx, r.exec, &d, query, id); err != nil {
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &d, query, id); err != nil {
 		return nil, fmt.Errorf("get delivery by id: %w", err)
 	}
 
 	return &d, nil
 }
 
-func (r *DeliveryRepository) Update(ctx context.Context, deliveryID uuid.UUID, column string, value any) error {
-	// whitelist columns
-	allowed := map[string]bool{
-		"status": true,
+func (r *DeliveryRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*delivery.Delivery, error) {
+	query := `
+		SELECT id, order_id, driver_id, assigned_at, picked_up_at, delivered_at, status
+		FROM deliveries
+		WHERE order_id = $1
+		ORDER BY assigned_at DESC
+		LIMIT 1
+	`
+
+	var d delivery.Delivery
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &d, query, orderID); err != nil {
+		return nil, fmt.Errorf("get delivery by order id: %w", err)
 	}
 
-	if !allowed[column] {
-		return fmt.Errorf("attempted to update disallowed column: %s", column)
+	return &d, nil
+}
+
+func (r *DeliveryRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status delivery.DeliveryStatus) error {
+	query := `
+		UPDATE deliveries
+		SET status = $2,
+			picked_up_at = CASE WHEN $2 = 'picked_up' THEN NOW() ELSE picked_up_at END,
+			delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() ELSE delivered_at END,
+			updated_at = NOW()
+		WHERE id = $1
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, status)
+	if err != nil {
+		return fmt.Errorf("update delivery status: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
 	}
 
-	query := fmt.Sprintf(`
-		UPDATE deliveries 
-		SET %s = :value, updated_at = NOW() 
-		WHERE id = :id
-	`, column)
+	if rows == 0 {
+		return fmt.Errorf("no delivery found with id %s", id)
+	}
+
+	return nil
+}
 
-	args := map[string]interface{}{
-		"value": value,
-		"id":    deliveryID,
+// Update sets the timestamps of req that are set.
+func (r *DeliveryRepository) Update(ctx context.Context, deliveryID uuid.UUID, req *delivery.UpdateDeliveryRequest) error {
+	var set setClause
+	if req.AssignedAt != nil {
+		set.add("assigned_at", *req.AssignedAt)
+	}
+	if req.PickedUpAt != nil {
+		set.add("picked_up_at", *req.PickedUpAt)
+	}
+	if req.DeliveredAt != nil {
+		set.add("delivered_at", *req.DeliveredAt)
+	}
+	if set.empty() {
+		return delivery.ErrorNothingToUpdate
 	}
 
-	res, err := sqlx.NamedExecContext(ctx, r.exec, query, args)
+	query, args := set.update("deliveries", deliveryID)
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		return fmt.Errorf("update delivery: %w", err)
 	}
@@ -106,7 +154,7 @@ func (r *DeliveryRepository) Accept(ctx context.Context, d *delivery.Delivery) e
 		WHERE id = :id AND status = 'assigned'
 	`
 
-	res, err := sqlx.NamedExecContext(ctx, r.exec, query, d)
+	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, d)
 	if err != nil {
 		return fmt.Errorf("failed to accept delivery: %w", err)
 	}
@@ -130,7 +178,7 @@ func (r *DeliveryRepository) List(ctx context.Context) ([]*delivery.Delivery, er
 	`
 	var deliveries []*delivery.Delivery
 
-	err := sqlx.SelectContext(ctx, r.exec, &deliveries, query)
+	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &deliveries, query)
 	return deliveries, err
 }
 
@@ -142,7 +190,7 @@ func (r *DeliveryRepository) ListByStatus(ctx context.Context, statuses []delive
 	`
 	var deliveries []*delivery.Delivery
 
-	err := sqlx.SelectContext(ctx, r.exec, &deliveries, query, pq.Array(statuses))
+	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &deliveries, query, pq.Array(statuses))
 	return deliveries, err
 }
 
@@ -152,7 +200,7 @@ func (r *DeliveryRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		WHERE id = $1
 	`
 
-	res, err := r.exec.ExecContext(ctx, query, id)
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete delivery: %w", err)
 	}
@@ -119,26 +119,43 @@ func (r *DriverRepository) UpdateProfile(ctx context.Context, driverID uuid.UUID
 	return nil
 }
 
-func (r *DriverRepository) UpdateColumn(ctx context.Context, driverID uuid.UUID, column string, value any) error {
-	allowed := map[string]bool{
-		"full_name":        true,
-		"email":            true,
-		"vehicle_info":     true,
-		"current_location": true,
+// Update sets the fields of req that are set.
+func (r *DriverRepository) Update(ctx context.Context, driverID uuid.UUID, req *driver.UpdateDriverRequest) error {
+	var set setClause
+	if req.FullName != nil {
+		set.add("full_name", *req.FullName)
+	}
+	if req.VehicleInfo != nil {
+		set.add("vehicle_info", *req.VehicleInfo)
+	}
+	if loc := req.Location(); loc != nil {
+		set.addExpr("current_location", "ST_GeomFromEWKT(%s)", fmt.Sprintf("SRID=%d;POINT(%f %f)", loc.SRID, loc.X, loc.Y))
+	}
+	if set.empty() {
+		return driver.ErrNothingToUpdate
 	}
 
-	if !allowed[column] {
-		return fmt.Errorf("attempted to update disallowed column: %s", column)
+	query, args := set.update("drivers", driverID)
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update driver: %w", err)
 	}
 
-	query := fmt.Sprintf(`
-		UPDATE drivers SET %s = $1, updated_at = NOW()
-		WHERE id = $2
-	`, column)
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return driver.ErrDriverNotFound
+	}
+	return nil
+}
 
-	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, value, driverID)
+// SetAvailable marks a driver available or reserved for a delivery.
+func (r *DriverRepository) SetAvailable(ctx context.Context, driverID uuid.UUID, available bool) error {
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, `UPDATE drivers SET available = $1 WHERE id = $2`, available, driverID)
 	if err != nil {
-		return fmt.Errorf("update driver: %w", err)
+		return fmt.Errorf("set driver availability: %w", err)
 	}
 
 	rows, err := res.RowsAffected()
@@ -146,7 +163,7 @@ func (r *DriverRepository) UpdateColumn(ctx context.Context, driverID uuid.UUID,
 		return fmt.Errorf("rows affected: %w", err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("no driver found with id %s", driverID)
+		return driver.ErrDriverNotFound
 	}
 	return nil
 }
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"backend/internal/domain/geocoding"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GeocodingRepository reads the gazetteer and the lookup cache. It always
+// uses the pool rather than a surrounding transaction: a failed cache write
+// must not abort the checkout or signup that triggered the lookup.
+type GeocodingRepository struct {
+	db *sqlx.DB
+}
+
+func NewGeocodingRepository(db *sqlx.DB) *GeocodingRepository {
+	return &GeocodingRepository{db: db}
+}
+
+const gazetteerColumns = `
+	name, kind, town, county,
+	ST_Y(point::geometry) AS lat, ST_X(point::geometry) AS lng
+`
+
+func (r *GeocodingRepository) FindByName(ctx context.Context, name string) ([]*geocoding.Entry, error) {
+	query := `
+		SELECT ` + gazetteerColumns + `
+		FROM gazetteer
+		WHERE name_normalized = $1
+		ORDER BY kind DESC, name
+	`
+
+	var entries []*geocoding.Entry
+	if err := sqlx.SelectContext(ctx, r.db, &entries, query, name); err != nil {
+		return nil, fmt.Errorf("search gazetteer: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *GeocodingRepository) Nearest(ctx context.Context, lat, lng float64, estateRadiusM, townRadiusM int) (*geocoding.Entry, error) {
+	query := `
+		SELECT ` + gazetteerColumns + `
+		FROM gazetteer, (SELECT ST_SetSRID(ST_MakePoint($2, $1), 4326)::geography AS p) AS q
+		WHERE ST_DWithin(point, q.p, CASE kind WHEN 'estate' THEN $3 ELSE $4 END)
+		ORDER BY kind = 'estate' DESC, ST_Distance(point, q.p)
+		LIMIT 1
+	`
+
+	var e geocoding.Entry
+	if err := sqlx.GetContext(ctx, r.db, &e, query, lat, lng, estateRadiusM, townRadiusM); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, geocoding.ErrorNoMatch
+		}
+		return nil, fmt.Errorf("nearest gazetteer entry: %w", err)
+	}
+	return &e, nil
+}
+
+func (r *GeocodingRepository) GetCached(ctx context.Context, kind, key string) (*geocoding.Place, error) {
+	query := `
+		SELECT name, address, lat, lng, source
+		FROM geocode_cache
+		WHERE kind = $1 AND key = $2
+	`
+
+	var p geocoding.Place
+	if err := sqlx.GetContext(ctx, r.db, &p, query, kind, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, geocoding.ErrorNoMatch
+		}
+		return nil, fmt.Errorf("get cached place: %w", err)
+	}
+	return &p, nil
+}
+
+func (r *GeocodingRepository) PutCached(ctx context.Context, kind, key string, p *geocoding.Place) error {
+	query := `
+		INSERT INTO geocode_cache (kind, key, name, address, lat, lng, source)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, key) DO UPDATE
+		SET name = EXCLUDED.name, address = EXCLUDED.address,
+			lat = EXCLUDED.lat, lng = EXCLUDED.lng,
+			source = EXCLUDED.source, created_at = now()
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, kind, key, p.Name, p.Address, p.Lat, p.Lng, p.Source); err != nil {
+		return fmt.Errorf("cache place: %w", err)
+	}
+	return nil
+}
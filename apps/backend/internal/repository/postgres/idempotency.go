@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"backend/internal/domain/idempotency"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{exec: db}
+}
+
+func (r *IdempotencyRepository) Reserve(ctx context.Context, rec *idempotency.Record) (*idempotency.Record, bool, error) {
+	// Insert, or take over a record that has expired. A live record makes
+	// the statement return no rows.
+	query := `
+		INSERT INTO idempotency_keys (key, owner, method, path, request_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (owner, key) DO UPDATE
+		SET method = EXCLUDED.method,
+			path = EXCLUDED.path,
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = NOW(),
+			completed_at = NULL
+		WHERE idempotency_keys.created_at < NOW() - $6::interval
+		RETURNING created_at
+	`
+
+	ttl := fmt.Sprintf("%d seconds", int(idempotency.TTL.Seconds()))
+	err := sqlx.GetContext(ctx, r.exec, &rec.CreatedAt, query, rec.Key, rec.Owner, rec.Method, rec.Path, rec.RequestHash, ttl)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	var existing idempotency.Record
+	err = sqlx.GetContext(ctx, r.exec, &existing, `
+		SELECT key, owner, method, path, request_hash, status_code, content_type, response_body, created_at, completed_at
+		FROM idempotency_keys
+		WHERE owner = $1 AND key = $2
+	`, rec.Owner, rec.Key)
+	if err != nil {
+		return nil, false, fmt.Errorf("get idempotency key: %w", err)
+	}
+
+	return &existing, false, nil
+}
+
+func (r *IdempotencyRepository) Complete(ctx context.Context, owner, key string, status int, contentType string, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_body = $5, completed_at = NOW()
+		WHERE owner = $1 AND key = $2
+	`
+
+	if _, err := r.exec.ExecContext(ctx, query, owner, key, status, contentType, body); err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepository) Release(ctx context.Context, owner, key string) error {
+	query := `
+		DELETE FROM idempotency_keys
+		WHERE owner = $1 AND key = $2 AND completed_at IS NULL
+	`
+
+	if _, err := r.exec.ExecContext(ctx, query, owner, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
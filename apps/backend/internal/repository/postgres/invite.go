@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"time"
 	"backend/internal/application"
 	"backend/internal/domain/invite"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
+const inviteColumns = `id, email, role, store_id, store_role, token_hash, expires_at, invited_by,
+		accepted_at, accepted_by, revoked_at, created_at`
+
 type InviteRepository struct {
 	exec sqlx.ExtContext
 }
@@ -27,8 +31,9 @@ func (r *InviteRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
 
 func (r *InviteRepository) Create(ctx context.Context, i *invite.Invite) error {
 	query := `
-		INSERT INTO invites (id, email, role, token, expires_at, invited_by, created_at)
-		VALUES (:id, :email, :role, :token, :expires_at, :invited_by, :created_at)
+		INSERT INTO invites (id, email, role, store_id, store_role, token_hash, expires_at, invited_by)
+		VALUES (:id, :email, :role, :store_id, :store_role, :token_hash, :expires_at, :invited_by)
+		RETURNING id, created_at
 	`
 	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, i)
 	if err != nil {
@@ -38,7 +43,7 @@ func (r *InviteRepository) Create(ctx context.Context, i *invite.Invite) error {
 	defer rows.Close()
 
 	if rows.Next() {
-		if err := rows.Scan(&i.ID); err != nil {
+		if err := rows.Scan(&i.ID, &i.CreatedAt); err != nil {
 			return fmt.Errorf("scanning new invite id: %w", err)
 		}
 	} else {
@@ -51,20 +56,20 @@ func (r *InviteRepository) Create(ctx context.Context, i *invite.Invite) error {
 func (r *InviteRepository) GetByToken(ctx context.Context, token string) (*invite.Invite, error) {
 	var i invite.Invite
 	query := `
-		SELECT id, email, role, token, expires_at, invited_by, created_at 
+		SELECT ` + inviteColumns + `
 		FROM invites 
-		WHERE token = $1
+		WHERE token_hash = $1
 	`
-	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &i, query, token)
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &i, query, invite.HashToken(token))
 	return &i, err
 }
 
 func (r *InviteRepository) ListPending(ctx context.Context) ([]*invite.Invite, error) {
 	var invites []*invite.Invite
 	query := `
-		SELECT id, email, role, token, expires_at, invited_by, created_at
+		SELECT ` + inviteColumns + `
         FROM invites
-        WHERE expires_at > NOW()
+        WHERE expires_at > NOW() AND accepted_at IS NULL AND revoked_at IS NULL
 	`
 	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &invites, query)
 	return invites, err
@@ -92,3 +97,44 @@ func (r *InviteRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+func (r *InviteRepository) GetByID(ctx context.Context, id uuid.UUID) (*invite.Invite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM invites WHERE id = $1`
+
+	var i invite.Invite
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &i, query, id); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *InviteRepository) GetByTokenForUpdate(ctx context.Context, tokenHash string) (*invite.Invite, error) {
+	query := `SELECT ` + inviteColumns + ` FROM invites WHERE token_hash = $1 FOR UPDATE`
+
+	var i invite.Invite
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &i, query, tokenHash); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *InviteRepository) MarkAccepted(ctx context.Context, id, userID uuid.UUID, acceptedAt time.Time) error {
+	query := `UPDATE invites SET accepted_at = $2, accepted_by = $3 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, acceptedAt, userID)
+	return err
+}
+
+func (r *InviteRepository) Renew(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `UPDATE invites SET token_hash = $2, expires_at = $3 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, tokenHash, expiresAt)
+	return err
+}
+
+func (r *InviteRepository) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE invites SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, id, revokedAt)
+	return err
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"backend/internal/application"
 	"backend/internal/domain/notification"
@@ -76,6 +77,35 @@ func (r *NotificationRepository) UpdateStatus(ctx context.Context, id uuid.UUID,
 	return nil
 }
 
+func (r *NotificationRepository) MarkAsRead(ctx context.Context, id, userID uuid.UUID) error {
+	query := `
+		UPDATE notifications
+		SET status = :status, updated_at = NOW()
+		WHERE id = :id AND user_id = :user_id
+	`
+
+	args := map[string]interface{}{
+		"status":  notification.Read,
+		"id":      id,
+		"user_id": userID,
+	}
+
+	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, args)
+	if err != nil {
+		return fmt.Errorf("mark notification as read: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
 func (r *NotificationRepository) UpdateAllAsRead(ctx context.Context, userID uuid.UUID) error {
 	query := `
 		UPDATE notifications
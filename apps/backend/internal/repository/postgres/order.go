@@ -5,10 +5,13 @@ import (
 	"backend/internal/domain/order"
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type OrderRepository struct {
@@ -26,97 +29,85 @@ func (r *OrderRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
 	return r.exec
 }
 
+// orderColumns lists the header columns shared by every order read.
+const orderColumns = `
+	id, store_id, merchant_id, user_id,
+	currency, subtotal, delivery_fee, discount, tax, total, promo_code,
+	pickup_address, pickup_point, delivery_address, delivery_point, address_id,
+	status, created_at, updated_at, cancel_reason, cancelled_at
+`
+
 func (r *OrderRepository) Create(ctx context.Context, o *order.Order) error {
 	query := `
 		INSERT INTO orders (
-			user_id, merchant_id, store_id, product_id, variant_id,
-			quantity, unit_price, currency, total,
-			product_name, variant_name, image_url,
+			user_id, merchant_id, store_id,
+			currency, subtotal, delivery_fee, discount, tax, total, promo_code,
 			pickup_address, delivery_address,
-			pickup_point, delivery_point,
+			pickup_point, delivery_point, address_id,
 			status
 		)
 		VALUES (
-			:user_id, :merchant_id, :store_id, :product_id, :variant_id,
-			:quantity, :unit_price, :currency, :total,
-			:product_name, :variant_name, :image_url,
+			:user_id, :merchant_id, :store_id,
+			:currency, :subtotal, :delivery_fee, :discount, :tax, :total, :promo_code,
 			:pickup_address, :delivery_address,
 			ST_SetSRID(ST_MakePoint(:pickup_point.x, :pickup_point.y), 4326),
 			ST_SetSRID(ST_MakePoint(:delivery_point.x, :delivery_point.y), 4326),
+			:address_id,
 			:status
 		)
-		RETURNING id
+		RETURNING id, created_at, updated_at
 	`
 
 	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, o)
 	if err != nil {
 		return fmt.Errorf("insert order: %w", err)
 	}
-	defer rows.Close()
 
 	if rows.Next() {
-		if err := rows.Scan(&o.ID); err != nil {
+		if err := rows.Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			rows.Close()
 			return fmt.Errorf("scanning new order id: %w", err)
 		}
 	} else {
+		rows.Close()
 		return fmt.Errorf("no id returned after scan")
 	}
+	rows.Close()
+
+	for i := range o.Items {
+		o.Items[i].OrderID = o.ID
+		if err := r.createItem(ctx, &o.Items[i]); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
-func (r *OrderRepository) CreatePending(ctx context.Context, o *order.Order) error {
+func (r *OrderRepository) createItem(ctx context.Context, item *order.OrderItem) error {
 	query := `
-		INSERT INTO orders (
-			user_id,
-			merchant_id,
-			store_id,
-			product_id,
-			variant_id,
-			quantity,
-			unit_price,
-			currency,
-			total,
-			product_name,
-			variant_name,
-			image_url,
-			pickup_address,
-			delivery_address,
-			pickup_point,
-			delivery_point,
-			status
+		INSERT INTO order_items (
+			order_id, product_id, variant_id, quantity,
+			unit_price, total,
+			product_name, variant_name, image_url
 		)
 		VALUES (
-			:user_id,
-			:merchant_id,
-			:store_id,
-			:product_id,
-			:variant_id,
-			:quantity,
-			:unit_price,
-			:currency,
-			:total,
-			:product_name,
-			:variant_name,
-			:image_url,
-			:pickup_address,
-			:delivery_address,
-			:pickup_point,
-			:delivery_point,
-			'pending'
+			:order_id, :product_id, :variant_id, :quantity,
+			:unit_price, :total,
+			:product_name, :variant_name, :image_url
 		)
 		RETURNING id
 	`
 
-	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, o)
+	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, item)
 	if err != nil {
-		return fmt.Errorf("insert pending order: %w", err)
+		return fmt.Errorf("insert order item: %w", err)
 	}
 	defer rows.Close()
 
 	if rows.Next() {
-		if err := rows.Scan(&o.ID); err != nil {
-			return fmt.Errorf("scanning new pending order id: %w", err)
+		if err := rows.Scan(&item.ID); err != nil {
+			return fmt.Errorf("scanning new order item id: %w", err)
 		}
 	} else {
 		return fmt.Errorf("no id returned after scan")
@@ -127,7 +118,7 @@ func (r *OrderRepository) CreatePending(ctx context.Context, o *order.Order) err
 
 func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
 	query := `
-		SELECT id, user_id, admin_id, inventory_id, quantity, pickup_address, pickup_point, delivery_address, delivery_point, status, created_at, updated_at 
+		SELECT ` + orderColumns + `
 		FROM orders 
 		WHERE id = $1
 	`
@@ -137,46 +128,231 @@ func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*order.Ord
 		return nil, fmt.Errorf("get order by id: %w", err)
 	}
 
+	if err := r.attachItems(ctx, []*order.Order{&o}); err != nil {
+		return nil, err
+	}
+
 	return &o, nil
 }
 
 func (r *OrderRepository) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*order.Order, error) {
 	query := `
-		SELECT id, user_id, admin_id, inventory_id, quantity, pickup_address, pickup_point, delivery_address, delivery_point, status, created_at, updated_at 
+		SELECT ` + orderColumns + `
 		FROM orders 
 		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	var orders []*order.Order
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query, customerID); err != nil {
+		return nil, fmt.Errorf("list orders by customer: %w", err)
+	}
+
+	return orders, r.attachItems(ctx, orders)
+}
+
+func (r *OrderRepository) ListByStatus(ctx context.Context, status order.OrderStatus) ([]*order.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders
+		WHERE status = $1
+		ORDER BY created_at ASC
+	`
+
+	var orders []*order.Order
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query, status); err != nil {
+		return nil, fmt.Errorf("list orders by status: %w", err)
+	}
+
+	return orders, r.attachItems(ctx, orders)
+}
+
+// attachItems loads the line items of all given orders in one query
+// and hangs them off their order headers.
+func (r *OrderRepository) attachItems(ctx context.Context, orders []*order.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(orders))
+	byID := make(map[uuid.UUID]*order.Order, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+		byID[o.ID] = o
+		o.Items = []order.OrderItem{}
+	}
+
+	query := `
+		SELECT id, order_id, product_id, variant_id, quantity,
+			unit_price, total, product_name, variant_name, image_url
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY created_at ASC
+	`
+
+	var items []order.OrderItem
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &items, query, pq.Array(ids)); err != nil {
+		return fmt.Errorf("list order items: %w", err)
+	}
+
+	for _, item := range items {
+		if o, ok := byID[item.OrderID]; ok {
+			o.Items = append(o.Items, item)
+		}
+	}
+
+	return nil
+}
+
+func (r *OrderRepository) ListFiltered(ctx context.Context, f order.ListFilter) ([]*order.Order, error) {
+	var (
+		where []string
+		args  []any
+	)
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.StoreID != nil {
+		where = append(where, "store_id = "+arg(*f.StoreID))
+	}
+	if f.MerchantID != nil {
+		where = append(where, "merchant_id = "+arg(*f.MerchantID))
+	}
+	if f.CustomerID != nil {
+		where = append(where, "user_id = "+arg(*f.CustomerID))
+	}
+	if len(f.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(pq.Array(f.Statuses))+")")
+	}
+	if f.CreatedFrom != nil {
+		where = append(where, "created_at >= "+arg(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		where = append(where, "created_at < "+arg(*f.CreatedTo))
+	}
+	if f.MinTotal != nil {
+		where = append(where, "total >= "+arg(*f.MinTotal))
+	}
+	if f.MaxTotal != nil {
+		where = append(where, "total <= "+arg(*f.MaxTotal))
+	}
+
+	// sort column comes from the SortField whitelist, never from the client
+	column, dir, cmp := "created_at", "ASC", ">"
+	if f.Sort == order.SortByTotal {
+		column = "total"
+	}
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if c := f.Cursor; c != nil {
+		var after any = c.CreatedAt
+		if f.Sort == order.SortByTotal {
+			after = c.Total
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", column, cmp, arg(after), arg(c.ID)))
+	}
+
+	query := `SELECT ` + orderColumns + ` FROM orders`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %s", column, dir, dir, arg(f.Limit))
+
+	var orders []*order.Order
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query, args...); err != nil {
+		return nil, fmt.Errorf("list filtered orders: %w", err)
+	}
+
+	return orders, r.attachItems(ctx, orders)
+}
+
+func (r *OrderRepository) ListExpiredPending(ctx context.Context, cutoff time.Time, limit int) ([]*order.Order, error) {
+	query := `
+		SELECT ` + orderColumns + `
+		FROM orders o
+		WHERE o.status = 'pending'
+			AND o.created_at < $1
+			AND NOT EXISTS (
+				SELECT 1 FROM payments p
+				WHERE p.order_id = o.id AND p.status = 'completed'
+			)
+		ORDER BY o.created_at ASC
+		LIMIT $2
 	`
 
 	var orders []*order.Order
-	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query, customerID)
-	return orders, err
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query, cutoff, limit); err != nil {
+		return nil, fmt.Errorf("list expired pending orders: %w", err)
+	}
+
+	if err := r.attachItems(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
 }
 
-func (r *OrderRepository) Update(ctx context.Context, orderID uuid.UUID, column string, value any) error {
-	allowed := map[string]bool{
-		"status":           true,
-		"quantity":         true,
-		"pickup_address":   true,
-		"delivery_address": true,
+func (r *OrderRepository) HasCompletedPayment(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	// Payments reference the order, so an insert still in flight holds a
+	// key share lock on it and the FOR UPDATE waits for it to commit. The
+	// check runs as its own statement to see such a payment.
+	var locked int
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &locked, `SELECT 1 FROM orders WHERE id = $1 FOR UPDATE`, orderID); err != nil {
+		return false, fmt.Errorf("lock order: %w", err)
 	}
 
-	if !allowed[column] {
-		return fmt.Errorf("attempted to update disallowed column: %s", column)
+	var paid bool
+	query := `SELECT EXISTS (SELECT 1 FROM payments WHERE order_id = $1 AND status = 'completed')`
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &paid, query, orderID); err != nil {
+		return false, fmt.Errorf("check completed payment: %w", err)
 	}
+	return paid, nil
+}
 
-	query := fmt.Sprintf(`
-		UPDATE orders SET %s = :value, updated_at = NOW() 
-		WHERE id = :id
-	`, column)
+func (r *OrderRepository) UpdateRoute(ctx context.Context, o *order.Order) error {
+	query := `
+		UPDATE orders
+		SET pickup_address = :pickup_address,
+			delivery_address = :delivery_address,
+			pickup_point = ST_SetSRID(ST_MakePoint(:pickup_point.x, :pickup_point.y), 4326),
+			delivery_point = ST_SetSRID(ST_MakePoint(:delivery_point.x, :delivery_point.y), 4326),
+			address_id = :address_id,
+			delivery_fee = :delivery_fee, discount = :discount, tax = :tax, total = :total,
+			updated_at = NOW()
+		WHERE id = :id AND status = 'pending'
+	`
 
-	args := map[string]interface{}{
-		"value": value,
-		"id":    orderID,
+	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, o)
+	if err != nil {
+		return fmt.Errorf("update order route: %w", err)
 	}
 
-	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, args)
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return order.ErrorStatusConflict
+	}
+	return nil
+}
+
+func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID uuid.UUID, from, to order.OrderStatus) error {
+	// Compare-and-set: a concurrent transition that got there first leaves
+	// this one with 0 rows instead of silently overwriting it.
+	query := `
+		UPDATE orders
+		SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, orderID, from, to)
 	if err != nil {
-		return fmt.Errorf("update order: %w", err)
+		return fmt.Errorf("update order status: %w", err)
 	}
 
 	rows, err := res.RowsAffected()
@@ -185,34 +361,49 @@ func (r *OrderRepository) Update(ctx context.Context, orderID uuid.UUID, column
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("no order found with id %s", orderID)
+		return order.ErrorStatusConflict
 	}
 
 	return nil
 }
 
-// func (r *OrderRepository) UpdateStatus(orderID uuid.UUID, status order.OrderStatus) error {
-// 	query := `UPDATE orders SET status = $1, updated_at = NOW() WHERE id = $2`
-// 	res, err := r.db.Exec(query, status, orderID)
-// 	if err != nil {
-// 		return err
-// 	}
-// 	rows, _ := res.RowsAffected()
-// 	if rows == 0 {
-// 		return fmt.Errorf("no order found with id %s", orderID)
-// 	}
-// 	return nil
-// }
+func (r *OrderRepository) SetCancellation(ctx context.Context, orderID uuid.UUID, reason string) error {
+	query := `
+		UPDATE orders
+		SET cancel_reason = NULLIF($2, ''), cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, orderID, reason)
+	if err != nil {
+		return fmt.Errorf("set order cancellation: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("no order found with id %s", orderID)
+	}
+
+	return nil
+}
 
 func (r *OrderRepository) List(ctx context.Context) ([]*order.Order, error) {
 	query := `
-		SELECT id, user_id, admin_id, inventory_id, quantity, pickup_address, delivery_address, status, created_at, updated_at, pickup_point, delivery_point
+		SELECT ` + orderColumns + `
 		FROM orders
+		ORDER BY created_at DESC
 	`
 
 	var orders []*order.Order
-	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query)
-	return orders, err
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &orders, query); err != nil {
+		return nil, fmt.Errorf("list orders: %w", err)
+	}
+
+	return orders, r.attachItems(ctx, orders)
 }
 
 func (r *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -221,7 +412,7 @@ func (r *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		WHERE id = $1
 	`
 
-	res, err := r.exec.ExecContext(ctx, query, id)
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete order: %w", err)
 	}
@@ -238,6 +429,50 @@ func (r *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (r *OrderRepository) AddEvent(ctx context.Context, e *order.Event) error {
+	query := `
+		INSERT INTO order_events (
+			order_id, type, field, old_value, new_value, note, actor_id, actor_role
+		)
+		VALUES (
+			:order_id, :type, :field, :old_value, :new_value, :note, :actor_id, :actor_role
+		)
+		RETURNING id, created_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, e)
+	if err != nil {
+		return fmt.Errorf("insert order event: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&e.ID, &e.CreatedAt); err != nil {
+			return fmt.Errorf("scanning new order event id: %w", err)
+		}
+	} else {
+		return fmt.Errorf("no id returned after scan")
+	}
+
+	return nil
+}
+
+func (r *OrderRepository) ListEvents(ctx context.Context, orderID uuid.UUID) ([]*order.Event, error) {
+	query := `
+		SELECT id, order_id, type, field, old_value, new_value, note, actor_id, actor_role, created_at
+		FROM order_events
+		WHERE order_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	events := []*order.Event{}
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &events, query, orderID); err != nil {
+		return nil, fmt.Errorf("list order events: %w", err)
+	}
+
+	return events, nil
+}
+
 func (r *OrderRepository) GetPickupPoint(ctx context.Context, orderID uuid.UUID) (postgis.PointS, error) {
 	var pt postgis.PointS
 	query := `
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type PaymentRepository struct {
@@ -63,8 +64,10 @@ func (r *PaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*payment
 
 func (r *PaymentRepository) GetByOrder(ctx context.Context, orderID uuid.UUID) (*payment.Payment, error) {
 	query := `
-		SELECT id, order_id, amount, status, paid_at FROM payments 
+		SELECT id, order_id, amount, currency, status, paid_at FROM payments 
 		WHERE order_id = $1
+		ORDER BY (status = 'completed') DESC, paid_at DESC NULLS LAST, created_at DESC
+		LIMIT 1
 	`
 
 	var p payment.Payment
@@ -104,3 +107,30 @@ func (r *PaymentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+func (r *PaymentRepository) CreateRefund(ctx context.Context, rf *payment.Refund) error {
+	query := `
+		INSERT INTO refunds (payment_id, order_id, amount, currency, reason, status)
+		VALUES (:payment_id, :order_id, :amount, :currency, :reason, :status)
+		RETURNING id, created_at
+	`
+
+	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, rf)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return payment.ErrorRefundAlreadyRequested
+		}
+		return fmt.Errorf("insert refund: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err := rows.Scan(&rf.ID, &rf.CreatedAt); err != nil {
+			return fmt.Errorf("scanning new refund id: %w", err)
+		}
+	} else {
+		return fmt.Errorf("no id returned after scan")
+	}
+
+	return nil
+}
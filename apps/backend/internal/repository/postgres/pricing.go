@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/pricing"
+	"context"
+	"fmt"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type PricingRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewPricingRepository(db *sqlx.DB) *PricingRepository {
+	return &PricingRepository{exec: db}
+}
+
+func (r *PricingRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+func (r *PricingRepository) ListBands(ctx context.Context, storeID *uuid.UUID) (pricing.Tariff, error) {
+	query := `
+		SELECT id, store_id, max_distance_m, fee, created_at
+		FROM delivery_tariff_bands
+		WHERE store_id IS NOT DISTINCT FROM $1
+		ORDER BY max_distance_m ASC
+	`
+
+	var bands pricing.Tariff
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &bands, query, storeID); err != nil {
+		return nil, fmt.Errorf("list tariff bands: %w", err)
+	}
+
+	return bands, nil
+}
+
+func (r *PricingRepository) ReplaceBands(ctx context.Context, storeID *uuid.UUID, bands pricing.Tariff) error {
+	exec := r.execFromCtx(ctx)
+
+	if _, err := exec.ExecContext(ctx, `DELETE FROM delivery_tariff_bands WHERE store_id IS NOT DISTINCT FROM $1`, storeID); err != nil {
+		return fmt.Errorf("delete tariff bands: %w", err)
+	}
+
+	query := `
+		INSERT INTO delivery_tariff_bands (store_id, max_distance_m, fee)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	for i := range bands {
+		b := &bands[i]
+		b.StoreID = storeID
+		if err := sqlx.GetContext(ctx, exec, b, query, storeID, b.MaxDistanceM, b.Fee); err != nil {
+			return fmt.Errorf("insert tariff band: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *PricingRepository) Distance(ctx context.Context, from, to postgis.PointS) (float64, error) {
+	query := `
+		SELECT ST_Distance(
+			ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
+			ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography
+		)
+	`
+
+	var meters float64
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &meters, query, from.X, from.Y, to.X, to.Y); err != nil {
+		return 0, fmt.Errorf("measure distance: %w", err)
+	}
+
+	return meters, nil
+}
@@ -192,6 +192,29 @@ func (r *ProductRepository) GetOptionIDByName(ctx context.Context, productID uui
 	return optionID, nil
 }
 
+func (r *ProductRepository) GetImageProductID(ctx context.Context, imageID uuid.UUID) (uuid.UUID, error) {
+	return r.productIDOf(ctx, "product_images", imageID)
+}
+
+func (r *ProductRepository) GetOptionProductID(ctx context.Context, optionID uuid.UUID) (uuid.UUID, error) {
+	return r.productIDOf(ctx, "product_options", optionID)
+}
+
+func (r *ProductRepository) GetOptionValueProductID(ctx context.Context, optionValueID uuid.UUID) (uuid.UUID, error) {
+	return r.productIDOf(ctx, "product_option_values", optionValueID)
+}
+
+// productIDOf returns the product_id of the row with id in table.
+func (r *ProductRepository) productIDOf(ctx context.Context, table string, id uuid.UUID) (uuid.UUID, error) {
+	query := fmt.Sprintf(`SELECT product_id FROM %s WHERE id = $1`, table)
+
+	var productID uuid.UUID
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &productID, query, id); err != nil {
+		return uuid.Nil, fmt.Errorf("get %s product id: %w", table, err)
+	}
+	return productID, nil
+}
+
 func (r *ProductRepository) ListOptionsByProductID(ctx context.Context, productID uuid.UUID) ([]product.Option, error) {
 	query := `
 		SELECT id, name
@@ -386,7 +409,7 @@ func (r *ProductRepository) AddVariantOptionValue(ctx context.Context, variantID
 
 func (r *ProductRepository) GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error) {
 	query := `
-		SELECT id, product_id, sku, price, stock, image_url, options 
+		SELECT id, product_id, sku, price, stock, COALESCE(image_url, '') AS image_url
 		FROM variants
 		WHERE id = $1
 	`
@@ -623,6 +646,7 @@ func (r *ProductRepository) GetProductByID(ctx context.Context, id uuid.UUID) (*
 			p.description,
 			p.category,
 			(v.product_id IS NOT NULL) AS has_variants,
+			COALESCE(pi.price, 0) AS price,
 			pi.stock
 		FROM products p
 		LEFT JOIN (
@@ -649,10 +673,10 @@ func (r *ProductRepository) UpdateProductStock(ctx context.Context, productID uu
 	}
 
 	query := `
-		UPDATE products
+		UPDATE product_inventory
 		SET stock = :stock,
 				updated_at = NOW()
-		WHERE id = :product_id
+		WHERE product_id = :product_id
 	`
 	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, params)
 	if err != nil {
@@ -680,6 +704,110 @@ func (r *ProductRepository) UpdateProductStock(ctx context.Context, productID uu
 
 }
 
+func (r *ProductRepository) DecrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error {
+	// Conditional decrement: the row lock taken by UPDATE serialises concurrent
+	// checkouts, and the WHERE clause makes the losing one see 0 rows.
+	query := `
+		UPDATE product_inventory
+		SET stock = stock - $2,
+				updated_at = NOW()
+		WHERE product_id = $1
+			AND stock >= $2
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, productID, quantity)
+	if err != nil {
+		return fmt.Errorf("decrement product stock: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return product.ErrInsufficientStock
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) DecrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error {
+	query := `
+		UPDATE variants
+		SET stock = stock - $2,
+				updated_at = NOW()
+		WHERE id = $1
+			AND stock >= $2
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, variantID, quantity)
+	if err != nil {
+		return fmt.Errorf("decrement variant stock: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return product.ErrInsufficientStock
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) IncrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error {
+	query := `
+		UPDATE product_inventory
+		SET stock = stock + $2,
+				updated_at = NOW()
+		WHERE product_id = $1
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, productID, quantity)
+	if err != nil {
+		return fmt.Errorf("increment product stock: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return product.ErrProductNotFound
+	}
+
+	return nil
+}
+
+func (r *ProductRepository) IncrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error {
+	query := `
+		UPDATE variants
+		SET stock = stock + $2,
+				updated_at = NOW()
+		WHERE id = $1
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, variantID, quantity)
+	if err != nil {
+		return fmt.Errorf("increment variant stock: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return product.ErrVariantNotFound
+	}
+
+	return nil
+}
+
 func (r *ProductRepository) UpdateDetails(ctx context.Context, productID uuid.UUID, name, description, category string) error {
 	params := map[string]interface{}{
 		"product_id":  productID,
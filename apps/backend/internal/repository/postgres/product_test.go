@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"backend/internal/domain/product"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// testDB connects to a migrated database given by TEST_DATABASE_URL,
+// skipping the test when none is configured.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// seedProduct creates a merchant, store and simple product with the given stock.
+func seedProduct(t *testing.T, db *sqlx.DB, stock int) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+
+	var ownerID, storeID, productID uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &ownerID, `
+		INSERT INTO users (full_name, email, password_hash, role, phone)
+		VALUES ('Stock Test', $1, 'x', 'merchant', '+254700000000')
+		RETURNING id
+	`, uuid.NewString()+"@test.local"))
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, ownerID) })
+
+	require.NoError(t, db.GetContext(ctx, &storeID, `
+		INSERT INTO stores (owner_id, name, name_normalized)
+		VALUES ($1, 'Stock Test', 'stock test')
+		RETURNING id
+	`, ownerID))
+
+	require.NoError(t, db.GetContext(ctx, &productID, `
+		INSERT INTO products (store_id, name)
+		VALUES ($1, 'Last Unit')
+		RETURNING id
+	`, storeID))
+
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO product_inventory (product_id, stock, price)
+		VALUES ($1, $2, 100)
+	`, productID, stock)
+	require.NoError(t, err)
+
+	return productID
+}
+
+func TestDecrementProductStock_ConcurrentCannotOversell(t *testing.T) {
+	const stock = 10
+	const buyers = 40
+
+	db := testDB(t)
+	repo := NewProductRepository(db)
+	productID := seedProduct(t, db, stock)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sold := 0
+
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := repo.DecrementProductStock(context.Background(), productID, 1)
+			if err != nil && !errors.Is(err, product.ErrInsufficientStock) {
+				t.Errorf("unexpected error: %v", err)
+				return
+			}
+			if err == nil {
+				mu.Lock()
+				sold++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var left int
+	require.NoError(t, db.Get(&left, `SELECT stock FROM product_inventory WHERE product_id = $1`, productID))
+
+	require.Equal(t, stock, sold)
+	require.Equal(t, 0, left)
+}
@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/promotion"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type PromotionRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewPromotionRepository(db *sqlx.DB) *PromotionRepository {
+	return &PromotionRepository{exec: db}
+}
+
+func (r *PromotionRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+const promotionColumns = `
+	id, code, store_id, product_ids, type, value,
+	min_subtotal, max_redemptions, max_per_customer, redemption_count,
+	starts_at, ends_at, active, created_by, created_at
+`
+
+// promotionRow scans the product scope, kept in a UUID[] column.
+type promotionRow struct {
+	promotion.Promotion
+	ProductIDs pq.StringArray `db:"product_ids"`
+}
+
+func (row *promotionRow) toPromotion() (*promotion.Promotion, error) {
+	p := row.Promotion
+	p.ProductIDs = nil
+	for _, s := range row.ProductIDs {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("parse promotion product id: %w", err)
+		}
+		p.ProductIDs = append(p.ProductIDs, id)
+	}
+	return &p, nil
+}
+
+func (r *PromotionRepository) Create(ctx context.Context, p *promotion.Promotion) error {
+	query := `
+		INSERT INTO promotions (
+			code, store_id, product_ids, type, value,
+			min_subtotal, max_redemptions, max_per_customer,
+			starts_at, ends_at, active, created_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at
+	`
+
+	productIDs := make([]string, len(p.ProductIDs))
+	for i, id := range p.ProductIDs {
+		productIDs[i] = id.String()
+	}
+
+	err := r.execFromCtx(ctx).QueryRowxContext(ctx, query,
+		p.Code, p.StoreID, pq.Array(productIDs), p.Type, p.Value,
+		p.MinSubtotal, p.MaxRedemptions, p.MaxPerCustomer,
+		p.StartsAt, p.EndsAt, p.Active, p.CreatedBy,
+	).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505":
+				return promotion.ErrorPromotionCodeConflict
+			case "23514":
+				return promotion.ErrorInvalidPromotion
+			}
+		}
+		return fmt.Errorf("insert promotion: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PromotionRepository) GetByID(ctx context.Context, id uuid.UUID) (*promotion.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE id = $1`
+
+	var row promotionRow
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &row, query, id); err != nil {
+		return nil, fmt.Errorf("get promotion by id: %w", err)
+	}
+	return row.toPromotion()
+}
+
+func (r *PromotionRepository) GetByCodeForUpdate(ctx context.Context, code string) (*promotion.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1 FOR UPDATE`
+
+	var row promotionRow
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &row, query, code); err != nil {
+		return nil, fmt.Errorf("get promotion by code: %w", err)
+	}
+	return row.toPromotion()
+}
+
+func (r *PromotionRepository) ListByStore(ctx context.Context, storeID *uuid.UUID) ([]*promotion.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE store_id IS NOT DISTINCT FROM $1
+		ORDER BY created_at DESC
+	`
+
+	var rows []promotionRow
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &rows, query, storeID); err != nil {
+		return nil, fmt.Errorf("list promotions: %w", err)
+	}
+
+	promotions := make([]*promotion.Promotion, 0, len(rows))
+	for i := range rows {
+		p, err := rows[i].toPromotion()
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, nil
+}
+
+func (r *PromotionRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, `UPDATE promotions SET active = $2 WHERE id = $1`, id, active)
+	if err != nil {
+		return fmt.Errorf("update promotion: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no promotion found with id %s", id)
+	}
+	return nil
+}
+
+func (r *PromotionRepository) CountCustomerRedemptions(ctx context.Context, promotionID, customerID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM promotion_redemptions
+		WHERE promotion_id = $1 AND customer_id = $2
+	`
+
+	var n int
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &n, query, promotionID, customerID); err != nil {
+		return 0, fmt.Errorf("count promotion redemptions: %w", err)
+	}
+	return n, nil
+}
+
+func (r *PromotionRepository) CreateRedemption(ctx context.Context, red *promotion.Redemption) error {
+	exec := r.execFromCtx(ctx)
+
+	// Conditional bump, like stock decrements: the global limit holds even
+	// if the promotion wasn't locked first.
+	res, err := exec.ExecContext(ctx, `
+		UPDATE promotions
+		SET redemption_count = redemption_count + 1
+		WHERE id = $1 AND (max_redemptions IS NULL OR redemption_count < max_redemptions)
+	`, red.PromotionID)
+	if err != nil {
+		return fmt.Errorf("count promotion redemption: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return promotion.ErrorPromotionExhausted
+	}
+
+	query := `
+		INSERT INTO promotion_redemptions (promotion_id, order_id, customer_id, amount)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err = exec.QueryRowxContext(ctx, query, red.PromotionID, red.OrderID, red.CustomerID, red.Amount).
+		Scan(&red.ID, &red.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert promotion redemption: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PromotionRepository) DeleteRedemption(ctx context.Context, orderID uuid.UUID) error {
+	query := `
+		WITH released AS (
+			DELETE FROM promotion_redemptions
+			WHERE order_id = $1
+			RETURNING promotion_id
+		)
+		UPDATE promotions
+		SET redemption_count = redemption_count - 1
+		WHERE id IN (SELECT promotion_id FROM released)
+	`
+
+	if _, err := r.execFromCtx(ctx).ExecContext(ctx, query, orderID); err != nil {
+		return fmt.Errorf("delete promotion redemption: %w", err)
+	}
+	return nil
+}
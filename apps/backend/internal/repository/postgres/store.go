@@ -4,8 +4,11 @@ import (
 	"backend/internal/application"
 	"backend/internal/domain/store"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
@@ -28,18 +31,37 @@ func (r *StoreRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
 
 func (r *StoreRepository) Create(ctx context.Context, s *store.Store) error {
 	query := `
-    INSERT INTO stores (owner_id, name, name_normalized, location, logo_url)
-		VALUES (:owner_id, :name, :name_normalized, :location, :logo_url)
+    INSERT INTO stores (owner_id, name, name_normalized, slug, location, logo_url, point, service_radius_m)
+		VALUES (
+			:owner_id, :name, :name_normalized, :slug, :location, :logo_url,
+			ST_SetSRID(ST_MakePoint(:lng, :lat), 4326),
+			:service_radius_m
+		)
 		RETURNING id, created_at, updated_at
 	`
 
-	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, s)
+	lng, lat := pointArgs(s.Point)
+	params := map[string]interface{}{
+		"owner_id":         s.OwnerID,
+		"name":             s.Name,
+		"name_normalized":  s.NameNormalized,
+		"slug":             s.Slug,
+		"location":         s.Location,
+		"logo_url":         s.LogoURL,
+		"lng":              lng,
+		"lat":              lat,
+		"service_radius_m": s.ServiceRadiusM,
+	}
+
+	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, params)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code {
-			case "23505":
+			switch {
+			case pqErr.Code == "23505" && pqErr.Constraint == "uniq_stores_slug":
+				return store.ErrSlugTaken
+			case pqErr.Code == "23505":
 				return store.ErrStoreNameConflict
-			case "23502":
+			case pqErr.Code == "23502":
 				return store.ErrInvalidStoreInput
 			}
 		}
@@ -138,6 +160,115 @@ func (r *StoreRepository) UpdateStoreDetails(ctx context.Context, storeID uuid.U
 	return nil
 }
 
+// pointArgs splits an optional point into nullable query arguments;
+// ST_MakePoint of NULLs is NULL.
+func pointArgs(p *postgis.PointS) (lng, lat *float64) {
+	if p == nil {
+		return nil, nil
+	}
+	return &p.X, &p.Y
+}
+
+func (r *StoreRepository) UpdateServiceArea(ctx context.Context, storeID uuid.UUID, point *postgis.PointS, radiusM int) error {
+	query := `
+		UPDATE stores
+		SET point = COALESCE(CAST(ST_SetSRID(ST_MakePoint(:lng, :lat), 4326) AS geography), point),
+			service_radius_m = COALESCE(NULLIF(:service_radius_m, 0), service_radius_m),
+			updated_at = NOW()
+		WHERE id = :store_id
+	`
+
+	lng, lat := pointArgs(point)
+	params := map[string]interface{}{
+		"store_id":         storeID,
+		"lng":              lng,
+		"lat":              lat,
+		"service_radius_m": radiusM,
+	}
+
+	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, params)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" { // check_violation
+			return store.ErrInvalidServiceRadius
+		}
+		return fmt.Errorf("update service area: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return store.ErrStoreNotFound
+	}
+
+	return nil
+}
+
+func (r *StoreRepository) SetPoint(ctx context.Context, storeID uuid.UUID, point *postgis.PointS) error {
+	query := `
+		UPDATE stores
+		SET point = CAST(ST_SetSRID(ST_MakePoint(:lng, :lat), 4326) AS geography),
+			updated_at = NOW()
+		WHERE id = :store_id
+	`
+
+	lng, lat := pointArgs(point)
+	params := map[string]interface{}{
+		"store_id": storeID,
+		"lng":      lng,
+		"lat":      lat,
+	}
+
+	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, params)
+	if err != nil {
+		return fmt.Errorf("set store point: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return store.ErrStoreNotFound
+	}
+
+	return nil
+}
+
+func (r *StoreRepository) ListNearby(ctx context.Context, filter store.NearbyFilter) ([]*store.StoreSummary, error) {
+	query := `
+		WITH here AS (
+			SELECT ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography AS point
+		)
+		SELECT s.id, s.name,
+			COALESCE(s.logo_url, '') AS logo_url,
+			COALESCE(s.location, '') AS location,
+			COALESCE((
+				SELECT AVG(f.rating)
+				FROM feedbacks f
+				JOIN orders o ON o.id = f.order_id
+				WHERE o.store_id = s.id
+			), 0) AS rating,
+			s.service_radius_m,
+			ST_Distance(s.point, here.point) AS distance_m
+		FROM stores s, here
+		WHERE ST_DWithin(s.point, here.point, $3)
+		  AND ST_DWithin(s.point, here.point, s.service_radius_m)
+		ORDER BY distance_m, s.id
+		LIMIT $4
+	`
+
+	stores := []*store.StoreSummary{}
+	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &stores, query, filter.Lng, filter.Lat, filter.RadiusM, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("list nearby stores: %w", err)
+	}
+	return stores, nil
+}
+
 func (r *StoreRepository) ListStores(ctx context.Context) ([]*store.Store, error) {
 	query := `
 		SELECT * FROM stores
@@ -150,8 +281,12 @@ func (r *StoreRepository) ListStores(ctx context.Context) ([]*store.Store, error
 
 func (r *StoreRepository) ListStoresByOwner(ctx context.Context, ownerID uuid.UUID) ([]*store.MyStores, error) {
 	query := `
-		SELECT id, name FROM stores
+		SELECT id, name, 'owner' AS role FROM stores
 		WHERE owner_id = $1
+		UNION ALL
+		SELECT s.id, s.name, m.role FROM stores s
+		JOIN store_members m ON m.store_id = s.id
+		WHERE m.user_id = $1
 	`
 
 	var stores []*store.MyStores
@@ -209,6 +344,20 @@ func (r *StoreRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (r *StoreRepository) ListSlugs(ctx context.Context, base string) ([]string, error) {
+	query := `
+		SELECT slug FROM stores
+		WHERE slug = $1
+		   OR (left(slug, length($1) + 1) = $1 || '-' AND substr(slug, length($1) + 2) ~ '^[0-9]+$')
+	`
+
+	slugs := []string{}
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &slugs, query, base); err != nil {
+		return nil, fmt.Errorf("list slugs: %w", err)
+	}
+	return slugs, nil
+}
+
 func (r *StoreRepository) Exists(ctx context.Context, storeID uuid.UUID) (bool, error) {
 	query := `
 		SELECT EXISTS (
@@ -240,3 +389,75 @@ func (r *StoreRepository) IsOwnedBy(ctx context.Context, storeID uuid.UUID, owne
 	}
 	return owned, nil
 }
+
+func (r *StoreRepository) GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error) {
+	query := `
+		SELECT CASE WHEN s.owner_id = $2 THEN 'owner' ELSE COALESCE(m.role, '') END
+		FROM stores s
+		LEFT JOIN store_members m ON m.store_id = s.id AND m.user_id = $2
+		WHERE s.id = $1
+	`
+
+	var role store.MemberRole
+	err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &role, query, storeID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return role, err
+}
+
+func (r *StoreRepository) AddMember(ctx context.Context, storeID, userID, inviteID uuid.UUID, role store.MemberRole) error {
+	query := `
+		INSERT INTO store_members (store_id, user_id, invite_id, role)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (store_id, user_id) DO NOTHING
+	`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, storeID, userID, inviteID, role)
+	return err
+}
+
+func (r *StoreRepository) ListMembers(ctx context.Context, storeID uuid.UUID) ([]*store.Member, error) {
+	query := `
+		SELECT m.store_id, m.user_id, m.role, m.invite_id, u.full_name, u.email, m.created_at
+		FROM store_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.store_id = $1
+		ORDER BY m.created_at
+	`
+
+	members := []*store.Member{}
+	err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &members, query, storeID)
+	return members, err
+}
+
+func (r *StoreRepository) UpdateMemberRole(ctx context.Context, storeID, userID uuid.UUID, role store.MemberRole) error {
+	query := `UPDATE store_members SET role = $3 WHERE store_id = $1 AND user_id = $2`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, storeID, userID, role)
+	if err != nil {
+		return err
+	}
+	return memberAffected(res)
+}
+
+func (r *StoreRepository) RemoveMember(ctx context.Context, storeID, userID uuid.UUID) error {
+	query := `DELETE FROM store_members WHERE store_id = $1 AND user_id = $2`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, storeID, userID)
+	if err != nil {
+		return err
+	}
+	return memberAffected(res)
+}
+
+func memberAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrMemberNotFound
+	}
+	return nil
+}
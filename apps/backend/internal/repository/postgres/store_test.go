@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStoreSlugMigration runs the slug backfill against a temporary stores
+// table, which shadows the real one for the rest of the transaction.
+func TestStoreSlugMigration(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	migration, err := os.ReadFile("../../../migrations/000056_store_slugs.up.sql")
+	require.NoError(t, err)
+
+	tx, err := db.BeginTxx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `CREATE TEMP TABLE stores (id UUID PRIMARY KEY, name_normalized TEXT NOT NULL, created_at TIMESTAMPTZ NOT NULL)`)
+	require.NoError(t, err)
+
+	// Stores oldest first, with the slug each one should get
+	stores := []struct{ name, slug string }{
+		{"duka", "duka"},
+		{"duka", "duka-2"},
+		{"duka 2", "duka-2-2"},
+		{"shop 2", "shop-2"},
+		{"shop", "shop"},
+		{"shop", "shop-3"},
+		{"nearby", "nearby-2"},
+		{"???", "store"},
+	}
+	ids := make([]uuid.UUID, len(stores))
+	start := time.Now()
+	for i, s := range stores {
+		ids[i] = uuid.New()
+		_, err := tx.ExecContext(ctx, `INSERT INTO stores (id, name_normalized, created_at) VALUES ($1, $2, $3)`,
+			ids[i], s.name, start.Add(time.Duration(i)*time.Minute))
+		require.NoError(t, err)
+	}
+
+	_, err = tx.ExecContext(ctx, string(migration))
+	require.NoError(t, err)
+
+	for i, s := range stores {
+		var slug string
+		require.NoError(t, tx.GetContext(ctx, &slug, `SELECT slug FROM stores WHERE id = $1`, ids[i]))
+		require.Equal(t, s.slug, slug, "store %d named %q", i, s.name)
+	}
+}
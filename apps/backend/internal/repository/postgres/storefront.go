@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"backend/internal/application"
+	"backend/internal/domain/storefront"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type StorefrontRepository struct {
+	exec sqlx.ExtContext
+}
+
+func NewStorefrontRepository(db *sqlx.DB) *StorefrontRepository {
+	return &StorefrontRepository{exec: db}
+}
+
+func (r *StorefrontRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
+	if tx := application.GetTx(ctx); tx != nil {
+		return tx
+	}
+	return r.exec
+}
+
+func (r *StorefrontRepository) GetStoreBySlug(ctx context.Context, slug string) (*storefront.Store, error) {
+	query := `
+		SELECT id, slug, name,
+			COALESCE(logo_url, '') AS logo_url,
+			COALESCE(location, '') AS location,
+			service_radius_m
+		FROM stores
+		WHERE slug = $1
+	`
+
+	var s storefront.Store
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &s, query, slug); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storefront.ErrorStoreNotFound
+		}
+		return nil, fmt.Errorf("get store by slug: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *StorefrontRepository) ListProducts(ctx context.Context, storeID uuid.UUID, limit, offset int) ([]storefront.Product, int, error) {
+	// A stock of NULL is not tracked and counts as in stock
+	query := `
+		SELECT
+			p.id,
+			p.name,
+			COALESCE(p.description, '') AS description,
+			COALESCE(p.category, '') AS category,
+			COALESCE(img.url, '') AS image_url,
+			v.count > 0 AS has_variants,
+			CASE WHEN v.count > 0 THEN v.min_price ELSE COALESCE(pi.price, 0) END AS min_price,
+			CASE WHEN v.count > 0 THEN v.max_price ELSE COALESCE(pi.price, 0) END AS max_price,
+			CASE WHEN v.count > 0 THEN v.in_stock ELSE COALESCE(pi.stock, 1) > 0 END AS in_stock,
+			count(*) OVER () AS total
+		FROM products p
+
+		-- Primary image
+		LEFT JOIN LATERAL (
+			SELECT url
+			FROM product_images
+			WHERE product_id = p.id
+			ORDER BY is_primary DESC, position ASC
+			LIMIT 1
+		) img ON true
+
+		-- Simple inventory
+		LEFT JOIN product_inventory pi ON pi.product_id = p.id
+
+		-- Variants
+		CROSS JOIN LATERAL (
+			SELECT count(*) AS count,
+				MIN(price) AS min_price,
+				MAX(price) AS max_price,
+				COALESCE(bool_or(stock > 0), false) AS in_stock
+			FROM variants
+			WHERE product_id = p.id
+		) v
+
+		WHERE p.store_id = $1
+		ORDER BY p.name, p.id
+		LIMIT $2 OFFSET $3
+	`
+
+	var rows []struct {
+		storefront.Product
+		Total int `db:"total"`
+	}
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &rows, query, storeID, limit, offset); err != nil {
+		return nil, 0, fmt.Errorf("list storefront products: %w", err)
+	}
+
+	products := make([]storefront.Product, len(rows))
+	for i, row := range rows {
+		products[i] = row.Product
+	}
+
+	if len(rows) > 0 {
+		return products, rows[0].Total, nil
+	}
+
+	// Past the last page the window count is gone; count separately
+	var total int
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &total, `SELECT count(*) FROM products WHERE store_id = $1`, storeID); err != nil {
+		return nil, 0, fmt.Errorf("count storefront products: %w", err)
+	}
+	return products, total, nil
+}
+
+func (r *StorefrontRepository) GetRatingSummary(ctx context.Context, storeID uuid.UUID) (*storefront.RatingSummary, error) {
+	query := `
+		SELECT f.rating, count(*) AS count
+		FROM feedbacks f
+		JOIN orders o ON o.id = f.order_id
+		WHERE o.store_id = $1
+		GROUP BY f.rating
+	`
+
+	var rows []struct {
+		Rating int `db:"rating"`
+		Count  int `db:"count"`
+	}
+	if err := sqlx.SelectContext(ctx, r.execFromCtx(ctx), &rows, query, storeID); err != nil {
+		return nil, fmt.Errorf("get rating summary: %w", err)
+	}
+
+	stars := make(map[int]int, len(rows))
+	for _, row := range rows {
+		stars[row.Rating] = row.Count
+	}
+	return storefront.SummarizeRatings(stars), nil
+}
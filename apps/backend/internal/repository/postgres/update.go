@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// setClause collects the columns of a partial update. Values become query
+// parameters; column names only ever come from the repositories, never from
+// requests.
+type setClause struct {
+	columns []string
+	args    []any
+}
+
+// add sets column to value.
+func (s *setClause) add(column string, value any) {
+	s.args = append(s.args, value)
+	s.columns = append(s.columns, fmt.Sprintf("%s = $%d", column, len(s.args)))
+}
+
+// addExpr sets column to an SQL expression of value, written with a single
+// %s for the value's parameter.
+func (s *setClause) addExpr(column, expr string, value any) {
+	s.args = append(s.args, value)
+	s.columns = append(s.columns, column+" = "+fmt.Sprintf(expr, fmt.Sprintf("$%d", len(s.args))))
+}
+
+func (s *setClause) empty() bool {
+	return len(s.columns) == 0
+}
+
+// update returns the statement updating the row of table with id, along
+// with its parameters. updated_at is always bumped.
+func (s *setClause) update(table string, id uuid.UUID) (string, []any) {
+	args := append(s.args, id)
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET %s, updated_at = NOW()
+		WHERE id = $%d
+	`, table, strings.Join(s.columns, ", "), len(args))
+	return query, args
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetClause(t *testing.T) {
+	var set setClause
+	require.True(t, set.empty())
+
+	set.add("full_name", "Jane")
+	set.addExpr("email_verified_at", "CASE WHEN email = %s THEN email_verified_at END", "jane@example.com")
+	require.False(t, set.empty())
+
+	id := uuid.New()
+	query, args := set.update("users", id)
+	require.Equal(t,
+		"UPDATE users SET full_name = $1, email_verified_at = CASE WHEN email = $2 THEN email_verified_at END, updated_at = NOW() WHERE id = $3",
+		strings.Join(strings.Fields(query), " "))
+	require.Equal(t, []any{"Jane", "jane@example.com", id}, args)
+}
@@ -5,6 +5,7 @@ import (
 	"backend/internal/domain/user"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
@@ -28,8 +29,8 @@ func (r *UserRepository) execFromCtx(ctx context.Context) sqlx.ExtContext {
 
 func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
 	query := `
-		INSERT INTO users (full_name, email, password_hash, role, status, phone, slug)
-		VALUES (:full_name, :email, :password_hash, :role, :status, :phone, :slug)
+		INSERT INTO users (full_name, email, password_hash, role, status, phone, slug, email_verified_at, phone_verified_at)
+		VALUES (:full_name, :email, :password_hash, :role, :status, :phone, :slug, :email_verified_at, :phone_verified_at)
 		RETURNING id
 	`
 	rows, err := sqlx.NamedQueryContext(ctx, r.execFromCtx(ctx), query, u)
@@ -60,7 +61,9 @@ func (r *UserRepository) Create(ctx context.Context, u *user.User) error {
 func (r *UserRepository) UpdateDriverProfile(ctx context.Context, userID uuid.UUID, phone string) error {
 	query := `
 		UPDATE users
-		SET phone = :phone, updated_at = NOW()
+		SET phone = :phone,
+			phone_verified_at = CASE WHEN phone = :phone THEN phone_verified_at END,
+			updated_at = NOW()
 		WHERE id = :id
 	`
 
@@ -97,6 +100,7 @@ func (r *UserRepository) UpdateUserProfile(ctx context.Context, userID uuid.UUID
 		UPDATE users
 		SET 
 			phone = :phone,
+			phone_verified_at = CASE WHEN phone = :phone THEN phone_verified_at END,
 			email = :email,
 			full_name = :name,
 			updated_at = NOW()
@@ -170,37 +174,32 @@ func (r *UserRepository) UpdateUserStatus(ctx context.Context, userID uuid.UUID,
 	return nil
 }
 
-func (r *UserRepository) UpdateColumn(ctx context.Context, userID uuid.UUID, column string, value any) error {
-	allowed := map[string]bool{
-		"full_name":     true,
-		"email":         true,
-		"phone":         true,
-		"role":          true,
-		"status":        true,
-		"last_login":    true,
-		"password_hash": true,
+// Update sets the fields of req that are set. A new email address has to be
+// verified again.
+func (r *UserRepository) Update(ctx context.Context, userID uuid.UUID, req *user.UpdateUserRequest) error {
+	var set setClause
+	if req.FullName != nil {
+		set.add("full_name", *req.FullName)
 	}
-
-	if !allowed[column] {
-		return fmt.Errorf("%w: %s", user.ErrInvalidColumn, column)
+	if req.Email != nil {
+		set.add("email", *req.Email)
+		set.addExpr("email_verified_at", "CASE WHEN email = %s THEN email_verified_at END", *req.Email)
 	}
-
-	query := fmt.Sprintf(`
-		UPDATE users SET %s = :value, updated_at = NOW() 
-		WHERE id = :id
-	`, column)
-
-	args := map[string]interface{}{
-		"value": value,
-		"id":    userID,
+	if req.Phone != nil {
+		set.add("phone", *req.Phone)
+		set.addExpr("phone_verified_at", "CASE WHEN phone = %s THEN phone_verified_at END", *req.Phone)
+	}
+	if set.empty() {
+		return user.ErrNothingToUpdate
 	}
 
-	res, err := sqlx.NamedExecContext(ctx, r.execFromCtx(ctx), query, args)
+	query, args := set.update("users", userID)
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, args...)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code {
-			case "23503": // foreign_key_violation
-				return user.ErrInvalidUserId
+			case "23505": // unique violation
+				return user.ErrUserAlreadyExists
 			case "23502": // not_null_violation
 				return user.ErrInvalidDataInput
 			}
@@ -220,9 +219,61 @@ func (r *UserRepository) UpdateColumn(ctx context.Context, userID uuid.UUID, col
 	return nil
 }
 
+func (r *UserRepository) UpdateLastLogin(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	query := `UPDATE users SET last_login = $2 WHERE id = $1`
+
+	_, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, at)
+	return err
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $2, must_change_password = FALSE, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW()
+		WHERE id = $1
+	`
+
+	res, err := r.execFromCtx(ctx).ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected: %w", err)
+	}
+	if rows == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*user.User, error) {
 	query := `
-		SELECT id, full_name, email, password_hash, role, status, last_login, phone, slug 
+		SELECT id, full_name, email, password_hash, role, status, last_login, created_at, phone, slug,
+			must_change_password, email_verified_at, phone_verified_at
 		FROM users 
 		WHERE id = $1
 	`
@@ -234,9 +285,10 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*user.User,
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.User, error) {
 	query := `
-		SELECT id, full_name, email, password_hash, role, status, last_login, created_at, phone, slug
+		SELECT id, full_name, email, password_hash, role, status, last_login, created_at, phone, slug,
+			must_change_password, email_verified_at, phone_verified_at
 		FROM users 
-		WHERE email = $1
+		WHERE email = $1 AND email <> ''
 	`
 
 	var u user.User
@@ -244,6 +296,23 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*user.Us
 	return &u, err
 }
 
+// GetCustomerByVerifiedPhone finds the customer who verified a normalised
+// number by signing in with it.
+func (r *UserRepository) GetCustomerByVerifiedPhone(ctx context.Context, phone string) (*user.User, error) {
+	query := `
+		SELECT id, full_name, email, password_hash, role, status, last_login, created_at, phone, slug,
+			must_change_password, email_verified_at, phone_verified_at
+		FROM users
+		WHERE role = 'customer' AND phone = $1 AND phone_verified_at IS NOT NULL
+	`
+
+	var u user.User
+	if err := sqlx.GetContext(ctx, r.execFromCtx(ctx), &u, query, phone); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 func (r *UserRepository) List(ctx context.Context) ([]*user.User, error) {
 	query := `
 		SELECT id, full_name, email, password_hash, role, status, last_login, phone, slug 
@@ -0,0 +1,178 @@
+package router
+
+import (
+	"backend/internal/domain/apikey"
+	"backend/internal/domain/authz"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	authzUsecase "backend/internal/usecase/authz"
+	"net/http"
+)
+
+var (
+	anyone    = []user.Role{user.Admin, user.Merchant, user.Driver, user.Customer, user.Guest}
+	admins    = []user.Role{user.Admin}
+	merchants = []user.Role{user.Merchant, user.Admin}
+	customers = []user.Role{user.Customer}
+	drivers   = []user.Role{user.Driver, user.Admin}
+
+	// orderParties may act on orders through the order endpoints. Drivers
+	// move orders through their delivery instead.
+	orderParties = []user.Role{user.Customer, user.Merchant, user.Admin}
+)
+
+// NewPolicy declares who may call each protected route. A route registered
+// without an entry here is refused for everyone, and the router tests fail.
+// Rules with a Scope are also open to merchant API keys granting it.
+func NewPolicy(az *authzUsecase.UseCase) authzUsecase.Policy {
+	self := authzUsecase.Self
+	staff := az.StoreStaff
+	keyStore := authzUsecase.KeyStore
+	body := authzUsecase.FromBody
+
+	rules := map[string]map[string]authz.Rule{
+		// Users
+		"/api/users/all_users":                   {http.MethodGet: {Roles: admins}},
+		"/api/users/logout":                      {http.MethodPost: {Roles: anyone, DuringPasswordChange: true, DuringTwoFactorEnrolment: true}},
+		"/api/users/by-id/{id}":                  {http.MethodGet: {Roles: anyone, Check: self("id")}},
+		"/api/users/by-email/{email}":            {http.MethodGet: {Roles: admins}},
+		"/api/users/{id}/driver_profile":         {http.MethodPatch: {Roles: drivers, Check: self("id")}},
+		"/api/users/{id}/profile":                {http.MethodPatch: {Roles: anyone, Check: self("id")}},
+		"/api/users/{id}/update":                 {http.MethodPatch: {Roles: anyone, Check: self("id")}},
+		"/api/users/{id}/password":               {http.MethodPut: {Roles: anyone, Check: self("id"), DuringPasswordChange: true, DuringTwoFactorEnrolment: true}},
+		"/api/users/{id}/status":                 {http.MethodPatch: {Roles: admins}},
+		"/api/users/{id}/unlock":                 {http.MethodPost: {Roles: admins}},
+		"/api/users/{id}":                        {http.MethodDelete: {Roles: anyone, Check: self("id")}},
+		"/api/users/{id}/addresses":              {http.MethodPost: {Roles: anyone, Check: self("id")}, http.MethodGet: {Roles: anyone, Check: self("id")}},
+		"/api/users/{id}/addresses/{address_id}": {http.MethodGet: {Roles: anyone, Check: self("id")}, http.MethodPatch: {Roles: anyone, Check: self("id")}, http.MethodDelete: {Roles: anyone, Check: self("id")}},
+
+		// Two-factor authentication, for admins and merchants
+		"/api/users/2fa":                     {http.MethodGet: {Roles: merchants, DuringTwoFactorEnrolment: true}},
+		"/api/users/2fa/enrol":               {http.MethodPost: {Roles: merchants, DuringTwoFactorEnrolment: true}},
+		"/api/users/2fa/confirm":             {http.MethodPost: {Roles: merchants, DuringTwoFactorEnrolment: true}},
+		"/api/users/2fa/disable":             {http.MethodPost: {Roles: merchants}},
+		"/api/users/2fa/recovery-codes":      {http.MethodPost: {Roles: merchants}},
+		"/api/users/2fa/requirements":        {http.MethodGet: {Roles: admins}},
+		"/api/users/2fa/requirements/{role}": {http.MethodPut: {Roles: admins}},
+
+		// API keys, managed with an access token only
+		"/api/api-keys/create":      {http.MethodPost: {Roles: []user.Role{user.Merchant}}},
+		"/api/api-keys/me":          {http.MethodGet: {Roles: []user.Role{user.Merchant}}},
+		"/api/api-keys/{id}/revoke": {http.MethodPost: {Roles: []user.Role{user.Merchant}}},
+
+		// Invites
+		"/api/invites/create":      {http.MethodPost: {Roles: merchants, Check: body(staff("store_id", store.PermManageStaff))}},
+		"/api/invites/{id}/resend": {http.MethodPost: {Roles: merchants, Check: az.InviteManager("id")}},
+		"/api/invites/{id}/revoke": {http.MethodPost: {Roles: merchants, Check: az.InviteManager("id")}},
+		"/api/invites/by-token":    {http.MethodGet: {Roles: anyone}},
+		"/api/invites/all_invites": {http.MethodGet: {Roles: admins}},
+		"/api/invites/{id}":        {http.MethodDelete: {Roles: admins}},
+
+		// Orders
+		"/api/orders/create":                    {http.MethodPost: {Roles: customers}},
+		"/api/orders/pending":                   {http.MethodPost: {Roles: customers}},
+		"/api/orders/quote":                     {http.MethodPost: {Roles: customers}},
+		"/api/orders/all_orders":                {http.MethodGet: {Roles: anyone, Check: keyStore("store_id"), Scope: apikey.ScopeOrdersRead}},
+		"/api/orders/assign":                    {http.MethodPost: {Roles: admins}},
+		"/api/orders/by-id/{id}":                {http.MethodGet: {Roles: anyone, Check: az.OrderParticipant("id"), Scope: apikey.ScopeOrdersRead}},
+		"/api/orders/by-customer/{customer_id}": {http.MethodGet: {Roles: []user.Role{user.Customer, user.Admin}, Check: self("customer_id")}},
+		"/api/orders/{id}/update":               {http.MethodPatch: {Roles: orderParties, Check: az.OrderParticipant("id"), Scope: apikey.ScopeOrdersWrite}},
+		"/api/orders/{id}/status":               {http.MethodPut: {Roles: orderParties, Check: az.OrderParticipant("id"), Scope: apikey.ScopeOrdersWrite}},
+		"/api/orders/{id}/timeline":             {http.MethodGet: {Roles: orderParties, Check: az.OrderParticipant("id"), Scope: apikey.ScopeOrdersRead}},
+		"/api/orders/{id}/cancel":               {http.MethodPost: {Roles: orderParties, Check: az.OrderParticipant("id"), Scope: apikey.ScopeOrdersWrite}},
+		"/api/orders/{id}":                      {http.MethodDelete: {Roles: admins}},
+
+		// Carts
+		"/api/carts/me":                         {http.MethodGet: {Roles: customers}},
+		"/api/carts/{store_id}":                 {http.MethodGet: {Roles: customers}, http.MethodDelete: {Roles: customers}},
+		"/api/carts/{store_id}/items":           {http.MethodPost: {Roles: customers}},
+		"/api/carts/{store_id}/items/{item_id}": {http.MethodPut: {Roles: customers}, http.MethodDelete: {Roles: customers}},
+		"/api/carts/{store_id}/checkout":        {http.MethodPost: {Roles: customers}},
+
+		// Pricing, store ownership is checked by the pricing usecase
+		"/api/pricing/tariffs": {http.MethodGet: {Roles: anyone}, http.MethodPut: {Roles: merchants}},
+
+		// Promotions, store ownership is checked by the promotion usecase
+		"/api/promotions/create":          {http.MethodPost: {Roles: merchants}},
+		"/api/promotions/all_promotions":  {http.MethodGet: {Roles: merchants}},
+		"/api/promotions/{id}/deactivate": {http.MethodPut: {Roles: merchants}},
+
+		// Drivers
+		"/api/drivers/all_drivers":      {http.MethodGet: {Roles: admins}},
+		"/api/drivers/by-id/{id}":       {http.MethodGet: {Roles: drivers, Check: self("id")}},
+		"/api/drivers/by-email/{email}": {http.MethodGet: {Roles: admins}},
+		"/api/drivers/{id}/profile":     {http.MethodPatch: {Roles: drivers, Check: self("id")}},
+		"/api/drivers/{id}/update":      {http.MethodPatch: {Roles: drivers, Check: self("id")}},
+		"/api/drivers/{id}":             {http.MethodDelete: {Roles: admins}},
+
+		// Deliveries
+		"/api/deliveries/all_deliveries": {http.MethodGet: {Roles: admins}},
+		"/api/deliveries/by-id/{id}":     {http.MethodGet: {Roles: drivers, Check: az.DeliveryAssignee("id")}},
+		"/api/deliveries/{id}/update":    {http.MethodPatch: {Roles: admins}},
+		"/api/deliveries/{id}/status":    {http.MethodPut: {Roles: drivers, Check: az.DeliveryAssignee("id")}},
+		"/api/deliveries/{id}/accept":    {http.MethodPut: {Roles: []user.Role{user.Driver}}},
+		"/api/deliveries/{id}":           {http.MethodDelete: {Roles: admins}},
+
+		// Payments
+		"/api/payments/create":         {http.MethodPost: {Roles: orderParties, Check: body(az.OrderParticipant("order_id"))}},
+		"/api/payments/all_payments":   {http.MethodGet: {Roles: admins}},
+		"/api/payments/{order_id}":     {http.MethodGet: {Roles: anyone, Check: az.OrderParticipant("order_id")}},
+		"/api/payments/mpesa-express":  {http.MethodPost: {Roles: customers}},
+		"/api/payments/mpesa-callback": {http.MethodPost: {Roles: anyone}},
+
+		// Feedbacks
+		"/api/feedbacks/create":        {http.MethodPost: {Roles: customers, Check: body(az.OrderParticipant("order_id"))}},
+		"/api/feedbacks/all_feedbacks": {http.MethodGet: {Roles: admins}},
+		"/api/feedbacks/{id}":          {http.MethodGet: {Roles: anyone}},
+
+		// Notifications
+		"/api/notifications/create":                    {http.MethodPost: {Roles: admins}},
+		"/api/notifications/all_pending_notifications": {http.MethodGet: {Roles: admins}},
+		"/api/notifications/all_my_notifications/{id}": {http.MethodGet: {Roles: anyone, Check: self("id")}},
+		"/api/notifications/{id}/status":               {http.MethodPut: {Roles: admins}},
+		"/api/notifications/{id}/read":                 {http.MethodPatch: {Roles: anyone}},
+		"/api/notifications/mark_all_as_read/{id}":     {http.MethodPatch: {Roles: anyone, Check: self("id")}},
+
+		// Stores, merchants act on the stores they own or are staff of, as far
+		// as their store role permits
+		"/api/stores/create":                 {http.MethodPost: {Roles: []user.Role{user.Merchant}}},
+		"/api/stores/all_stores":             {http.MethodGet: {Roles: admins}},
+		"/api/stores/me":                     {http.MethodGet: {Roles: []user.Role{user.Merchant}, Scope: apikey.ScopeStoresRead}},
+		"/api/stores/me/paged":               {http.MethodGet: {Roles: []user.Role{user.Merchant}, Scope: apikey.ScopeStoresRead}},
+		"/api/stores/by-id/{id}":             {http.MethodGet: {Roles: anyone, Check: keyStore("id"), Scope: apikey.ScopeStoresRead}},
+		"/api/stores/{id}/summary":           {http.MethodGet: {Roles: anyone, Check: keyStore("id"), Scope: apikey.ScopeStoresRead}},
+		"/api/stores/{id}/update":            {http.MethodPut: {Roles: []user.Role{user.Merchant}, Check: staff("id", store.PermManageStore)}},
+		"/api/stores/{id}/delete":            {http.MethodDelete: {Roles: []user.Role{user.Merchant}, Check: staff("id", store.PermDeleteStore)}},
+		"/api/stores/{id}/members":           {http.MethodGet: {Roles: []user.Role{user.Merchant}, Check: staff("id", store.PermManageStaff)}},
+		"/api/stores/{id}/members/{user_id}": {http.MethodPatch: {Roles: []user.Role{user.Merchant}, Check: staff("id", store.PermManageStaff)}, http.MethodDelete: {Roles: []user.Role{user.Merchant}, Check: staff("id", store.PermManageStaff)}},
+
+		// Products, ids in the body are checked like ids in the path
+		"/api/products/cloudinary/signature":            {http.MethodPost: {Roles: merchants, Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/create":                          {http.MethodPost: {Roles: merchants, Check: body(staff("store_id", store.PermManageCatalog)), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/images/add":                      {http.MethodPost: {Roles: merchants, Check: body(az.ProductEditor("product_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/options/add":                     {http.MethodPost: {Roles: merchants, Check: body(az.ProductEditor("product_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/options/values/add":              {http.MethodPost: {Roles: merchants, Check: body(az.ProductEditor("product_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/variants/add":                    {http.MethodPost: {Roles: merchants, Check: body(az.ProductEditor("product_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/variants/stock/update":           {http.MethodPatch: {Roles: merchants, Check: body(az.VariantEditor("variant_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/variants/price/update":           {http.MethodPatch: {Roles: merchants, Check: body(az.VariantEditor("variant_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/images/reorder":                  {http.MethodPatch: {Roles: merchants, Check: body(az.ProductEditor("product_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/inventory":                       {http.MethodPatch: {Roles: merchants, Check: body(az.ProductEditor("product_id")), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/{store_id}/all_products":         {http.MethodGet: {Roles: anyone, Check: keyStore("store_id"), Scope: apikey.ScopeCatalogRead}},
+		"/api/products/{id}/product_details":            {http.MethodPatch: {Roles: merchants, Check: az.ProductEditor("id"), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/{productId}/options":             {http.MethodGet: {Roles: anyone, Scope: apikey.ScopeCatalogRead}},
+		"/api/products/by-id/{id}":                      {http.MethodGet: {Roles: anyone, Scope: apikey.ScopeCatalogRead}},
+		"/api/products/{id}/delete":                     {http.MethodDelete: {Roles: merchants, Check: az.ProductEditor("id"), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/images/{imageId}/delete":         {http.MethodDelete: {Roles: merchants, Check: az.ImageEditor("imageId"), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/options/{optionId}/delete":       {http.MethodDelete: {Roles: merchants, Check: az.OptionEditor("optionId"), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/options/values/{valueId}/delete": {http.MethodDelete: {Roles: merchants, Check: az.OptionValueEditor("valueId"), Scope: apikey.ScopeCatalogWrite}},
+		"/api/products/variants/{variantId}/delete":     {http.MethodDelete: {Roles: merchants, Check: az.VariantEditor("variantId"), Scope: apikey.ScopeCatalogWrite}},
+	}
+
+	policy := authzUsecase.Policy{}
+	for pattern, methods := range rules {
+		for method, rule := range methods {
+			policy[authz.Route(method, pattern)] = rule
+		}
+	}
+	return policy
+}
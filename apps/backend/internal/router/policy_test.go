@@ -0,0 +1,498 @@
+package router
+
+import (
+	"backend/internal/domain/apikey"
+	"backend/internal/domain/auth"
+	"backend/internal/domain/authz"
+	"backend/internal/domain/delivery"
+	"backend/internal/domain/invite"
+	"backend/internal/domain/order"
+	"backend/internal/domain/product"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	authMiddleware "backend/internal/middleware"
+	authzUsecase "backend/internal/usecase/authz"
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+type fakeCatalog struct {
+	owners   map[uuid.UUID]uuid.UUID                      // store -> owner
+	staff    map[uuid.UUID]map[uuid.UUID]store.MemberRole // store -> user -> role
+	products map[uuid.UUID]*product.Product
+	variants map[uuid.UUID]*product.Variant
+	parts    map[uuid.UUID]uuid.UUID // image, option or option value -> product
+}
+
+func (f *fakeCatalog) GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error) {
+	if f.owners[storeID] == userID {
+		return store.MemberOwner, nil
+	}
+	return f.staff[storeID][userID], nil
+}
+
+func (f *fakeCatalog) GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error) {
+	if p, ok := f.products[id]; ok {
+		return p, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCatalog) GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error) {
+	if v, ok := f.variants[id]; ok {
+		return v, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCatalog) GetImageProductID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return f.productOf(id)
+}
+
+func (f *fakeCatalog) GetOptionProductID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return f.productOf(id)
+}
+
+func (f *fakeCatalog) GetOptionValueProductID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return f.productOf(id)
+}
+
+func (f *fakeCatalog) productOf(id uuid.UUID) (uuid.UUID, error) {
+	if p, ok := f.parts[id]; ok {
+		return p, nil
+	}
+	return uuid.Nil, sql.ErrNoRows
+}
+
+type fakeOrders map[uuid.UUID]*order.Order
+
+func (f fakeOrders) GetByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	if o, ok := f[id]; ok {
+		return o, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeDeliveries map[uuid.UUID]*delivery.Delivery
+
+func (f fakeDeliveries) GetByID(ctx context.Context, id uuid.UUID) (*delivery.Delivery, error) {
+	if d, ok := f[id]; ok {
+		return d, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f fakeDeliveries) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*delivery.Delivery, error) {
+	for _, d := range f {
+		if d.OrderID == orderID {
+			return d, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeInvites map[uuid.UUID]*invite.Invite
+
+func (f fakeInvites) GetByID(ctx context.Context, id uuid.UUID) (*invite.Invite, error) {
+	if i, ok := f[id]; ok {
+		return i, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+var (
+	adminID    = uuid.New()
+	merchantID = uuid.New()
+	customerID = uuid.New()
+	driverID   = uuid.New()
+	otherID    = uuid.New()
+	editorID   = uuid.New()
+	fulfilerID = uuid.New()
+
+	storeID    = uuid.New()
+	productID  = uuid.New()
+	variantID  = uuid.New()
+	imageID    = uuid.New()
+	optionID   = uuid.New()
+	valueID    = uuid.New()
+	orderID    = uuid.New()
+	deliveryID = uuid.New()
+	inviteID   = uuid.New()
+)
+
+func newTestAuthz() *authzUsecase.UseCase {
+	catalog := &fakeCatalog{
+		owners: map[uuid.UUID]uuid.UUID{storeID: merchantID},
+		staff: map[uuid.UUID]map[uuid.UUID]store.MemberRole{
+			storeID: {editorID: store.MemberCatalogEditor, fulfilerID: store.MemberFulfilment},
+		},
+		products: map[uuid.UUID]*product.Product{productID: {ID: productID, StoreID: storeID}},
+		variants: map[uuid.UUID]*product.Variant{variantID: {ID: variantID, ProductID: productID}},
+		parts:    map[uuid.UUID]uuid.UUID{imageID: productID, optionID: productID, valueID: productID},
+	}
+	orders := fakeOrders{orderID: {ID: orderID, StoreID: storeID, CustomerID: customerID, MerchantID: merchantID}}
+	deliveries := fakeDeliveries{deliveryID: {ID: deliveryID, OrderID: orderID, DriverID: driverID}}
+	invites := fakeInvites{inviteID: {ID: inviteID, StoreID: &storeID, InvitedBy: adminID}}
+	return authzUsecase.NewUseCase(catalog, catalog, orders, deliveries, invites)
+}
+
+// openSessions treats every session as open. Users mapped to true must
+// change their password.
+type openSessions map[uuid.UUID]bool
+
+func (s openSessions) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) (*auth.SessionState, error) {
+	return &auth.SessionState{UserID: userID, Status: user.Active, MustChangePassword: s[userID]}, nil
+}
+
+// enrolSessions treats every session as open. Users mapped to true must set
+// up two-factor authentication.
+type enrolSessions map[uuid.UUID]bool
+
+func (s enrolSessions) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) (*auth.SessionState, error) {
+	return &auth.SessionState{UserID: userID, Status: user.Active, MustEnrolTwoFactor: s[userID]}, nil
+}
+
+// fakeKeys maps raw API keys to their grants.
+type fakeKeys map[string]*apikey.Grant
+
+func (f fakeKeys) AuthenticateKey(ctx context.Context, raw string) (*apikey.Grant, error) {
+	if g, ok := f[raw]; ok {
+		return g, nil
+	}
+	return nil, apikey.ErrorInvalidKey
+}
+
+// otherStoreID is a store the merchant works in besides storeID.
+var otherStoreID = uuid.New()
+
+var testKeys = fakeKeys{
+	"fbk_catalog": {UserID: merchantID, Role: user.Merchant, Scopes: []apikey.Scope{apikey.ScopeCatalogRead, apikey.ScopeCatalogWrite}},
+	"fbk_store":   {UserID: merchantID, Role: user.Merchant, Scopes: apikey.Scopes, StoreID: &storeID},
+	"fbk_other":   {UserID: merchantID, Role: user.Merchant, Scopes: apikey.Scopes, StoreID: &otherStoreID},
+}
+
+type route struct {
+	method, pattern string
+}
+
+// protectedRoutes walks the real router and returns every route behind the
+// JWT middleware. Handlers are never called, so they can be nil.
+func protectedRoutes(t *testing.T) []route {
+	r := NewRouter(nil, nil, nil, nil, nil, nil, nil, "", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, newTestAuthz(), openSessions{}, fakeKeys{})
+
+	var routes []route
+	err := chi.Walk(r.(chi.Routes), func(method, pattern string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(pattern, "/api/") ||
+			strings.HasPrefix(pattern, "/api/public/") ||
+			strings.HasPrefix(pattern, "/api/swagger/") {
+			return nil
+		}
+		routes = append(routes, route{method, pattern})
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, routes)
+	return routes
+}
+
+func TestPolicy_CoversEveryRoute(t *testing.T) {
+	policy := NewPolicy(newTestAuthz())
+	routes := protectedRoutes(t)
+
+	registered := map[string]bool{}
+	for _, rt := range routes {
+		key := authz.Route(rt.method, rt.pattern)
+		registered[key] = true
+		require.Contains(t, policy, key, "route has no policy")
+		require.NotEmpty(t, policy[key].Roles, "route allows no role: %s", key)
+	}
+	for key := range policy {
+		require.True(t, registered[key], "policy for unknown route: %s", key)
+	}
+}
+
+func TestRouter_RequiresTokenOnEveryRoute(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	r := NewRouter(nil, nil, nil, nil, nil, nil, nil, "", nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, newTestAuthz(), openSessions{}, fakeKeys{})
+
+	for _, rt := range protectedRoutes(t) {
+		req := httptest.NewRequest(rt.method, samplePath(rt.pattern), nil)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusUnauthorized, rec.Code, "%s %s", rt.method, rt.pattern)
+	}
+}
+
+// samplePath fills the URL parameters of a pattern with a random ID.
+func samplePath(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, "{") {
+			parts[i] = uuid.NewString()
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// newStubRouter registers every protected route of the real router behind
+// the real authentication and authorization middleware, with a handler
+// answering 204. testKeys are the API keys it accepts.
+func newStubRouter(t *testing.T, sessions authMiddleware.SessionValidator) http.Handler {
+	r := chi.NewRouter()
+	r.Group(func(g chi.Router) {
+		g.Use(authMiddleware.AuthMiddleware(sessions, testKeys))
+		g.Use(authMiddleware.Authorize(r, NewPolicy(newTestAuthz())))
+		for _, rt := range protectedRoutes(t) {
+			g.MethodFunc(rt.method, rt.pattern, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			})
+		}
+	})
+	return r
+}
+
+func token(t *testing.T, id uuid.UUID, role user.Role) string {
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  id.String(),
+		"sid":  uuid.NewString(),
+		"role": string(role),
+	}).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestRouter_EnforcesPolicy(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	r := newStubRouter(t, openSessions{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		id     uuid.UUID
+		role   user.Role
+		want   int
+	}{
+		// Users
+		{"admin lists users", "GET", "/api/users/all_users", "", adminID, user.Admin, 204},
+		{"customer can't list users", "GET", "/api/users/all_users", "", customerID, user.Customer, 403},
+		{"user reads self", "GET", "/api/users/by-id/" + customerID.String(), "", customerID, user.Customer, 204},
+		{"user can't read others", "GET", "/api/users/by-id/" + otherID.String(), "", customerID, user.Customer, 403},
+		{"user can't delete others", "DELETE", "/api/users/" + otherID.String(), "", merchantID, user.Merchant, 403},
+		{"admin deletes anyone", "DELETE", "/api/users/" + otherID.String(), "", adminID, user.Admin, 204},
+		{"only admins change status", "PATCH", "/api/users/" + customerID.String() + "/status", "", customerID, user.Customer, 403},
+		{"admin unlocks account", "POST", "/api/users/" + customerID.String() + "/unlock", "", adminID, user.Admin, 204},
+		{"user can't unlock own account", "POST", "/api/users/" + customerID.String() + "/unlock", "", customerID, user.Customer, 403},
+		{"bad user id", "GET", "/api/users/by-id/nope", "", customerID, user.Customer, 400},
+		{"address book is private", "GET", "/api/users/" + otherID.String() + "/addresses", "", customerID, user.Customer, 403},
+		{"merchant enrols in 2fa", "POST", "/api/users/2fa/enrol", "", merchantID, user.Merchant, 204},
+		{"customer can't enrol in 2fa", "POST", "/api/users/2fa/enrol", "", customerID, user.Customer, 403},
+		{"admin requires 2fa", "PUT", "/api/users/2fa/requirements/merchant", "", adminID, user.Admin, 204},
+		{"merchant can't lift 2fa requirement", "PUT", "/api/users/2fa/requirements/merchant", "", merchantID, user.Merchant, 403},
+
+		// Orders
+		{"customer places order", "POST", "/api/orders/create", "", customerID, user.Customer, 204},
+		{"merchant can't place order", "POST", "/api/orders/create", "", merchantID, user.Merchant, 403},
+		{"customer reads own order", "GET", "/api/orders/by-id/" + orderID.String(), "", customerID, user.Customer, 204},
+		{"merchant reads store order", "GET", "/api/orders/by-id/" + orderID.String(), "", merchantID, user.Merchant, 204},
+		{"driver reads delivered order", "GET", "/api/orders/by-id/" + orderID.String(), "", driverID, user.Driver, 204},
+		{"fulfilment staff read store order", "GET", "/api/orders/by-id/" + orderID.String(), "", fulfilerID, user.Merchant, 204},
+		{"fulfilment staff update status", "PUT", "/api/orders/" + orderID.String() + "/status", "", fulfilerID, user.Merchant, 204},
+		{"catalog editor can't read order", "GET", "/api/orders/by-id/" + orderID.String(), "", editorID, user.Merchant, 403},
+		{"stranger can't read order", "GET", "/api/orders/by-id/" + orderID.String(), "", otherID, user.Customer, 403},
+		{"missing order", "GET", "/api/orders/by-id/" + uuid.NewString(), "", customerID, user.Customer, 404},
+		{"driver can't cancel order", "POST", "/api/orders/" + orderID.String() + "/cancel", "", driverID, user.Driver, 403},
+		{"only admins assign", "POST", "/api/orders/assign", "", merchantID, user.Merchant, 403},
+		{"customer lists own orders", "GET", "/api/orders/by-customer/" + customerID.String(), "", customerID, user.Customer, 204},
+		{"customer can't list others' orders", "GET", "/api/orders/by-customer/" + otherID.String(), "", customerID, user.Customer, 403},
+
+		// Promotions
+		{"merchant lists promotions", "GET", "/api/promotions/all_promotions?store_id=" + storeID.String(), "", merchantID, user.Merchant, 204},
+		{"customer can't list promotions", "GET", "/api/promotions/all_promotions", "", customerID, user.Customer, 403},
+
+		// Carts
+		{"customer reads cart", "GET", "/api/carts/" + storeID.String(), "", customerID, user.Customer, 204},
+		{"driver has no cart", "GET", "/api/carts/me", "", driverID, user.Driver, 403},
+
+		// Drivers and deliveries
+		{"driver updates self", "PATCH", "/api/drivers/" + driverID.String() + "/update", "", driverID, user.Driver, 204},
+		{"driver can't update others", "PATCH", "/api/drivers/" + otherID.String() + "/update", "", driverID, user.Driver, 403},
+		{"customer can't update drivers", "PATCH", "/api/drivers/" + customerID.String() + "/update", "", customerID, user.Customer, 403},
+		{"assignee moves delivery", "PUT", "/api/deliveries/" + deliveryID.String() + "/status", "", driverID, user.Driver, 204},
+		{"other driver can't move delivery", "PUT", "/api/deliveries/" + deliveryID.String() + "/status", "", otherID, user.Driver, 403},
+		{"driver accepts", "PUT", "/api/deliveries/" + orderID.String() + "/accept", "", driverID, user.Driver, 204},
+		{"only admins list deliveries", "GET", "/api/deliveries/all_deliveries", "", driverID, user.Driver, 403},
+
+		// Stores and products
+		{"owner updates store", "PUT", "/api/stores/" + storeID.String() + "/update", "", merchantID, user.Merchant, 204},
+		{"other merchant can't update store", "PUT", "/api/stores/" + storeID.String() + "/update", "", otherID, user.Merchant, 403},
+		{"anyone reads store", "GET", "/api/stores/by-id/" + storeID.String(), "", customerID, user.Customer, 204},
+		{"owner creates product", "POST", "/api/products/create", `{"store_id":"` + storeID.String() + `"}`, merchantID, user.Merchant, 204},
+		{"stranger can't create product", "POST", "/api/products/create", `{"store_id":"` + storeID.String() + `"}`, otherID, user.Merchant, 403},
+		{"product without store", "POST", "/api/products/create", `{}`, merchantID, user.Merchant, 400},
+		{"owner deletes product", "DELETE", "/api/products/" + productID.String() + "/delete", "", merchantID, user.Merchant, 204},
+		{"customer can't delete product", "DELETE", "/api/products/" + productID.String() + "/delete", "", customerID, user.Customer, 403},
+		{"owner prices variant", "PATCH", "/api/products/variants/price/update", `{"variant_id":"` + variantID.String() + `"}`, merchantID, user.Merchant, 204},
+		{"stranger can't price variant", "PATCH", "/api/products/variants/price/update", `{"variant_id":"` + variantID.String() + `"}`, otherID, user.Merchant, 403},
+		{"catalog editor creates product", "POST", "/api/products/create", `{"store_id":"` + storeID.String() + `"}`, editorID, user.Merchant, 204},
+		{"catalog editor prices variant", "PATCH", "/api/products/variants/price/update", `{"variant_id":"` + variantID.String() + `"}`, editorID, user.Merchant, 204},
+		{"fulfilment staff can't edit catalog", "DELETE", "/api/products/" + productID.String() + "/delete", "", fulfilerID, user.Merchant, 403},
+		{"catalog editor can't update store", "PUT", "/api/stores/" + storeID.String() + "/update", "", editorID, user.Merchant, 403},
+		{"owner lists staff", "GET", "/api/stores/" + storeID.String() + "/members", "", merchantID, user.Merchant, 204},
+		{"staff can't manage staff", "DELETE", "/api/stores/" + storeID.String() + "/members/" + editorID.String(), "", fulfilerID, user.Merchant, 403},
+		{"admin deletes any variant", "DELETE", "/api/products/variants/" + variantID.String() + "/delete", "", adminID, user.Admin, 204},
+		{"owner deletes image", "DELETE", "/api/products/images/" + imageID.String() + "/delete", "", merchantID, user.Merchant, 204},
+		{"stranger can't delete image", "DELETE", "/api/products/images/" + imageID.String() + "/delete", "", otherID, user.Merchant, 403},
+		{"catalog editor deletes option", "DELETE", "/api/products/options/" + optionID.String() + "/delete", "", editorID, user.Merchant, 204},
+		{"stranger can't delete option", "DELETE", "/api/products/options/" + optionID.String() + "/delete", "", otherID, user.Merchant, 403},
+		{"fulfilment staff can't delete option value", "DELETE", "/api/products/options/values/" + valueID.String() + "/delete", "", fulfilerID, user.Merchant, 403},
+		{"stranger can't delete option value", "DELETE", "/api/products/options/values/" + valueID.String() + "/delete", "", otherID, user.Merchant, 403},
+		{"missing image", "DELETE", "/api/products/images/" + uuid.NewString() + "/delete", "", merchantID, user.Merchant, 404},
+
+		// Invites
+		{"owner invites staff", "POST", "/api/invites/create", `{"store_id":"` + storeID.String() + `"}`, merchantID, user.Merchant, 204},
+		{"catalog editor can't invite staff", "POST", "/api/invites/create", `{"store_id":"` + storeID.String() + `"}`, editorID, user.Merchant, 403},
+		{"stranger can't invite staff", "POST", "/api/invites/create", `{"store_id":"` + storeID.String() + `"}`, otherID, user.Merchant, 403},
+		{"body id not taken from the query", "POST", "/api/invites/create?store_id=" + storeID.String(), "", merchantID, user.Merchant, 400},
+		{"query can't vouch for another body id", "POST", "/api/invites/create?store_id=" + storeID.String(), `{"store_id":"` + uuid.NewString() + `"}`, merchantID, user.Merchant, 400},
+		{"body keys differing in case must agree", "POST", "/api/products/create", `{"store_id":"` + storeID.String() + `","STORE_ID":"` + uuid.NewString() + `"}`, merchantID, user.Merchant, 400},
+		{"body key in another case", "POST", "/api/products/create", `{"Store_Id":"` + storeID.String() + `"}`, merchantID, user.Merchant, 204},
+		{"merchant invites need a store", "POST", "/api/invites/create", `{}`, merchantID, user.Merchant, 400},
+		{"admin invites anyone", "POST", "/api/invites/create", `{}`, adminID, user.Admin, 204},
+		{"owner revokes store invite", "POST", "/api/invites/" + inviteID.String() + "/revoke", "", merchantID, user.Merchant, 204},
+		{"stranger can't resend invite", "POST", "/api/invites/" + inviteID.String() + "/resend", "", otherID, user.Merchant, 403},
+
+		// Payments, feedbacks and notifications
+		{"customer reads order payment", "GET", "/api/payments/" + orderID.String(), "", customerID, user.Customer, 204},
+		{"stranger can't read payment", "GET", "/api/payments/" + orderID.String(), "", otherID, user.Customer, 403},
+		{"customer reviews own order", "POST", "/api/feedbacks/create", `{"order_id":"` + orderID.String() + `"}`, customerID, user.Customer, 204},
+		{"customer can't review others' order", "POST", "/api/feedbacks/create", `{"order_id":"` + orderID.String() + `"}`, otherID, user.Customer, 403},
+		{"user reads own notifications", "GET", "/api/notifications/all_my_notifications/" + driverID.String(), "", driverID, user.Driver, 204},
+		{"user can't read others' notifications", "GET", "/api/notifications/all_my_notifications/" + customerID.String(), "", driverID, user.Driver, 403},
+		{"only admins create notifications", "POST", "/api/notifications/create", "", merchantID, user.Merchant, 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+token(t, tt.id, tt.role))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestRouter_BlocksUntilPasswordChanged(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	flagged, other := uuid.New(), uuid.New()
+	r := newStubRouter(t, openSessions{flagged: true})
+
+	tests := []struct {
+		name         string
+		method, path string
+		id           uuid.UUID
+		want         int
+	}{
+		{"changes own password", "PUT", "/api/users/" + flagged.String() + "/password", flagged, 204},
+		{"logs out", "POST", "/api/users/logout", flagged, 204},
+		{"can't read profile", "GET", "/api/users/by-id/" + flagged.String(), flagged, 403},
+		{"can't read stores", "GET", "/api/stores/by-id/" + other.String(), flagged, 403},
+		{"can't change others' password", "PUT", "/api/users/" + other.String() + "/password", flagged, 403},
+		{"unflagged users unaffected", "GET", "/api/users/by-id/" + other.String(), other, 204},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token(t, tt.id, user.Customer))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestRouter_BlocksUntilTwoFactorEnrolled(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	flagged := uuid.New()
+	r := newStubRouter(t, enrolSessions{flagged: true})
+
+	tests := []struct {
+		name         string
+		method, path string
+		id           uuid.UUID
+		want         int
+	}{
+		{"enrols", "POST", "/api/users/2fa/enrol", flagged, 204},
+		{"confirms", "POST", "/api/users/2fa/confirm", flagged, 204},
+		{"reads status", "GET", "/api/users/2fa", flagged, 204},
+		{"changes own password", "PUT", "/api/users/" + flagged.String() + "/password", flagged, 204},
+		{"logs out", "POST", "/api/users/logout", flagged, 204},
+		{"can't read stores", "GET", "/api/stores/me", flagged, 403},
+		{"can't edit catalog", "POST", "/api/products/create", flagged, 403},
+		{"unflagged users unaffected", "GET", "/api/stores/me", merchantID, 204},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token(t, tt.id, user.Merchant))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestRouter_HoldsAPIKeysToTheirGrant(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+	r := newStubRouter(t, openSessions{})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		key    string
+		want   int
+	}{
+		{"key edits catalog", "POST", "/api/products/create", `{"store_id":"` + storeID.String() + `"}`, "fbk_catalog", 204},
+		{"key reads catalog", "GET", "/api/products/by-id/" + productID.String(), "", "fbk_catalog", 204},
+		{"key without scope", "GET", "/api/orders/by-id/" + orderID.String(), "", "fbk_catalog", 403},
+		{"routes without scope are closed to keys", "PUT", "/api/stores/" + storeID.String() + "/update", "", "fbk_store", 403},
+		{"keys can't issue keys", "POST", "/api/api-keys/create", "", "fbk_store", 403},
+		{"store key in its store", "PUT", "/api/orders/" + orderID.String() + "/status", "", "fbk_store", 204},
+		{"store key lists its store", "GET", "/api/products/" + storeID.String() + "/all_products", "", "fbk_store", 204},
+		{"store key lists its orders", "GET", "/api/orders/all_orders?store_id=" + storeID.String(), "", "fbk_store", 204},
+		{"store key outside its store", "PATCH", "/api/products/variants/price/update", `{"variant_id":"` + variantID.String() + `"}`, "fbk_other", 403},
+		{"store key on another store's order", "GET", "/api/orders/by-id/" + orderID.String(), "", "fbk_other", 403},
+		{"store key on another store's catalog", "GET", "/api/products/" + storeID.String() + "/all_products", "", "fbk_other", 403},
+		{"store key names no store", "GET", "/api/orders/all_orders", "", "fbk_store", 400},
+		{"store key on route without resource", "POST", "/api/products/cloudinary/signature", "", "fbk_store", 403},
+		{"store key on another store's image", "DELETE", "/api/products/images/" + imageID.String() + "/delete", "", "fbk_other", 403},
+		{"unknown key", "GET", "/api/products/by-id/" + productID.String(), "", "fbk_nope", 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(authMiddleware.APIKeyHeader, tt.key)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			require.Equal(t, tt.want, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -10,7 +10,9 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"backend/handlers"
+	"backend/internal/domain/idempotency"
 	authMiddleware "backend/internal/middleware"
+	authzUsecase "backend/internal/usecase/authz"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -27,20 +29,38 @@ func NewRouter(
 	s *handlers.StoreHandler,
 	pr *handlers.ProductHandler,
 	db *sqlx.DB,
+	idempotencyRepo idempotency.Repository,
+	pc *handlers.PricingHandler,
+	pm *handlers.PromotionHandler,
+	ct *handlers.CartHandler,
+	ad *handlers.AddressHandler,
+	sf *handlers.StorefrontHandler,
+	ak *handlers.APIKeyHandler,
+	az *authzUsecase.UseCase,
+	sessions authMiddleware.SessionValidator,
+	keys authMiddleware.APIKeyAuthenticator,
 ) http.Handler {
 	r := chi.NewRouter()
 
+	// Replays responses of retried non-idempotent requests
+	idempotent := authMiddleware.Idempotency(idempotencyRepo)
+
+	// Enforces the role and ownership rules declared in policy.go
+	authorize := authMiddleware.Authorize(r, NewPolicy(az))
+
 	// Enable Cors
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", idempotency.Header},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed", "Retry-After"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
-	// Basic middleware
+	// Basic middleware. Requests come through the gateway, so the client's
+	// address is taken from its forwarding headers
+	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 
@@ -55,26 +75,59 @@ func NewRouter(
 			// Public auth
 			r.Post("/create", u.CreateUser)
 			r.Post("/login", u.LoginUser)
+			r.Post("/login/2fa", u.VerifyLogin)
+			r.Post("/login/phone", u.RequestPhoneCode)
+			r.Post("/login/phone/verify", u.VerifyPhoneCode)
+			r.Post("/refresh", u.RefreshToken)
+			r.Post("/forgot-password", u.ForgotPassword)
+			r.Post("/reset-password", u.ResetPassword)
+			r.Post("/verify-email", u.VerifyEmail)
+			r.Post("/resend-verification", u.ResendVerification)
+			r.Post("/invites/accept", c.AcceptInvite)
 
 			// Public store pages
-
+			r.Get("/stores/nearby", s.ListNearbyStores)
+			r.Get("/stores/{slug}", sf.GetStorefront)
+			r.Get("/stores/{slug}/products", sf.ListStorefrontProducts)
+			r.Get("/stores/{slug}/products/{product_id}", sf.GetStorefrontProduct)
+			r.Get("/stores/{slug}/rating", sf.GetStorefrontRating)
 		})
 
-		// Protected Routes (auth required)
+		// Protected Routes (access token or API key required)
 		r.Group(func(r chi.Router) {
-			r.Use(authMiddleware.JWTAuthMiddleware)
+			r.Use(authMiddleware.AuthMiddleware(sessions, keys))
+			r.Use(authorize)
 
 			// Users
 			r.Route("/users", func(r chi.Router) {
 				r.Get("/all_users", u.ListUsers)
+				r.Post("/logout", u.Logout)
+
+				// Two-factor authentication
+				r.Get("/2fa", u.GetTwoFactorStatus)
+				r.Post("/2fa/enrol", u.EnrolTwoFactor)
+				r.Post("/2fa/confirm", u.ConfirmTwoFactor)
+				r.Post("/2fa/disable", u.DisableTwoFactor)
+				r.Post("/2fa/recovery-codes", u.RegenerateRecoveryCodes)
+				r.Get("/2fa/requirements", u.ListTwoFactorRequirements)
+				r.Put("/2fa/requirements/{role}", u.SetTwoFactorRequirement)
+
 				r.Get("/by-id/{id}", u.GetUserByID)
 				r.Get("/by-email/{email}", u.GetUserByEmail)
 				r.Patch("/{id}/driver_profile", u.UpdateDriverProfile)
 				r.Patch("/{id}/profile", u.UpdateUserProfile)
-				r.Put("/{id}/update", u.UpdateUser)
+				r.Patch("/{id}/update", u.UpdateUser)
 				r.Put("/{id}/password", u.ChangePassword)
 				r.Patch("/{id}/status", u.UpdateUserStatus)
+				r.Post("/{id}/unlock", u.UnlockUser)
 				r.Delete("/{id}", u.DeleteUser)
+
+				// Address book
+				r.Post("/{id}/addresses", ad.CreateAddress)
+				r.Get("/{id}/addresses", ad.ListAddresses)
+				r.Get("/{id}/addresses/{address_id}", ad.GetAddress)
+				r.Patch("/{id}/addresses/{address_id}", ad.UpdateAddress)
+				r.Delete("/{id}/addresses/{address_id}", ad.DeleteAddress)
 			})
 
 			// Invites
@@ -82,27 +135,65 @@ func NewRouter(
 				r.Post("/create", c.CreateMember)
 				r.Get("/by-token", c.GetMemberByToken)
 				r.Get("/all_invites", c.ListPendingMembers)
+				r.Post("/{id}/resend", c.ResendInvite)
+				r.Post("/{id}/revoke", c.RevokeInvite)
 				r.Delete("/{id}", c.DeleteMember)
 			})
 
+			// API keys
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Post("/create", ak.CreateAPIKey)
+				r.Get("/me", ak.ListAPIKeys)
+				r.Post("/{id}/revoke", ak.RevokeAPIKey)
+			})
+
 			// Orders
 			r.Route("/orders", func(r chi.Router) {
-				r.Post("/create", o.CreateOrder)
+				r.With(idempotent).Post("/create", o.CreateOrder)
+				r.Post("/pending", o.CreatePending)
+				r.Post("/quote", o.QuoteOrder)
 				r.Get("/all_orders", o.ListOrders)
 				r.Post("/assign", o.AutoAssignOrders)
 				r.Get("/by-id/{id}", o.GetOrderByID)
 				r.Get("/by-customer/{customer_id}", o.GetOrderByCustomer)
-				r.Put("/{id}/update", o.UpdateOrder)
+				r.Patch("/{id}/update", o.UpdateOrder)
+				r.Put("/{id}/status", o.UpdateOrderStatus)
+				r.Get("/{id}/timeline", o.GetOrderTimeline)
+				r.Post("/{id}/cancel", o.CancelOrder)
 				r.Delete("/{id}", o.DeleteOrder)
 			})
 
+			// Carts
+			r.Route("/carts", func(r chi.Router) {
+				r.Get("/me", ct.ListCarts)
+				r.Get("/{store_id}", ct.GetCart)
+				r.Delete("/{store_id}", ct.ClearCart)
+				r.Post("/{store_id}/items", ct.AddCartItem)
+				r.Put("/{store_id}/items/{item_id}", ct.UpdateCartItem)
+				r.Delete("/{store_id}/items/{item_id}", ct.RemoveCartItem)
+				r.With(idempotent).Post("/{store_id}/checkout", ct.CheckoutCart)
+			})
+
+			// Pricing
+			r.Route("/pricing", func(r chi.Router) {
+				r.Get("/tariffs", pc.GetTariff)
+				r.Put("/tariffs", pc.ReplaceTariff)
+			})
+
+			// Promotions
+			r.Route("/promotions", func(r chi.Router) {
+				r.Post("/create", pm.CreatePromotion)
+				r.Get("/all_promotions", pm.ListPromotions)
+				r.Put("/{id}/deactivate", pm.DeactivatePromotion)
+			})
+
 			// Drivers
 			r.Route("/drivers", func(r chi.Router) {
 				r.Get("/all_drivers", d.ListDrivers)
 				r.Get("/by-id/{id}", d.GetDriverByID)
 				r.Get("/by-email/{email}", d.GetDriverByEmail)
 				r.Patch("/{id}/profile", d.UpdateDriverProfile)
-				r.Put("/{id}/update", d.UpdateDriver)
+				r.Patch("/{id}/update", d.UpdateDriver)
 				r.Delete("/{id}", d.DeleteDriver)
 			})
 
@@ -110,7 +201,8 @@ func NewRouter(
 			r.Route("/deliveries", func(r chi.Router) {
 				r.Get("/all_deliveries", e.ListDeliveries)
 				r.Get("/by-id/{id}", e.GetDeliveryByID)
-				r.Put("/{id}/update", e.UpdateDelivery)
+				r.Patch("/{id}/update", e.UpdateDelivery)
+				r.Put("/{id}/status", e.UpdateDeliveryStatus)
 				r.Put("/{id}/accept", e.AcceptDelivery)
 				r.Delete("/{id}", e.DeleteDelivery)
 			})
@@ -123,7 +215,7 @@ func NewRouter(
 				r.Get("/{order_id}", p.GetPaymentByOrderID)
 
 				// MPesa STK Push
-				r.Post("/mpesa-express", p.MpesaExpress)
+				r.With(idempotent).Post("/mpesa-express", p.MpesaExpress)
 				r.Post("/mpesa-callback", p.MpesaCallback)
 			})
 
@@ -154,6 +246,9 @@ func NewRouter(
 				r.Get("/{id}/summary", s.GetStoreSummary)
 				r.Put("/{id}/update", s.UpdateStore)
 				r.Delete("/{id}/delete", s.DeleteStore)
+				r.Get("/{id}/members", s.ListMembers)
+				r.Patch("/{id}/members/{user_id}", s.UpdateMemberRole)
+				r.Delete("/{id}/members/{user_id}", s.RemoveMember)
 			})
 
 			// Products
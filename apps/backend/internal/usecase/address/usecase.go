@@ -0,0 +1,208 @@
+package address
+
+import (
+	"backend/internal/domain/address"
+	"backend/internal/domain/geocoding"
+	"backend/internal/usecase/common"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UseCase encapsulates address book business logic and dependencies.
+type UseCase struct {
+	repo      address.Repository
+	txManager common.TxManager
+	geocoder  address.Geocoder
+}
+
+// NewUseCase creates a new address UseCase.
+func NewUseCase(repo address.Repository, txm common.TxManager, geocoder address.Geocoder) *UseCase {
+	return &UseCase{repo: repo, txManager: txm, geocoder: geocoder}
+}
+
+// locate completes an address that has only text or only coordinates.
+func (uc *UseCase) locate(ctx context.Context, a *address.Address) error {
+	hasPoint := a.Point.X != 0 || a.Point.Y != 0
+	text := strings.TrimSpace(a.Address)
+
+	var (
+		p   *geocoding.Place
+		err error
+	)
+	switch {
+	case !hasPoint && text != "":
+		if p, err = uc.geocoder.Geocode(ctx, text); err == nil {
+			a.Point = address.NewPoint(p.Lat, p.Lng)
+		}
+	case hasPoint && text == "":
+		if !address.ValidCoordinates(a.Point.Y, a.Point.X) {
+			return address.ErrorInvalidCoordinates
+		}
+		if p, err = uc.geocoder.ReverseGeocode(ctx, a.Point.Y, a.Point.X); err == nil {
+			a.Address = p.Address
+		}
+	}
+
+	if errors.Is(err, geocoding.ErrorNoMatch) {
+		return address.ErrorLocationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("geocode address: %w", err)
+	}
+	return nil
+}
+
+// authorize lets users manage their own address book and admins any.
+func authorize(callerID uuid.UUID, isAdmin bool, userID uuid.UUID) error {
+	if !isAdmin && callerID != userID {
+		return address.ErrorForbidden
+	}
+	return nil
+}
+
+// CreateAddress saves a new address for the user. The first address of a
+// user becomes the default one.
+func (uc *UseCase) CreateAddress(ctx context.Context, callerID uuid.UUID, isAdmin bool, a *address.Address) error {
+	if err := authorize(callerID, isAdmin, a.UserID); err != nil {
+		return err
+	}
+	if err := uc.locate(ctx, a); err != nil {
+		return err
+	}
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		existing, err := uc.repo.ListByUser(txCtx, a.UserID)
+		if err != nil {
+			return fmt.Errorf("list addresses: %w", err)
+		}
+		if len(existing) == 0 {
+			a.IsDefault = true
+		}
+
+		if a.IsDefault {
+			if err := uc.repo.ClearDefault(txCtx, a.UserID); err != nil {
+				return fmt.Errorf("clear default address: %w", err)
+			}
+		}
+
+		if err := uc.repo.Create(txCtx, a); err != nil {
+			return fmt.Errorf("create address: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListAddresses returns the user's address book, default first.
+func (uc *UseCase) ListAddresses(ctx context.Context, callerID uuid.UUID, isAdmin bool, userID uuid.UUID) ([]*address.Address, error) {
+	if err := authorize(callerID, isAdmin, userID); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListByUser(ctx, userID)
+}
+
+// GetAddress returns one address of the user.
+func (uc *UseCase) GetAddress(ctx context.Context, callerID uuid.UUID, isAdmin bool, userID, id uuid.UUID) (*address.Address, error) {
+	if err := authorize(callerID, isAdmin, userID); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetByID(ctx, userID, id)
+}
+
+// UpdateAddress changes an address. Making it the default unsets the
+// previous default; the default can't be unset directly, only moved.
+func (uc *UseCase) UpdateAddress(ctx context.Context, callerID uuid.UUID, isAdmin bool, userID, id uuid.UUID, req *address.UpdateAddressRequest) (*address.Address, error) {
+	if err := authorize(callerID, isAdmin, userID); err != nil {
+		return nil, err
+	}
+
+	var a *address.Address
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		if a, err = uc.repo.GetByID(txCtx, userID, id); err != nil {
+			return err
+		}
+
+		wasDefault := a.IsDefault
+		if err := req.Apply(a); err != nil {
+			return err
+		}
+		if err := uc.locate(txCtx, a); err != nil {
+			return err
+		}
+		if err := a.Validate(); err != nil {
+			return err
+		}
+		if wasDefault {
+			a.IsDefault = true
+		}
+
+		if a.IsDefault && !wasDefault {
+			if err := uc.repo.ClearDefault(txCtx, userID); err != nil {
+				return fmt.Errorf("clear default address: %w", err)
+			}
+		}
+
+		if err := uc.repo.Update(txCtx, a); err != nil {
+			return fmt.Errorf("update address: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// DeleteAddress removes an address. When it was the default, the most
+// recently added remaining address takes over.
+func (uc *UseCase) DeleteAddress(ctx context.Context, callerID uuid.UUID, isAdmin bool, userID, id uuid.UUID) error {
+	if err := authorize(callerID, isAdmin, userID); err != nil {
+		return err
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		a, err := uc.repo.GetByID(txCtx, userID, id)
+		if err != nil {
+			return err
+		}
+		if err := uc.repo.Delete(txCtx, userID, id); err != nil {
+			return fmt.Errorf("delete address: %w", err)
+		}
+		if !a.IsDefault {
+			return nil
+		}
+
+		rest, err := uc.repo.ListByUser(txCtx, userID)
+		if err != nil {
+			return fmt.Errorf("list addresses: %w", err)
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+
+		next := rest[0]
+		for _, r := range rest[1:] {
+			if r.CreatedAt.After(next.CreatedAt) {
+				next = r
+			}
+		}
+		next.IsDefault = true
+		if err := uc.repo.Update(txCtx, next); err != nil {
+			return fmt.Errorf("promote default address: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAddressForUser returns an address of the user for use in an order.
+func (uc *UseCase) GetAddressForUser(ctx context.Context, userID, id uuid.UUID) (*address.Address, error) {
+	return uc.repo.GetByID(ctx, userID, id)
+}
@@ -0,0 +1,231 @@
+package address
+
+import (
+	"backend/internal/domain/address"
+	"backend/internal/domain/geocoding"
+	"backend/internal/usecase/common"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(common.MarkTx(ctx))
+}
+
+type fakeAddressRepo struct {
+	addresses []*address.Address
+	clock     time.Time
+}
+
+func (f *fakeAddressRepo) Create(ctx context.Context, a *address.Address) error {
+	f.clock = f.clock.Add(time.Minute)
+	a.ID = uuid.New()
+	a.CreatedAt = f.clock
+	f.addresses = append(f.addresses, a)
+	return nil
+}
+
+// GetByID returns a copy, like a row read from the database.
+func (f *fakeAddressRepo) GetByID(ctx context.Context, userID, id uuid.UUID) (*address.Address, error) {
+	for _, a := range f.addresses {
+		if a.ID == id && a.UserID == userID {
+			copied := *a
+			return &copied, nil
+		}
+	}
+	return nil, address.ErrorAddressNotFound
+}
+
+func (f *fakeAddressRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*address.Address, error) {
+	list := []*address.Address{}
+	for _, a := range f.addresses {
+		if a.UserID == userID {
+			copied := *a
+			list = append(list, &copied)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeAddressRepo) Update(ctx context.Context, a *address.Address) error {
+	for i, saved := range f.addresses {
+		if saved.ID == a.ID && saved.UserID == a.UserID {
+			copied := *a
+			f.addresses[i] = &copied
+			return nil
+		}
+	}
+	return address.ErrorAddressNotFound
+}
+
+func (f *fakeAddressRepo) Delete(ctx context.Context, userID, id uuid.UUID) error {
+	for i, a := range f.addresses {
+		if a.ID == id && a.UserID == userID {
+			f.addresses = append(f.addresses[:i], f.addresses[i+1:]...)
+			return nil
+		}
+	}
+	return address.ErrorAddressNotFound
+}
+
+func (f *fakeAddressRepo) ClearDefault(ctx context.Context, userID uuid.UUID) error {
+	for _, a := range f.addresses {
+		if a.UserID == userID {
+			a.IsDefault = false
+		}
+	}
+	return nil
+}
+
+// fakeGeocoder knows Nairobi only.
+type fakeGeocoder struct{}
+
+func (fakeGeocoder) Geocode(ctx context.Context, query string) (*geocoding.Place, error) {
+	if !strings.Contains(strings.ToLower(query), "nairobi") {
+		return nil, geocoding.ErrorNoMatch
+	}
+	return &geocoding.Place{Name: "Nairobi", Address: "Nairobi", Lat: -1.2864, Lng: 36.8172}, nil
+}
+
+func (fakeGeocoder) ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.Place, error) {
+	if lat < -1.5 || lat > -1.1 || lng < 36.6 || lng > 37.1 {
+		return nil, geocoding.ErrorNoMatch
+	}
+	return &geocoding.Place{Name: "Kilimani", Address: "Kilimani, Nairobi", Lat: -1.2906, Lng: 36.7856}, nil
+}
+
+func (f *fakeAddressRepo) defaults(userID uuid.UUID) []string {
+	var labels []string
+	for _, a := range f.addresses {
+		if a.UserID == userID && a.IsDefault {
+			labels = append(labels, a.Label)
+		}
+	}
+	return labels
+}
+
+func newAddress(userID uuid.UUID, label string, isDefault bool) *address.Address {
+	req := address.CreateAddressRequest{Label: label, Address: label + ", Nairobi", Lat: -1.29, Lng: 36.82, IsDefault: isDefault}
+	return req.ToAddress(userID)
+}
+
+func TestCreateAddress_KeepsOneDefault(t *testing.T) {
+	repo := &fakeAddressRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, newAddress(userID, "Home", false)))
+	require.Equal(t, []string{"Home"}, repo.defaults(userID), "first address is the default")
+
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, newAddress(userID, "Office", false)))
+	require.Equal(t, []string{"Home"}, repo.defaults(userID))
+
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, newAddress(userID, "Gym", true)))
+	require.Equal(t, []string{"Gym"}, repo.defaults(userID))
+}
+
+func TestCreateAddress_Validation(t *testing.T) {
+	uc := NewUseCase(&fakeAddressRepo{}, fakeTxManager{}, fakeGeocoder{})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	a := newAddress(userID, "Home", false)
+	a.Address, a.Point = "", address.NewPoint(0, 0)
+	require.Error(t, uc.CreateAddress(ctx, userID, false, a), "either the address or the coordinates are needed")
+
+	a = newAddress(userID, "Home", false)
+	a.Point = address.NewPoint(-1.29, 200)
+	require.ErrorIs(t, uc.CreateAddress(ctx, userID, false, a), address.ErrorInvalidCoordinates)
+
+	require.ErrorIs(t, uc.CreateAddress(ctx, userID, false, newAddress(userID, "  ", false)), address.ErrorInvalidLabel)
+
+	require.ErrorIs(t, uc.CreateAddress(ctx, uuid.New(), false, newAddress(userID, "Home", false)), address.ErrorForbidden)
+	require.NoError(t, uc.CreateAddress(ctx, uuid.New(), true, newAddress(userID, "Home", false)), "admins manage any address book")
+}
+
+func TestUpdateAddress_MovesDefault(t *testing.T) {
+	repo := &fakeAddressRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	home := newAddress(userID, "Home", false)
+	office := newAddress(userID, "Office", false)
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, home))
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, office))
+
+	yes, no := true, false
+	_, err := uc.UpdateAddress(ctx, userID, false, userID, office.ID, &address.UpdateAddressRequest{IsDefault: &yes})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Office"}, repo.defaults(userID))
+
+	_, err = uc.UpdateAddress(ctx, userID, false, userID, office.ID, &address.UpdateAddressRequest{IsDefault: &no})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Office"}, repo.defaults(userID), "the default can only be moved")
+
+	lat := -1.30
+	_, err = uc.UpdateAddress(ctx, userID, false, userID, office.ID, &address.UpdateAddressRequest{Lat: &lat})
+	require.ErrorIs(t, err, address.ErrorInvalidCoordinates)
+}
+
+func TestDeleteAddress_PromotesNewestToDefault(t *testing.T) {
+	repo := &fakeAddressRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	home := newAddress(userID, "Home", false)
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, home))
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, newAddress(userID, "Office", false)))
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, newAddress(userID, "Gym", false)))
+
+	require.NoError(t, uc.DeleteAddress(ctx, userID, false, userID, home.ID))
+	require.Equal(t, []string{"Gym"}, repo.defaults(userID))
+
+	require.ErrorIs(t, uc.DeleteAddress(ctx, userID, false, userID, home.ID), address.ErrorAddressNotFound)
+}
+
+func TestCreateAddress_Geocodes(t *testing.T) {
+	repo := &fakeAddressRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	byText := (&address.CreateAddressRequest{Label: "Home", Address: "Argwings Kodhek Rd, Nairobi"}).ToAddress(userID)
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, byText))
+	require.Equal(t, address.NewPoint(-1.2864, 36.8172), byText.Point)
+	require.Equal(t, "Argwings Kodhek Rd, Nairobi", byText.Address, "the text is kept as sent")
+
+	byPoint := (&address.CreateAddressRequest{Label: "Office", Lat: -1.2910, Lng: 36.7860}).ToAddress(userID)
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, byPoint))
+	require.Equal(t, "Kilimani, Nairobi", byPoint.Address)
+	require.Equal(t, address.NewPoint(-1.2910, 36.7860), byPoint.Point, "the coordinates are kept as sent")
+
+	unknown := (&address.CreateAddressRequest{Label: "Cabin", Address: "Atlantis"}).ToAddress(userID)
+	require.ErrorIs(t, uc.CreateAddress(ctx, userID, false, unknown), address.ErrorLocationNotFound)
+	require.Len(t, repo.addresses, 2)
+}
+
+func TestUpdateAddress_GeocodesNewText(t *testing.T) {
+	repo := &fakeAddressRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	userID := uuid.New()
+	ctx := context.Background()
+
+	home := newAddress(userID, "Home", false)
+	home.Point = address.NewPoint(-1.30, 36.80)
+	require.NoError(t, uc.CreateAddress(ctx, userID, false, home))
+
+	text := "Moi Avenue, Nairobi"
+	updated, err := uc.UpdateAddress(ctx, userID, false, userID, home.ID, &address.UpdateAddressRequest{Address: &text})
+	require.NoError(t, err)
+	require.Equal(t, address.NewPoint(-1.2864, 36.8172), updated.Point)
+}
@@ -0,0 +1,140 @@
+package apikey
+
+import (
+	domain "backend/internal/domain/apikey"
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UseCase issues, lists and revokes merchant API keys and authenticates the
+// requests made with them.
+type UseCase struct {
+	repo   domain.Repository
+	users  domain.UserReader
+	stores domain.StoreAccess
+	now    func() time.Time
+}
+
+// NewUseCase creates a new API key UseCase.
+func NewUseCase(repo domain.Repository, users domain.UserReader, stores domain.StoreAccess) *UseCase {
+	return &UseCase{repo: repo, users: users, stores: stores, now: time.Now}
+}
+
+// CreateKey issues a key for a merchant. The raw key is returned in k.Key,
+// the only time it is known. A key restricted to a store may only be issued
+// by the store's owner or staff, and never reaches further than they do.
+func (uc *UseCase) CreateKey(ctx context.Context, role user.Role, k *domain.APIKey) error {
+	if role != user.Merchant {
+		return domain.ErrorNotMerchant
+	}
+
+	k.Name = strings.TrimSpace(k.Name)
+	if k.Name == "" {
+		return domain.ErrorMissingName
+	}
+	if len(k.Scopes) == 0 {
+		return domain.ErrorNoScopes
+	}
+	for _, s := range k.Scopes {
+		if !s.Valid() {
+			return fmt.Errorf("%w: %s", domain.ErrorInvalidScope, s)
+		}
+	}
+	slices.Sort(k.Scopes)
+	k.Scopes = slices.Compact(k.Scopes)
+
+	if k.StoreID != nil {
+		r, err := uc.stores.GetMemberRole(ctx, *k.StoreID, k.UserID)
+		if err != nil {
+			return fmt.Errorf("get store role: %w", err)
+		}
+		if r == "" {
+			return domain.ErrorStoreNotAllowed
+		}
+	}
+
+	raw, err := randomKey()
+	if err != nil {
+		return fmt.Errorf("generate api key: %w", err)
+	}
+	k.Key, k.KeyHash = raw, domain.HashKey(raw)
+	k.Prefix = raw[:len(domain.KeyPrefix)+6]
+
+	if err := uc.repo.Create(ctx, k); err != nil {
+		return fmt.Errorf("create api key: %w", err)
+	}
+	return nil
+}
+
+// ListKeys returns a user's keys, without their secrets.
+func (uc *UseCase) ListKeys(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	return uc.repo.ListByUser(ctx, userID)
+}
+
+// RevokeKey stops one of the user's keys from working. The key stays on
+// record.
+func (uc *UseCase) RevokeKey(ctx context.Context, userID, id uuid.UUID) error {
+	return uc.repo.Revoke(ctx, id, userID, uc.now())
+}
+
+// AuthenticateKey resolves a raw key to what requests made with it may do.
+// Keys of revoked, unknown or disabled accounts, and of users who are no
+// longer merchants, are refused. Use is recorded at most once per
+// domain.TouchInterval.
+func (uc *UseCase) AuthenticateKey(ctx context.Context, raw string) (*domain.Grant, error) {
+	if !strings.HasPrefix(raw, domain.KeyPrefix) {
+		return nil, domain.ErrorInvalidKey
+	}
+
+	k, err := uc.repo.GetByHash(ctx, domain.HashKey(raw))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrorInvalidKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+	if k.RevokedAt != nil {
+		return nil, domain.ErrorInvalidKey
+	}
+
+	u, err := uc.users.GetByID(ctx, k.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("get api key owner: %w", err)
+	}
+	if !auth.CanSignIn(u.Status) {
+		return nil, auth.ErrorAccountDisabled
+	}
+	if u.Role != user.Merchant {
+		return nil, domain.ErrorInvalidKey
+	}
+
+	now := uc.now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= domain.TouchInterval {
+		if err := uc.repo.TouchLastUsed(ctx, k.ID, now); err != nil {
+			log.Printf("touch api key %s: %v", k.ID, err)
+		}
+	}
+
+	return &domain.Grant{KeyID: k.ID, UserID: u.ID, Role: u.Role, Scopes: k.Scopes, StoreID: k.StoreID}, nil
+}
+
+// randomKey returns KeyPrefix followed by 256 random bits, URL-safe encoded.
+func randomKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return domain.KeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
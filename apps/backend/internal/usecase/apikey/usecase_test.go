@@ -0,0 +1,204 @@
+package apikey
+
+import (
+	domain "backend/internal/domain/apikey"
+	"backend/internal/domain/auth"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	keys    map[uuid.UUID]*domain.APIKey
+	touched int
+}
+
+func (f *fakeRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	k.ID = uuid.New()
+	cp := *k
+	cp.Key = ""
+	f.keys[k.ID] = &cp
+	return nil
+}
+
+func (f *fakeRepo) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	for _, k := range f.keys {
+		if k.KeyHash == keyHash {
+			cp := *k
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepo) ListByUser(ctx context.Context, userID uuid.UUID) ([]*domain.APIKey, error) {
+	var out []*domain.APIKey
+	for _, k := range f.keys {
+		if k.UserID == userID {
+			out = append(out, k)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) Revoke(ctx context.Context, id, userID uuid.UUID, revokedAt time.Time) error {
+	k, ok := f.keys[id]
+	if !ok || k.UserID != userID || k.RevokedAt != nil {
+		return domain.ErrorKeyNotFound
+	}
+	k.RevokedAt = &revokedAt
+	return nil
+}
+
+func (f *fakeRepo) TouchLastUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	f.keys[id].LastUsedAt = &usedAt
+	f.touched++
+	return nil
+}
+
+type fakeUsers map[uuid.UUID]*user.User
+
+func (f fakeUsers) GetByID(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	if u, ok := f[id]; ok {
+		return u, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// fakeStores maps a store to its members' roles.
+type fakeStores map[uuid.UUID]map[uuid.UUID]store.MemberRole
+
+func (f fakeStores) GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error) {
+	return f[storeID][userID], nil
+}
+
+type world struct {
+	uc       *UseCase
+	repo     *fakeRepo
+	merchant *user.User
+	storeID  uuid.UUID
+}
+
+func newWorld() *world {
+	w := &world{
+		repo:     &fakeRepo{keys: map[uuid.UUID]*domain.APIKey{}},
+		merchant: &user.User{ID: uuid.New(), Role: user.Merchant, Status: user.Active},
+		storeID:  uuid.New(),
+	}
+	users := fakeUsers{w.merchant.ID: w.merchant}
+	stores := fakeStores{w.storeID: {w.merchant.ID: store.MemberCatalogEditor}}
+	w.uc = NewUseCase(w.repo, users, stores)
+	return w
+}
+
+func (w *world) create(t *testing.T, storeID *uuid.UUID, scopes ...domain.Scope) *domain.APIKey {
+	k := &domain.APIKey{UserID: w.merchant.ID, Name: "ERP sync", Scopes: scopes, StoreID: storeID}
+	require.NoError(t, w.uc.CreateKey(context.Background(), user.Merchant, k))
+	return k
+}
+
+func TestCreateKey(t *testing.T) {
+	w := newWorld()
+	k := w.create(t, &w.storeID, domain.ScopeOrdersRead, domain.ScopeCatalogWrite, domain.ScopeOrdersRead)
+
+	require.True(t, strings.HasPrefix(k.Key, domain.KeyPrefix))
+	require.True(t, strings.HasPrefix(k.Key, k.Prefix))
+	require.Equal(t, domain.HashKey(k.Key), w.repo.keys[k.ID].KeyHash)
+	require.Empty(t, w.repo.keys[k.ID].Key, "the raw key is never stored")
+	require.Equal(t, []domain.Scope{domain.ScopeCatalogWrite, domain.ScopeOrdersRead}, k.Scopes)
+}
+
+func TestCreateKey_Validates(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	elsewhere := uuid.New()
+
+	tests := []struct {
+		name string
+		role user.Role
+		key  *domain.APIKey
+		want error
+	}{
+		{"not a merchant", user.Customer, &domain.APIKey{Name: "k", Scopes: []domain.Scope{domain.ScopeOrdersRead}}, domain.ErrorNotMerchant},
+		{"no name", user.Merchant, &domain.APIKey{Name: " ", Scopes: []domain.Scope{domain.ScopeOrdersRead}}, domain.ErrorMissingName},
+		{"no scopes", user.Merchant, &domain.APIKey{Name: "k"}, domain.ErrorNoScopes},
+		{"unknown scope", user.Merchant, &domain.APIKey{Name: "k", Scopes: []domain.Scope{"orders:delete"}}, domain.ErrorInvalidScope},
+		{"store not theirs", user.Merchant, &domain.APIKey{Name: "k", Scopes: []domain.Scope{domain.ScopeOrdersRead}, StoreID: &elsewhere}, domain.ErrorStoreNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.key.UserID = w.merchant.ID
+			require.ErrorIs(t, w.uc.CreateKey(ctx, tt.role, tt.key), tt.want)
+		})
+	}
+	require.Empty(t, w.repo.keys)
+}
+
+func TestAuthenticateKey(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	k := w.create(t, &w.storeID, domain.ScopeCatalogWrite)
+
+	g, err := w.uc.AuthenticateKey(ctx, k.Key)
+	require.NoError(t, err)
+	require.Equal(t, &domain.Grant{KeyID: k.ID, UserID: w.merchant.ID, Role: user.Merchant, Scopes: k.Scopes, StoreID: &w.storeID}, g)
+	require.True(t, g.Has(domain.ScopeCatalogWrite))
+	require.False(t, g.Has(domain.ScopeOrdersRead))
+
+	// Use within the touch interval isn't recorded again
+	_, err = w.uc.AuthenticateKey(ctx, k.Key)
+	require.NoError(t, err)
+	require.Equal(t, 1, w.repo.touched)
+
+	w.uc.now = func() time.Time { return time.Now().Add(domain.TouchInterval) }
+	_, err = w.uc.AuthenticateKey(ctx, k.Key)
+	require.NoError(t, err)
+	require.Equal(t, 2, w.repo.touched)
+}
+
+func TestAuthenticateKey_Rejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown", func(t *testing.T) {
+		w := newWorld()
+		_, err := w.uc.AuthenticateKey(ctx, domain.KeyPrefix+"nope")
+		require.ErrorIs(t, err, domain.ErrorInvalidKey)
+		_, err = w.uc.AuthenticateKey(ctx, "Bearer nope")
+		require.ErrorIs(t, err, domain.ErrorInvalidKey)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		w := newWorld()
+		k := w.create(t, nil, domain.ScopeOrdersRead)
+		require.ErrorIs(t, w.uc.RevokeKey(ctx, uuid.New(), k.ID), domain.ErrorKeyNotFound, "someone else's key")
+		require.NoError(t, w.uc.RevokeKey(ctx, w.merchant.ID, k.ID))
+
+		_, err := w.uc.AuthenticateKey(ctx, k.Key)
+		require.ErrorIs(t, err, domain.ErrorInvalidKey)
+	})
+
+	t.Run("suspended owner", func(t *testing.T) {
+		w := newWorld()
+		k := w.create(t, nil, domain.ScopeOrdersRead)
+		w.merchant.Status = user.Suspended
+
+		_, err := w.uc.AuthenticateKey(ctx, k.Key)
+		require.ErrorIs(t, err, auth.ErrorAccountDisabled)
+	})
+
+	t.Run("owner no longer a merchant", func(t *testing.T) {
+		w := newWorld()
+		k := w.create(t, nil, domain.ScopeOrdersRead)
+		w.merchant.Role = user.Customer
+
+		_, err := w.uc.AuthenticateKey(ctx, k.Key)
+		require.ErrorIs(t, err, domain.ErrorInvalidKey)
+	})
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Login checks an email address and password and begins the sign-in with
+// BeginLogin. Attempts are throttled per account and per client IP.
+func (uc *UseCase) Login(ctx context.Context, email, password, ip string) (*user.User, *auth.LoginResult, error) {
+	u, err := uc.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		u = nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("get user: %w", err)
+	}
+
+	if err := uc.checkPassword(ctx, email, u, password, ip); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := uc.BeginLogin(ctx, u)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, res, nil
+}
+
+// ChangePassword replaces a signed-in user's password once the current one
+// checks out. It is throttled like sign-in, so a stolen session can't be
+// used to guess the password, and lifts a forced password change. The new
+// password has to be long enough and differ from the current one.
+func (uc *UseCase) ChangePassword(ctx context.Context, userID uuid.UUID, req *user.ChangePasswordRequest, ip string) error {
+	if len(req.NewPassword) < auth.MinPasswordLength {
+		return auth.ErrorWeakPassword
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return user.ErrPasswordUnchanged
+	}
+
+	u, err := uc.users.GetByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	if err := uc.checkPassword(ctx, u.Email, u, req.CurrentPassword, ip); err != nil {
+		if errors.Is(err, auth.ErrorInvalidCredentials) {
+			return user.ErrInvalidCurrentPassword
+		}
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	if err := uc.users.UpdatePassword(ctx, userID, string(hashed)); err != nil {
+		return fmt.Errorf("update password: %w", err)
+	}
+	return nil
+}
+
+// UnlockAccount lifts a lockout and forgets the account's failed attempts.
+func (uc *UseCase) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	u, err := uc.users.GetByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return user.ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+
+	if err := uc.repo.DeleteThrottle(ctx, auth.ThrottleAccount, auth.AccountKey(u.Email)); err != nil {
+		return fmt.Errorf("delete throttle: %w", err)
+	}
+	return nil
+}
+
+type throttleKey struct {
+	scope auth.ThrottleScope
+	key   string
+}
+
+// checkPassword compares password with that of u, which is nil for unknown
+// addresses so they can't be told apart by their lockouts. An attempt must
+// wait out the delays earned by earlier failures on the account and from the
+// IP, and fails with auth.ThrottledError meanwhile. A failure counts against
+// both, a success clears the account's count. IPs are only cleared by time,
+// so one good password doesn't cover for guesses across many accounts.
+//
+// The throttles stay locked from the check until the outcome is stored, so
+// parallel guesses are judged one after the other.
+func (uc *UseCase) checkPassword(ctx context.Context, email string, u *user.User, password, ip string) error {
+	keys := []throttleKey{{auth.ThrottleAccount, auth.AccountKey(email)}}
+	if ip != "" {
+		keys = append(keys, throttleKey{auth.ThrottleIP, ip})
+	}
+
+	var wrong, locked bool
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		// Always account before IP, so concurrent attempts can't deadlock
+		throttles := make([]*auth.Throttle, 0, len(keys))
+		for _, k := range keys {
+			t, err := uc.repo.LockThrottle(txCtx, k.scope, k.key)
+			if err != nil {
+				return fmt.Errorf("lock throttle: %w", err)
+			}
+			throttles = append(throttles, t)
+		}
+
+		now := uc.now()
+		for _, t := range throttles {
+			if wait := t.Wait(now); wait > 0 {
+				e := auth.ErrorTooManyAttempts
+				if t.Locked(now) {
+					e = auth.ErrorAccountLocked
+				}
+				return &auth.ThrottledError{Err: e, RetryAfter: wait}
+			}
+		}
+
+		if u != nil && u.ComparePassword(password) {
+			// Drop the account's count and throttles made just for this attempt
+			for _, t := range throttles {
+				if t.Scope != auth.ThrottleAccount && t.Failures > 0 {
+					continue
+				}
+				if err := uc.repo.DeleteThrottle(txCtx, t.Scope, t.Key); err != nil {
+					return fmt.Errorf("delete throttle: %w", err)
+				}
+			}
+			return nil
+		}
+
+		// Store the failure and commit, the error is returned once it is stored
+		wrong = true
+		for _, t := range throttles {
+			if t.Fail(now) {
+				locked = true
+			}
+			if err := uc.repo.SaveThrottle(txCtx, t); err != nil {
+				return fmt.Errorf("save throttle: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !wrong {
+		return nil
+	}
+
+	if locked && u != nil {
+		if err := uc.sendLockoutNotice(ctx, u); err != nil {
+			log.Printf("mail lockout notice to user %s: %v", u.ID, err)
+		}
+	}
+	return auth.ErrorInvalidCredentials
+}
+
+func (uc *UseCase) sendLockoutNotice(ctx context.Context, u *user.User) error {
+	body := fmt.Sprintf("Hi %s,\n\nYour account was locked for %s after %d failed sign-in attempts.\n\nIf this wasn't you, someone may be guessing your password. Once the lock lifts, reset your password to be safe, or contact support to unlock your account sooner.",
+		u.FullName, auth.LockoutDuration, auth.LockoutThreshold)
+	return uc.mailer.SendEmail(ctx, u.Email, "Your account was locked", body)
+}
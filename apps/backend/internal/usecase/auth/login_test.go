@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LockThrottle hands out a fresh throttle without storing it, like the
+// insert of a transaction that is rolled back unless the throttle is saved.
+func (f *fakeRepo) LockThrottle(ctx context.Context, scope auth.ThrottleScope, key string) (*auth.Throttle, error) {
+	t, ok := f.throttles[string(scope)+":"+key]
+	if !ok {
+		return &auth.Throttle{Scope: scope, Key: key}, nil
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (f *fakeRepo) SaveThrottle(ctx context.Context, t *auth.Throttle) error {
+	cp := *t
+	f.throttles[string(t.Scope)+":"+t.Key] = &cp
+	return nil
+}
+
+func (f *fakeRepo) DeleteThrottle(ctx context.Context, scope auth.ThrottleScope, key string) error {
+	delete(f.throttles, string(scope)+":"+key)
+	return nil
+}
+
+const testPassword = "correct horse"
+
+func withPassword(t *testing.T, u *user.User) *user.User {
+	hash, err := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+	require.NoError(t, err)
+	u.PasswordHash = string(hash)
+	return u
+}
+
+// retryAfter returns how long a throttled err asks to wait.
+func retryAfter(t *testing.T, err error) time.Duration {
+	var te *auth.ThrottledError
+	require.ErrorAs(t, err, &te)
+	return te.RetryAfter
+}
+
+func TestLogin(t *testing.T) {
+	u := withPassword(t, newUser(user.Active))
+	uc, _ := newTestUseCase(u)
+
+	got, res, err := uc.Login(context.Background(), " wanjiru@example.com ", testPassword, "203.0.113.7")
+	require.NoError(t, err)
+	require.Equal(t, u.ID, got.ID)
+	require.NotNil(t, res.Tokens)
+
+	_, _, err = uc.Login(context.Background(), u.Email, "wrong", "203.0.113.7")
+	require.ErrorIs(t, err, auth.ErrorInvalidCredentials)
+
+	_, _, err = uc.Login(context.Background(), "nobody@example.com", testPassword, "203.0.113.7")
+	require.ErrorIs(t, err, auth.ErrorInvalidCredentials)
+}
+
+func TestLogin_ProgressiveDelay(t *testing.T) {
+	ctx := context.Background()
+	u := withPassword(t, newUser(user.Active))
+	uc, repo := newTestUseCase(u)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	for range auth.AccountFreeAttempts + 1 {
+		_, _, err := uc.Login(ctx, u.Email, "wrong", "")
+		require.ErrorIs(t, err, auth.ErrorInvalidCredentials)
+	}
+
+	// Even the right password has to wait out the delay
+	_, _, err := uc.Login(ctx, u.Email, testPassword, "")
+	require.ErrorIs(t, err, auth.ErrorTooManyAttempts)
+	require.Equal(t, time.Second, retryAfter(t, err))
+
+	now = now.Add(time.Second)
+	_, _, err = uc.Login(ctx, u.Email, testPassword, "")
+	require.NoError(t, err)
+	require.Empty(t, repo.throttles, "a success clears the account's failures")
+}
+
+func TestLogin_LocksAccount(t *testing.T) {
+	ctx := context.Background()
+	u := withPassword(t, newUser(user.Active))
+	uc, repo, mail := newTestUseCaseWithMail(u)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	for range auth.LockoutThreshold {
+		_, _, err := uc.Login(ctx, u.Email, "wrong", "")
+		require.ErrorIs(t, err, auth.ErrorInvalidCredentials)
+		now = now.Add(auth.MaxAttemptDelay)
+	}
+	require.Contains(t, mail[u.Email], "locked")
+
+	_, _, err := uc.Login(ctx, u.Email, testPassword, "")
+	require.ErrorIs(t, err, auth.ErrorAccountLocked)
+	require.Equal(t, auth.LockoutDuration-auth.MaxAttemptDelay, retryAfter(t, err))
+
+	// An admin can lift the lock early
+	require.NoError(t, uc.UnlockAccount(ctx, u.ID))
+	require.Empty(t, repo.throttles)
+	_, _, err = uc.Login(ctx, u.Email, testPassword, "")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, uc.UnlockAccount(ctx, newUser(user.Active).ID), user.ErrUserNotFound)
+}
+
+func TestLogin_ThrottlesIP(t *testing.T) {
+	ctx := context.Background()
+	u := withPassword(t, newUser(user.Active))
+	uc, _ := newTestUseCase(u)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	// Guesses spread over many accounts still add up for the IP
+	for i := range auth.IPFreeAttempts + 1 {
+		email := string(rune('a'+i)) + "@example.com"
+		_, _, err := uc.Login(ctx, email, "wrong", "203.0.113.7")
+		require.ErrorIs(t, err, auth.ErrorInvalidCredentials)
+	}
+
+	_, _, err := uc.Login(ctx, u.Email, testPassword, "203.0.113.7")
+	require.ErrorIs(t, err, auth.ErrorTooManyAttempts)
+
+	_, _, err = uc.Login(ctx, u.Email, testPassword, "198.51.100.2")
+	require.NoError(t, err, "other clients are not held up")
+}
+
+func TestChangePassword(t *testing.T) {
+	ctx := context.Background()
+	u := withPassword(t, newUser(user.Active))
+	u.Must_change_password = true
+	uc, _ := newTestUseCase(u)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	for range auth.AccountFreeAttempts + 1 {
+		err := uc.ChangePassword(ctx, u.ID, &user.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new password"}, "")
+		require.ErrorIs(t, err, user.ErrInvalidCurrentPassword)
+	}
+
+	req := &user.ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: "new password"}
+	require.ErrorIs(t, uc.ChangePassword(ctx, u.ID, req, ""), auth.ErrorTooManyAttempts)
+
+	now = now.Add(time.Second)
+	require.NoError(t, uc.ChangePassword(ctx, u.ID, req, ""))
+	require.True(t, u.ComparePassword("new password"))
+	require.False(t, u.Must_change_password)
+}
+
+func TestChangePassword_RejectsWeakOrUnchangedPassword(t *testing.T) {
+	ctx := context.Background()
+	u := withPassword(t, newUser(user.Active))
+	u.Must_change_password = true
+	uc, _ := newTestUseCase(u)
+
+	for _, newPassword := range []string{"", "short"} {
+		err := uc.ChangePassword(ctx, u.ID, &user.ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: newPassword}, "")
+		require.ErrorIs(t, err, auth.ErrorWeakPassword)
+	}
+
+	err := uc.ChangePassword(ctx, u.ID, &user.ChangePasswordRequest{CurrentPassword: testPassword, NewPassword: testPassword}, "")
+	require.ErrorIs(t, err, user.ErrPasswordUnchanged)
+
+	require.True(t, u.ComparePassword(testPassword))
+	require.True(t, u.Must_change_password, "the forced change is still due")
+}
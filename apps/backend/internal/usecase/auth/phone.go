@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// RequestPhoneCode texts a sign-in code to a Kenyan mobile number, replacing
+// any code sent before. Numbers nobody verified yet get one too, their
+// customer is created on sign-in.
+func (uc *UseCase) RequestPhoneCode(ctx context.Context, rawPhone, ip string) error {
+	phone, err := user.NormalizePhone(rawPhone)
+	if err != nil {
+		return err
+	}
+
+	code, err := randomPhoneCode()
+	if err != nil {
+		return fmt.Errorf("generate phone code: %w", err)
+	}
+
+	// The latest code stays locked from the limit check until the new one is
+	// stored, so concurrent requests can't both pass the resend interval
+	err = uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		now := uc.now()
+		if err := uc.checkPhoneCodeLimits(txCtx, phone, ip, now); err != nil {
+			return err
+		}
+		c := &auth.PhoneCode{
+			Phone:     phone,
+			CodeHash:  auth.HashToken(code),
+			IP:        ip,
+			ExpiresAt: now.Add(auth.PhoneCodeTTL),
+			CreatedAt: now,
+		}
+		if err := uc.repo.CreatePhoneCode(txCtx, c); err != nil {
+			return fmt.Errorf("create phone code: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg := fmt.Sprintf("Your sign-in code is %s. It expires in %d minutes. Never share it with anyone.",
+		code, int(auth.PhoneCodeTTL.Minutes()))
+	if err := uc.sms.SendSMS(ctx, phone, msg); err != nil {
+		return fmt.Errorf("send phone code: %w", err)
+	}
+	return nil
+}
+
+// VerifyPhoneCode signs a customer in with the last code texted to their
+// number. The first sign-in of a number verifies it on a new guest customer.
+func (uc *UseCase) VerifyPhoneCode(ctx context.Context, rawPhone, code string) (*user.User, *auth.TokenPair, error) {
+	phone, err := user.NormalizePhone(rawPhone)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		u     *user.User
+		pair  *auth.TokenPair
+		wrong bool
+	)
+	err = uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		c, err := uc.repo.GetLatestPhoneCodeForUpdate(txCtx, phone)
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.ErrorInvalidPhoneCode
+		}
+		if err != nil {
+			return fmt.Errorf("get phone code: %w", err)
+		}
+
+		now := uc.now()
+		if c.UsedAt != nil || c.Attempts >= auth.MaxPhoneCodeAttempts || !now.Before(c.ExpiresAt) {
+			return auth.ErrorInvalidPhoneCode
+		}
+
+		// Count the attempt and commit, the error is returned once it is stored
+		if c.CodeHash != auth.HashToken(strings.TrimSpace(code)) {
+			wrong = true
+			return uc.repo.AddPhoneCodeAttempt(txCtx, c.ID)
+		}
+
+		if err := uc.repo.MarkPhoneCodeUsed(txCtx, c.ID, now); err != nil {
+			return fmt.Errorf("mark phone code used: %w", err)
+		}
+		u, err = uc.customerByPhone(txCtx, phone, now)
+		if err != nil {
+			return err
+		}
+		pair, err = uc.StartSession(txCtx, u)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if wrong {
+		return nil, nil, auth.ErrorInvalidPhoneCode
+	}
+	return u, pair, nil
+}
+
+// checkPhoneCodeLimits refuses a code sooner than auth.PhoneCodeResendInterval
+// after the last one, and more than the hourly caps per number and IP.
+func (uc *UseCase) checkPhoneCodeLimits(ctx context.Context, phone, ip string, now time.Time) error {
+	last, err := uc.repo.GetLatestPhoneCodeForUpdate(ctx, phone)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get phone code: %w", err)
+	}
+	if err == nil {
+		if wait := last.CreatedAt.Add(auth.PhoneCodeResendInterval).Sub(now); wait > 0 {
+			return &auth.ThrottledError{Err: auth.ErrorTooManyCodeRequests, RetryAfter: wait}
+		}
+	}
+
+	since := now.Add(-auth.PhoneCodeWindow)
+	n, err := uc.repo.CountPhoneCodes(ctx, phone, since)
+	if err != nil {
+		return fmt.Errorf("count phone codes: %w", err)
+	}
+	if n >= auth.MaxPhoneCodesPerPhone {
+		return &auth.ThrottledError{Err: auth.ErrorTooManyCodeRequests, RetryAfter: auth.PhoneCodeWindow}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	n, err = uc.repo.CountPhoneCodesFromIP(ctx, ip, since)
+	if err != nil {
+		return fmt.Errorf("count phone codes: %w", err)
+	}
+	if n >= auth.MaxPhoneCodesPerIP {
+		return &auth.ThrottledError{Err: auth.ErrorTooManyCodeRequests, RetryAfter: auth.PhoneCodeWindow}
+	}
+	return nil
+}
+
+// customerByPhone returns the customer who verified a number, creating a
+// guest customer without email address or password for it if there is none.
+// Customers who merely typed the number into their profile are never signed
+// into, it was never proven to be theirs.
+func (uc *UseCase) customerByPhone(ctx context.Context, phone string, now time.Time) (*user.User, error) {
+	u, err := uc.users.GetCustomerByVerifiedPhone(ctx, phone)
+	if err == nil {
+		return u, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+
+	u = &user.User{
+		FullName:        auth.GuestName,
+		Role:            user.Customer,
+		Status:          user.Active,
+		Phone:           phone,
+		PhoneVerifiedAt: &now,
+	}
+	if err := uc.users.Create(ctx, u); err != nil {
+		return nil, fmt.Errorf("create guest customer: %w", err)
+	}
+	return u, nil
+}
+
+// randomPhoneCode returns auth.PhoneCodeLength random digits.
+func randomPhoneCode() (string, error) {
+	limit := big.NewInt(1)
+	for range auth.PhoneCodeLength {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", auth.PhoneCodeLength, n), nil
+}
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeRepo) Create(ctx context.Context, u *user.User) error {
+	u.ID = uuid.New()
+	f.users[u.ID] = u
+	return nil
+}
+
+func (f *fakeRepo) GetCustomerByVerifiedPhone(ctx context.Context, phone string) (*user.User, error) {
+	for _, u := range f.users {
+		if u.Phone == phone && u.PhoneVerifiedAt != nil && u.Role == user.Customer {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepo) CreatePhoneCode(ctx context.Context, c *auth.PhoneCode) error {
+	c.ID = uuid.New()
+	cp := *c
+	f.phoneCodes = append(f.phoneCodes, &cp)
+	return nil
+}
+
+func (f *fakeRepo) GetLatestPhoneCodeForUpdate(ctx context.Context, phone string) (*auth.PhoneCode, error) {
+	for i := len(f.phoneCodes) - 1; i >= 0; i-- {
+		if c := f.phoneCodes[i]; c.Phone == phone {
+			cp := *c
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepo) CountPhoneCodes(ctx context.Context, phone string, since time.Time) (int, error) {
+	n := 0
+	for _, c := range f.phoneCodes {
+		if c.Phone == phone && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeRepo) CountPhoneCodesFromIP(ctx context.Context, ip string, since time.Time) (int, error) {
+	n := 0
+	for _, c := range f.phoneCodes {
+		if c.IP == ip && !c.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeRepo) AddPhoneCodeAttempt(ctx context.Context, id uuid.UUID) error {
+	for _, c := range f.phoneCodes {
+		if c.ID == id {
+			c.Attempts++
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) MarkPhoneCodeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	for _, c := range f.phoneCodes {
+		if c.ID == id {
+			c.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// fakeSMS keeps the last text sent to each number.
+type fakeSMS map[string]string
+
+func (f fakeSMS) SendSMS(ctx context.Context, phone string, message string) error {
+	f[phone] = message
+	return nil
+}
+
+// codeIn pulls the code out of the last text to phone.
+func codeIn(t *testing.T, uc *UseCase, phone string) string {
+	msg, ok := uc.sms.(fakeSMS)[phone]
+	require.True(t, ok, "no text to %s", phone)
+	_, rest, _ := strings.Cut(msg, "code is ")
+	code, _, _ := strings.Cut(rest, ".")
+	require.Len(t, code, auth.PhoneCodeLength, msg)
+	return code
+}
+
+func TestPhoneLogin_CreatesGuest(t *testing.T) {
+	ctx := context.Background()
+	uc, repo := newTestUseCase()
+
+	require.NoError(t, uc.RequestPhoneCode(ctx, "0712 345 678", "203.0.113.7"))
+	code := codeIn(t, uc, "+254712345678")
+
+	u, pair, err := uc.VerifyPhoneCode(ctx, "+254712345678", code)
+	require.NoError(t, err)
+	require.NotNil(t, pair)
+	require.Equal(t, user.Customer, u.Role)
+	require.Equal(t, "+254712345678", u.Phone)
+	require.Empty(t, u.Email)
+	require.NotNil(t, u.PhoneVerifiedAt)
+	require.Len(t, repo.users, 1)
+
+	// A code works once
+	_, _, err = uc.VerifyPhoneCode(ctx, "+254712345678", code)
+	require.ErrorIs(t, err, auth.ErrorInvalidPhoneCode)
+}
+
+func TestPhoneLogin_ExistingCustomer(t *testing.T) {
+	ctx := context.Background()
+	c := newUser(user.Active)
+	c.Phone = "+254712345678"
+	verified := time.Now()
+	c.PhoneVerifiedAt = &verified
+	uc, repo := newTestUseCase(c)
+
+	require.NoError(t, uc.RequestPhoneCode(ctx, "712345678", ""))
+	u, _, err := uc.VerifyPhoneCode(ctx, "0712345678", codeIn(t, uc, "+254712345678"))
+	require.NoError(t, err)
+	require.Equal(t, c.ID, u.ID)
+	require.Len(t, repo.users, 1)
+}
+
+func TestPhoneLogin_IgnoresUnverifiedNumbers(t *testing.T) {
+	ctx := context.Background()
+	c := newUser(user.Active)
+	c.Phone = "0712345678"
+	uc, repo := newTestUseCase(c)
+
+	require.NoError(t, uc.RequestPhoneCode(ctx, "0712345678", ""))
+	u, _, err := uc.VerifyPhoneCode(ctx, "0712345678", codeIn(t, uc, "+254712345678"))
+	require.NoError(t, err)
+	require.NotEqual(t, c.ID, u.ID, "a number typed into a profile doesn't sign into it")
+	require.Equal(t, auth.GuestName, u.FullName)
+	require.NotNil(t, u.PhoneVerifiedAt)
+	require.Len(t, repo.users, 2)
+}
+
+func TestPhoneLogin_Rejects(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+	phone := "+254712345678"
+
+	require.ErrorIs(t, uc.RequestPhoneCode(ctx, "12345", ""), user.ErrInvalidPhone)
+
+	_, _, err := uc.VerifyPhoneCode(ctx, phone, "123456")
+	require.ErrorIs(t, err, auth.ErrorInvalidPhoneCode, "no code sent")
+
+	// Wrong codes use up the code
+	require.NoError(t, uc.RequestPhoneCode(ctx, phone, ""))
+	code := codeIn(t, uc, phone)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for range auth.MaxPhoneCodeAttempts {
+		_, _, err = uc.VerifyPhoneCode(ctx, phone, wrong)
+		require.ErrorIs(t, err, auth.ErrorInvalidPhoneCode)
+	}
+	_, _, err = uc.VerifyPhoneCode(ctx, phone, code)
+	require.ErrorIs(t, err, auth.ErrorInvalidPhoneCode)
+
+	// Codes expire
+	now = now.Add(auth.PhoneCodeResendInterval)
+	require.NoError(t, uc.RequestPhoneCode(ctx, phone, ""))
+	code = codeIn(t, uc, phone)
+	now = now.Add(auth.PhoneCodeTTL)
+	_, _, err = uc.VerifyPhoneCode(ctx, phone, code)
+	require.ErrorIs(t, err, auth.ErrorInvalidPhoneCode)
+}
+
+func TestRequestPhoneCode_RateLimits(t *testing.T) {
+	ctx := context.Background()
+	uc, _ := newTestUseCase()
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+	phone := "+254712345678"
+
+	require.NoError(t, uc.RequestPhoneCode(ctx, phone, ""))
+	err := uc.RequestPhoneCode(ctx, phone, "")
+	require.ErrorIs(t, err, auth.ErrorTooManyCodeRequests)
+	require.Equal(t, auth.PhoneCodeResendInterval, retryAfter(t, err))
+
+	for range auth.MaxPhoneCodesPerPhone - 1 {
+		now = now.Add(auth.PhoneCodeResendInterval)
+		require.NoError(t, uc.RequestPhoneCode(ctx, phone, ""))
+	}
+	now = now.Add(auth.PhoneCodeResendInterval)
+	require.ErrorIs(t, uc.RequestPhoneCode(ctx, phone, ""), auth.ErrorTooManyCodeRequests, "hourly cap per number")
+
+	// Many numbers from one client
+	for i := range auth.MaxPhoneCodesPerIP {
+		require.NoError(t, uc.RequestPhoneCode(ctx, "07000000"+string(rune('0'+i/10))+string(rune('0'+i%10)), "203.0.113.7"))
+	}
+	require.ErrorIs(t, uc.RequestPhoneCode(ctx, "0799999999", "203.0.113.7"), auth.ErrorTooManyCodeRequests, "hourly cap per IP")
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SendEmailVerification mails a user a link confirming their address. It is
+// a no-op for addresses that are already verified.
+func (uc *UseCase) SendEmailVerification(ctx context.Context, u *user.User) error {
+	if u.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	raw, err := uc.createOneTimeToken(ctx, u.ID, auth.PurposeEmailVerification, auth.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nConfirm your email address with %s\n\nIt expires in %s.",
+		u.FullName, uc.link("verify-email", raw), auth.EmailVerificationTTL)
+	return uc.mailer.SendEmail(ctx, u.Email, "Verify your email address", body)
+}
+
+// ResendEmailVerification mails a new verification link. Unknown addresses
+// are ignored so the endpoint does not reveal who has an account.
+func (uc *UseCase) ResendEmailVerification(ctx context.Context, email string) error {
+	u, err := uc.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	return uc.SendEmailVerification(ctx, u)
+}
+
+// VerifyEmail marks the address of the token's user as verified.
+func (uc *UseCase) VerifyEmail(ctx context.Context, raw string) error {
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		t, err := uc.consumeOneTimeToken(txCtx, raw, auth.PurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		return uc.users.MarkEmailVerified(txCtx, t.UserID)
+	})
+}
+
+// RequestPasswordReset mails a password reset link. Unknown addresses and
+// disabled accounts are ignored so the endpoint does not reveal either.
+func (uc *UseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	u, err := uc.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get user: %w", err)
+	}
+	if !auth.CanSignIn(u.Status) {
+		return nil
+	}
+
+	raw, err := uc.createOneTimeToken(ctx, u.ID, auth.PurposePasswordReset, auth.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nChoose a new password with %s\n\nIt expires in %s. If you did not ask for this, ignore this email.",
+		u.FullName, uc.link("reset-password", raw), auth.PasswordResetTTL)
+	return uc.mailer.SendEmail(ctx, u.Email, "Reset your password", body)
+}
+
+// ResetPassword sets a new password with a reset token. It lifts a forced
+// password change and signs the user out everywhere, since whoever knew the
+// old password may hold a session.
+func (uc *UseCase) ResetPassword(ctx context.Context, raw, newPassword string) error {
+	if len(newPassword) < auth.MinPasswordLength {
+		return auth.ErrorWeakPassword
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		t, err := uc.consumeOneTimeToken(txCtx, raw, auth.PurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		if err := uc.users.UpdatePassword(txCtx, t.UserID, string(hashed)); err != nil {
+			return fmt.Errorf("update password: %w", err)
+		}
+		if err := uc.repo.RevokeUserSessions(txCtx, t.UserID, auth.RevokedByPasswordReset); err != nil {
+			return fmt.Errorf("revoke sessions: %w", err)
+		}
+		return nil
+	})
+}
+
+func (uc *UseCase) createOneTimeToken(ctx context.Context, userID uuid.UUID, purpose auth.Purpose, ttl time.Duration) (string, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+
+	t := &auth.OneTimeToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: auth.HashToken(raw),
+		ExpiresAt: uc.now().Add(ttl),
+	}
+	if err := uc.repo.CreateOneTimeToken(ctx, t); err != nil {
+		return "", fmt.Errorf("create %s token: %w", purpose, err)
+	}
+	return raw, nil
+}
+
+// consumeOneTimeToken marks an unused, unexpired token as used. Must run in
+// a transaction.
+func (uc *UseCase) consumeOneTimeToken(ctx context.Context, raw string, purpose auth.Purpose) (*auth.OneTimeToken, error) {
+	t, err := uc.repo.GetOneTimeTokenForUpdate(ctx, auth.HashToken(raw), purpose)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrorInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get %s token: %w", purpose, err)
+	}
+
+	now := uc.now()
+	if t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, auth.ErrorInvalidToken
+	}
+	if err := uc.repo.MarkOneTimeTokenUsed(ctx, t.ID, now); err != nil {
+		return nil, fmt.Errorf("mark %s token used: %w", purpose, err)
+	}
+	return t, nil
+}
+
+// link points at the app page handling the token, or is the bare token when
+// no app URL is configured.
+func (uc *UseCase) link(page, raw string) string {
+	if uc.cfg.AppBaseURL == "" {
+		return "this code: " + raw
+	}
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimRight(uc.cfg.AppBaseURL, "/"), page, url.QueryEscape(raw))
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordReset(t *testing.T) {
+	u := newUser(user.Active)
+	u.Must_change_password = true
+	uc, repo, mail := newTestUseCaseWithMail(u)
+	ctx := context.Background()
+
+	pair, err := uc.StartSession(ctx, u)
+	require.NoError(t, err)
+
+	require.NoError(t, uc.RequestPasswordReset(ctx, "  "+u.Email+" "))
+	require.Contains(t, mail[u.Email], "https://app.example.com/reset-password?token=")
+	raw := mail.tokenIn(t, u.Email)
+
+	require.ErrorIs(t, uc.ResetPassword(ctx, raw, "short"), auth.ErrorWeakPassword)
+	require.NoError(t, uc.ResetPassword(ctx, raw, "a-new-password"))
+	require.True(t, u.ComparePassword("a-new-password"))
+	require.False(t, u.Must_change_password, "reset lifts the forced change")
+
+	// Single use
+	require.ErrorIs(t, uc.ResetPassword(ctx, raw, "another-password"), auth.ErrorInvalidToken)
+
+	// Every session is signed out
+	userID, sessionID := sessionOf(t, pair.AccessToken)
+	require.ErrorIs(t, sessionErr(uc, userID, sessionID), auth.ErrorSessionRevoked)
+	require.Equal(t, auth.RevokedByPasswordReset, *repo.sessions[sessionID].RevokedReason)
+}
+
+func TestPasswordReset_Rejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expired token", func(t *testing.T) {
+		u := newUser(user.Active)
+		uc, _, mail := newTestUseCaseWithMail(u)
+		require.NoError(t, uc.RequestPasswordReset(ctx, u.Email))
+
+		uc.now = func() time.Time { return time.Now().Add(auth.PasswordResetTTL + time.Minute) }
+		require.ErrorIs(t, uc.ResetPassword(ctx, mail.tokenIn(t, u.Email), "a-new-password"), auth.ErrorInvalidToken)
+	})
+
+	t.Run("superseded token", func(t *testing.T) {
+		u := newUser(user.Active)
+		uc, _, mail := newTestUseCaseWithMail(u)
+		require.NoError(t, uc.RequestPasswordReset(ctx, u.Email))
+		first := mail.tokenIn(t, u.Email)
+		require.NoError(t, uc.RequestPasswordReset(ctx, u.Email))
+
+		require.ErrorIs(t, uc.ResetPassword(ctx, first, "a-new-password"), auth.ErrorInvalidToken)
+		require.NoError(t, uc.ResetPassword(ctx, mail.tokenIn(t, u.Email), "a-new-password"))
+	})
+
+	t.Run("verification token", func(t *testing.T) {
+		u := newUser(user.Active)
+		uc, _, mail := newTestUseCaseWithMail(u)
+		require.NoError(t, uc.SendEmailVerification(ctx, u))
+
+		require.ErrorIs(t, uc.ResetPassword(ctx, mail.tokenIn(t, u.Email), "a-new-password"), auth.ErrorInvalidToken)
+	})
+}
+
+func TestRequestPasswordReset_Silent(t *testing.T) {
+	suspended := newUser(user.Suspended)
+	uc, _, mail := newTestUseCaseWithMail(suspended)
+	ctx := context.Background()
+
+	require.NoError(t, uc.RequestPasswordReset(ctx, "nobody@example.com"))
+	require.NoError(t, uc.RequestPasswordReset(ctx, suspended.Email))
+	require.Empty(t, mail)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	u := newUser(user.Pending)
+	uc, _, mail := newTestUseCaseWithMail(u)
+	ctx := context.Background()
+
+	require.NoError(t, uc.SendEmailVerification(ctx, u))
+	require.Contains(t, mail[u.Email], "https://app.example.com/verify-email?token=")
+	raw := mail.tokenIn(t, u.Email)
+
+	require.NoError(t, uc.VerifyEmail(ctx, raw))
+	require.NotNil(t, u.EmailVerifiedAt)
+	require.ErrorIs(t, uc.VerifyEmail(ctx, raw), auth.ErrorInvalidToken)
+
+	// Verified addresses get no more mail
+	delete(mail, u.Email)
+	require.NoError(t, uc.ResendEmailVerification(ctx, u.Email))
+	require.NoError(t, uc.ResendEmailVerification(ctx, "nobody@example.com"))
+	require.Empty(t, mail)
+}
+
+func TestVerifyEmail_Expired(t *testing.T) {
+	u := newUser(user.Pending)
+	uc, _, mail := newTestUseCaseWithMail(u)
+	ctx := context.Background()
+
+	require.NoError(t, uc.ResendEmailVerification(ctx, u.Email))
+
+	uc.now = func() time.Time { return time.Now().Add(auth.EmailVerificationTTL + time.Minute) }
+	require.ErrorIs(t, uc.VerifyEmail(ctx, mail.tokenIn(t, u.Email)), auth.ErrorInvalidToken)
+	require.Nil(t, u.EmailVerifiedAt)
+}
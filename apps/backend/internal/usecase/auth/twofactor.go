@@ -0,0 +1,426 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// BeginLogin follows a correct password. Users with two-factor
+// authentication get a challenge to answer with CompleteLogin, everyone else
+// a session straight away. MustEnrolTwoFactor is set on the result when the
+// user's role requires a second factor they haven't set up yet.
+func (uc *UseCase) BeginLogin(ctx context.Context, u *user.User) (*auth.LoginResult, error) {
+	if !auth.CanSignIn(u.Status) {
+		return nil, auth.ErrorAccountDisabled
+	}
+
+	tf, err := uc.getTwoFactor(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		c, err := uc.openChallenge(ctx, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.LoginResult{Challenge: c}, nil
+	}
+
+	required, err := uc.twoFactorRequired(ctx, u.Role)
+	if err != nil {
+		return nil, err
+	}
+	pair, err := uc.StartSession(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.LoginResult{Tokens: pair, MustEnrolTwoFactor: required}, nil
+}
+
+// CompleteLogin answers a login challenge with a code from the user's app or
+// a recovery code, and opens the session. A challenge works once and only
+// takes auth.MaxChallengeAttempts wrong codes.
+func (uc *UseCase) CompleteLogin(ctx context.Context, rawChallenge, code string) (*user.User, *auth.TokenPair, error) {
+	var (
+		u     *user.User
+		pair  *auth.TokenPair
+		wrong bool
+	)
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		c, err := uc.repo.GetLoginChallengeForUpdate(txCtx, auth.HashToken(rawChallenge))
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.ErrorInvalidChallenge
+		}
+		if err != nil {
+			return fmt.Errorf("get login challenge: %w", err)
+		}
+
+		now := uc.now()
+		if c.UsedAt != nil || c.Attempts >= auth.MaxChallengeAttempts || !now.Before(c.ExpiresAt) {
+			return auth.ErrorInvalidChallenge
+		}
+
+		u, err = uc.users.GetByID(txCtx, c.UserID)
+		if err != nil {
+			return fmt.Errorf("get user: %w", err)
+		}
+		tf, err := uc.getTwoFactor(txCtx, u.ID)
+		if err != nil {
+			return err
+		}
+		if !tf.Enabled() {
+			return auth.ErrorInvalidChallenge
+		}
+
+		// Count the attempt and commit, the error is returned once it is stored
+		err = uc.verifySecondFactor(txCtx, tf, code)
+		if errors.Is(err, auth.ErrorInvalidTwoFactorCode) {
+			wrong = true
+			return uc.repo.AddLoginChallengeAttempt(txCtx, c.ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := uc.repo.MarkLoginChallengeUsed(txCtx, c.ID, now); err != nil {
+			return fmt.Errorf("mark login challenge used: %w", err)
+		}
+		pair, err = uc.StartSession(txCtx, u)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if wrong {
+		return nil, nil, auth.ErrorInvalidTwoFactorCode
+	}
+	return u, pair, nil
+}
+
+// EnrolTwoFactor generates a new authenticator secret for the user. It
+// guards nothing until confirmed with ConfirmTwoFactor; enrolling again
+// before that replaces the secret.
+func (uc *UseCase) EnrolTwoFactor(ctx context.Context, userID uuid.UUID) (*auth.TwoFactorEnrolment, error) {
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	if !auth.CanUseTwoFactor(u.Role) {
+		return nil, auth.ErrorTwoFactorNotAllowed
+	}
+
+	tf, err := uc.getTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		return nil, auth.ErrorTwoFactorAlreadyEnabled
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("generate totp secret: %w", err)
+	}
+	if err := uc.repo.SaveTwoFactorSecret(ctx, userID, secret); err != nil {
+		return nil, fmt.Errorf("save totp secret: %w", err)
+	}
+
+	return &auth.TwoFactorEnrolment{
+		Secret: secret,
+		URI:    auth.ProvisioningURI(uc.cfg.TOTPIssuer, u.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on with a first code from
+// the newly enrolled app. It returns the user's recovery codes, the only
+// time they are known.
+func (uc *UseCase) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var codes []string
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		tf, err := uc.getTwoFactor(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if tf == nil {
+			return auth.ErrorTwoFactorNotEnrolled
+		}
+		if tf.Enabled() {
+			return auth.ErrorTwoFactorAlreadyEnabled
+		}
+
+		now := uc.now()
+		step, ok := auth.MatchTOTP(tf.Secret, normalizeCode(code), now, tf.LastUsedStep)
+		if !ok {
+			return auth.ErrorInvalidTwoFactorCode
+		}
+		if err := uc.repo.ConfirmTwoFactor(txCtx, userID, now, step); err != nil {
+			return fmt.Errorf("confirm two-factor: %w", err)
+		}
+
+		codes, err = uc.replaceRecoveryCodes(txCtx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, proven with a code.
+// Users whose role requires it can't. Wrong codes are throttled per user.
+func (uc *UseCase) DisableTwoFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	var wrong bool
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		u, err := uc.users.GetByID(txCtx, userID)
+		if err != nil {
+			return fmt.Errorf("get user: %w", err)
+		}
+		required, err := uc.twoFactorRequired(txCtx, u.Role)
+		if err != nil {
+			return err
+		}
+		if required {
+			return auth.ErrorTwoFactorRequired
+		}
+
+		tf, err := uc.getTwoFactor(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if !tf.Enabled() {
+			return auth.ErrorTwoFactorNotEnabled
+		}
+		if wrong, err = uc.checkSecondFactor(txCtx, tf, code); err != nil || wrong {
+			return err
+		}
+
+		if err := uc.repo.DeleteTwoFactor(txCtx, userID); err != nil {
+			return fmt.Errorf("delete two-factor: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if wrong {
+		return auth.ErrorInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, proven with a
+// code. The old ones stop working. Wrong codes are throttled per user.
+func (uc *UseCase) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	var (
+		codes []string
+		wrong bool
+	)
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		tf, err := uc.getTwoFactor(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if !tf.Enabled() {
+			return auth.ErrorTwoFactorNotEnabled
+		}
+		if wrong, err = uc.checkSecondFactor(txCtx, tf, code); err != nil || wrong {
+			return err
+		}
+
+		codes, err = uc.replaceRecoveryCodes(txCtx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if wrong {
+		return nil, auth.ErrorInvalidTwoFactorCode
+	}
+	return codes, nil
+}
+
+// TwoFactorStatus tells whether the user has two-factor authentication on,
+// how many recovery codes they have left and whether their role requires it.
+func (uc *UseCase) TwoFactorStatus(ctx context.Context, userID uuid.UUID) (*auth.TwoFactorStatus, error) {
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+
+	st := &auth.TwoFactorStatus{}
+	if st.Required, err = uc.twoFactorRequired(ctx, u.Role); err != nil {
+		return nil, err
+	}
+
+	tf, err := uc.getTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled() {
+		st.Enabled, st.EnabledAt = true, tf.ConfirmedAt
+		if st.RecoveryCodesLeft, err = uc.repo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, fmt.Errorf("count recovery codes: %w", err)
+		}
+	}
+	return st, nil
+}
+
+// ListTwoFactorRequirements returns the roles two-factor authentication is
+// mandatory for.
+func (uc *UseCase) ListTwoFactorRequirements(ctx context.Context) ([]*auth.TwoFactorRequirement, error) {
+	return uc.repo.ListTwoFactorRequirements(ctx)
+}
+
+// SetTwoFactorRequired makes two-factor authentication mandatory for a role,
+// or optional again. Users of the role without it are held to setting it up
+// on their next request.
+func (uc *UseCase) SetTwoFactorRequired(ctx context.Context, adminID uuid.UUID, role user.Role, required bool) error {
+	if !auth.CanUseTwoFactor(role) {
+		return auth.ErrorTwoFactorNotAllowed
+	}
+	return uc.repo.SetTwoFactorRequired(ctx, role, required, adminID)
+}
+
+// verifySecondFactor accepts a current code from the user's app or one of
+// their unused recovery codes, and uses it up.
+func (uc *UseCase) verifySecondFactor(ctx context.Context, tf *auth.TwoFactor, code string) error {
+	code = normalizeCode(code)
+
+	if len(code) == auth.TOTPDigits {
+		step, ok := auth.MatchTOTP(tf.Secret, code, uc.now(), tf.LastUsedStep)
+		if !ok {
+			return auth.ErrorInvalidTwoFactorCode
+		}
+		if err := uc.repo.UseTOTPStep(ctx, tf.UserID, step); err != nil {
+			return fmt.Errorf("use totp step: %w", err)
+		}
+		return nil
+	}
+
+	err := uc.repo.UseRecoveryCode(ctx, tf.UserID, auth.HashToken(code), uc.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return auth.ErrorInvalidTwoFactorCode
+	}
+	if err != nil {
+		return fmt.Errorf("use recovery code: %w", err)
+	}
+	return nil
+}
+
+// checkSecondFactor is verifySecondFactor for a signed-in user changing their
+// two-factor settings, where no login challenge caps the attempts. Wrong
+// codes count against the user's throttle like passwords against an account,
+// so a stolen session can't be used to guess the code, and attempts fail
+// with auth.ThrottledError while it makes them wait. A wrong code is
+// reported through wrong rather than an error, so the caller commits it.
+func (uc *UseCase) checkSecondFactor(ctx context.Context, tf *auth.TwoFactor, code string) (wrong bool, err error) {
+	t, err := uc.repo.LockThrottle(ctx, auth.ThrottleTwoFactor, tf.UserID.String())
+	if err != nil {
+		return false, fmt.Errorf("lock throttle: %w", err)
+	}
+
+	now := uc.now()
+	if wait := t.Wait(now); wait > 0 {
+		return false, &auth.ThrottledError{Err: auth.ErrorTooManyAttempts, RetryAfter: wait}
+	}
+
+	err = uc.verifySecondFactor(ctx, tf, code)
+	if errors.Is(err, auth.ErrorInvalidTwoFactorCode) {
+		t.Fail(now)
+		if err := uc.repo.SaveThrottle(ctx, t); err != nil {
+			return false, fmt.Errorf("save throttle: %w", err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := uc.repo.DeleteThrottle(ctx, t.Scope, t.Key); err != nil {
+		return false, fmt.Errorf("delete throttle: %w", err)
+	}
+	return false, nil
+}
+
+// getTwoFactor returns nil for users who never enrolled.
+func (uc *UseCase) getTwoFactor(ctx context.Context, userID uuid.UUID) (*auth.TwoFactor, error) {
+	tf, err := uc.repo.GetTwoFactor(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get two-factor: %w", err)
+	}
+	return tf, nil
+}
+
+func (uc *UseCase) twoFactorRequired(ctx context.Context, role user.Role) (bool, error) {
+	if !auth.CanUseTwoFactor(role) {
+		return false, nil
+	}
+	required, err := uc.repo.TwoFactorRequired(ctx, role)
+	if err != nil {
+		return false, fmt.Errorf("get two-factor requirement: %w", err)
+	}
+	return required, nil
+}
+
+func (uc *UseCase) openChallenge(ctx context.Context, userID uuid.UUID) (*auth.ChallengeResponse, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate login challenge: %w", err)
+	}
+
+	c := &auth.LoginChallenge{
+		UserID:    userID,
+		TokenHash: auth.HashToken(raw),
+		ExpiresAt: uc.now().Add(auth.LoginChallengeTTL),
+	}
+	if err := uc.repo.CreateLoginChallenge(ctx, c); err != nil {
+		return nil, fmt.Errorf("create login challenge: %w", err)
+	}
+	return &auth.ChallengeResponse{TwoFactorRequired: true, ChallengeToken: raw, ExpiresAt: c.ExpiresAt}, nil
+}
+
+func (uc *UseCase) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, auth.RecoveryCodeCount)
+	hashes := make([]string, auth.RecoveryCodeCount)
+	for i := range codes {
+		raw, err := randomRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		codes[i], hashes[i] = raw, auth.HashToken(normalizeCode(raw))
+	}
+
+	if err := uc.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("replace recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// randomRecoveryCode returns 50 random bits as "xxxxx-xxxxx".
+func randomRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := recoveryEncoding.EncodeToString(b)
+	return s[:5] + "-" + s[5:10], nil
+}
+
+// normalizeCode drops the spaces and dashes codes are often typed with.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
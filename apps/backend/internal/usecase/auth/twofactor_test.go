@@ -0,0 +1,389 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func (f *fakeRepo) GetTwoFactor(ctx context.Context, userID uuid.UUID) (*auth.TwoFactor, error) {
+	t, ok := f.twoFactor[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (f *fakeRepo) SaveTwoFactorSecret(ctx context.Context, userID uuid.UUID, secret string) error {
+	if f.twoFactor[userID].Enabled() {
+		return nil
+	}
+	f.twoFactor[userID] = &auth.TwoFactor{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (f *fakeRepo) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, confirmedAt time.Time, step int64) error {
+	t := f.twoFactor[userID]
+	t.ConfirmedAt, t.LastUsedStep = &confirmedAt, step
+	return nil
+}
+
+func (f *fakeRepo) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	t := f.twoFactor[userID]
+	if t.LastUsedStep >= step {
+		return auth.ErrorInvalidTwoFactorCode
+	}
+	t.LastUsedStep = step
+	return nil
+}
+
+func (f *fakeRepo) DeleteTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	delete(f.twoFactor, userID)
+	delete(f.recovery, userID)
+	return nil
+}
+
+func (f *fakeRepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	f.recovery[userID] = map[string]bool{}
+	for _, h := range codeHashes {
+		f.recovery[userID][h] = false
+	}
+	return nil
+}
+
+func (f *fakeRepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string, usedAt time.Time) error {
+	used, ok := f.recovery[userID][codeHash]
+	if !ok || used {
+		return sql.ErrNoRows
+	}
+	f.recovery[userID][codeHash] = true
+	return nil
+}
+
+func (f *fakeRepo) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	n := 0
+	for _, used := range f.recovery[userID] {
+		if !used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeRepo) ListTwoFactorRequirements(ctx context.Context) ([]*auth.TwoFactorRequirement, error) {
+	var reqs []*auth.TwoFactorRequirement
+	for role := range f.required {
+		reqs = append(reqs, &auth.TwoFactorRequirement{Role: role})
+	}
+	return reqs, nil
+}
+
+func (f *fakeRepo) SetTwoFactorRequired(ctx context.Context, role user.Role, required bool, by uuid.UUID) error {
+	if required {
+		f.required[role] = true
+	} else {
+		delete(f.required, role)
+	}
+	return nil
+}
+
+func (f *fakeRepo) TwoFactorRequired(ctx context.Context, role user.Role) (bool, error) {
+	return f.required[role], nil
+}
+
+func (f *fakeRepo) CreateLoginChallenge(ctx context.Context, c *auth.LoginChallenge) error {
+	c.ID = uuid.New()
+	cp := *c
+	f.challenges[c.TokenHash] = &cp
+	return nil
+}
+
+func (f *fakeRepo) GetLoginChallengeForUpdate(ctx context.Context, tokenHash string) (*auth.LoginChallenge, error) {
+	c, ok := f.challenges[tokenHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *c
+	return &cp, nil
+}
+
+func (f *fakeRepo) AddLoginChallengeAttempt(ctx context.Context, id uuid.UUID) error {
+	for _, c := range f.challenges {
+		if c.ID == id {
+			c.Attempts++
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) MarkLoginChallengeUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	for _, c := range f.challenges {
+		if c.ID == id {
+			c.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func newMerchant() *user.User {
+	return &user.User{ID: uuid.New(), Email: "otieno@example.com", Role: user.Merchant, Status: user.Active}
+}
+
+// codeAt returns the app's code for secret at t.
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(at))
+	require.NoError(t, err)
+	return code
+}
+
+// enrol turns two-factor authentication on for u and returns its secret and
+// recovery codes. The clock is moved on a period so later codes are fresh.
+func enrol(t *testing.T, uc *UseCase, u *user.User) (string, []string) {
+	ctx := context.Background()
+	e, err := uc.EnrolTwoFactor(ctx, u.ID)
+	require.NoError(t, err)
+
+	at := uc.now()
+	codes, err := uc.ConfirmTwoFactor(ctx, u.ID, codeAt(t, e.Secret, at))
+	require.NoError(t, err)
+
+	uc.now = func() time.Time { return at.Add(auth.TOTPPeriod) }
+	return e.Secret, codes
+}
+
+func TestEnrolTwoFactor(t *testing.T) {
+	u := newMerchant()
+	uc, repo := newTestUseCase(u)
+	ctx := context.Background()
+
+	e, err := uc.EnrolTwoFactor(ctx, u.ID)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(e.URI, "otpauth://totp/Fastabiz:otieno@example.com?"))
+	require.Contains(t, e.URI, "secret="+e.Secret)
+	require.False(t, repo.twoFactor[u.ID].Enabled(), "unconfirmed")
+
+	_, err = uc.ConfirmTwoFactor(ctx, u.ID, "000000")
+	require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode)
+
+	codes, err := uc.ConfirmTwoFactor(ctx, u.ID, codeAt(t, e.Secret, time.Now()))
+	require.NoError(t, err)
+	require.Len(t, codes, auth.RecoveryCodeCount)
+	require.True(t, repo.twoFactor[u.ID].Enabled())
+
+	_, err = uc.EnrolTwoFactor(ctx, u.ID)
+	require.ErrorIs(t, err, auth.ErrorTwoFactorAlreadyEnabled)
+
+	st, err := uc.TwoFactorStatus(ctx, u.ID)
+	require.NoError(t, err)
+	require.True(t, st.Enabled)
+	require.Equal(t, auth.RecoveryCodeCount, st.RecoveryCodesLeft)
+}
+
+func TestEnrolTwoFactor_Rejects(t *testing.T) {
+	ctx := context.Background()
+
+	customer := newUser(user.Active)
+	uc, _ := newTestUseCase(customer)
+	_, err := uc.EnrolTwoFactor(ctx, customer.ID)
+	require.ErrorIs(t, err, auth.ErrorTwoFactorNotAllowed)
+
+	u := newMerchant()
+	uc, _ = newTestUseCase(u)
+	_, err = uc.ConfirmTwoFactor(ctx, u.ID, "123456")
+	require.ErrorIs(t, err, auth.ErrorTwoFactorNotEnrolled)
+}
+
+func TestLogin_WithoutTwoFactor(t *testing.T) {
+	u := newMerchant()
+	uc, _ := newTestUseCase(u)
+
+	res, err := uc.BeginLogin(context.Background(), u)
+	require.NoError(t, err)
+	require.Nil(t, res.Challenge)
+	require.NotNil(t, res.Tokens)
+	require.False(t, res.MustEnrolTwoFactor)
+}
+
+func TestLogin_WithTwoFactor(t *testing.T) {
+	u := newMerchant()
+	uc, repo := newTestUseCase(u)
+	ctx := context.Background()
+	secret, _ := enrol(t, uc, u)
+
+	res, err := uc.BeginLogin(ctx, u)
+	require.NoError(t, err)
+	require.Nil(t, res.Tokens, "no session before the code")
+	require.True(t, res.Challenge.TwoFactorRequired)
+	require.Empty(t, repo.sessions)
+
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, "000000")
+	require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode)
+
+	code := codeAt(t, secret, uc.now())
+	got, pair, err := uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, code[:3]+" "+code[3:])
+	require.NoError(t, err)
+	require.Equal(t, u.ID, got.ID)
+	userID, sessionID := sessionOf(t, pair.AccessToken)
+	require.NoError(t, sessionErr(uc, userID, sessionID))
+
+	// The challenge and the code work once
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, code)
+	require.ErrorIs(t, err, auth.ErrorInvalidChallenge)
+	res, err = uc.BeginLogin(ctx, u)
+	require.NoError(t, err)
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, code)
+	require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode, "replayed code")
+}
+
+func TestLogin_RecoveryCode(t *testing.T) {
+	u := newMerchant()
+	uc, _ := newTestUseCase(u)
+	ctx := context.Background()
+	_, codes := enrol(t, uc, u)
+
+	res, err := uc.BeginLogin(ctx, u)
+	require.NoError(t, err)
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, strings.ToUpper(codes[0]))
+	require.NoError(t, err)
+
+	res, err = uc.BeginLogin(ctx, u)
+	require.NoError(t, err)
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, codes[0])
+	require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode, "used recovery code")
+
+	st, err := uc.TwoFactorStatus(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, auth.RecoveryCodeCount-1, st.RecoveryCodesLeft)
+}
+
+func TestCompleteLogin_ChallengeLimits(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("too many wrong codes", func(t *testing.T) {
+		u := newMerchant()
+		uc, _ := newTestUseCase(u)
+		secret, _ := enrol(t, uc, u)
+		res, err := uc.BeginLogin(ctx, u)
+		require.NoError(t, err)
+
+		for range auth.MaxChallengeAttempts {
+			_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, "000000")
+			require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode)
+		}
+		_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, codeAt(t, secret, uc.now()))
+		require.ErrorIs(t, err, auth.ErrorInvalidChallenge)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		u := newMerchant()
+		uc, _ := newTestUseCase(u)
+		secret, _ := enrol(t, uc, u)
+		res, err := uc.BeginLogin(ctx, u)
+		require.NoError(t, err)
+
+		later := uc.now().Add(auth.LoginChallengeTTL)
+		uc.now = func() time.Time { return later }
+		_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, codeAt(t, secret, later))
+		require.ErrorIs(t, err, auth.ErrorInvalidChallenge)
+	})
+
+	t.Run("suspended meanwhile", func(t *testing.T) {
+		u := newMerchant()
+		uc, _ := newTestUseCase(u)
+		secret, _ := enrol(t, uc, u)
+		res, err := uc.BeginLogin(ctx, u)
+		require.NoError(t, err)
+
+		u.Status = user.Suspended
+		_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, codeAt(t, secret, uc.now()))
+		require.ErrorIs(t, err, auth.ErrorAccountDisabled)
+	})
+}
+
+func TestTwoFactorRequirement(t *testing.T) {
+	u := newMerchant()
+	uc, _ := newTestUseCase(u)
+	ctx := context.Background()
+
+	require.ErrorIs(t, uc.SetTwoFactorRequired(ctx, uuid.New(), user.Driver, true), auth.ErrorTwoFactorNotAllowed)
+	require.NoError(t, uc.SetTwoFactorRequired(ctx, uuid.New(), user.Merchant, true))
+
+	// Without an authenticator the session is held to enrolment
+	res, err := uc.BeginLogin(ctx, u)
+	require.NoError(t, err)
+	require.True(t, res.MustEnrolTwoFactor)
+	userID, sessionID := sessionOf(t, res.Tokens.AccessToken)
+	state, err := uc.ValidateSession(ctx, userID, sessionID)
+	require.NoError(t, err)
+	require.True(t, state.MustEnrolTwoFactor)
+
+	secret, _ := enrol(t, uc, u)
+	state, err = uc.ValidateSession(ctx, userID, sessionID)
+	require.NoError(t, err)
+	require.False(t, state.MustEnrolTwoFactor, "lifted once enrolled")
+
+	err = uc.DisableTwoFactor(ctx, u.ID, codeAt(t, secret, uc.now()))
+	require.ErrorIs(t, err, auth.ErrorTwoFactorRequired)
+
+	require.NoError(t, uc.SetTwoFactorRequired(ctx, uuid.New(), user.Merchant, false))
+	require.NoError(t, uc.DisableTwoFactor(ctx, u.ID, codeAt(t, secret, uc.now())))
+	st, err := uc.TwoFactorStatus(ctx, u.ID)
+	require.NoError(t, err)
+	require.Equal(t, &auth.TwoFactorStatus{}, st)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	u := newMerchant()
+	uc, _ := newTestUseCase(u)
+	ctx := context.Background()
+	secret, old := enrol(t, uc, u)
+
+	_, err := uc.RegenerateRecoveryCodes(ctx, u.ID, "000000")
+	require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode)
+
+	codes, err := uc.RegenerateRecoveryCodes(ctx, u.ID, codeAt(t, secret, uc.now()))
+	require.NoError(t, err)
+	require.Len(t, codes, auth.RecoveryCodeCount)
+
+	res, err := uc.BeginLogin(ctx, u)
+	require.NoError(t, err)
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, old[0])
+	require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode, "old codes stop working")
+	_, _, err = uc.CompleteLogin(ctx, res.Challenge.ChallengeToken, codes[0])
+	require.NoError(t, err)
+}
+
+func TestTwoFactorSettings_ThrottleWrongCodes(t *testing.T) {
+	u := newMerchant()
+	uc, repo := newTestUseCase(u)
+	ctx := context.Background()
+	secret, _ := enrol(t, uc, u)
+	now := uc.now().Add(auth.TOTPPeriod)
+	uc.now = func() time.Time { return now }
+
+	for range auth.AccountFreeAttempts + 1 {
+		require.ErrorIs(t, uc.DisableTwoFactor(ctx, u.ID, "000000"), auth.ErrorInvalidTwoFactorCode)
+	}
+	err := uc.DisableTwoFactor(ctx, u.ID, codeAt(t, secret, now))
+	require.Equal(t, time.Second, retryAfter(t, err), "even the right code has to wait")
+
+	for range auth.LockoutThreshold - auth.AccountFreeAttempts - 1 {
+		now = now.Add(auth.MaxAttemptDelay)
+		_, err := uc.RegenerateRecoveryCodes(ctx, u.ID, "000000")
+		require.ErrorIs(t, err, auth.ErrorInvalidTwoFactorCode)
+	}
+	_, err = uc.RegenerateRecoveryCodes(ctx, u.ID, codeAt(t, secret, now))
+	require.Equal(t, auth.LockoutDuration, retryAfter(t, err), "both count towards the lockout")
+
+	now = now.Add(auth.LockoutDuration)
+	require.NoError(t, uc.DisableTwoFactor(ctx, u.ID, codeAt(t, secret, now)))
+	require.Empty(t, repo.throttles, "a right code clears the failures")
+}
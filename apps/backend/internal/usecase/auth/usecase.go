@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/notification"
+	"backend/internal/domain/user"
+	"backend/internal/usecase/common"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// tokenIssuer is the JWT issuer Kong matches its consumer key against.
+const tokenIssuer = "my-client"
+
+// UseCase issues short-lived access tokens and rotating refresh tokens,
+// checks that the session behind an access token is still open, runs the
+// emailed password reset and address verification flows, the TOTP second
+// factor of admins and merchants, and the sign-in of customers by texted
+// code.
+type UseCase struct {
+	repo      auth.Repository
+	users     auth.UserReader
+	txManager common.TxManager
+	mailer    notification.EmailSender
+	sms       notification.SMSSender
+	cfg       auth.Config
+	now       func() time.Time
+}
+
+// NewUseCase creates a new auth UseCase. Zero lifetimes and an empty issuer
+// in cfg fall back to the defaults.
+func NewUseCase(repo auth.Repository, users auth.UserReader, txm common.TxManager, mailer notification.EmailSender, sms notification.SMSSender, cfg auth.Config) *UseCase {
+	if cfg.AccessTokenTTL <= 0 {
+		cfg.AccessTokenTTL = auth.DefaultAccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL <= 0 {
+		cfg.RefreshTokenTTL = auth.DefaultRefreshTokenTTL
+	}
+	if cfg.TOTPIssuer == "" {
+		cfg.TOTPIssuer = auth.DefaultTOTPIssuer
+	}
+	return &UseCase{repo: repo, users: users, txManager: txm, mailer: mailer, sms: sms, cfg: cfg, now: time.Now}
+}
+
+// StartSession opens a session for a user whose credentials were checked
+// and returns its first token pair.
+func (uc *UseCase) StartSession(ctx context.Context, u *user.User) (*auth.TokenPair, error) {
+	if !auth.CanSignIn(u.Status) {
+		return nil, auth.ErrorAccountDisabled
+	}
+
+	var pair *auth.TokenPair
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		s := &auth.Session{UserID: u.ID}
+		if err := uc.repo.CreateSession(txCtx, s); err != nil {
+			return fmt.Errorf("create session: %w", err)
+		}
+
+		var err error
+		pair, err = uc.issue(txCtx, u, s.ID)
+		return err
+	})
+	return pair, err
+}
+
+// Refresh exchanges a refresh token for a new pair. Each refresh token can be
+// used once; presenting a used one revokes its whole session, since either
+// the client or an attacker holds a stolen copy.
+func (uc *UseCase) Refresh(ctx context.Context, raw string) (*auth.TokenPair, error) {
+	var (
+		pair   *auth.TokenPair
+		reused bool
+	)
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		t, err := uc.repo.GetRefreshTokenForUpdate(txCtx, auth.HashToken(raw))
+		if errors.Is(err, sql.ErrNoRows) {
+			return auth.ErrorInvalidRefreshToken
+		}
+		if err != nil {
+			return fmt.Errorf("get refresh token: %w", err)
+		}
+
+		state, err := uc.repo.GetSessionState(txCtx, t.SessionID)
+		if err != nil {
+			return fmt.Errorf("get session: %w", err)
+		}
+		if state.RevokedAt != nil {
+			return auth.ErrorInvalidRefreshToken
+		}
+
+		// Revoke and commit, the error is returned once the revocation is stored
+		if t.UsedAt != nil {
+			reused = true
+			return uc.repo.RevokeSession(txCtx, t.SessionID, auth.RevokedByReuse)
+		}
+
+		now := uc.now()
+		if !now.Before(t.ExpiresAt) {
+			return auth.ErrorInvalidRefreshToken
+		}
+		if !auth.CanSignIn(state.Status) {
+			return auth.ErrorAccountDisabled
+		}
+
+		if err := uc.repo.MarkRefreshTokenUsed(txCtx, t.ID, now); err != nil {
+			return fmt.Errorf("mark refresh token used: %w", err)
+		}
+
+		u, err := uc.users.GetByID(txCtx, state.UserID)
+		if err != nil {
+			return fmt.Errorf("get user: %w", err)
+		}
+
+		pair, err = uc.issue(txCtx, u, t.SessionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, auth.ErrorRefreshTokenReused
+	}
+	return pair, nil
+}
+
+// Logout revokes the session an access token was issued for, together with
+// its refresh tokens and every other access token of the session.
+func (uc *UseCase) Logout(ctx context.Context, userID, sessionID uuid.UUID) error {
+	if _, err := uc.ValidateSession(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	return uc.repo.RevokeSession(ctx, sessionID, auth.RevokedByLogout)
+}
+
+// ValidateSession is checked on every authenticated request. It fails with
+// auth.ErrorSessionRevoked once the session is revoked and with
+// auth.ErrorAccountDisabled once its user is suspended or deactivated.
+func (uc *UseCase) ValidateSession(ctx context.Context, userID, sessionID uuid.UUID) (*auth.SessionState, error) {
+	state, err := uc.repo.GetSessionState(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, auth.ErrorSessionRevoked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+
+	if state.UserID != userID || state.RevokedAt != nil {
+		return nil, auth.ErrorSessionRevoked
+	}
+	if !auth.CanSignIn(state.Status) {
+		return nil, auth.ErrorAccountDisabled
+	}
+	return state, nil
+}
+
+// issue stores a new refresh token for the session and signs an access
+// token carrying the session ID.
+func (uc *UseCase) issue(ctx context.Context, u *user.User, sessionID uuid.UUID) (*auth.TokenPair, error) {
+	raw, err := randomToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+
+	now := uc.now()
+	t := &auth.RefreshToken{
+		SessionID: sessionID,
+		TokenHash: auth.HashToken(raw),
+		ExpiresAt: now.Add(uc.cfg.RefreshTokenTTL),
+	}
+	if err := uc.repo.CreateRefreshToken(ctx, t); err != nil {
+		return nil, fmt.Errorf("create refresh token: %w", err)
+	}
+
+	access, accessExpiry, err := uc.signAccessToken(u, sessionID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TokenPair{
+		AccessToken:           access,
+		AccessTokenExpiresAt:  accessExpiry,
+		RefreshToken:          raw,
+		RefreshTokenExpiresAt: t.ExpiresAt,
+	}, nil
+}
+
+func (uc *UseCase) signAccessToken(u *user.User, sessionID uuid.UUID, now time.Time) (string, time.Time, error) {
+	expiresAt := now.Add(uc.cfg.AccessTokenTTL)
+
+	claims := jwt.MapClaims{
+		"iss":        tokenIssuer,
+		"sub":        u.ID.String(),
+		"sid":        sessionID.String(),
+		"jti":        uuid.NewString(),
+		"email":      u.Email,
+		"role":       string(u.Role),
+		"name":       u.FullName,
+		"phone":      u.Phone,
+		"slug":       u.Slug,
+		"status":     string(u.Status),
+		"created_at": u.CreatedAt.Unix(),
+		"iat":        now.Unix(),
+		"exp":        expiresAt.Unix(),
+	}
+	if u.LastLogin != nil {
+		claims["last_login"] = u.LastLogin.Unix()
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(uc.cfg.Secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign access token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// randomToken returns 256 random bits, URL-safe encoded.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"backend/internal/domain/auth"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeRepo struct {
+	sessions   map[uuid.UUID]*auth.Session
+	tokens     map[string]*auth.RefreshToken
+	oneTime    map[string]*auth.OneTimeToken
+	users      map[uuid.UUID]*user.User
+	twoFactor  map[uuid.UUID]*auth.TwoFactor
+	recovery   map[uuid.UUID]map[string]bool // code hash to used
+	required   map[user.Role]bool
+	challenges map[string]*auth.LoginChallenge
+	throttles  map[string]*auth.Throttle // by scope:key
+	phoneCodes []*auth.PhoneCode
+}
+
+func newFakeRepo(users ...*user.User) *fakeRepo {
+	f := &fakeRepo{
+		sessions:   map[uuid.UUID]*auth.Session{},
+		tokens:     map[string]*auth.RefreshToken{},
+		oneTime:    map[string]*auth.OneTimeToken{},
+		users:      map[uuid.UUID]*user.User{},
+		twoFactor:  map[uuid.UUID]*auth.TwoFactor{},
+		recovery:   map[uuid.UUID]map[string]bool{},
+		required:   map[user.Role]bool{},
+		challenges: map[string]*auth.LoginChallenge{},
+		throttles:  map[string]*auth.Throttle{},
+	}
+	for _, u := range users {
+		f.users[u.ID] = u
+	}
+	return f
+}
+
+func (f *fakeRepo) CreateSession(ctx context.Context, s *auth.Session) error {
+	s.ID = uuid.New()
+	cp := *s
+	f.sessions[s.ID] = &cp
+	return nil
+}
+
+func (f *fakeRepo) GetSessionState(ctx context.Context, sessionID uuid.UUID) (*auth.SessionState, error) {
+	s, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	u := f.users[s.UserID]
+	return &auth.SessionState{
+		UserID:             s.UserID,
+		Status:             u.Status,
+		MustChangePassword: u.Must_change_password,
+		MustEnrolTwoFactor: f.required[u.Role] && !f.twoFactor[u.ID].Enabled(),
+		RevokedAt:          s.RevokedAt,
+	}, nil
+}
+
+func (f *fakeRepo) RevokeSession(ctx context.Context, sessionID uuid.UUID, reason string) error {
+	s := f.sessions[sessionID]
+	if s.RevokedAt == nil {
+		now := time.Now()
+		s.RevokedAt, s.RevokedReason = &now, &reason
+	}
+	return nil
+}
+
+func (f *fakeRepo) CreateRefreshToken(ctx context.Context, t *auth.RefreshToken) error {
+	t.ID = uuid.New()
+	cp := *t
+	f.tokens[t.TokenHash] = &cp
+	return nil
+}
+
+func (f *fakeRepo) GetRefreshTokenForUpdate(ctx context.Context, tokenHash string) (*auth.RefreshToken, error) {
+	t, ok := f.tokens[tokenHash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (f *fakeRepo) MarkRefreshTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	for _, t := range f.tokens {
+		if t.ID == id {
+			t.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) RevokeUserSessions(ctx context.Context, userID uuid.UUID, reason string) error {
+	for id, s := range f.sessions {
+		if s.UserID == userID {
+			f.RevokeSession(ctx, id, reason)
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) CreateOneTimeToken(ctx context.Context, t *auth.OneTimeToken) error {
+	now := time.Now()
+	for _, old := range f.oneTime {
+		if old.UserID == t.UserID && old.Purpose == t.Purpose && old.UsedAt == nil {
+			old.UsedAt = &now
+		}
+	}
+	t.ID = uuid.New()
+	cp := *t
+	f.oneTime[t.TokenHash] = &cp
+	return nil
+}
+
+func (f *fakeRepo) GetOneTimeTokenForUpdate(ctx context.Context, tokenHash string, purpose auth.Purpose) (*auth.OneTimeToken, error) {
+	t, ok := f.oneTime[tokenHash]
+	if !ok || t.Purpose != purpose {
+		return nil, sql.ErrNoRows
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (f *fakeRepo) MarkOneTimeTokenUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	for _, t := range f.oneTime {
+		if t.ID == id {
+			t.UsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeRepo) GetByID(ctx context.Context, id uuid.UUID) (*user.User, error) {
+	if u, ok := f.users[id]; ok {
+		return u, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepo) GetByEmail(ctx context.Context, email string) (*user.User, error) {
+	for _, u := range f.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeRepo) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	u := f.users[id]
+	u.PasswordHash, u.Must_change_password = passwordHash, false
+	return nil
+}
+
+func (f *fakeRepo) MarkEmailVerified(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	f.users[id].EmailVerifiedAt = &now
+	return nil
+}
+
+// fakeMailer keeps the last email sent to each address.
+type fakeMailer map[string]string
+
+func (f fakeMailer) SendEmail(ctx context.Context, to string, subject string, body string) error {
+	f[to] = body
+	return nil
+}
+
+// tokenIn pulls the token out of the link in a mailed body.
+func (f fakeMailer) tokenIn(t *testing.T, to string) string {
+	body, ok := f[to]
+	require.True(t, ok, "no email to %s", to)
+	_, rest, found := strings.Cut(body, "?token=")
+	require.True(t, found, body)
+	raw, _, _ := strings.Cut(rest, "\n")
+	return raw
+}
+
+var testSecret = []byte("test-secret")
+
+func newTestUseCase(users ...*user.User) (*UseCase, *fakeRepo) {
+	uc, repo, _ := newTestUseCaseWithMail(users...)
+	return uc, repo
+}
+
+func newTestUseCaseWithMail(users ...*user.User) (*UseCase, *fakeRepo, fakeMailer) {
+	repo := newFakeRepo(users...)
+	mail := fakeMailer{}
+	cfg := auth.Config{Secret: testSecret, AppBaseURL: "https://app.example.com/"}
+	return NewUseCase(repo, repo, fakeTxManager{}, mail, fakeSMS{}, cfg), repo, mail
+}
+
+func sessionErr(uc *UseCase, userID, sessionID uuid.UUID) error {
+	_, err := uc.ValidateSession(context.Background(), userID, sessionID)
+	return err
+}
+
+func newUser(status user.UserStatus) *user.User {
+	return &user.User{ID: uuid.New(), Email: "wanjiru@example.com", Role: user.Customer, Status: status}
+}
+
+func sessionOf(t *testing.T, access string) (uuid.UUID, uuid.UUID) {
+	token, err := jwt.Parse(access, func(*jwt.Token) (interface{}, error) { return testSecret, nil })
+	require.NoError(t, err)
+	claims := token.Claims.(jwt.MapClaims)
+	return uuid.MustParse(claims["sub"].(string)), uuid.MustParse(claims["sid"].(string))
+}
+
+func TestStartSession(t *testing.T) {
+	u := newUser(user.Active)
+	uc, _ := newTestUseCase(u)
+
+	pair, err := uc.StartSession(context.Background(), u)
+	require.NoError(t, err)
+	require.NotEmpty(t, pair.RefreshToken)
+	require.WithinDuration(t, time.Now().Add(auth.DefaultAccessTokenTTL), pair.AccessTokenExpiresAt, time.Minute)
+
+	userID, sessionID := sessionOf(t, pair.AccessToken)
+	require.Equal(t, u.ID, userID)
+	require.NoError(t, sessionErr(uc, userID, sessionID))
+}
+
+func TestStartSession_RefusesDisabledUsers(t *testing.T) {
+	for _, status := range []user.UserStatus{user.Suspended, user.Inactive} {
+		u := newUser(status)
+		uc, _ := newTestUseCase(u)
+
+		_, err := uc.StartSession(context.Background(), u)
+		require.ErrorIs(t, err, auth.ErrorAccountDisabled, status)
+	}
+}
+
+func TestRefresh_RotatesToken(t *testing.T) {
+	u := newUser(user.Active)
+	uc, _ := newTestUseCase(u)
+	ctx := context.Background()
+
+	first, err := uc.StartSession(ctx, u)
+	require.NoError(t, err)
+
+	second, err := uc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	_, firstSession := sessionOf(t, first.AccessToken)
+	_, secondSession := sessionOf(t, second.AccessToken)
+	require.Equal(t, firstSession, secondSession, "refresh keeps the session")
+
+	_, err = uc.Refresh(ctx, second.RefreshToken)
+	require.NoError(t, err)
+}
+
+func TestRefresh_ReuseRevokesFamily(t *testing.T) {
+	u := newUser(user.Active)
+	uc, repo := newTestUseCase(u)
+	ctx := context.Background()
+
+	first, err := uc.StartSession(ctx, u)
+	require.NoError(t, err)
+	second, err := uc.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+
+	_, err = uc.Refresh(ctx, first.RefreshToken)
+	require.ErrorIs(t, err, auth.ErrorRefreshTokenReused)
+
+	// The legitimate latest token dies with the family
+	_, err = uc.Refresh(ctx, second.RefreshToken)
+	require.ErrorIs(t, err, auth.ErrorInvalidRefreshToken)
+
+	userID, sessionID := sessionOf(t, second.AccessToken)
+	require.ErrorIs(t, sessionErr(uc, userID, sessionID), auth.ErrorSessionRevoked)
+	require.Equal(t, auth.RevokedByReuse, *repo.sessions[sessionID].RevokedReason)
+}
+
+func TestRefresh_Rejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown token", func(t *testing.T) {
+		uc, _ := newTestUseCase()
+		_, err := uc.Refresh(ctx, "nope")
+		require.ErrorIs(t, err, auth.ErrorInvalidRefreshToken)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		u := newUser(user.Active)
+		uc, _ := newTestUseCase(u)
+		pair, err := uc.StartSession(ctx, u)
+		require.NoError(t, err)
+
+		uc.now = func() time.Time { return time.Now().Add(auth.DefaultRefreshTokenTTL + time.Hour) }
+		_, err = uc.Refresh(ctx, pair.RefreshToken)
+		require.ErrorIs(t, err, auth.ErrorInvalidRefreshToken)
+	})
+
+	t.Run("suspended user", func(t *testing.T) {
+		u := newUser(user.Active)
+		uc, _ := newTestUseCase(u)
+		pair, err := uc.StartSession(ctx, u)
+		require.NoError(t, err)
+
+		u.Status = user.Suspended
+		_, err = uc.Refresh(ctx, pair.RefreshToken)
+		require.ErrorIs(t, err, auth.ErrorAccountDisabled)
+	})
+}
+
+func TestLogout(t *testing.T) {
+	u := newUser(user.Active)
+	uc, _ := newTestUseCase(u)
+	ctx := context.Background()
+
+	pair, err := uc.StartSession(ctx, u)
+	require.NoError(t, err)
+	userID, sessionID := sessionOf(t, pair.AccessToken)
+
+	require.NoError(t, uc.Logout(ctx, userID, sessionID))
+	require.ErrorIs(t, sessionErr(uc, userID, sessionID), auth.ErrorSessionRevoked)
+
+	_, err = uc.Refresh(ctx, pair.RefreshToken)
+	require.ErrorIs(t, err, auth.ErrorInvalidRefreshToken)
+
+	require.ErrorIs(t, uc.Logout(ctx, userID, sessionID), auth.ErrorSessionRevoked)
+}
+
+func TestValidateSession(t *testing.T) {
+	u := newUser(user.Active)
+	uc, _ := newTestUseCase(u)
+	ctx := context.Background()
+
+	pair, err := uc.StartSession(ctx, u)
+	require.NoError(t, err)
+	userID, sessionID := sessionOf(t, pair.AccessToken)
+
+	require.ErrorIs(t, sessionErr(uc, uuid.New(), sessionID), auth.ErrorSessionRevoked, "other user")
+	require.ErrorIs(t, sessionErr(uc, userID, uuid.New()), auth.ErrorSessionRevoked, "unknown session")
+
+	u.Status = user.Inactive
+	require.ErrorIs(t, sessionErr(uc, userID, sessionID), auth.ErrorAccountDisabled)
+	u.Status = user.Pending
+	require.NoError(t, sessionErr(uc, userID, sessionID))
+}
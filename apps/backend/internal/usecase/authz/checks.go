@@ -0,0 +1,138 @@
+package authz
+
+import (
+	"backend/internal/domain/authz"
+	"backend/internal/domain/store"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Self only lets callers act on their own account, named by param.
+func Self(param string) authz.Check {
+	return func(ctx context.Context, sub authz.Subject, params authz.Params) error {
+		id, err := resourceID(params, param)
+		if err != nil {
+			return err
+		}
+		if !sub.IsAdmin() && id != sub.ID {
+			return authz.ErrorForbidden
+		}
+		return nil
+	}
+}
+
+// KeyStore holds API keys restricted to a store to that store, named by
+// param. Other callers pass, so it suits routes open to everyone, e.g. the
+// catalog of a store.
+func KeyStore(param string) authz.Check {
+	return func(ctx context.Context, sub authz.Subject, params authz.Params) error {
+		if sub.Key == nil || sub.Key.StoreID == nil {
+			return nil
+		}
+		id, err := resourceID(params, param)
+		if err != nil {
+			return err
+		}
+		if !sub.InStore(id) {
+			return authz.ErrorForbidden
+		}
+		return nil
+	}
+}
+
+// FromBody makes check read the identifiers it names from the JSON body, for
+// routes whose handler decodes them from there. They are never taken from
+// the query string instead.
+func FromBody(check authz.Check) authz.Check {
+	return func(ctx context.Context, sub authz.Subject, params authz.Params) error {
+		return check(ctx, sub, bodyParams{params})
+	}
+}
+
+// bodyParams looks every identifier up in the body.
+type bodyParams struct {
+	authz.Params
+}
+
+func (p bodyParams) Param(name string) (string, error) {
+	return p.Body(name)
+}
+
+// StoreStaff requires the caller's role in the store named by param to grant
+// perm.
+func (uc *UseCase) StoreStaff(param string, perm store.Permission) authz.Check {
+	return uc.check(param, func(ctx context.Context, sub authz.Subject, id uuid.UUID) error {
+		return uc.CanInStore(ctx, sub, id, perm)
+	})
+}
+
+// ProductEditor requires the caller to edit the catalog of the store of the
+// product named by param.
+func (uc *UseCase) ProductEditor(param string) authz.Check {
+	return uc.check(param, uc.CanManageProduct)
+}
+
+// VariantEditor requires the caller to edit the catalog of the store of the
+// variant named by param.
+func (uc *UseCase) VariantEditor(param string) authz.Check {
+	return uc.check(param, uc.CanManageVariant)
+}
+
+// ImageEditor requires the caller to edit the catalog of the store of the
+// product image named by param.
+func (uc *UseCase) ImageEditor(param string) authz.Check {
+	return uc.check(param, uc.CanManageImage)
+}
+
+// OptionEditor requires the caller to edit the catalog of the store of the
+// product option named by param.
+func (uc *UseCase) OptionEditor(param string) authz.Check {
+	return uc.check(param, uc.CanManageOption)
+}
+
+// OptionValueEditor requires the caller to edit the catalog of the store of
+// the product option value named by param.
+func (uc *UseCase) OptionValueEditor(param string) authz.Check {
+	return uc.check(param, uc.CanManageOptionValue)
+}
+
+// OrderParticipant requires the caller to take part in the order named by
+// param.
+func (uc *UseCase) OrderParticipant(param string) authz.Check {
+	return uc.check(param, uc.CanAccessOrder)
+}
+
+// DeliveryAssignee requires the caller to be the driver of the delivery named
+// by param.
+func (uc *UseCase) DeliveryAssignee(param string) authz.Check {
+	return uc.check(param, uc.CanAccessDelivery)
+}
+
+// InviteManager requires the caller to have sent the invite named by param,
+// or to manage the staff of the store it invites to.
+func (uc *UseCase) InviteManager(param string) authz.Check {
+	return uc.check(param, uc.CanManageInvite)
+}
+
+func (uc *UseCase) check(param string, can func(context.Context, authz.Subject, uuid.UUID) error) authz.Check {
+	return func(ctx context.Context, sub authz.Subject, params authz.Params) error {
+		id, err := resourceID(params, param)
+		if err != nil {
+			return err
+		}
+		return can(ctx, sub, id)
+	}
+}
+
+func resourceID(params authz.Params, name string) (uuid.UUID, error) {
+	v, err := params.Param(name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	id, err := uuid.Parse(v)
+	if err != nil {
+		return uuid.Nil, authz.ErrorInvalidResourceID
+	}
+	return id, nil
+}
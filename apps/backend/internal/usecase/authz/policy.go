@@ -0,0 +1,42 @@
+package authz
+
+import (
+	"backend/internal/domain/authz"
+	"context"
+)
+
+// Policy maps every protected route, keyed by authz.Route, to the rule
+// guarding it.
+type Policy map[string]authz.Rule
+
+// Authorize decides whether sub may call route. Routes without a rule are
+// refused, so a route added without a policy fails closed. API keys
+// restricted to a store only reach routes whose check names a resource, as
+// that is where the store is checked.
+func (p Policy) Authorize(ctx context.Context, route string, sub authz.Subject, params authz.Params) error {
+	rule, ok := p[route]
+	if !ok {
+		return authz.ErrorNoPolicy
+	}
+	if !rule.Allows(sub.Role) {
+		return authz.ErrorRoleNotAllowed
+	}
+	if sub.MustChangePassword && !rule.DuringPasswordChange {
+		return authz.ErrorPasswordChangeRequired
+	}
+	if sub.MustEnrolTwoFactor && !rule.DuringTwoFactorEnrolment {
+		return authz.ErrorTwoFactorEnrolmentRequired
+	}
+	if sub.Key != nil {
+		if rule.Scope == "" || !sub.Key.Has(rule.Scope) {
+			return authz.ErrorScopeNotGranted
+		}
+		if sub.Key.StoreID != nil && rule.Check == nil {
+			return authz.ErrorForbidden
+		}
+	}
+	if rule.Check == nil || sub.IsAdmin() {
+		return nil
+	}
+	return rule.Check(ctx, sub, params)
+}
@@ -0,0 +1,197 @@
+package authz
+
+import (
+	"backend/internal/domain/authz"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// UseCase decides whether a caller may act on a store, product, order,
+// delivery or invite. Admins may act on all of them.
+type UseCase struct {
+	stores     authz.StoreAccess
+	products   authz.ProductReader
+	orders     authz.OrderReader
+	deliveries authz.DeliveryReader
+	invites    authz.InviteReader
+}
+
+// NewUseCase creates a new authz UseCase.
+func NewUseCase(stores authz.StoreAccess, products authz.ProductReader, orders authz.OrderReader, deliveries authz.DeliveryReader, invites authz.InviteReader) *UseCase {
+	return &UseCase{stores: stores, products: products, orders: orders, deliveries: deliveries, invites: invites}
+}
+
+// CanInStore lets merchants do what their role in a store permits. Owners
+// may do everything, staff what their store role grants.
+func (uc *UseCase) CanInStore(ctx context.Context, sub authz.Subject, storeID uuid.UUID, perm store.Permission) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+	if sub.Role != user.Merchant || !sub.InStore(storeID) {
+		return authz.ErrorForbidden
+	}
+
+	role, err := uc.stores.GetMemberRole(ctx, storeID, sub.ID)
+	if err != nil {
+		return fmt.Errorf("get store role: %w", err)
+	}
+	if !role.Can(perm) {
+		return authz.ErrorForbidden
+	}
+	return nil
+}
+
+// CanManageStore lets owners and managers change a store's settings.
+func (uc *UseCase) CanManageStore(ctx context.Context, sub authz.Subject, storeID uuid.UUID) error {
+	return uc.CanInStore(ctx, sub, storeID, store.PermManageStore)
+}
+
+// CanManageProduct lets the staff editing a store's catalog act on its
+// products.
+func (uc *UseCase) CanManageProduct(ctx context.Context, sub authz.Subject, productID uuid.UUID) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+
+	p, err := uc.products.GetProductByID(ctx, productID)
+	if err != nil {
+		return notFound("get product", err)
+	}
+	return uc.CanInStore(ctx, sub, p.StoreID, store.PermManageCatalog)
+}
+
+// CanManageVariant lets the staff editing a store's catalog act on its
+// variants.
+func (uc *UseCase) CanManageVariant(ctx context.Context, sub authz.Subject, variantID uuid.UUID) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+
+	v, err := uc.products.GetVariantByID(ctx, variantID)
+	if err != nil {
+		return notFound("get variant", err)
+	}
+	return uc.CanManageProduct(ctx, sub, v.ProductID)
+}
+
+// CanManageImage lets the staff editing a store's catalog act on the images
+// of its products.
+func (uc *UseCase) CanManageImage(ctx context.Context, sub authz.Subject, imageID uuid.UUID) error {
+	return uc.canManageProductPart(ctx, sub, imageID, "image", uc.products.GetImageProductID)
+}
+
+// CanManageOption lets the staff editing a store's catalog act on the
+// options of its products.
+func (uc *UseCase) CanManageOption(ctx context.Context, sub authz.Subject, optionID uuid.UUID) error {
+	return uc.canManageProductPart(ctx, sub, optionID, "option", uc.products.GetOptionProductID)
+}
+
+// CanManageOptionValue lets the staff editing a store's catalog act on the
+// option values of its products.
+func (uc *UseCase) CanManageOptionValue(ctx context.Context, sub authz.Subject, optionValueID uuid.UUID) error {
+	return uc.canManageProductPart(ctx, sub, optionValueID, "option value", uc.products.GetOptionValueProductID)
+}
+
+func (uc *UseCase) canManageProductPart(ctx context.Context, sub authz.Subject, id uuid.UUID, part string, productOf func(context.Context, uuid.UUID) (uuid.UUID, error)) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+
+	productID, err := productOf(ctx, id)
+	if err != nil {
+		return notFound("get "+part, err)
+	}
+	return uc.CanManageProduct(ctx, sub, productID)
+}
+
+// CanAccessOrder lets the customer who placed an order, the merchant it was
+// placed with, the store's fulfilment staff and the driver delivering it see
+// the order.
+func (uc *UseCase) CanAccessOrder(ctx context.Context, sub authz.Subject, orderID uuid.UUID) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+
+	o, err := uc.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return notFound("get order", err)
+	}
+
+	switch sub.Role {
+	case user.Customer:
+		if o.CustomerID == sub.ID {
+			return nil
+		}
+	case user.Merchant:
+		if o.MerchantID == sub.ID && sub.InStore(o.StoreID) {
+			return nil
+		}
+		return uc.CanInStore(ctx, sub, o.StoreID, store.PermFulfilOrders)
+	case user.Driver:
+		d, err := uc.deliveries.GetByOrderID(ctx, orderID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return authz.ErrorForbidden
+		}
+		if err != nil {
+			return fmt.Errorf("get order delivery: %w", err)
+		}
+		if d.DriverID == sub.ID {
+			return nil
+		}
+	}
+	return authz.ErrorForbidden
+}
+
+// CanAccessDelivery lets only the driver assigned to a delivery act on it.
+func (uc *UseCase) CanAccessDelivery(ctx context.Context, sub authz.Subject, deliveryID uuid.UUID) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+	if sub.Role != user.Driver {
+		return authz.ErrorForbidden
+	}
+
+	d, err := uc.deliveries.GetByID(ctx, deliveryID)
+	if err != nil {
+		return notFound("get delivery", err)
+	}
+	if d.DriverID != sub.ID {
+		return authz.ErrorForbidden
+	}
+	return nil
+}
+
+// CanManageInvite lets merchants act on the invites they sent and on the
+// invites to stores whose staff they manage.
+func (uc *UseCase) CanManageInvite(ctx context.Context, sub authz.Subject, inviteID uuid.UUID) error {
+	if sub.IsAdmin() {
+		return nil
+	}
+
+	i, err := uc.invites.GetByID(ctx, inviteID)
+	if err != nil {
+		return notFound("get invite", err)
+	}
+	if i.InvitedBy == sub.ID {
+		return nil
+	}
+	if i.StoreID == nil {
+		return authz.ErrorForbidden
+	}
+	return uc.CanInStore(ctx, sub, *i.StoreID, store.PermManageStaff)
+}
+
+// notFound reports missing resources as authz.ErrorNotFound and wraps
+// everything else.
+func notFound(op string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return authz.ErrorNotFound
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
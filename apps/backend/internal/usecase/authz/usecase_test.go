@@ -0,0 +1,285 @@
+package authz
+
+import (
+	"backend/internal/domain/authz"
+	"backend/internal/domain/delivery"
+	"backend/internal/domain/invite"
+	"backend/internal/domain/order"
+	"backend/internal/domain/product"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCatalog struct {
+	owners   map[uuid.UUID]uuid.UUID                      // store -> owner
+	staff    map[uuid.UUID]map[uuid.UUID]store.MemberRole // store -> user -> role
+	products map[uuid.UUID]*product.Product
+	variants map[uuid.UUID]*product.Variant
+	parts    map[uuid.UUID]uuid.UUID // image, option or option value -> product
+}
+
+func (f *fakeCatalog) GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error) {
+	if f.owners[storeID] == userID {
+		return store.MemberOwner, nil
+	}
+	return f.staff[storeID][userID], nil
+}
+
+func (f *fakeCatalog) GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error) {
+	if p, ok := f.products[id]; ok {
+		return p, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCatalog) GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error) {
+	if v, ok := f.variants[id]; ok {
+		return v, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeCatalog) GetImageProductID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return f.productOf(id)
+}
+
+func (f *fakeCatalog) GetOptionProductID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return f.productOf(id)
+}
+
+func (f *fakeCatalog) GetOptionValueProductID(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	return f.productOf(id)
+}
+
+func (f *fakeCatalog) productOf(id uuid.UUID) (uuid.UUID, error) {
+	if p, ok := f.parts[id]; ok {
+		return p, nil
+	}
+	return uuid.Nil, sql.ErrNoRows
+}
+
+type fakeOrders map[uuid.UUID]*order.Order
+
+func (f fakeOrders) GetByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	if o, ok := f[id]; ok {
+		return o, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeDeliveries map[uuid.UUID]*delivery.Delivery
+
+func (f fakeDeliveries) GetByID(ctx context.Context, id uuid.UUID) (*delivery.Delivery, error) {
+	if d, ok := f[id]; ok {
+		return d, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f fakeDeliveries) GetByOrderID(ctx context.Context, orderID uuid.UUID) (*delivery.Delivery, error) {
+	for _, d := range f {
+		if d.OrderID == orderID {
+			return d, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+type fakeInvites map[uuid.UUID]*invite.Invite
+
+func (f fakeInvites) GetByID(ctx context.Context, id uuid.UUID) (*invite.Invite, error) {
+	if i, ok := f[id]; ok {
+		return i, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// params holds URL parameters and query values, and body fields under
+// "body." + name.
+type params map[string]string
+
+func (p params) Param(name string) (string, error) { return p[name], nil }
+func (p params) Body(name string) (string, error)  { return p["body."+name], nil }
+
+type world struct {
+	uc                                  *UseCase
+	merchant, customer, driver, other   uuid.UUID
+	editor, fulfiler                    uuid.UUID
+	storeID, productID, variantID       uuid.UUID
+	imageID                             uuid.UUID
+	orderID, unassignedOrderID, delivID uuid.UUID
+	storeInvite, ownInvite              uuid.UUID
+}
+
+func newWorld() *world {
+	w := &world{
+		merchant: uuid.New(), customer: uuid.New(), driver: uuid.New(), other: uuid.New(),
+		editor: uuid.New(), fulfiler: uuid.New(),
+		storeID: uuid.New(), productID: uuid.New(), variantID: uuid.New(), imageID: uuid.New(),
+		orderID: uuid.New(), unassignedOrderID: uuid.New(), delivID: uuid.New(),
+		storeInvite: uuid.New(), ownInvite: uuid.New(),
+	}
+	catalog := &fakeCatalog{
+		owners: map[uuid.UUID]uuid.UUID{w.storeID: w.merchant},
+		staff: map[uuid.UUID]map[uuid.UUID]store.MemberRole{
+			w.storeID: {w.editor: store.MemberCatalogEditor, w.fulfiler: store.MemberFulfilment},
+		},
+		products: map[uuid.UUID]*product.Product{w.productID: {ID: w.productID, StoreID: w.storeID}},
+		variants: map[uuid.UUID]*product.Variant{w.variantID: {ID: w.variantID, ProductID: w.productID}},
+		parts:    map[uuid.UUID]uuid.UUID{w.imageID: w.productID},
+	}
+	orders := fakeOrders{
+		w.orderID:           {ID: w.orderID, StoreID: w.storeID, CustomerID: w.customer, MerchantID: w.merchant},
+		w.unassignedOrderID: {ID: w.unassignedOrderID, StoreID: w.storeID, CustomerID: w.customer, MerchantID: w.merchant},
+	}
+	deliveries := fakeDeliveries{w.delivID: {ID: w.delivID, OrderID: w.orderID, DriverID: w.driver}}
+	invites := fakeInvites{
+		w.storeInvite: {ID: w.storeInvite, StoreID: &w.storeID, InvitedBy: uuid.New()},
+		w.ownInvite:   {ID: w.ownInvite, InvitedBy: w.other},
+	}
+	w.uc = NewUseCase(catalog, catalog, orders, deliveries, invites)
+	return w
+}
+
+func TestCanAccessOrder(t *testing.T) {
+	w := newWorld()
+
+	tests := []struct {
+		name    string
+		sub     authz.Subject
+		orderID uuid.UUID
+		wantErr error
+	}{
+		{"customer of the order", authz.Subject{ID: w.customer, Role: user.Customer}, w.orderID, nil},
+		{"merchant of the order", authz.Subject{ID: w.merchant, Role: user.Merchant}, w.orderID, nil},
+		{"fulfilment staff", authz.Subject{ID: w.fulfiler, Role: user.Merchant}, w.orderID, nil},
+		{"catalog editor", authz.Subject{ID: w.editor, Role: user.Merchant}, w.orderID, authz.ErrorForbidden},
+		{"assigned driver", authz.Subject{ID: w.driver, Role: user.Driver}, w.orderID, nil},
+		{"admin", authz.Subject{ID: w.other, Role: user.Admin}, w.orderID, nil},
+		{"other customer", authz.Subject{ID: w.other, Role: user.Customer}, w.orderID, authz.ErrorForbidden},
+		{"other merchant", authz.Subject{ID: w.other, Role: user.Merchant}, w.orderID, authz.ErrorForbidden},
+		{"driver without delivery", authz.Subject{ID: w.driver, Role: user.Driver}, w.unassignedOrderID, authz.ErrorForbidden},
+		{"guest", authz.Subject{ID: w.customer, Role: user.Guest}, w.orderID, authz.ErrorForbidden},
+		{"missing order", authz.Subject{ID: w.customer, Role: user.Customer}, uuid.New(), authz.ErrorNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := w.uc.CanAccessOrder(context.Background(), tt.sub, tt.orderID)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCanManageCatalog(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	owner := authz.Subject{ID: w.merchant, Role: user.Merchant}
+	stranger := authz.Subject{ID: w.other, Role: user.Merchant}
+	customer := authz.Subject{ID: w.customer, Role: user.Customer}
+
+	require.NoError(t, w.uc.CanManageStore(ctx, owner, w.storeID))
+	require.NoError(t, w.uc.CanManageProduct(ctx, owner, w.productID))
+	require.NoError(t, w.uc.CanManageVariant(ctx, owner, w.variantID))
+
+	require.ErrorIs(t, w.uc.CanManageStore(ctx, stranger, w.storeID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanManageProduct(ctx, stranger, w.productID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanManageVariant(ctx, stranger, w.variantID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanManageStore(ctx, customer, w.storeID), authz.ErrorForbidden)
+
+	require.ErrorIs(t, w.uc.CanManageProduct(ctx, owner, uuid.New()), authz.ErrorNotFound)
+	require.ErrorIs(t, w.uc.CanManageVariant(ctx, owner, uuid.New()), authz.ErrorNotFound)
+
+	require.NoError(t, w.uc.CanManageImage(ctx, owner, w.imageID))
+	require.ErrorIs(t, w.uc.CanManageImage(ctx, stranger, w.imageID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanManageOption(ctx, owner, uuid.New()), authz.ErrorNotFound)
+}
+
+func TestCanInStore_StaffRoles(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	editor := authz.Subject{ID: w.editor, Role: user.Merchant}
+	fulfiler := authz.Subject{ID: w.fulfiler, Role: user.Merchant}
+
+	require.NoError(t, w.uc.CanManageProduct(ctx, editor, w.productID))
+	require.NoError(t, w.uc.CanManageVariant(ctx, editor, w.variantID))
+	require.ErrorIs(t, w.uc.CanManageStore(ctx, editor, w.storeID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanInStore(ctx, editor, w.storeID, store.PermManageStaff), authz.ErrorForbidden)
+
+	require.NoError(t, w.uc.CanInStore(ctx, fulfiler, w.storeID, store.PermFulfilOrders))
+	require.ErrorIs(t, w.uc.CanManageProduct(ctx, fulfiler, w.productID), authz.ErrorForbidden)
+
+	owner := authz.Subject{ID: w.merchant, Role: user.Merchant}
+	require.NoError(t, w.uc.CanInStore(ctx, owner, w.storeID, store.PermDeleteStore))
+	require.ErrorIs(t, w.uc.CanInStore(ctx, authz.Subject{ID: w.editor, Role: user.Customer}, w.storeID, store.PermManageCatalog), authz.ErrorForbidden, "staff act as merchants")
+}
+
+func TestCanAccessDelivery(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+
+	require.NoError(t, w.uc.CanAccessDelivery(ctx, authz.Subject{ID: w.driver, Role: user.Driver}, w.delivID))
+	require.NoError(t, w.uc.CanAccessDelivery(ctx, authz.Subject{ID: w.other, Role: user.Admin}, w.delivID))
+	require.ErrorIs(t, w.uc.CanAccessDelivery(ctx, authz.Subject{ID: w.other, Role: user.Driver}, w.delivID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanAccessDelivery(ctx, authz.Subject{ID: w.customer, Role: user.Customer}, w.delivID), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanAccessDelivery(ctx, authz.Subject{ID: w.driver, Role: user.Driver}, uuid.New()), authz.ErrorNotFound)
+}
+
+func TestCanManageInvite(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	merchant := authz.Subject{ID: w.merchant, Role: user.Merchant}
+	other := authz.Subject{ID: w.other, Role: user.Merchant}
+
+	require.NoError(t, w.uc.CanManageInvite(ctx, merchant, w.storeInvite), "store owner")
+	require.NoError(t, w.uc.CanManageInvite(ctx, other, w.ownInvite), "inviter")
+	require.ErrorIs(t, w.uc.CanManageInvite(ctx, other, w.storeInvite), authz.ErrorForbidden)
+	require.ErrorIs(t, w.uc.CanManageInvite(ctx, merchant, w.ownInvite), authz.ErrorForbidden)
+	require.NoError(t, w.uc.CanManageInvite(ctx, authz.Subject{ID: w.other, Role: user.Admin}, w.ownInvite))
+	require.ErrorIs(t, w.uc.CanManageInvite(ctx, merchant, uuid.New()), authz.ErrorNotFound)
+}
+
+func TestPolicy_Authorize(t *testing.T) {
+	w := newWorld()
+	route := authz.Route("PUT", "/api/stores/{id}/update")
+	policy := Policy{
+		route: {Roles: []user.Role{user.Merchant, user.Admin}, Check: w.uc.StoreStaff("id", store.PermManageStore)},
+		authz.Route("GET", "/api/users/by-id/{id}"): {Roles: []user.Role{user.Customer}, Check: Self("id")},
+		authz.Route("POST", "/api/invites/create"):  {Roles: []user.Role{user.Merchant}, Check: FromBody(w.uc.StoreStaff("store_id", store.PermManageStaff))},
+	}
+	ctx := context.Background()
+	invite := authz.Route("POST", "/api/invites/create")
+	storeParams := params{"id": w.storeID.String()}
+
+	tests := []struct {
+		name    string
+		route   string
+		sub     authz.Subject
+		params  params
+		wantErr error
+	}{
+		{"owner", route, authz.Subject{ID: w.merchant, Role: user.Merchant}, storeParams, nil},
+		{"admin skips the check", route, authz.Subject{ID: w.other, Role: user.Admin}, storeParams, nil},
+		{"other merchant", route, authz.Subject{ID: w.other, Role: user.Merchant}, storeParams, authz.ErrorForbidden},
+		{"role not allowed", route, authz.Subject{ID: w.customer, Role: user.Customer}, storeParams, authz.ErrorRoleNotAllowed},
+		{"invalid id", route, authz.Subject{ID: w.merchant, Role: user.Merchant}, params{"id": "nope"}, authz.ErrorInvalidResourceID},
+		{"no rule", authz.Route("GET", "/api/unknown"), authz.Subject{ID: w.other, Role: user.Admin}, nil, authz.ErrorNoPolicy},
+		{"self", authz.Route("GET", "/api/users/by-id/{id}"), authz.Subject{ID: w.customer, Role: user.Customer}, params{"id": w.customer.String()}, nil},
+		{"not self", authz.Route("GET", "/api/users/by-id/{id}"), authz.Subject{ID: w.customer, Role: user.Customer}, params{"id": w.other.String()}, authz.ErrorForbidden},
+		{"id from body", invite, authz.Subject{ID: w.merchant, Role: user.Merchant}, params{"body.store_id": w.storeID.String()}, nil},
+		{"body ids not read from the query", invite, authz.Subject{ID: w.merchant, Role: user.Merchant}, params{"store_id": w.storeID.String()}, authz.ErrorInvalidResourceID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Authorize(ctx, tt.route, tt.sub, tt.params)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package cart
+
+import (
+	"backend/internal/domain/cart"
+	"backend/internal/domain/order"
+	prod "backend/internal/domain/product"
+	"backend/internal/usecase/common"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// UseCase encapsulates cart business logic and dependencies.
+type UseCase struct {
+	repo      cart.Repository
+	prodRepo  cart.ProductReader
+	orders    cart.OrderPlacer
+	txManager common.TxManager
+}
+
+// NewUseCase creates a new cart UseCase.
+func NewUseCase(repo cart.Repository, prodRepo cart.ProductReader, orders cart.OrderPlacer, txm common.TxManager) *UseCase {
+	return &UseCase{repo: repo, prodRepo: prodRepo, orders: orders, txManager: txm}
+}
+
+// GetCart returns the customer's cart for a store priced against the current
+// catalog. A customer without a cart gets an empty one.
+func (uc *UseCase) GetCart(ctx context.Context, customerID, storeID uuid.UUID) (*cart.View, error) {
+	c, err := uc.repo.Get(ctx, customerID, storeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &cart.View{CustomerID: customerID, StoreID: storeID, Lines: []cart.Line{}, Currency: order.DefaultCurrency}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+	return uc.price(ctx, c)
+}
+
+// ListCarts returns every cart of the customer, priced.
+func (uc *UseCase) ListCarts(ctx context.Context, customerID uuid.UUID) ([]*cart.View, error) {
+	carts, err := uc.repo.ListByCustomer(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("list carts: %w", err)
+	}
+
+	views := make([]*cart.View, 0, len(carts))
+	for _, c := range carts {
+		v, err := uc.price(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, v)
+	}
+	return views, nil
+}
+
+// AddItem puts a product (or variant) in the customer's cart for the store,
+// adding to the quantity if it is already there.
+func (uc *UseCase) AddItem(ctx context.Context, customerID, storeID uuid.UUID, req cart.AddItemRequest) (*cart.View, error) {
+	if req.Quantity <= 0 {
+		return nil, cart.ErrorInvalidQuantity
+	}
+
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if _, _, err := uc.loadItem(txCtx, storeID, req.ProductID, req.VariantID); err != nil {
+			return err
+		}
+
+		c, err := uc.repo.GetOrCreate(txCtx, customerID, storeID)
+		if err != nil {
+			return fmt.Errorf("get cart: %w", err)
+		}
+
+		_, err = uc.repo.AddItem(txCtx, c.ID, &cart.Item{
+			ProductID: req.ProductID,
+			VariantID: req.VariantID,
+			Quantity:  req.Quantity,
+		})
+		if err != nil {
+			return fmt.Errorf("add cart item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.GetCart(ctx, customerID, storeID)
+}
+
+// UpdateItem sets the quantity of a line of the customer's cart.
+func (uc *UseCase) UpdateItem(ctx context.Context, customerID, storeID, itemID uuid.UUID, quantity int) (*cart.View, error) {
+	if quantity <= 0 {
+		return nil, cart.ErrorInvalidQuantity
+	}
+
+	c, err := uc.ownCart(ctx, customerID, storeID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.SetItemQuantity(ctx, c.ID, itemID, quantity); err != nil {
+		return nil, fmt.Errorf("update cart item: %w", err)
+	}
+
+	return uc.GetCart(ctx, customerID, storeID)
+}
+
+// RemoveItem deletes a line of the customer's cart.
+func (uc *UseCase) RemoveItem(ctx context.Context, customerID, storeID, itemID uuid.UUID) (*cart.View, error) {
+	c, err := uc.ownCart(ctx, customerID, storeID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.RemoveItem(ctx, c.ID, itemID); err != nil {
+		return nil, fmt.Errorf("remove cart item: %w", err)
+	}
+
+	return uc.GetCart(ctx, customerID, storeID)
+}
+
+// ClearCart empties the customer's cart for a store.
+func (uc *UseCase) ClearCart(ctx context.Context, customerID, storeID uuid.UUID) error {
+	c, err := uc.repo.Get(ctx, customerID, storeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get cart: %w", err)
+	}
+	return uc.repo.Clear(ctx, c.ID)
+}
+
+// Checkout places an order for everything in the customer's cart and empties
+// it. Stock is reserved and prices are snapshotted by the order usecase; a
+// cart with lines that can't be ordered is refused so the customer can fix it
+// first.
+func (uc *UseCase) Checkout(ctx context.Context, customerID, storeID uuid.UUID, req cart.CheckoutRequest) (*order.Order, error) {
+	c, err := uc.repo.Get(ctx, customerID, storeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, cart.ErrorEmptyCart
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+	if len(c.Items) == 0 {
+		return nil, cart.ErrorEmptyCart
+	}
+
+	v, err := uc.price(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanCheckout {
+		return nil, cart.ErrorCartNotOrderable
+	}
+
+	// The order is placed in its own transaction, which also sends the
+	// confirmation, so emptying the cart comes after it. A cart left behind
+	// is only an inconvenience; the order stands either way.
+	o, err := uc.orders.CreateOrder(ctx, customerID, req.ToOrderRequest(c))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.Clear(ctx, c.ID); err != nil {
+		log.Printf("cart %s: clear after checkout of order %s failed: %v", c.ID, o.ID, err)
+	}
+
+	return o, nil
+}
+
+// ownCart returns the customer's cart for the store if it holds the item.
+func (uc *UseCase) ownCart(ctx context.Context, customerID, storeID, itemID uuid.UUID) (*cart.Cart, error) {
+	c, err := uc.repo.Get(ctx, customerID, storeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, cart.ErrorItemNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cart: %w", err)
+	}
+
+	for _, item := range c.Items {
+		if item.ID == itemID {
+			return c, nil
+		}
+	}
+	return nil, cart.ErrorItemNotFound
+}
+
+// price re-prices every line from the catalog and flags lines that are out
+// of stock or point at products that are gone.
+func (uc *UseCase) price(ctx context.Context, c *cart.Cart) (*cart.View, error) {
+	v := &cart.View{
+		ID:         c.ID,
+		CustomerID: c.CustomerID,
+		StoreID:    c.StoreID,
+		Lines:      []cart.Line{},
+		Currency:   order.DefaultCurrency,
+		UpdatedAt:  c.UpdatedAt,
+	}
+
+	for _, item := range c.Items {
+		line := cart.Line{
+			ItemID:    item.ID,
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+
+		p, variant, err := uc.loadItem(ctx, c.StoreID, item.ProductID, item.VariantID)
+		switch {
+		case isGone(err):
+			line.Unavailable = true
+		case err != nil:
+			return nil, err
+		default:
+			fillLine(&line, p, variant)
+		}
+
+		v.Add(line)
+	}
+
+	return v, nil
+}
+
+// loadItem fetches the product and variant of a line and checks that they
+// can be ordered from the store.
+func (uc *UseCase) loadItem(ctx context.Context, storeID, productID uuid.UUID, variantID *uuid.UUID) (*prod.Product, *prod.Variant, error) {
+	p, err := uc.prodRepo.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("product not found: %w", err)
+	}
+	if p.StoreID != storeID {
+		return nil, nil, cart.ErrorProductNotInStore
+	}
+
+	if !p.HasVariants {
+		if variantID != nil {
+			return nil, nil, cart.ErrorVariantNotAllowed
+		}
+		return p, nil, nil
+	}
+
+	if variantID == nil {
+		return nil, nil, cart.ErrorVariantRequired
+	}
+	v, err := uc.prodRepo.GetVariantByID(ctx, *variantID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && v.ProductID != p.ID) {
+		return nil, nil, cart.ErrorVariantNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("variant not found: %w", err)
+	}
+
+	return p, v, nil
+}
+
+// isGone reports whether a line no longer matches the catalog: the product
+// or variant was deleted, or the product changed shape since it was added.
+func isGone(err error) bool {
+	for _, target := range []error{
+		sql.ErrNoRows,
+		cart.ErrorProductNotInStore,
+		cart.ErrorVariantRequired,
+		cart.ErrorVariantNotAllowed,
+		cart.ErrorVariantNotFound,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// fillLine prices a line the way the order usecase snapshots order lines.
+func fillLine(l *cart.Line, p *prod.Product, v *prod.Variant) {
+	l.ProductName = p.Name
+	l.UnitPrice = prod.UnitPrice(p, v)
+
+	if v != nil {
+		stock := v.Stock
+		l.Stock = &stock
+
+		name := prod.VariantName(v)
+		l.VariantName = &name
+	} else {
+		l.Stock = p.Stock
+	}
+	l.Total = l.UnitPrice * int64(l.Quantity)
+	l.OutOfStock = l.Stock != nil && *l.Stock < l.Quantity
+
+	if img := prod.PrimaryImage(p, v); img != "" {
+		l.ImageURL = &img
+	}
+}
@@ -0,0 +1,301 @@
+package cart
+
+import (
+	"backend/internal/domain/cart"
+	"backend/internal/domain/order"
+	"backend/internal/domain/product"
+	"backend/internal/usecase/common"
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(common.MarkTx(ctx))
+}
+
+type fakeCartRepo struct {
+	carts map[string]*cart.Cart
+}
+
+func cartKey(customerID, storeID uuid.UUID) string {
+	return customerID.String() + storeID.String()
+}
+
+func (f *fakeCartRepo) GetOrCreate(ctx context.Context, customerID, storeID uuid.UUID) (*cart.Cart, error) {
+	if c, ok := f.carts[cartKey(customerID, storeID)]; ok {
+		return c, nil
+	}
+	c := &cart.Cart{ID: uuid.New(), CustomerID: customerID, StoreID: storeID, Items: []cart.Item{}}
+	f.carts[cartKey(customerID, storeID)] = c
+	return c, nil
+}
+
+func (f *fakeCartRepo) Get(ctx context.Context, customerID, storeID uuid.UUID) (*cart.Cart, error) {
+	c, ok := f.carts[cartKey(customerID, storeID)]
+	if !ok {
+		return nil, fmt.Errorf("get cart: %w", sql.ErrNoRows)
+	}
+	return c, nil
+}
+
+func (f *fakeCartRepo) ListByCustomer(ctx context.Context, customerID uuid.UUID) ([]*cart.Cart, error) {
+	var carts []*cart.Cart
+	for _, c := range f.carts {
+		if c.CustomerID == customerID {
+			carts = append(carts, c)
+		}
+	}
+	return carts, nil
+}
+
+func (f *fakeCartRepo) byID(cartID uuid.UUID) *cart.Cart {
+	for _, c := range f.carts {
+		if c.ID == cartID {
+			return c
+		}
+	}
+	return nil
+}
+
+func (f *fakeCartRepo) AddItem(ctx context.Context, cartID uuid.UUID, item *cart.Item) (*cart.Item, error) {
+	c := f.byID(cartID)
+	for i := range c.Items {
+		same := c.Items[i].ProductID == item.ProductID &&
+			((c.Items[i].VariantID == nil && item.VariantID == nil) ||
+				(c.Items[i].VariantID != nil && item.VariantID != nil && *c.Items[i].VariantID == *item.VariantID))
+		if same {
+			c.Items[i].Quantity += item.Quantity
+			return &c.Items[i], nil
+		}
+	}
+	saved := *item
+	saved.ID = uuid.New()
+	saved.CartID = cartID
+	c.Items = append(c.Items, saved)
+	return &saved, nil
+}
+
+func (f *fakeCartRepo) SetItemQuantity(ctx context.Context, cartID, itemID uuid.UUID, quantity int) error {
+	c := f.byID(cartID)
+	for i := range c.Items {
+		if c.Items[i].ID == itemID {
+			c.Items[i].Quantity = quantity
+			return nil
+		}
+	}
+	return cart.ErrorItemNotFound
+}
+
+func (f *fakeCartRepo) RemoveItem(ctx context.Context, cartID, itemID uuid.UUID) error {
+	c := f.byID(cartID)
+	for i := range c.Items {
+		if c.Items[i].ID == itemID {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			return nil
+		}
+	}
+	return cart.ErrorItemNotFound
+}
+
+func (f *fakeCartRepo) Clear(ctx context.Context, cartID uuid.UUID) error {
+	f.byID(cartID).Items = []cart.Item{}
+	return nil
+}
+
+type fakeProductRepo struct {
+	products map[uuid.UUID]*product.Product
+	variants map[uuid.UUID]*product.Variant
+}
+
+func (f *fakeProductRepo) GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error) {
+	p, ok := f.products[id]
+	if !ok {
+		return nil, fmt.Errorf("get product by id: %w", sql.ErrNoRows)
+	}
+	return p, nil
+}
+
+func (f *fakeProductRepo) GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error) {
+	v, ok := f.variants[id]
+	if !ok {
+		return nil, fmt.Errorf("get variant by id: %w", sql.ErrNoRows)
+	}
+	return v, nil
+}
+
+type fakeOrders struct {
+	placed []*order.CreateOrderRequest
+}
+
+func (f *fakeOrders) CreateOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) (*order.Order, error) {
+	f.placed = append(f.placed, req)
+	return &order.Order{ID: uuid.New(), CustomerID: customerID, StoreID: req.StoreID}, nil
+}
+
+type fixture struct {
+	uc         *UseCase
+	carts      *fakeCartRepo
+	products   *fakeProductRepo
+	orders     *fakeOrders
+	customerID uuid.UUID
+	storeID    uuid.UUID
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		carts:      &fakeCartRepo{carts: map[string]*cart.Cart{}},
+		products:   &fakeProductRepo{products: map[uuid.UUID]*product.Product{}, variants: map[uuid.UUID]*product.Variant{}},
+		orders:     &fakeOrders{},
+		customerID: uuid.New(),
+		storeID:    uuid.New(),
+	}
+	f.uc = NewUseCase(f.carts, f.products, f.orders, fakeTxManager{})
+	return f
+}
+
+func (f *fixture) addProduct(price float64, stock int) *product.Product {
+	p := &product.Product{ID: uuid.New(), StoreID: f.storeID, Name: "Sukuma", Price: price, Stock: &stock}
+	f.products.products[p.ID] = p
+	return p
+}
+
+func (f *fixture) add(t *testing.T, productID uuid.UUID, quantity int) *cart.View {
+	t.Helper()
+	v, err := f.uc.AddItem(context.Background(), f.customerID, f.storeID, cart.AddItemRequest{ProductID: productID, Quantity: quantity})
+	require.NoError(t, err)
+	return v
+}
+
+func TestAddItem_MergesSameProduct(t *testing.T) {
+	f := newFixture()
+	p := f.addProduct(20, 10)
+
+	f.add(t, p.ID, 2)
+	v := f.add(t, p.ID, 3)
+
+	require.Len(t, v.Lines, 1)
+	require.Equal(t, 5, v.Lines[0].Quantity)
+	require.Equal(t, int64(10000), v.Subtotal)
+	require.True(t, v.CanCheckout)
+}
+
+func TestAddItem_RoundsPricesToCents(t *testing.T) {
+	f := newFixture()
+	p := f.addProduct(19.99, 10) // 19.99 * 100 is 1998.9999999999998
+
+	v := f.add(t, p.ID, 1)
+
+	require.Equal(t, int64(1999), v.Lines[0].UnitPrice)
+}
+
+func TestAddItem_RejectsProductOfOtherStore(t *testing.T) {
+	f := newFixture()
+	p := f.addProduct(20, 10)
+	p.StoreID = uuid.New()
+
+	_, err := f.uc.AddItem(context.Background(), f.customerID, f.storeID, cart.AddItemRequest{ProductID: p.ID, Quantity: 1})
+
+	require.ErrorIs(t, err, cart.ErrorProductNotInStore)
+	require.Empty(t, f.carts.carts)
+}
+
+func TestGetCart_RepricesAndFlagsLines(t *testing.T) {
+	f := newFixture()
+	cheap := f.addProduct(10, 10)
+	scarce := f.addProduct(5, 10)
+	gone := f.addProduct(1, 10)
+	f.add(t, cheap.ID, 2)
+	f.add(t, scarce.ID, 4)
+	f.add(t, gone.ID, 1)
+
+	// The catalog moves on after the items were added
+	cheap.Price = 12
+	*scarce.Stock = 3
+	delete(f.products.products, gone.ID)
+
+	v, err := f.uc.GetCart(context.Background(), f.customerID, f.storeID)
+
+	require.NoError(t, err)
+	require.Len(t, v.Lines, 3)
+	require.Equal(t, int64(1200), v.Lines[0].UnitPrice)
+	require.Equal(t, int64(2400), v.Lines[0].Total)
+	require.True(t, v.Lines[1].OutOfStock)
+	require.Equal(t, 3, *v.Lines[1].Stock)
+	require.True(t, v.Lines[2].Unavailable)
+	require.Equal(t, int64(2400), v.Subtotal, "only orderable lines count")
+	require.False(t, v.CanCheckout)
+}
+
+func TestGetCart_EmptyWithoutCart(t *testing.T) {
+	f := newFixture()
+
+	v, err := f.uc.GetCart(context.Background(), f.customerID, f.storeID)
+
+	require.NoError(t, err)
+	require.Empty(t, v.Lines)
+	require.False(t, v.CanCheckout)
+}
+
+func TestUpdateItem_OnlyOwnCart(t *testing.T) {
+	f := newFixture()
+	p := f.addProduct(10, 10)
+	v := f.add(t, p.ID, 1)
+
+	_, err := f.uc.UpdateItem(context.Background(), uuid.New(), f.storeID, v.Lines[0].ItemID, 4)
+	require.ErrorIs(t, err, cart.ErrorItemNotFound)
+
+	v, err = f.uc.UpdateItem(context.Background(), f.customerID, f.storeID, v.Lines[0].ItemID, 4)
+	require.NoError(t, err)
+	require.Equal(t, 4, v.Lines[0].Quantity)
+
+	_, err = f.uc.UpdateItem(context.Background(), f.customerID, f.storeID, v.Lines[0].ItemID, 0)
+	require.ErrorIs(t, err, cart.ErrorInvalidQuantity)
+}
+
+func TestCheckout_PlacesOrderAndEmptiesCart(t *testing.T) {
+	f := newFixture()
+	a := f.addProduct(10, 10)
+	b := f.addProduct(5, 10)
+	f.add(t, a.ID, 2)
+	f.add(t, b.ID, 1)
+
+	o, err := f.uc.Checkout(context.Background(), f.customerID, f.storeID, cart.CheckoutRequest{
+		PickupAddress:   "Shop",
+		DeliveryAddress: "Home",
+		PromoCode:       "KARIBU",
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, f.storeID, o.StoreID)
+	require.Len(t, f.orders.placed, 1)
+	req := f.orders.placed[0]
+	require.Equal(t, "KARIBU", req.PromoCode)
+	require.Equal(t, []order.CreateOrderItem{
+		{ProductID: a.ID, Quantity: 2},
+		{ProductID: b.ID, Quantity: 1},
+	}, req.Items)
+
+	v, err := f.uc.GetCart(context.Background(), f.customerID, f.storeID)
+	require.NoError(t, err)
+	require.Empty(t, v.Lines)
+}
+
+func TestCheckout_RefusesUnorderableCart(t *testing.T) {
+	f := newFixture()
+	p := f.addProduct(10, 1)
+	f.add(t, p.ID, 2)
+
+	_, err := f.uc.Checkout(context.Background(), f.customerID, f.storeID, cart.CheckoutRequest{})
+	require.ErrorIs(t, err, cart.ErrorCartNotOrderable)
+	require.Empty(t, f.orders.placed)
+
+	_, err = f.uc.Checkout(context.Background(), uuid.New(), f.storeID, cart.CheckoutRequest{})
+	require.ErrorIs(t, err, cart.ErrorEmptyCart)
+}
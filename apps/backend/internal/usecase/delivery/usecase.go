@@ -1,11 +1,14 @@
 package delivery
 
 import (
-	"context"
-	"fmt"
 	"backend/internal/domain/delivery"
 	"backend/internal/domain/notification"
+	"backend/internal/domain/order"
 	"backend/internal/usecase/common"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -26,19 +29,28 @@ func (uc *UseCase) GetDeliveryByID(ctx context.Context, deliveryId uuid.UUID) (*
 	return uc.repo.GetByID(ctx, deliveryId)
 }
 
-func (uc *UseCase) UpdateDelivery(ctx context.Context, deliveryID uuid.UUID, column string, value any) error {
+// UpdateDelivery corrects the timestamps of a delivery. Status is excluded:
+// it only changes through UpdateDeliveryStatus.
+func (uc *UseCase) UpdateDelivery(ctx context.Context, deliveryID uuid.UUID, req *delivery.UpdateDeliveryRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		d, err := uc.repo.GetByID(txCtx, deliveryID)
 		if err != nil {
 			return fmt.Errorf("could not fetch delivery: %w", err)
 		}
+		if err := req.Apply(d); err != nil {
+			return err
+		}
 
-		if err := uc.repo.Update(txCtx, deliveryID, column, value); err != nil {
+		if err := uc.repo.Update(txCtx, deliveryID, req); err != nil {
 			return fmt.Errorf("update delivery failed: %w", err)
 		}
 
 		go func() {
-			msg := fmt.Sprintf("ℹ️ Delivery for order %s updated: '%s' changed.", d.OrderID, column)
+			msg := fmt.Sprintf("ℹ️ Delivery for order %s updated.", d.OrderID)
 			_ = uc.notify(ctx, d.DriverID, msg)
 		}()
 
@@ -46,43 +58,107 @@ func (uc *UseCase) UpdateDelivery(ctx context.Context, deliveryID uuid.UUID, col
 	})
 }
 
-func (uc *UseCase) AcceptDelivery(ctx context.Context, d *delivery.Delivery) error {
+// UpdateDeliveryStatus moves a delivery to a new status by moving its order
+// through the order state machine, which applies the delivery side effects.
+// Drivers may only update deliveries assigned to them.
+func (uc *UseCase) UpdateDeliveryStatus(ctx context.Context, deliveryID, callerID uuid.UUID, actor order.Actor, status delivery.DeliveryStatus) (*delivery.Delivery, error) {
+	to, err := status.OrderStatus()
+	if err != nil {
+		return nil, err
+	}
 
+	var d *delivery.Delivery
+	err = uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		d, err = uc.repo.GetByID(txCtx, deliveryID)
+		if err != nil {
+			return fmt.Errorf("could not fetch delivery: %w", err)
+		}
+
+		if actor == order.ActorDriver && d.DriverID != callerID {
+			return delivery.ErrorNotAssignedDriver
+		}
+
+		if _, err := uc.ordRepo.TransitionOrder(txCtx, d.OrderID, to, actor, callerID); err != nil {
+			return err
+		}
+
+		d, err = uc.repo.GetByID(txCtx, deliveryID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// AcceptDelivery creates the delivery for an order assigned to the driver and
+// moves the order in transit, which picks the delivery up and reserves the driver.
+func (uc *UseCase) AcceptDelivery(ctx context.Context, d *delivery.Delivery) error {
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		driver, err := uc.drvRepo.GetDriverByID(txCtx, d.DriverID)
 		if err != nil || !driver.Available {
 			return fmt.Errorf("driver not available")
 		}
 
-		order, err := uc.ordRepo.GetOrderByID(txCtx, d.OrderID)
-		if err != nil {
+		d.Status = delivery.Assigned
+		if err := uc.repo.Create(txCtx, d); err != nil {
 			return err
 		}
 
-		if err := uc.ordRepo.UpdateOrder(txCtx, order.ID, "status", "in_transit"); err != nil {
+		o, err := uc.ordRepo.TransitionOrder(txCtx, d.OrderID, order.InTransit, order.ActorDriver, d.DriverID)
+		if err != nil {
 			return err
 		}
 
-		if err := uc.drvRepo.UpdateDriverAvailability(txCtx, driver.ID, "availability", false); err != nil {
+		accepted, err := uc.repo.GetByID(txCtx, d.ID)
+		if err != nil {
 			return err
 		}
-
-		// Remove pending assignment
-		// if err := uc.ordRepo.MarkAssignmentAccepted(txCtx, order.ID, driver.ID); err != nil {
-		//     return err
-		// }
+		*d = *accepted
 
 		go func() {
-			msgCustomer := fmt.Sprintf("🚚 Your order %s is now in transit with driver %s.", order.ID, driver.FullName)
-			_ = uc.notify(ctx, order.CustomerID, msgCustomer)
+			msgCustomer := fmt.Sprintf("🚚 Your order %s is now in transit with driver %s.", o.ID, driver.FullName)
+			_ = uc.notify(ctx, o.CustomerID, msgCustomer)
 
-			msgDriver := fmt.Sprintf("✅ You have accepted delivery for order %s.", order.ID)
+			msgDriver := fmt.Sprintf("✅ You have accepted delivery for order %s.", o.ID)
 			_ = uc.notify(ctx, driver.ID, msgDriver)
 		}()
 
-		return uc.repo.Create(txCtx, d)
+		return nil
 	})
+}
+
+// ApplyTransition applies the delivery and driver side effects of an order
+// status transition. It runs inside the transaction of the order change.
+func (uc *UseCase) ApplyTransition(ctx context.Context, o *order.Order, t order.Transition) error {
+	d, err := uc.repo.GetByOrderID(ctx, o.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil // assigned but not accepted yet: nothing to update
+		}
+		return err
+	}
+
+	for _, e := range t.Effects {
+		switch e {
+		case order.EffectReserveDriver:
+			err = uc.drvRepo.UpdateDriverAvailability(ctx, d.DriverID, false)
+		case order.EffectReleaseDriver:
+			err = uc.drvRepo.UpdateDriverAvailability(ctx, d.DriverID, true)
+		case order.EffectPickUpDelivery:
+			err = uc.repo.UpdateStatus(ctx, d.ID, delivery.PickedUp)
+		case order.EffectCompleteDelivery:
+			err = uc.repo.UpdateStatus(ctx, d.ID, delivery.Delivered)
+		case order.EffectFailDelivery:
+			err = uc.repo.UpdateStatus(ctx, d.ID, delivery.Failed)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", e, err)
+		}
+	}
 
+	return nil
 }
 
 func (uc *UseCase) ListDeliveries(ctx context.Context) ([]*delivery.Delivery, error) {
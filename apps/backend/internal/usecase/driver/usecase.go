@@ -55,19 +55,25 @@ func (uc *UseCase) UpdateDriverProfile(ctx context.Context, id uuid.UUID, req *d
 	})
 }
 
+// UpdateDriver applies a partial update to a driver's profile, location and
+// availability.
 func (uc *UseCase) UpdateDriver(ctx context.Context, id uuid.UUID, req *domain.UpdateDriverRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		driver, err := uc.repo.GetByID(txCtx, id)
 		if err != nil {
 			return fmt.Errorf("could not fetch driver: %w", err)
 		}
 
-		if err := uc.repo.UpdateColumn(txCtx, id, req.Column, req.Value); err != nil {
+		if err := uc.repo.Update(txCtx, id, req); err != nil {
 			return fmt.Errorf("update driver failed: %w", err)
 		}
 
 		go func() {
-			msg := fmt.Sprintf("ℹ️ Your driver account column '%s' has been updated.", req.Column)
+			msg := "ℹ️ Your driver account has been updated."
 			_ = uc.notify(ctx, driver.ID, msg)
 		}()
 
@@ -75,14 +81,14 @@ func (uc *UseCase) UpdateDriver(ctx context.Context, id uuid.UUID, req *domain.U
 	})
 }
 
-func (uc *UseCase) UpdateDriverAvailability(ctx context.Context, driverID uuid.UUID, column string, available bool) error {
+func (uc *UseCase) UpdateDriverAvailability(ctx context.Context, driverID uuid.UUID, available bool) error {
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		driver, err := uc.repo.GetByID(txCtx, driverID)
 		if err != nil {
 			return fmt.Errorf("could not fetch driver: %w", err)
 		}
 
-		if err := uc.repo.UpdateColumn(txCtx, driverID, column, available); err != nil {
+		if err := uc.repo.SetAvailable(txCtx, driverID, available); err != nil {
 			return fmt.Errorf("update driver availability failed: %w", err)
 		}
 
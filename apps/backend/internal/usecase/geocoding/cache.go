@@ -0,0 +1,58 @@
+package geocoding
+
+import (
+	"backend/internal/domain/geocoding"
+	"context"
+	"errors"
+	"log"
+)
+
+// Cached wraps a Provider and keeps its results in Postgres, so repeated
+// lookups of the same place don't hit the provider again. Misses are not
+// cached: the gazetteer or a remote provider may learn the place later.
+type Cached struct {
+	inner geocoding.Provider
+	repo  geocoding.Repository
+}
+
+// NewCached puts a cache in front of a provider.
+func NewCached(inner geocoding.Provider, repo geocoding.Repository) *Cached {
+	return &Cached{inner: inner, repo: repo}
+}
+
+func (c *Cached) Geocode(ctx context.Context, query string) (*geocoding.Place, error) {
+	key := geocoding.NormalizeQuery(query)
+	if key == "" {
+		return nil, geocoding.ErrorEmptyQuery
+	}
+	return c.lookup(ctx, geocoding.Forward, key, func() (*geocoding.Place, error) {
+		return c.inner.Geocode(ctx, query)
+	})
+}
+
+func (c *Cached) ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.Place, error) {
+	return c.lookup(ctx, geocoding.Reverse, geocoding.ReverseKey(lat, lng), func() (*geocoding.Place, error) {
+		return c.inner.ReverseGeocode(ctx, lat, lng)
+	})
+}
+
+func (c *Cached) lookup(ctx context.Context, kind, key string, resolve func() (*geocoding.Place, error)) (*geocoding.Place, error) {
+	p, err := c.repo.GetCached(ctx, kind, key)
+	if err == nil {
+		return p, nil
+	}
+	if !errors.Is(err, geocoding.ErrorNoMatch) {
+		// A broken cache must not break lookups
+		log.Printf("geocoding cache read %s %q: %v", kind, key, err)
+	}
+
+	p, err = resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.repo.PutCached(ctx, kind, key, p); err != nil {
+		log.Printf("geocoding cache write %s %q: %v", kind, key, err)
+	}
+	return p, nil
+}
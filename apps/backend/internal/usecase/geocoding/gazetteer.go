@@ -0,0 +1,81 @@
+package geocoding
+
+import (
+	"backend/internal/domain/geocoding"
+	"context"
+	"strings"
+)
+
+// Search radii of reverse lookups: an estate is only named when the point
+// is close to it, otherwise the nearest town is.
+const (
+	estateRadiusM = 3_000
+	townRadiusM   = 30_000
+)
+
+// Gazetteer is an offline Provider backed by the local table of Kenyan towns
+// and estates. It needs no network, so dev and test runs use it.
+type Gazetteer struct {
+	repo geocoding.Repository
+}
+
+// NewGazetteer creates the offline provider.
+func NewGazetteer(repo geocoding.Repository) *Gazetteer {
+	return &Gazetteer{repo: repo}
+}
+
+// Geocode resolves queries such as "Kilimani, Nairobi" or "Shop 4, Moi
+// Avenue, Nakuru". The comma-separated parts are tried from the most
+// specific one on; an estate whose town is named later in the query wins
+// over a namesake elsewhere.
+func (g *Gazetteer) Geocode(ctx context.Context, query string) (*geocoding.Place, error) {
+	q := geocoding.NormalizeQuery(query)
+	if q == "" {
+		return nil, geocoding.ErrorEmptyQuery
+	}
+	parts := strings.Split(q, ", ")
+
+	for i, part := range parts {
+		entries, err := g.repo.FindByName(ctx, part)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		best := entries[0]
+		for _, e := range entries {
+			if e.Town != nil && mentions(parts[i+1:], *e.Town) {
+				best = e
+				break
+			}
+		}
+		return best.Place(), nil
+	}
+
+	return nil, geocoding.ErrorNoMatch
+}
+
+// ReverseGeocode names the estate or town nearest to a point.
+func (g *Gazetteer) ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.Place, error) {
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, geocoding.ErrorInvalidCoordinates
+	}
+
+	e, err := g.repo.Nearest(ctx, lat, lng, estateRadiusM, townRadiusM)
+	if err != nil {
+		return nil, err
+	}
+	return e.Place(), nil
+}
+
+func mentions(parts []string, town string) bool {
+	town = geocoding.NormalizeQuery(town)
+	for _, p := range parts {
+		if p == town {
+			return true
+		}
+	}
+	return false
+}
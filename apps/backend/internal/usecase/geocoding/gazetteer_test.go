@@ -0,0 +1,185 @@
+package geocoding
+
+import (
+	"backend/internal/domain/geocoding"
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo is an in-memory gazetteer and cache.
+type fakeRepo struct {
+	entries []*geocoding.Entry
+	cache   map[string]*geocoding.Place
+	failPut bool
+}
+
+func ptr(s string) *string { return &s }
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		cache: map[string]*geocoding.Place{},
+		entries: []*geocoding.Entry{
+			{Name: "Nairobi", Kind: "town", County: "Nairobi", Lat: -1.2864, Lng: 36.8172},
+			{Name: "Nakuru", Kind: "town", County: "Nakuru", Lat: -0.3031, Lng: 36.0800},
+			{Name: "Kisumu", Kind: "town", County: "Kisumu", Lat: -0.0917, Lng: 34.7680},
+			{Name: "Milimani", Kind: "estate", Town: ptr("Kisumu"), County: "Kisumu", Lat: -0.1040, Lng: 34.7530},
+			{Name: "Milimani", Kind: "estate", Town: ptr("Nakuru"), County: "Nakuru", Lat: -0.2880, Lng: 36.0680},
+			{Name: "Kilimani", Kind: "estate", Town: ptr("Nairobi"), County: "Nairobi", Lat: -1.2906, Lng: 36.7856},
+		},
+	}
+}
+
+func (f *fakeRepo) FindByName(ctx context.Context, name string) ([]*geocoding.Entry, error) {
+	var found []*geocoding.Entry
+	for _, e := range f.entries {
+		if geocoding.NormalizeQuery(e.Name) == name {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+// Nearest approximates distances on a flat map, which is fine at these scales.
+func (f *fakeRepo) Nearest(ctx context.Context, lat, lng float64, estateRadiusM, townRadiusM int) (*geocoding.Entry, error) {
+	var best *geocoding.Entry
+	bestDist := math.MaxFloat64
+	for _, kind := range []string{"estate", "town"} {
+		radius := float64(townRadiusM)
+		if kind == "estate" {
+			radius = float64(estateRadiusM)
+		}
+		for _, e := range f.entries {
+			d := math.Hypot(e.Lat-lat, e.Lng-lng) * 111_000
+			if e.Kind == kind && d <= radius && d < bestDist {
+				best, bestDist = e, d
+			}
+		}
+		if best != nil {
+			return best, nil
+		}
+	}
+	return nil, geocoding.ErrorNoMatch
+}
+
+func (f *fakeRepo) GetCached(ctx context.Context, kind, key string) (*geocoding.Place, error) {
+	p, ok := f.cache[kind+"|"+key]
+	if !ok {
+		return nil, geocoding.ErrorNoMatch
+	}
+	return p, nil
+}
+
+func (f *fakeRepo) PutCached(ctx context.Context, kind, key string, p *geocoding.Place) error {
+	if f.failPut {
+		return errors.New("cache down")
+	}
+	f.cache[kind+"|"+key] = p
+	return nil
+}
+
+func TestGazetteer_Geocode(t *testing.T) {
+	g := NewGazetteer(newFakeRepo())
+	ctx := context.Background()
+
+	tests := []struct {
+		query   string
+		address string
+		err     error
+	}{
+		{"Kilimani", "Kilimani, Nairobi", nil},
+		{"kilimani, nairobi, KENYA", "Kilimani, Nairobi", nil},
+		{"Milimani, Nakuru", "Milimani, Nakuru", nil},
+		{"Milimani, Kisumu", "Milimani, Kisumu", nil},
+		{"Shop 4, Moi Avenue, Nakuru", "Nakuru", nil},
+		{"Atlantis", "", geocoding.ErrorNoMatch},
+		{"  ", "", geocoding.ErrorEmptyQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			p, err := g.Geocode(ctx, tt.query)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.address, p.Address)
+		})
+	}
+}
+
+func TestGazetteer_ReverseGeocode(t *testing.T) {
+	g := NewGazetteer(newFakeRepo())
+	ctx := context.Background()
+
+	p, err := g.ReverseGeocode(ctx, -1.2910, 36.7860)
+	require.NoError(t, err)
+	require.Equal(t, "Kilimani, Nairobi", p.Address, "close to an estate")
+
+	p, err = g.ReverseGeocode(ctx, -1.35, 36.90)
+	require.NoError(t, err)
+	require.Equal(t, "Nairobi", p.Address, "only near a town")
+
+	_, err = g.ReverseGeocode(ctx, -4.04, 39.67)
+	require.ErrorIs(t, err, geocoding.ErrorNoMatch)
+
+	_, err = g.ReverseGeocode(ctx, 91, 0)
+	require.ErrorIs(t, err, geocoding.ErrorInvalidCoordinates)
+}
+
+// countingProvider counts lookups that reach it.
+type countingProvider struct {
+	geocoding.Provider
+	calls int
+}
+
+func (c *countingProvider) Geocode(ctx context.Context, query string) (*geocoding.Place, error) {
+	c.calls++
+	return c.Provider.Geocode(ctx, query)
+}
+
+func (c *countingProvider) ReverseGeocode(ctx context.Context, lat, lng float64) (*geocoding.Place, error) {
+	c.calls++
+	return c.Provider.ReverseGeocode(ctx, lat, lng)
+}
+
+func TestCached_ReusesResults(t *testing.T) {
+	repo := newFakeRepo()
+	inner := &countingProvider{Provider: NewGazetteer(repo)}
+	c := NewCached(inner, repo)
+	ctx := context.Background()
+
+	first, err := c.Geocode(ctx, "Kilimani, Nairobi")
+	require.NoError(t, err)
+	second, err := c.Geocode(ctx, "  KILIMANI, nairobi ")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+	require.Equal(t, 1, inner.calls, "same normalized query is served from the cache")
+
+	_, err = c.ReverseGeocode(ctx, -1.29061, 36.78562)
+	require.NoError(t, err)
+	_, err = c.ReverseGeocode(ctx, -1.29059, 36.78558)
+	require.NoError(t, err)
+	require.Equal(t, 2, inner.calls, "nearby points share a cache entry")
+
+	_, err = c.Geocode(ctx, "Atlantis")
+	require.ErrorIs(t, err, geocoding.ErrorNoMatch)
+	_, err = c.Geocode(ctx, "Atlantis")
+	require.ErrorIs(t, err, geocoding.ErrorNoMatch)
+	require.Equal(t, 4, inner.calls, "misses are not cached")
+}
+
+func TestCached_SurvivesCacheFailure(t *testing.T) {
+	repo := newFakeRepo()
+	repo.failPut = true
+	c := NewCached(NewGazetteer(repo), repo)
+
+	p, err := c.Geocode(context.Background(), "Nakuru")
+
+	require.NoError(t, err)
+	require.Equal(t, "Nakuru", p.Name)
+}
@@ -1,40 +1,93 @@
 package invite
 
 import (
-	"context"
-	"fmt"
+	"backend/internal/domain/auth"
 	domain "backend/internal/domain/invite"
+	"backend/internal/domain/notification"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
 	"backend/internal/usecase/common"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type UseCase struct {
-	repo      domain.Repository
-	txManager common.TxManager
-	// optional user repo - auto-create users on accept
+	repo       domain.Repository
+	users      domain.UserRegistrar
+	stores     domain.StoreMembership
+	txManager  common.TxManager
+	mailer     notification.EmailSender
+	appBaseURL string
+	now        func() time.Time
 }
 
-func NewUseCase(repo domain.Repository, txm common.TxManager) *UseCase {
-	return &UseCase{repo: repo, txManager: txm}
+// NewUseCase creates a new invite UseCase. Invite emails link to the accept
+// page under appBaseURL, or carry the bare token when it is empty.
+func NewUseCase(repo domain.Repository, users domain.UserRegistrar, stores domain.StoreMembership, txm common.TxManager, mailer notification.EmailSender, appBaseURL string) *UseCase {
+	return &UseCase{repo: repo, users: users, stores: stores, txManager: txm, mailer: mailer, appBaseURL: appBaseURL, now: time.Now}
 }
 
 // Add a new invited member from the shareable invite link
+//
+// The token is generated here and returned in i.Token, the only time it is
+// known. A failed invite email doesn't undo the invite, the link can still
+// be shared by hand or resent.
 func (uc *UseCase) InviteMember(ctx context.Context, i *domain.Invite) error {
-	// generate token, store invite
-	// optionally notify user
-	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+	if err := uc.validate(i); err != nil {
+		return err
+	}
+
+	raw, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("generate invite token: %w", err)
+	}
+	i.ID = uuid.New()
+	i.Token, i.TokenHash = raw, domain.HashToken(raw)
+	if i.ExpiresAt.IsZero() {
+		i.ExpiresAt = uc.now().Add(domain.DefaultTTL)
+	}
+
+	err = uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		if err := uc.repo.Create(txCtx, i); err != nil {
 			return fmt.Errorf("create invite failed: %w", err)
 		}
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	if err := uc.sendInvite(ctx, i); err != nil {
+		log.Printf("mail invite %s: %v", i.ID, err)
+	}
+	return nil
 }
 
-// Get invited member by token
+// Get invited member by token, as long as the invite can still be accepted
 func (uc *UseCase) GetMemberByToken(ctx context.Context, token string) (*domain.Invite, error) {
-	return uc.repo.GetByToken(ctx, token)
+	i, err := uc.repo.GetByToken(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrorInviteNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := i.Usable(uc.now()); err != nil {
+		return nil, err
+	}
+	return i, nil
 }
 
 // List all invited members
@@ -52,3 +105,182 @@ func (uc *UseCase) DeleteMember(ctx context.Context, id uuid.UUID) error {
 		return nil
 	})
 }
+
+// AcceptInvite creates the invited account with the invite's email and role,
+// adds it to the invite's store and consumes the invite. The email address
+// counts as verified, since the token was mailed to it.
+func (uc *UseCase) AcceptInvite(ctx context.Context, req *domain.AcceptInviteRequest) (*user.User, error) {
+	req.FullName, req.Phone = strings.TrimSpace(req.FullName), strings.TrimSpace(req.Phone)
+	if req.FullName == "" || req.Phone == "" || req.Password == "" {
+		return nil, domain.ErrorMissingFields
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		return nil, auth.ErrorWeakPassword
+	}
+
+	var u *user.User
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		i, err := uc.repo.GetByTokenForUpdate(txCtx, domain.HashToken(req.Token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErrorInviteNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("get invite: %w", err)
+		}
+
+		now := uc.now()
+		if err := i.Usable(now); err != nil {
+			return err
+		}
+
+		create := user.CreateUserRequest{
+			FullName: req.FullName,
+			Email:    i.Email,
+			Password: req.Password,
+			Role:     user.Role(i.Role),
+			Phone:    req.Phone,
+		}
+		u = create.ToUser()
+		u.EmailVerifiedAt = &now
+
+		// RegisterUser hashes the password and adds the driver profile
+		if err := uc.users.RegisterUser(txCtx, u); err != nil {
+			return err
+		}
+
+		if i.StoreID != nil {
+			if err := uc.stores.AddMember(txCtx, *i.StoreID, u.ID, i.ID, *i.StoreRole); err != nil {
+				return fmt.Errorf("add store member: %w", err)
+			}
+		}
+
+		if err := uc.repo.MarkAccepted(txCtx, i.ID, u.ID, now); err != nil {
+			return fmt.Errorf("mark invite accepted: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// ResendInvite replaces the token of a pending invite, which also revives an
+// expired one, and mails the new link. The returned invite carries the new
+// token.
+func (uc *UseCase) ResendInvite(ctx context.Context, id uuid.UUID) (*domain.Invite, error) {
+	var i *domain.Invite
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		i, err = uc.getByID(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if err := i.Usable(uc.now()); err != nil && !errors.Is(err, domain.ErrorInviteExpired) {
+			return err
+		}
+
+		raw, err := randomToken()
+		if err != nil {
+			return fmt.Errorf("generate invite token: %w", err)
+		}
+		i.Token, i.TokenHash = raw, domain.HashToken(raw)
+		i.ExpiresAt = uc.now().Add(domain.DefaultTTL)
+
+		if err := uc.repo.Renew(txCtx, i.ID, i.TokenHash, i.ExpiresAt); err != nil {
+			return fmt.Errorf("renew invite: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.sendInvite(ctx, i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// RevokeInvite stops a pending invite from being accepted. Unlike deleting
+// it, the invite stays on record.
+func (uc *UseCase) RevokeInvite(ctx context.Context, id uuid.UUID) error {
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		i, err := uc.getByID(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if i.AcceptedAt != nil {
+			return domain.ErrorInviteAccepted
+		}
+		if i.RevokedAt != nil {
+			return nil
+		}
+
+		if err := uc.repo.Revoke(txCtx, id, uc.now()); err != nil {
+			return fmt.Errorf("revoke invite: %w", err)
+		}
+		return nil
+	})
+}
+
+func (uc *UseCase) validate(i *domain.Invite) error {
+	i.Email = strings.TrimSpace(i.Email)
+	if _, err := mail.ParseAddress(i.Email); err != nil {
+		return domain.ErrorInvalidEmail
+	}
+
+	switch i.Role {
+	case domain.Admin, domain.Merchant, domain.Driver, domain.Customer, domain.Guest:
+	default:
+		return domain.ErrorInvalidRole
+	}
+	if i.StoreID != nil && i.Role != domain.Merchant {
+		return domain.ErrorStoreInviteRole
+	}
+	switch {
+	case i.StoreID == nil && i.StoreRole != nil:
+		return domain.ErrorInvalidStoreRole
+	case i.StoreID != nil && i.StoreRole == nil:
+		role := store.MemberManager
+		i.StoreRole = &role
+	case i.StoreID != nil && !i.StoreRole.IsStaff():
+		return domain.ErrorInvalidStoreRole
+	}
+
+	if !i.ExpiresAt.IsZero() && !i.ExpiresAt.After(uc.now()) {
+		return domain.ErrorInvalidExpiry
+	}
+	return nil
+}
+
+func (uc *UseCase) getByID(ctx context.Context, id uuid.UUID) (*domain.Invite, error) {
+	i, err := uc.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrorInviteNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get invite: %w", err)
+	}
+	return i, nil
+}
+
+func (uc *UseCase) sendInvite(ctx context.Context, i *domain.Invite) error {
+	link := "this code: " + i.Token
+	if uc.appBaseURL != "" {
+		link = fmt.Sprintf("%s/invites/accept?token=%s", strings.TrimRight(uc.appBaseURL, "/"), url.QueryEscape(i.Token))
+	}
+
+	body := fmt.Sprintf("Hi,\n\nYou have been invited to join as %s. Create your account with %s\n\nThe invite expires on %s.",
+		i.Role, link, i.ExpiresAt.Format("2 Jan 2006 15:04 MST"))
+	return uc.mailer.SendEmail(ctx, i.Email, "You're invited", body)
+}
+
+// randomToken returns 256 random bits, URL-safe encoded.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
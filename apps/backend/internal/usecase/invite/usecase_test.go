@@ -0,0 +1,266 @@
+package invite
+
+import (
+	"backend/internal/domain/auth"
+	domain "backend/internal/domain/invite"
+	"backend/internal/domain/store"
+	"backend/internal/domain/user"
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeRepo map[uuid.UUID]*domain.Invite
+
+func (f fakeRepo) Create(ctx context.Context, i *domain.Invite) error {
+	cp := *i
+	f[i.ID] = &cp
+	return nil
+}
+
+func (f fakeRepo) GetByToken(ctx context.Context, token string) (*domain.Invite, error) {
+	return f.GetByTokenForUpdate(ctx, domain.HashToken(token))
+}
+
+func (f fakeRepo) ListPending(ctx context.Context) ([]*domain.Invite, error) { return nil, nil }
+
+func (f fakeRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(f, id)
+	return nil
+}
+
+func (f fakeRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.Invite, error) {
+	if i, ok := f[id]; ok {
+		cp := *i
+		return &cp, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f fakeRepo) GetByTokenForUpdate(ctx context.Context, tokenHash string) (*domain.Invite, error) {
+	for _, i := range f {
+		if i.TokenHash == tokenHash {
+			cp := *i
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f fakeRepo) MarkAccepted(ctx context.Context, id, userID uuid.UUID, acceptedAt time.Time) error {
+	f[id].AcceptedAt, f[id].AcceptedBy = &acceptedAt, &userID
+	return nil
+}
+
+func (f fakeRepo) Renew(ctx context.Context, id uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	f[id].TokenHash, f[id].ExpiresAt = tokenHash, expiresAt
+	return nil
+}
+
+func (f fakeRepo) Revoke(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	f[id].RevokedAt = &revokedAt
+	return nil
+}
+
+type fakeUsers map[string]*user.User
+
+func (f fakeUsers) RegisterUser(ctx context.Context, u *user.User) error {
+	if _, ok := f[u.Email]; ok {
+		return user.ErrUserAlreadyExists
+	}
+	u.ID = uuid.New()
+	f[u.Email] = u
+	return nil
+}
+
+type member struct {
+	storeID, userID, inviteID uuid.UUID
+	role                      store.MemberRole
+}
+
+type fakeStores struct{ members []member }
+
+func (f *fakeStores) AddMember(ctx context.Context, storeID, userID, inviteID uuid.UUID, role store.MemberRole) error {
+	f.members = append(f.members, member{storeID, userID, inviteID, role})
+	return nil
+}
+
+// fakeMailer keeps the last email sent to each address.
+type fakeMailer map[string]string
+
+func (f fakeMailer) SendEmail(ctx context.Context, to string, subject string, body string) error {
+	f[to] = body
+	return nil
+}
+
+type world struct {
+	uc     *UseCase
+	repo   fakeRepo
+	users  fakeUsers
+	stores *fakeStores
+	mail   fakeMailer
+}
+
+func newWorld() *world {
+	w := &world{repo: fakeRepo{}, users: fakeUsers{}, stores: &fakeStores{}, mail: fakeMailer{}}
+	w.uc = NewUseCase(w.repo, w.users, w.stores, fakeTxManager{}, w.mail, "https://app.example.com")
+	return w
+}
+
+func (w *world) invite(t *testing.T, role domain.Role, storeID *uuid.UUID) *domain.Invite {
+	i := &domain.Invite{Email: "akinyi@example.com", Role: role, StoreID: storeID, InvitedBy: uuid.New()}
+	require.NoError(t, w.uc.InviteMember(context.Background(), i))
+	return i
+}
+
+func accept(token string) *domain.AcceptInviteRequest {
+	return &domain.AcceptInviteRequest{Token: token, FullName: "Akinyi Otieno", Phone: "+254712345678", Password: "a-good-password"}
+}
+
+func TestInviteMember(t *testing.T) {
+	w := newWorld()
+	i := w.invite(t, domain.Driver, nil)
+
+	require.NotEmpty(t, i.Token)
+	require.Equal(t, domain.HashToken(i.Token), w.repo[i.ID].TokenHash)
+	require.WithinDuration(t, time.Now().Add(domain.DefaultTTL), i.ExpiresAt, time.Minute)
+	require.Contains(t, w.mail[i.Email], "https://app.example.com/invites/accept?token="+i.Token)
+}
+
+func TestInviteMember_Validates(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	storeID := uuid.New()
+	past := time.Now().Add(-time.Hour)
+	owner, editor := store.MemberOwner, store.MemberCatalogEditor
+
+	tests := []struct {
+		name   string
+		invite *domain.Invite
+		want   error
+	}{
+		{"bad email", &domain.Invite{Email: "nope", Role: domain.Customer}, domain.ErrorInvalidEmail},
+		{"unknown role", &domain.Invite{Email: "a@example.com", Role: "owner"}, domain.ErrorInvalidRole},
+		{"store staff not merchant", &domain.Invite{Email: "a@example.com", Role: domain.Driver, StoreID: &storeID}, domain.ErrorStoreInviteRole},
+		{"store role without store", &domain.Invite{Email: "a@example.com", Role: domain.Merchant, StoreRole: &editor}, domain.ErrorInvalidStoreRole},
+		{"store owner role", &domain.Invite{Email: "a@example.com", Role: domain.Merchant, StoreID: &storeID, StoreRole: &owner}, domain.ErrorInvalidStoreRole},
+		{"expiry in the past", &domain.Invite{Email: "a@example.com", Role: domain.Customer, ExpiresAt: past}, domain.ErrorInvalidExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, w.uc.InviteMember(ctx, tt.invite), tt.want)
+		})
+	}
+	require.Empty(t, w.repo)
+}
+
+func TestAcceptInvite(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	storeID := uuid.New()
+	i := w.invite(t, domain.Merchant, &storeID)
+
+	u, err := w.uc.AcceptInvite(ctx, accept(i.Token))
+	require.NoError(t, err)
+	require.Equal(t, i.Email, u.Email)
+	require.Equal(t, user.Merchant, u.Role)
+	require.NotNil(t, u.EmailVerifiedAt, "the invite mail proves the address")
+	require.Equal(t, []member{{storeID, u.ID, i.ID, store.MemberManager}}, w.stores.members, "store role defaults to manager")
+	require.Equal(t, u.ID, *w.repo[i.ID].AcceptedBy)
+
+	_, err = w.uc.AcceptInvite(ctx, accept(i.Token))
+	require.ErrorIs(t, err, domain.ErrorInviteAccepted)
+}
+
+func TestAcceptInvite_StoreRole(t *testing.T) {
+	w := newWorld()
+	storeID, role := uuid.New(), store.MemberFulfilment
+	i := &domain.Invite{Email: "wanjiru@example.com", Role: domain.Merchant, StoreID: &storeID, StoreRole: &role, InvitedBy: uuid.New()}
+	require.NoError(t, w.uc.InviteMember(context.Background(), i))
+
+	u, err := w.uc.AcceptInvite(context.Background(), accept(i.Token))
+	require.NoError(t, err)
+	require.Equal(t, []member{{storeID, u.ID, i.ID, store.MemberFulfilment}}, w.stores.members)
+}
+
+func TestAcceptInvite_Rejects(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("unknown token", func(t *testing.T) {
+		w := newWorld()
+		_, err := w.uc.AcceptInvite(ctx, accept("nope"))
+		require.ErrorIs(t, err, domain.ErrorInviteNotFound)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		w := newWorld()
+		i := w.invite(t, domain.Customer, nil)
+		w.uc.now = func() time.Time { return time.Now().Add(domain.DefaultTTL + time.Minute) }
+
+		_, err := w.uc.AcceptInvite(ctx, accept(i.Token))
+		require.ErrorIs(t, err, domain.ErrorInviteExpired)
+		require.Empty(t, w.users)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		w := newWorld()
+		i := w.invite(t, domain.Customer, nil)
+		require.NoError(t, w.uc.RevokeInvite(ctx, i.ID))
+
+		_, err := w.uc.AcceptInvite(ctx, accept(i.Token))
+		require.ErrorIs(t, err, domain.ErrorInviteRevoked)
+	})
+
+	t.Run("weak password", func(t *testing.T) {
+		w := newWorld()
+		i := w.invite(t, domain.Customer, nil)
+		req := accept(i.Token)
+		req.Password = "short"
+
+		_, err := w.uc.AcceptInvite(ctx, req)
+		require.ErrorIs(t, err, auth.ErrorWeakPassword)
+	})
+
+	t.Run("existing account", func(t *testing.T) {
+		w := newWorld()
+		i := w.invite(t, domain.Customer, nil)
+		w.users[i.Email] = &user.User{Email: i.Email}
+
+		_, err := w.uc.AcceptInvite(ctx, accept(i.Token))
+		require.ErrorIs(t, err, user.ErrUserAlreadyExists)
+		require.Nil(t, w.repo[i.ID].AcceptedAt)
+	})
+}
+
+func TestResendInvite(t *testing.T) {
+	w := newWorld()
+	ctx := context.Background()
+	i := w.invite(t, domain.Customer, nil)
+
+	// Resending revives an expired invite under a new token
+	w.uc.now = func() time.Time { return time.Now().Add(domain.DefaultTTL + time.Hour) }
+	resent, err := w.uc.ResendInvite(ctx, i.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, i.Token, resent.Token)
+	require.True(t, strings.Contains(w.mail[i.Email], resent.Token))
+
+	_, err = w.uc.AcceptInvite(ctx, accept(i.Token))
+	require.ErrorIs(t, err, domain.ErrorInviteNotFound, "old token")
+	_, err = w.uc.AcceptInvite(ctx, accept(resent.Token))
+	require.NoError(t, err)
+
+	_, err = w.uc.ResendInvite(ctx, i.ID)
+	require.ErrorIs(t, err, domain.ErrorInviteAccepted)
+	require.ErrorIs(t, w.uc.RevokeInvite(ctx, i.ID), domain.ErrorInviteAccepted)
+}
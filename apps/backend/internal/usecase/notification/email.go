@@ -0,0 +1,68 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPConfig holds the relay emails are submitted to. Username may be empty
+// for relays that accept mail without authentication.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPSender is an EmailSender delivering plain-text mail through an SMTP
+// relay.
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender creates an SMTPSender. Port defaults to 587.
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) SendEmail(ctx context.Context, to string, subject string, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("send email: header contains a line break")
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	msg := "From: " + s.cfg.From + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n")
+
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	if err := smtp.SendMail(addr, auth, s.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
+
+// LogSender is an EmailSender that only logs emails, for dev runs without a
+// relay.
+type LogSender struct{}
+
+func (LogSender) SendEmail(ctx context.Context, to string, subject string, body string) error {
+	log.Printf("email to %s: %s\n%s", to, subject, body)
+	return nil
+}
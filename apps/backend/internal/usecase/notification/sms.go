@@ -0,0 +1,15 @@
+package notification
+
+import (
+	"context"
+	"log"
+)
+
+// LogSMSSender is an SMSSender that only logs texts, for dev runs without an
+// SMS gateway.
+type LogSMSSender struct{}
+
+func (LogSMSSender) SendSMS(ctx context.Context, phone string, message string) error {
+	log.Printf("sms to %s: %s", phone, message)
+	return nil
+}
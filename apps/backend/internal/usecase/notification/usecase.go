@@ -38,8 +38,10 @@ func (uc *UseCase) UpdateNotificationStatus(ctx context.Context, id uuid.UUID, s
 	})
 }
 
-func (uc *UseCase) MarkAsRead(ctx context.Context, id uuid.UUID) error {
-	return uc.repo.UpdateStatus(ctx, id, domain.Read)
+// MarkAsRead marks one of a user's notifications read. Notifications of
+// other users are reported as not found.
+func (uc *UseCase) MarkAsRead(ctx context.Context, id, userID uuid.UUID) error {
+	return uc.repo.MarkAsRead(ctx, id, userID)
 }
 
 func (uc *UseCase) MarkAllAsRead(ctx context.Context, userID uuid.UUID) error {
@@ -1,19 +1,29 @@
 package order
 
 import (
-	"backend/internal/domain/driver"
+	"backend/internal/domain/address"
 	"backend/internal/domain/notification"
 	"backend/internal/domain/order"
+	"backend/internal/domain/pricing"
 	prod "backend/internal/domain/product"
+	"backend/internal/domain/promotion"
+	"backend/internal/domain/store"
 	"backend/internal/usecase/common"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 )
 
+// expiryReason is recorded on orders cancelled because their stock
+// reservation expired.
+const expiryReason = "reservation expired"
+
 // UseCase encapsulates order business logic and dependencies.
 type UseCase struct {
 	repo      order.Repository
@@ -23,6 +33,13 @@ type UseCase struct {
 	notfRepo  order.NotificationReader
 	prodvrt   order.ProductOrVariantReader
 	storeRepo order.StoreReader
+	refunds   order.RefundRequester
+	pricer    order.Pricer
+	promos    order.PromotionApplier
+	addresses order.AddressReader
+	geocoder  order.Geocoder
+
+	fulfilment order.FulfilmentWriter
 }
 
 // NewUseCase creates a new order UseCase.
@@ -34,6 +51,11 @@ func NewUseCase(
 	notf order.NotificationReader,
 	prodvrt order.ProductOrVariantReader,
 	strRepo order.StoreReader,
+	refunds order.RefundRequester,
+	pricer order.Pricer,
+	promos order.PromotionApplier,
+	addresses order.AddressReader,
+	geocoder order.Geocoder,
 ) *UseCase {
 	return &UseCase{
 		repo:      repo,
@@ -43,121 +65,328 @@ func NewUseCase(
 		notfRepo:  notf,
 		prodvrt:   prodvrt,
 		storeRepo: strRepo,
+		refunds:   refunds,
+		pricer:    pricer,
+		promos:    promos,
+		addresses: addresses,
+		geocoder:  geocoder,
 	}
 }
 
-// CreateOrder creates new confirmed orders and returns them.
-func (uc *UseCase) CreateOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) ([]*order.Order, error) {
-	orders, err := uc.createOrders(ctx, customerID, req, order.Pending)
+// SetFulfilment wires the delivery side of status transitions. The delivery
+// usecase depends on this one, so it is attached after both are built.
+func (uc *UseCase) SetFulfilment(f order.FulfilmentWriter) {
+	uc.fulfilment = f
+}
+
+// CreateOrder creates a new order from the requested items and returns it.
+func (uc *UseCase) CreateOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) (*order.Order, error) {
+	o, err := uc.createOrder(ctx, customerID, req, order.Pending)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fire notifications asynchronously
-	for _, o := range orders {
-		go uc.fireOrderNotifications(ctx, o)
-	}
+	go uc.fireOrderNotifications(ctx, o)
 
-	return orders, nil
+	return o, nil
 }
 
-// CreatePendingOrders creates new orders marked as pending (used for carts or pre-orders)
-func (uc *UseCase) CreatePendingOrders(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) ([]*order.Order, error) {
-	return uc.createOrders(ctx, customerID, req, order.Pending)
+// CreatePendingOrder creates a new order marked as pending (used for carts or pre-orders)
+func (uc *UseCase) CreatePendingOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) (*order.Order, error) {
+	return uc.createOrder(ctx, customerID, req, order.Pending)
 }
 
-// createOrders is a unified helper that handles both pending and confirmed orders.
-func (uc *UseCase) createOrders(
+// createOrder is a unified helper that builds one order aggregate holding
+// a line per requested item, for both pending and confirmed orders.
+//
+// The whole checkout runs in a single transaction: stock is reserved with
+// conditional decrements, so two concurrent checkouts cannot both take the
+// last unit, and a failure on any line rolls back every reservation.
+func (uc *UseCase) createOrder(
 	ctx context.Context,
 	customerID uuid.UUID,
 	req *order.CreateOrderRequest,
 	status order.OrderStatus,
-) ([]*order.Order, error) {
-	store, err := uc.storeRepo.GetByID(ctx, req.StoreID)
-	if err != nil {
-		return nil, fmt.Errorf("store not found: %w", err)
+) (*order.Order, error) {
+	if len(req.Items) == 0 {
+		return nil, order.ErrorEmptyOrder
 	}
-
-	var createdOrders []*order.Order
-
 	for _, item := range req.Items {
-		// Load product
-		p, err := uc.prodvrt.GetProductByID(ctx, item.ProductID)
-		if err != nil {
-			return nil, fmt.Errorf("product not found: %w", err)
+		if item.Quantity <= 0 {
+			return nil, order.ErrorInvalidQuantity
 		}
+	}
 
-		// Handle variants if present
-		var variant *prod.Variant
-		if p.HasVariants {
-			if item.VariantID == nil {
-				return nil, order.ErrorVariantRequired
-			}
-			variant = findVariant(p, item.VariantID)
-			if variant == nil {
-				return nil, fmt.Errorf("variant %s not found in product %s", item.VariantID, p.ID)
-			}
-			if variant.Stock < item.Quantity {
-				return nil, order.ErrorOutOfStock
-			}
-		} else {
-			if item.VariantID != nil {
-				return nil, order.ErrorVariantNotAllowed
-			}
-			if p.Stock == nil {
-				return nil, fmt.Errorf("product %s has no stock value", p.ID)
-			}
-			if *p.Stock < item.Quantity {
-				return nil, order.ErrorOutOfStock
-			}
+	var o *order.Order
+
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		store, err := uc.storeRepo.GetByID(txCtx, req.StoreID)
+		if err != nil {
+			return fmt.Errorf("store not found: %w", err)
 		}
 
-		// Build order snapshot
-		o := req.ToOrder()
+		o = req.ToOrder()
 		o.CustomerID = customerID
+		if err := uc.resolveDelivery(txCtx, o, req, store); err != nil {
+			return err
+		}
 		o.MerchantID = store.OwnerID
-		o.ProductID = p.ID
-		o.VariantID = item.VariantID
-		o.Quantity = item.Quantity
+		o.Currency = order.DefaultCurrency
 		o.Status = status
 
-		if p.HasVariants {
-			o.UnitPrice = int64(variant.Price * 100)
-			o.Total = o.UnitPrice * int64(item.Quantity)
-			o.ProductName = p.Name
-			o.VariantName = variant.SKU
-			o.ImageURL = variant.ImageURL
-
-			// Decrement stock
-			newStock := variant.Stock - item.Quantity
-			if err := uc.prodvrt.UpdateVariantStock(ctx, variant.ID, newStock); err != nil {
-				return nil, fmt.Errorf("update variant stock failed: %w", err)
+		lines := make([]order.CartItemSnapshot, len(req.Items))
+		for _, i := range lockOrder(req.Items) {
+			item := req.Items[i]
+
+			p, variant, err := uc.loadItem(txCtx, store.ID, item)
+			if err != nil {
+				return err
 			}
-		} else {
-			o.UnitPrice = int64(p.Price * 100)
-			o.Total = o.UnitPrice * int64(item.Quantity)
-			o.ProductName = p.Name
-			o.ImageURL = firstImage(p.Images)
-
-			// Decrement stock
-			if p.Stock == nil {
-				return nil, fmt.Errorf("product %s has no stock value", p.ID)
+
+			if err := uc.reserveStock(txCtx, p, variant, item.Quantity); err != nil {
+				return err
 			}
-			newStock := *p.Stock - item.Quantity
-			if err := uc.prodvrt.UpdateProductStock(ctx, p.ID, newStock); err != nil {
-				return nil, fmt.Errorf("update product stock failed: %w", err)
+
+			lines[i] = snapshot(p, variant, item.Quantity, o.Currency)
+		}
+
+		// Keep the lines in the order the customer sent them
+		for _, line := range lines {
+			o.AddItem(line)
+		}
+
+		_, promo, err := uc.price(txCtx, o, req.PromoCode)
+		if err != nil {
+			return err
+		}
+
+		// Persist header and lines together
+		if err := uc.repo.Create(txCtx, o); err != nil {
+			return fmt.Errorf("create order failed: %w", err)
+		}
+
+		if promo != nil {
+			if err := uc.promos.RecordRedemption(txCtx, promo, o.ID, o.CustomerID); err != nil {
+				return fmt.Errorf("redeem promotion: %w", err)
 			}
 		}
 
-		// Persist
-		if err := uc.repo.Create(ctx, o); err != nil {
-			return nil, fmt.Errorf("create order failed: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// QuoteOrder prices the requested items, delivery and promotion code without
+// placing the order or reserving stock, so the fee can be shown before checkout.
+func (uc *UseCase) QuoteOrder(ctx context.Context, customerID uuid.UUID, req *order.CreateOrderRequest) (*pricing.Breakdown, error) {
+	if len(req.Items) == 0 {
+		return nil, order.ErrorEmptyOrder
+	}
+
+	store, err := uc.storeRepo.GetByID(ctx, req.StoreID)
+	if err != nil {
+		return nil, fmt.Errorf("store not found: %w", err)
+	}
+
+	o := req.ToOrder()
+	o.CustomerID = customerID
+	if err := uc.resolveDelivery(ctx, o, req, store); err != nil {
+		return nil, err
+	}
+	o.Currency = order.DefaultCurrency
+	for _, item := range req.Items {
+		if item.Quantity <= 0 {
+			return nil, order.ErrorInvalidQuantity
+		}
+
+		p, variant, err := uc.loadItem(ctx, store.ID, item)
+		if err != nil {
+			return nil, err
+		}
+		o.AddItem(snapshot(p, variant, item.Quantity, o.Currency))
+	}
+
+	b, _, err := uc.price(ctx, o, req.PromoCode)
+	return b, err
+}
+
+// resolveDelivery fills in the delivery address and point from the
+// customer's saved address when the request references one, defaults the
+// pickup to the store, geocodes addresses sent without coordinates and
+// checks the rest.
+func (uc *UseCase) resolveDelivery(ctx context.Context, o *order.Order, req *order.CreateOrderRequest, s *store.Store) error {
+	if req.AddressID != nil {
+		a, err := uc.addresses.GetAddressForUser(ctx, o.CustomerID, *req.AddressID)
+		if err != nil {
+			return err
+		}
+		o.AddressID = &a.ID
+		o.DeliveryAddress = a.Address
+		o.DeliveryPoint = a.Point
+	} else if err := uc.locate(ctx, o.DeliveryAddress, &o.DeliveryPoint); err != nil {
+		return err
+	}
+
+	// Orders are picked up at the store unless the request says otherwise
+	noPickup := strings.TrimSpace(o.PickupAddress) == "" && o.PickupPoint.X == 0 && o.PickupPoint.Y == 0
+	if noPickup && s.Point != nil {
+		o.PickupAddress = s.Location
+		o.PickupPoint = *s.Point
+	}
+
+	return uc.locate(ctx, o.PickupAddress, &o.PickupPoint)
+}
+
+// locate geocodes text into point when no coordinates were sent, and
+// otherwise checks them.
+func (uc *UseCase) locate(ctx context.Context, text string, point *postgis.PointS) error {
+	if point.X == 0 && point.Y == 0 && strings.TrimSpace(text) != "" && uc.geocoder != nil {
+		p, err := uc.geocoder.Geocode(ctx, text)
+		if err != nil {
+			return fmt.Errorf("geocode %q: %w", text, err)
+		}
+		*point = address.NewPoint(p.Lat, p.Lng)
+	}
+
+	if !address.ValidCoordinates(point.Y, point.X) {
+		return order.ErrorInvalidLocation
+	}
+	return nil
+}
+
+// price adds the delivery fee, tax and the discount of a promotion code,
+// if any, to an order holding all of its lines.
+func (uc *UseCase) price(ctx context.Context, o *order.Order, code string) (*pricing.Breakdown, *promotion.Application, error) {
+	b := &pricing.Breakdown{Currency: o.Currency, Subtotal: o.Subtotal}
+	if uc.pricer != nil {
+		var err error
+		if b, err = uc.pricer.Quote(ctx, o.StoreID, o.Subtotal, o.Currency, o.PickupPoint, o.DeliveryPoint); err != nil {
+			return nil, nil, fmt.Errorf("price order: %w", err)
+		}
+	}
+	b.Recalculate()
+
+	var promo *promotion.Application
+	if code = strings.TrimSpace(code); code != "" {
+		basket := promotion.Basket{
+			StoreID:     o.StoreID,
+			CustomerID:  o.CustomerID,
+			Subtotal:    b.Subtotal,
+			DeliveryFee: b.DeliveryFee,
+		}
+		for _, item := range o.Items {
+			basket.Lines = append(basket.Lines, promotion.BasketLine{ProductID: item.ProductID, Total: item.Total})
+		}
+
+		var err error
+		if promo, err = uc.promos.ApplyPromotion(ctx, code, basket); err != nil {
+			return nil, nil, err
+		}
+		b.ApplyDiscount(promo.Discount)
+		o.PromoCode = &promo.Code
+	}
+
+	o.ApplyPricing(b)
+	return b, promo, nil
+}
+
+// loadItem fetches the product (and variant, if any) for a requested line
+// and checks that it can be ordered from the given store.
+func (uc *UseCase) loadItem(ctx context.Context, storeID uuid.UUID, item order.CreateOrderItem) (*prod.Product, *prod.Variant, error) {
+	p, err := uc.prodvrt.GetProductByID(ctx, item.ProductID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("product not found: %w", err)
+	}
+	if p.StoreID != storeID {
+		return nil, nil, order.ErrorProductNotInStore
+	}
+
+	if !p.HasVariants {
+		if item.VariantID != nil {
+			return nil, nil, order.ErrorVariantNotAllowed
+		}
+		return p, nil, nil
+	}
+
+	if item.VariantID == nil {
+		return nil, nil, order.ErrorVariantRequired
+	}
+	v, err := uc.prodvrt.GetVariantByID(ctx, *item.VariantID)
+	if err != nil || v.ProductID != p.ID {
+		return nil, nil, fmt.Errorf("variant %s not found in product %s", item.VariantID, p.ID)
+	}
+
+	return p, v, nil
+}
+
+// reserveStock takes quantity units out of the product or variant stock.
+func (uc *UseCase) reserveStock(ctx context.Context, p *prod.Product, v *prod.Variant, quantity int) error {
+	var err error
+	if v != nil {
+		err = uc.prodvrt.DecrementVariantStock(ctx, v.ID, quantity)
+	} else {
+		err = uc.prodvrt.DecrementProductStock(ctx, p.ID, quantity)
+	}
+
+	switch {
+	case errors.Is(err, prod.ErrInsufficientStock):
+		return order.ErrorOutOfStock
+	case err != nil:
+		return fmt.Errorf("reserve stock failed: %w", err)
+	}
+	return nil
+}
+
+// lockOrder returns the item indexes sorted by product and variant ID so that
+// concurrent checkouts lock stock rows in the same order and cannot deadlock.
+func lockOrder(items []order.CreateOrderItem) []int {
+	key := func(i order.CreateOrderItem) string {
+		if i.VariantID != nil {
+			return i.ProductID.String() + i.VariantID.String()
 		}
+		return i.ProductID.String()
+	}
+
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(a, b int) bool {
+		return key(items[idx[a]]) < key(items[idx[b]])
+	})
+	return idx
+}
+
+// snapshot captures price, name and image of the ordered product at purchase time.
+func snapshot(p *prod.Product, v *prod.Variant, quantity int, currency string) order.CartItemSnapshot {
+	unitPrice := prod.UnitPrice(p, v)
+
+	s := order.CartItemSnapshot{
+		ProductID:   p.ID,
+		Quantity:    quantity,
+		UnitPrice:   unitPrice,
+		Currency:    currency,
+		Total:       unitPrice * int64(quantity),
+		ProductName: p.Name,
+	}
+
+	if v != nil {
+		s.VariantID = &v.ID
+		name := prod.VariantName(v)
+		s.VariantName = &name
+	}
 
-		createdOrders = append(createdOrders, o)
+	if img := prod.PrimaryImage(p, v); img != "" {
+		s.ImageURL = &img
 	}
 
-	return createdOrders, nil
+	return s
 }
 
 // GetOrder fetches a single order by its ID
@@ -180,126 +409,419 @@ func (uc *UseCase) GetOrderByCustomer(ctx context.Context, customerID uuid.UUID)
 	return uc.repo.ListByCustomer(ctx, customerID)
 }
 
-// UpdateOrder updates a single column value in an order row
-func (uc *UseCase) UpdateOrder(ctx context.Context, orderID uuid.UUID, column string, value any) error {
+// UpdateOrder applies a partial update to the addresses of a pending order
+// and records each edit in the order timeline. Changed addresses are
+// geocoded again and the order re-priced for the new route. Status is
+// excluded: it only changes through TransitionOrder.
+func (uc *UseCase) UpdateOrder(ctx context.Context, orderID, callerID uuid.UUID, actor order.Actor, req *order.UpdateOrderRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
-		if err := uc.repo.Update(txCtx, orderID, column, value); err != nil {
+		o, err := uc.repo.GetByID(txCtx, orderID)
+		if err != nil {
+			return fmt.Errorf("fetch order: %w", err)
+		}
+		if o.Status != order.Pending {
+			return order.ErrorOrderNotEditable
+		}
+
+		edits := []struct {
+			field    string
+			old, new *string
+			point    *postgis.PointS
+		}{
+			{"pickup_address", &o.PickupAddress, req.PickupAddress, &o.PickupPoint},
+			{"delivery_address", &o.DeliveryAddress, req.DeliveryAddress, &o.DeliveryPoint},
+		}
+		var events []*order.Event
+		for _, ed := range edits {
+			if ed.new == nil {
+				continue
+			}
+			events = append(events, order.NewEvent(orderID, order.EventEdited, ed.field, actor, callerID).
+				Change(*ed.old, *ed.new))
+
+			*ed.old = *ed.new
+			*ed.point = postgis.PointS{}
+			if err := uc.locate(txCtx, *ed.new, ed.point); err != nil {
+				return err
+			}
+		}
+		if req.DeliveryAddress != nil {
+			// The saved address no longer describes where the order goes
+			o.AddressID = nil
+		}
+
+		if err := uc.reprice(txCtx, o); err != nil {
+			return err
+		}
+		if err := uc.repo.UpdateRoute(txCtx, o); err != nil {
 			return fmt.Errorf("update order failed: %w", err)
 		}
+
+		for _, e := range events {
+			if err := uc.repo.AddEvent(txCtx, e); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
 }
 
-// ListOrders returns all orders
-func (uc *UseCase) ListOrders(ctx context.Context) ([]*order.Order, error) {
-	return uc.repo.List(ctx)
-}
-
-// DeleteOrder removes an order by ID
-func (uc *UseCase) DeleteOrder(ctx context.Context, id uuid.UUID) error {
-	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
-		if err := uc.repo.Delete(txCtx, id); err != nil {
-			return fmt.Errorf("delete order failed: %w", err)
+// reprice quotes the delivery fee of an order again after its route
+// changed, keeping the discount it was placed with.
+func (uc *UseCase) reprice(ctx context.Context, o *order.Order) error {
+	b := &pricing.Breakdown{Currency: o.Currency, Subtotal: o.Subtotal, DeliveryFee: o.DeliveryFee}
+	if uc.pricer != nil {
+		var err error
+		if b, err = uc.pricer.Quote(ctx, o.StoreID, o.Subtotal, o.Currency, o.PickupPoint, o.DeliveryPoint); err != nil {
+			return fmt.Errorf("price order: %w", err)
 		}
-		return nil
-	})
+	}
+	b.ApplyDiscount(o.Discount)
+	o.ApplyPricing(b)
+	return nil
 }
 
-// AssignOrderToDriver assigns a driver to an order
-func (uc *UseCase) AssignOrderToDriver(ctx context.Context, orderID, driverID uuid.UUID, maxDistance float64) (*driver.Driver, error) {
-	o, err := uc.GetOrder(ctx, orderID)
+// GetOrderTimeline returns the audit history of an order, oldest first.
+// Customers and merchants only see the history of their own orders.
+func (uc *UseCase) GetOrderTimeline(ctx context.Context, orderID, callerID uuid.UUID, actor order.Actor) ([]*order.Event, error) {
+	o, err := uc.repo.GetByID(ctx, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("fetch order: %w", err)
 	}
-	if o.Status != order.Pending {
-		return nil, fmt.Errorf("order not pending")
+	if err := uc.participant(callerID, actor)(ctx, o); err != nil {
+		return nil, err
 	}
 
-	pickupPoint, err := uc.repo.GetPickupPoint(ctx, orderID)
-	if err != nil {
-		return nil, fmt.Errorf("get pickup point: %w", err)
-	}
+	return uc.repo.ListEvents(ctx, orderID)
+}
 
-	nearestDriver, err := uc.drvRepo.GetNearestDriver(ctx, pickupPoint, maxDistance)
-	if err != nil || nearestDriver == nil {
-		return nil, fmt.Errorf("no available driver within %.2f meters", maxDistance)
-	}
+// UpdateOrderStatus moves an order to a new status on behalf of a caller.
+func (uc *UseCase) UpdateOrderStatus(ctx context.Context, orderID, callerID uuid.UUID, actor order.Actor, to order.OrderStatus) (*order.Order, error) {
+	return uc.transition(ctx, orderID, to, actor, transitionOptions{
+		actorID:   callerID,
+		authorize: uc.participant(callerID, actor),
+	})
+}
 
-	if err := uc.UpdateOrder(ctx, orderID, "status", order.Assigned); err != nil {
-		return nil, fmt.Errorf("update order status: %w", err)
+// CancelOrder cancels an order on behalf of a caller. In one transaction it
+// restores stock, releases the driver, fails the open delivery and opens a
+// refund for the payment. Whether the caller may still cancel depends on the
+// order status, e.g. customers can't once the order is in transit.
+func (uc *UseCase) CancelOrder(ctx context.Context, orderID, callerID uuid.UUID, actor order.Actor, reason string) (*order.Order, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, order.ErrorCancelReasonMissing
 	}
 
-	return nearestDriver, nil
+	return uc.transition(ctx, orderID, order.Cancelled, actor, transitionOptions{
+		actorID:   callerID,
+		reason:    reason,
+		authorize: uc.participant(callerID, actor),
+	})
 }
 
-// notify sends a notification to a user
-func (uc *UseCase) notify(ctx context.Context, userID uuid.UUID, message string) error {
-	n := &notification.Notification{
-		UserID:  userID,
-		Message: message,
-		Type:    notification.System,
-		Status:  notification.Pending,
-	}
-	return uc.notfRepo.Create(ctx, n)
+// TransitionOrder moves an order to a new status as the given actor and
+// applies every side effect of the transition in the same transaction.
+// actorID may be uuid.Nil for system changes.
+func (uc *UseCase) TransitionOrder(ctx context.Context, orderID uuid.UUID, to order.OrderStatus, actor order.Actor, actorID uuid.UUID) (*order.Order, error) {
+	return uc.transition(ctx, orderID, to, actor, transitionOptions{actorID: actorID})
 }
 
-// fireOrderNotifications triggers customer notifications
-func (uc *UseCase) fireOrderNotifications(ctx context.Context, o *order.Order) {
-	msg := fmt.Sprintf("Your order %s has been placed successfully.", o.ID)
-	_ = uc.notify(ctx, o.CustomerID, msg)
+// ExpireOrder cancels a pending order whose stock reservation ran out and
+// puts its stock back in one transaction. The caller notifies the customer.
+func (uc *UseCase) ExpireOrder(ctx context.Context, orderID uuid.UUID) (*order.Order, error) {
+	return uc.transition(ctx, orderID, order.Cancelled, order.ActorSystem, transitionOptions{
+		reason: expiryReason,
+		authorize: func(ctx context.Context, o *order.Order) error {
+			if o.Status != order.Pending {
+				return order.ErrorStatusConflict
+			}
+			// The order was listed as unpaid outside this transaction
+			paid, err := uc.repo.HasCompletedPayment(ctx, o.ID)
+			if err != nil {
+				return err
+			}
+			if paid {
+				return order.ErrorStatusConflict
+			}
+			return nil
+		},
+		silent: true,
+	})
 }
 
-// firstImage returns the first image from the list or empty
-func firstImage(images []string) string {
-	if len(images) == 0 {
-		return ""
+// ListExpiredPendingOrders returns up to limit unpaid pending orders placed
+// more than ttl ago, oldest first.
+func (uc *UseCase) ListExpiredPendingOrders(ctx context.Context, ttl time.Duration, limit int) ([]*order.Order, error) {
+	return uc.repo.ListExpiredPending(ctx, time.Now().Add(-ttl), limit)
+}
+
+// participant only lets customers and merchants act on their own orders,
+// and the fulfilment staff of a store on the store's orders. Drivers move
+// orders through their delivery instead.
+func (uc *UseCase) participant(callerID uuid.UUID, actor order.Actor) func(ctx context.Context, o *order.Order) error {
+	return func(ctx context.Context, o *order.Order) error {
+		switch actor {
+		case order.ActorCustomer:
+			if o.CustomerID != callerID {
+				return order.ErrorNotOrderParticipant
+			}
+		case order.ActorMerchant:
+			if o.MerchantID == callerID {
+				return nil
+			}
+			role, err := uc.storeRepo.GetMemberRole(ctx, o.StoreID, callerID)
+			if err != nil {
+				return fmt.Errorf("check store role: %w", err)
+			}
+			if !role.Can(store.PermFulfilOrders) {
+				return order.ErrorNotOrderParticipant
+			}
+		case order.ActorDriver:
+			return order.ErrorTransitionForbidden
+		}
+		return nil
 	}
-	return images[0]
 }
 
-// findVariant searches for a variant by ID
-func findVariant(product *prod.Product, variantID *uuid.UUID) *prod.Variant {
-	if variantID == nil {
+// transitionOptions tune a single status change.
+type transitionOptions struct {
+	actorID   uuid.UUID                                       // recorded in the timeline, uuid.Nil for the system
+	reason    string                                          // recorded on cancellation and passed on to the refund
+	authorize func(ctx context.Context, o *order.Order) error // checked against the loaded order before the transition
+	silent    bool                                            // caller notifies the customer itself
+}
+
+// transition runs a status change through the order state machine.
+func (uc *UseCase) transition(
+	ctx context.Context,
+	orderID uuid.UUID,
+	to order.OrderStatus,
+	actor order.Actor,
+	opts transitionOptions,
+) (*order.Order, error) {
+	reason := opts.reason
+
+	var (
+		o *order.Order
+		t order.Transition
+	)
+
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		var err error
+		o, err = uc.repo.GetByID(txCtx, orderID)
+		if err != nil {
+			return fmt.Errorf("fetch order: %w", err)
+		}
+
+		if opts.authorize != nil {
+			if err := opts.authorize(txCtx, o); err != nil {
+				return err
+			}
+		}
+
+		t, err = order.NextTransition(o.Status, to, actor)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.repo.UpdateStatus(txCtx, o.ID, t.From, t.To); err != nil {
+			return err
+		}
+		o.Status = t.To
+
+		e := order.NewEvent(o.ID, order.EventStatusChanged, "status", actor, opts.actorID).
+			Change(string(t.From), string(t.To)).
+			WithNote(reason)
+		if err := uc.repo.AddEvent(txCtx, e); err != nil {
+			return err
+		}
+
+		if t.To == order.Cancelled {
+			if err := uc.repo.SetCancellation(txCtx, o.ID, reason); err != nil {
+				return err
+			}
+			if reason != "" {
+				o.CancelReason = &reason
+			}
+		}
+
+		if t.Has(order.EffectRestoreStock) {
+			if err := uc.restoreStock(txCtx, o); err != nil {
+				return err
+			}
+		}
+
+		if t.NeedsFulfilment() && uc.fulfilment != nil {
+			if err := uc.fulfilment.ApplyTransition(txCtx, o, t); err != nil {
+				return fmt.Errorf("apply delivery effects: %w", err)
+			}
+		}
+
+		if t.Has(order.EffectRefundPayment) && uc.refunds != nil {
+			if err := uc.refunds.RequestRefund(txCtx, o.ID, refundReason(reason)); err != nil {
+				return fmt.Errorf("request refund: %w", err)
+			}
+		}
+
+		if t.Has(order.EffectReleasePromotion) && uc.promos != nil {
+			if err := uc.promos.ReleaseRedemption(txCtx, o.ID); err != nil {
+				return fmt.Errorf("release promotion: %w", err)
+			}
+		}
+
 		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Has(order.EffectNotifyCustomer) && !opts.silent {
+		go func() {
+			msg := fmt.Sprintf("Your order %s is now %s.", o.ID, strings.ReplaceAll(string(o.Status), "_", " "))
+			_ = uc.notify(ctx, o.CustomerID, msg)
+		}()
+	}
+
+	return o, nil
+}
+
+func refundReason(reason string) string {
+	if reason == "" {
+		return "order cancelled"
 	}
-	for _, v := range product.Variants {
-		if v.ID == *variantID {
-			return &v
+	return reason
+}
+
+// restoreStock puts the quantity of every line back on the shelf.
+func (uc *UseCase) restoreStock(ctx context.Context, o *order.Order) error {
+	for _, item := range o.Items {
+		var err error
+		if item.VariantID != nil {
+			err = uc.prodvrt.IncrementVariantStock(ctx, *item.VariantID, item.Quantity)
+		} else {
+			err = uc.prodvrt.IncrementProductStock(ctx, item.ProductID, item.Quantity)
+		}
+		if err != nil {
+			return fmt.Errorf("restore stock for %s: %w", item.ProductName, err)
 		}
 	}
 	return nil
 }
 
-// resolvePrice returns the correct unit price (product or variant)
-func resolvePrice(product *prod.Product, variant *prod.Variant) int64 {
-	if variant != nil {
-		return int64(variant.Price * 100)
-	}
-	return int64(product.Price * 100)
+// ListOrders returns all orders
+func (uc *UseCase) ListOrders(ctx context.Context) ([]*order.Order, error) {
+	return uc.repo.List(ctx)
 }
 
-// variantName returns a human-readable name for a variant
-func variantName(v *prod.Variant) string {
-	if v == nil {
-		return ""
+// ListOrdersFiltered returns one page of the orders the caller may see.
+// Customers only see their own orders and merchants only those of stores
+// they own or fulfil orders for; admins see everything.
+func (uc *UseCase) ListOrdersFiltered(ctx context.Context, callerID uuid.UUID, actor order.Actor, filter order.ListFilter) (*order.Page, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
 	}
-	if len(v.Options) > 0 {
-		nameParts := []string{}
-		for k, val := range v.Options {
-			nameParts = append(nameParts, fmt.Sprintf("%s:%s", k, val))
+
+	switch actor {
+	case order.ActorAdmin, order.ActorSystem:
+	case order.ActorCustomer:
+		if filter.CustomerID != nil && *filter.CustomerID != callerID {
+			return nil, order.ErrorListForbidden
 		}
-		return fmt.Sprintf("%s (%s)", v.SKU, strings.Join(nameParts, ", "))
+		filter.CustomerID = &callerID
+	case order.ActorMerchant:
+		if filter.MerchantID != nil && *filter.MerchantID != callerID {
+			return nil, order.ErrorListForbidden
+		}
+		if filter.StoreID != nil {
+			role, err := uc.storeRepo.GetMemberRole(ctx, *filter.StoreID, callerID)
+			if err != nil {
+				return nil, fmt.Errorf("check store role: %w", err)
+			}
+			if !role.Can(store.PermFulfilOrders) {
+				return nil, order.ErrorListForbidden
+			}
+		} else {
+			filter.MerchantID = &callerID
+		}
+	default:
+		return nil, order.ErrorListForbidden
 	}
-	return v.SKU
+
+	// fetch one extra row to learn whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	orders, err := uc.repo.ListFiltered(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &order.Page{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		page.NextCursor = order.CursorAfter(page.Orders[limit-1], filter).Encode()
+	}
+	if page.Orders == nil {
+		page.Orders = []*order.Order{}
+	}
+
+	return page, nil
 }
 
-// primaryImage returns the image URL for a variant or product
-func primaryImage(images []string, variant *prod.Variant) string {
-	if variant != nil && variant.ImageURL != "" {
-		return variant.ImageURL
+// ListOrdersByStatus returns all orders in the given status, oldest first
+func (uc *UseCase) ListOrdersByStatus(ctx context.Context, status order.OrderStatus) ([]*order.Order, error) {
+	return uc.repo.ListByStatus(ctx, status)
+}
+
+// DeleteOrder removes an order by ID
+func (uc *UseCase) DeleteOrder(ctx context.Context, id uuid.UUID) error {
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := uc.repo.Delete(txCtx, id); err != nil {
+			return fmt.Errorf("delete order failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// AssignOrderToDriver assigns the driver picked by the dispatcher to an
+// order and records who it was in the order timeline.
+func (uc *UseCase) AssignOrderToDriver(ctx context.Context, orderID, driverID uuid.UUID) error {
+	o, err := uc.GetOrder(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("fetch order: %w", err)
 	}
-	if len(images) > 0 {
-		return images[0]
+	if _, err := order.NextTransition(o.Status, order.Assigned, order.ActorSystem); err != nil {
+		return err
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if _, err := uc.TransitionOrder(txCtx, orderID, order.Assigned, order.ActorSystem, uuid.Nil); err != nil {
+			return fmt.Errorf("update order status: %w", err)
+		}
+
+		e := order.NewEvent(orderID, order.EventDriverAssigned, "driver_id", order.ActorSystem, uuid.Nil).
+			Change("", driverID.String())
+		return uc.repo.AddEvent(txCtx, e)
+	})
+}
+
+// notify sends a notification to a user
+func (uc *UseCase) notify(ctx context.Context, userID uuid.UUID, message string) error {
+	n := &notification.Notification{
+		UserID:  userID,
+		Message: message,
+		Type:    notification.System,
+		Status:  notification.Pending,
 	}
-	return ""
+	return uc.notfRepo.Create(ctx, n)
+}
+
+// fireOrderNotifications triggers customer notifications
+func (uc *UseCase) fireOrderNotifications(ctx context.Context, o *order.Order) {
+	msg := fmt.Sprintf("Your order %s (%d items) has been placed successfully.", o.ID, len(o.Items))
+	_ = uc.notify(ctx, o.CustomerID, msg)
 }
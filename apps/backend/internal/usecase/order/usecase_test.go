@@ -0,0 +1,1130 @@
+package order
+
+import (
+	"backend/internal/domain/address"
+	"backend/internal/domain/geocoding"
+	"backend/internal/domain/notification"
+	"backend/internal/domain/order"
+	"backend/internal/domain/pricing"
+	"backend/internal/domain/product"
+	"backend/internal/domain/promotion"
+	"backend/internal/domain/store"
+	"backend/internal/usecase/common"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTxManager runs fn with a transactional ctx and replays the undo log
+// of everything done inside it when fn fails, like a database rollback.
+type fakeTxManager struct{}
+
+type undoLogKey struct{}
+
+type undoLog struct {
+	steps []func()
+}
+
+func (f *fakeTxManager) Do(ctx context.Context, fn func(context.Context) error) error {
+	if _, ok := ctx.Value(undoLogKey{}).(*undoLog); ok {
+		return fn(ctx)
+	}
+
+	log := &undoLog{}
+	txCtx := context.WithValue(common.MarkTx(ctx), undoLogKey{}, log)
+
+	if err := fn(txCtx); err != nil {
+		for i := len(log.steps) - 1; i >= 0; i-- {
+			log.steps[i]()
+		}
+		return err
+	}
+	return nil
+}
+
+func onRollback(ctx context.Context, step func()) {
+	if log, ok := ctx.Value(undoLogKey{}).(*undoLog); ok {
+		log.steps = append(log.steps, step)
+	}
+}
+
+type fakeProductRepo struct {
+	mu       sync.Mutex
+	products map[uuid.UUID]*product.Product
+	usedTx   bool
+}
+
+func (f *fakeProductRepo) GetProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.products[id]
+	if !ok {
+		return nil, product.ErrProductNotFound
+	}
+	cp := *p
+	stock := *p.Stock
+	cp.Stock = &stock
+	return &cp, nil
+}
+
+func (f *fakeProductRepo) GetVariantByID(ctx context.Context, id uuid.UUID) (*product.Variant, error) {
+	return nil, product.ErrVariantNotFound
+}
+
+// DecrementProductStock mirrors `stock = stock - $n WHERE stock >= $n`.
+func (f *fakeProductRepo) DecrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.usedTx = common.IsTransactional(ctx)
+
+	p, ok := f.products[productID]
+	if !ok || *p.Stock < quantity {
+		return product.ErrInsufficientStock
+	}
+	*p.Stock -= quantity
+
+	onRollback(ctx, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		*p.Stock += quantity
+	})
+	return nil
+}
+
+func (f *fakeProductRepo) DecrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error {
+	return product.ErrInsufficientStock
+}
+
+func (f *fakeProductRepo) IncrementProductStock(ctx context.Context, productID uuid.UUID, quantity int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p, ok := f.products[productID]
+	if !ok {
+		return product.ErrProductNotFound
+	}
+	*p.Stock += quantity
+	return nil
+}
+
+func (f *fakeProductRepo) IncrementVariantStock(ctx context.Context, variantID uuid.UUID, quantity int) error {
+	return product.ErrVariantNotFound
+}
+
+func (f *fakeProductRepo) stock(id uuid.UUID) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *f.products[id].Stock
+}
+
+type fakeOrderRepo struct {
+	mu      sync.Mutex
+	created []*order.Order
+	usedTx  bool
+
+	lastFilter order.ListFilter
+	events     []*order.Event
+	paid       map[uuid.UUID]bool
+}
+
+func (f *fakeOrderRepo) Create(ctx context.Context, o *order.Order) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.usedTx = common.IsTransactional(ctx)
+	o.ID = uuid.New()
+	f.created = append(f.created, o)
+	return nil
+}
+
+func (f *fakeOrderRepo) GetByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, o := range f.created {
+		if o.ID == id {
+			cp := *o
+			return &cp, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeOrderRepo) UpdateStatus(ctx context.Context, id uuid.UUID, from, to order.OrderStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, o := range f.created {
+		if o.ID == id {
+			if o.Status != from {
+				return order.ErrorStatusConflict
+			}
+			o.Status = to
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeOrderRepo) UpdateRoute(ctx context.Context, o *order.Order) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, cur := range f.created {
+		if cur.ID == o.ID {
+			if cur.Status != order.Pending {
+				return order.ErrorStatusConflict
+			}
+			cp := *o
+			f.created[i] = &cp
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (f *fakeOrderRepo) SetCancellation(ctx context.Context, id uuid.UUID, reason string) error {
+	return nil
+}
+
+func (f *fakeOrderRepo) ListExpiredPending(ctx context.Context, cutoff time.Time, limit int) ([]*order.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var out []*order.Order
+	for _, o := range f.created {
+		if o.Status == order.Pending && o.CreatedAt.Before(cutoff) && len(out) < limit {
+			cp := *o
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderRepo) HasCompletedPayment(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.paid[orderID], nil
+}
+
+// ListFiltered supports the scoping fields and created_at ascending order,
+// which is all the listing tests use.
+func (f *fakeOrderRepo) ListFiltered(ctx context.Context, filter order.ListFilter) ([]*order.Order, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastFilter = filter
+	var out []*order.Order
+	for _, o := range f.created {
+		switch {
+		case filter.CustomerID != nil && o.CustomerID != *filter.CustomerID,
+			filter.MerchantID != nil && o.MerchantID != *filter.MerchantID,
+			filter.StoreID != nil && o.StoreID != *filter.StoreID,
+			filter.Cursor != nil && !o.CreatedAt.After(filter.Cursor.CreatedAt):
+			continue
+		}
+		if len(out) < filter.Limit {
+			cp := *o
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderRepo) AddEvent(ctx context.Context, e *order.Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e.ID = uuid.New()
+	f.events = append(f.events, e)
+	return nil
+}
+
+func (f *fakeOrderRepo) ListEvents(ctx context.Context, orderID uuid.UUID) ([]*order.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var out []*order.Event
+	for _, e := range f.events {
+		if e.OrderID == orderID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOrderRepo) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.created)
+}
+
+type fakeStoreRepo struct {
+	store *store.Store
+	staff map[uuid.UUID]store.MemberRole // user -> role in the store
+}
+
+func (f *fakeStoreRepo) GetByID(ctx context.Context, id uuid.UUID) (*store.Store, error) {
+	return f.store, nil
+}
+
+func (f *fakeStoreRepo) GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error) {
+	switch {
+	case storeID != f.store.ID:
+		return "", nil
+	case userID == f.store.OwnerID:
+		return store.MemberOwner, nil
+	}
+	return f.staff[userID], nil
+}
+
+type fakeNotificationRepo struct{}
+
+func (f *fakeNotificationRepo) Create(ctx context.Context, n *notification.Notification) error {
+	return nil
+}
+
+type fakeRefunds struct {
+	mu      sync.Mutex
+	reasons map[uuid.UUID]string
+}
+
+func (f *fakeRefunds) RequestRefund(ctx context.Context, orderID uuid.UUID, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.reasons == nil {
+		f.reasons = map[uuid.UUID]string{}
+	}
+	f.reasons[orderID] = reason
+	return nil
+}
+
+// fakePricer charges a flat delivery fee and 10% tax.
+type fakePricer struct{}
+
+const fakeDeliveryFee = 300
+
+func (f *fakePricer) Quote(ctx context.Context, storeID uuid.UUID, subtotal int64, currency string, pickup, delivery postgis.PointS) (*pricing.Breakdown, error) {
+	b := &pricing.Breakdown{Currency: currency, Subtotal: subtotal, DeliveryFee: fakeDeliveryFee, TaxRateBps: 1000}
+	b.Recalculate()
+	return b, nil
+}
+
+// fakePromotions knows a fixed set of codes and their discounts.
+type fakePromotions struct {
+	discounts map[string]int64
+	redeemed  map[uuid.UUID]string
+}
+
+func (f *fakePromotions) ApplyPromotion(ctx context.Context, code string, b promotion.Basket) (*promotion.Application, error) {
+	code = promotion.NormalizeCode(code)
+	d, ok := f.discounts[code]
+	if !ok {
+		return nil, promotion.ErrorPromotionNotFound
+	}
+	return &promotion.Application{PromotionID: uuid.New(), Code: code, Discount: d}, nil
+}
+
+func (f *fakePromotions) RecordRedemption(ctx context.Context, a *promotion.Application, orderID, customerID uuid.UUID) error {
+	if f.redeemed == nil {
+		f.redeemed = map[uuid.UUID]string{}
+	}
+	f.redeemed[orderID] = a.Code
+	return nil
+}
+
+func (f *fakePromotions) ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error {
+	delete(f.redeemed, orderID)
+	return nil
+}
+
+// fakeAddresses holds saved addresses by ID.
+type fakeAddresses struct {
+	saved map[uuid.UUID]*address.Address
+}
+
+func (f *fakeAddresses) GetAddressForUser(ctx context.Context, userID, id uuid.UUID) (*address.Address, error) {
+	a, ok := f.saved[id]
+	if !ok || a.UserID != userID {
+		return nil, address.ErrorAddressNotFound
+	}
+	return a, nil
+}
+
+// fakeGeocoder knows one place.
+type fakeGeocoder struct{}
+
+func (fakeGeocoder) Geocode(ctx context.Context, query string) (*geocoding.Place, error) {
+	if !strings.Contains(strings.ToLower(query), "kilimani") {
+		return nil, geocoding.ErrorNoMatch
+	}
+	return &geocoding.Place{Name: "Kilimani", Address: "Kilimani, Nairobi", Lat: -1.2906, Lng: 36.7856}, nil
+}
+
+type fixture struct {
+	uc        *UseCase
+	orders    *fakeOrderRepo
+	products  *fakeProductRepo
+	refunds   *fakeRefunds
+	promos    *fakePromotions
+	addresses *fakeAddresses
+	store     *store.Store
+	stores    *fakeStoreRepo
+}
+
+func newFixture() *fixture {
+	s := &store.Store{ID: uuid.New(), OwnerID: uuid.New()}
+	orders := &fakeOrderRepo{}
+	products := &fakeProductRepo{products: map[uuid.UUID]*product.Product{}}
+
+	refunds := &fakeRefunds{}
+	promos := &fakePromotions{discounts: map[string]int64{"KARIBU": 500}}
+
+	addresses := &fakeAddresses{saved: map[uuid.UUID]*address.Address{}}
+
+	stores := &fakeStoreRepo{store: s, staff: map[uuid.UUID]store.MemberRole{}}
+
+	uc := NewUseCase(orders, nil, nil, &fakeTxManager{}, &fakeNotificationRepo{}, products, stores, refunds, &fakePricer{}, promos, addresses, fakeGeocoder{})
+
+	return &fixture{uc: uc, orders: orders, products: products, refunds: refunds, promos: promos, addresses: addresses, store: s, stores: stores}
+}
+
+func (f *fixture) addProduct(price float64, stock int) uuid.UUID {
+	id := uuid.New()
+	f.products.products[id] = &product.Product{
+		ID:      id,
+		StoreID: f.store.ID,
+		Name:    "Product " + id.String()[:4],
+		Price:   price,
+		Stock:   &stock,
+	}
+	return id
+}
+
+func (f *fixture) request(items ...order.CreateOrderItem) *order.CreateOrderRequest {
+	return &order.CreateOrderRequest{
+		StoreID:         f.store.ID,
+		Items:           items,
+		PickupAddress:   "Shop",
+		PickupLat:       -1.2833,
+		PickupLng:       36.8167,
+		DeliveryAddress: "Home",
+		DeliveryLat:     -1.2921,
+		DeliveryLng:     36.7856,
+	}
+}
+
+func TestCreateOrder_OneOrderHoldsAllLines(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+	pears := f.addProduct(2.5, 5)
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: apples, Quantity: 2},
+		order.CreateOrderItem{ProductID: pears, Quantity: 4},
+	))
+
+	require.NoError(t, err)
+	require.Equal(t, 1, f.orders.count())
+	require.Len(t, o.Items, 2)
+	require.Equal(t, apples, o.Items[0].ProductID)
+	require.Equal(t, pears, o.Items[1].ProductID)
+	require.Equal(t, int64(2*1000+4*250), o.Subtotal)
+	require.Equal(t, int64(fakeDeliveryFee), o.DeliveryFee)
+	require.Equal(t, int64(330), o.Tax)
+	require.Equal(t, int64(3000+fakeDeliveryFee+330), o.Total, "total is the grand total")
+	require.Equal(t, f.store.OwnerID, o.MerchantID)
+}
+
+func TestCreateOrder_RoundsPricesToCents(t *testing.T) {
+	f := newFixture()
+	pens := f.addProduct(19.99, 5) // 19.99 * 100 is 1998.9999999999998
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: pens, Quantity: 1},
+	))
+	require.NoError(t, err)
+	require.Equal(t, int64(1999), o.Items[0].UnitPrice)
+}
+
+func TestCreateOrder_AppliesPromoCode(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+
+	req := f.request(order.CreateOrderItem{ProductID: apples, Quantity: 3})
+	req.PromoCode = " karibu "
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(3000), o.Subtotal)
+	require.Equal(t, int64(500), o.Discount)
+	require.Equal(t, int64(280), o.Tax, "tax is charged after the discount")
+	require.Equal(t, int64(3000+fakeDeliveryFee-500+280), o.Total)
+	require.NotNil(t, o.PromoCode)
+	require.Equal(t, "KARIBU", *o.PromoCode)
+	require.Equal(t, "KARIBU", f.promos.redeemed[o.ID])
+}
+
+func TestCreateOrder_UnknownPromoCodeRollsBack(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+
+	req := f.request(order.CreateOrderItem{ProductID: apples, Quantity: 3})
+	req.PromoCode = "NOPE"
+	_, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+
+	require.ErrorIs(t, err, promotion.ErrorPromotionNotFound)
+	require.Equal(t, 0, f.orders.count())
+	require.Equal(t, 5, *f.products.products[apples].Stock, "reservation is released")
+	require.Empty(t, f.promos.redeemed)
+}
+
+func TestCreateOrder_DeliversToSavedAddress(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+	customerID := uuid.New()
+	saved := &address.Address{ID: uuid.New(), UserID: customerID, Address: "Kilimani, Argwings Kodhek Rd", Point: address.NewPoint(-1.2890, 36.7840)}
+	f.addresses.saved[saved.ID] = saved
+
+	req := f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1})
+	req.AddressID = &saved.ID
+	o, err := f.uc.CreatePendingOrder(context.Background(), customerID, req)
+
+	require.NoError(t, err)
+	require.Equal(t, saved.ID, *o.AddressID)
+	require.Equal(t, saved.Address, o.DeliveryAddress)
+	require.Equal(t, saved.Point, o.DeliveryPoint)
+
+	// Someone else's address can't be used
+	_, err = f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.ErrorIs(t, err, address.ErrorAddressNotFound)
+}
+
+func TestCreateOrder_RejectsInvalidCoordinates(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+
+	req := f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1})
+	req.DeliveryAddress, req.DeliveryLat, req.DeliveryLng = "", 0, 0
+	_, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.ErrorIs(t, err, order.ErrorInvalidLocation)
+
+	req = f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1})
+	req.PickupLat = 95
+	_, err = f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.ErrorIs(t, err, order.ErrorInvalidLocation)
+	require.Equal(t, 5, *f.products.products[apples].Stock)
+}
+
+func TestCreateOrder_GeocodesDeliveryAddress(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+
+	req := f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1})
+	req.DeliveryAddress, req.DeliveryLat, req.DeliveryLng = "Argwings Kodhek Rd, Kilimani", 0, 0
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+	require.Equal(t, address.NewPoint(-1.2906, 36.7856), o.DeliveryPoint)
+	require.Equal(t, "Argwings Kodhek Rd, Kilimani", o.DeliveryAddress)
+
+	req = f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1})
+	req.DeliveryAddress, req.DeliveryLat, req.DeliveryLng = "Atlantis", 0, 0
+	_, err = f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.ErrorIs(t, err, geocoding.ErrorNoMatch)
+	require.Equal(t, 4, *f.products.products[apples].Stock)
+}
+
+func TestCreateOrder_PicksUpAtStoreByDefault(t *testing.T) {
+	f := newFixture()
+	apples := f.addProduct(10, 5)
+	shop := address.NewPoint(-1.2833, 36.8167)
+	f.store.Location, f.store.Point = "Moi Avenue, Nairobi", &shop
+
+	req := f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1})
+	req.PickupAddress, req.PickupLat, req.PickupLng = "", 0, 0
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.NoError(t, err)
+	require.Equal(t, "Moi Avenue, Nairobi", o.PickupAddress)
+	require.Equal(t, shop, o.PickupPoint)
+
+	// A pickup sent by the client wins
+	o, err = f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(order.CreateOrderItem{ProductID: apples, Quantity: 1}))
+	require.NoError(t, err)
+	require.Equal(t, "Shop", o.PickupAddress)
+
+	// Stores that were never located need a pickup point
+	f.store.Point = nil
+	_, err = f.uc.CreatePendingOrder(context.Background(), uuid.New(), req)
+	require.ErrorIs(t, err, order.ErrorInvalidLocation)
+}
+
+func TestCreateOrder_RunsInsideTransaction(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+
+	_, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+
+	require.NoError(t, err)
+	require.True(t, f.products.usedTx, "stock must be reserved inside the checkout transaction")
+	require.True(t, f.orders.usedTx, "order must be created inside the checkout transaction")
+}
+
+func TestCreateOrder_FailedLineRollsBackReservations(t *testing.T) {
+	f := newFixture()
+	inStock := f.addProduct(10, 5)
+	soldOut := f.addProduct(10, 0)
+
+	_, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: inStock, Quantity: 2},
+		order.CreateOrderItem{ProductID: soldOut, Quantity: 1},
+	))
+
+	require.ErrorIs(t, err, order.ErrorOutOfStock)
+	require.Equal(t, 5, f.products.stock(inStock), "stock of earlier lines must be restored")
+	require.Equal(t, 0, f.orders.count())
+}
+
+func TestCreateOrder_ConcurrentCheckoutsCannotOversell(t *testing.T) {
+	const stock = 7
+	const buyers = 50
+
+	f := newFixture()
+	id := f.addProduct(10, stock)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, buyers)
+
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+				order.CreateOrderItem{ProductID: id, Quantity: 1},
+			))
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	sold := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			sold++
+		case errors.Is(err, order.ErrorOutOfStock):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	require.Equal(t, stock, sold)
+	require.Equal(t, stock, f.orders.count())
+	require.Equal(t, 0, f.products.stock(id))
+}
+
+type fakeFulfilment struct {
+	applied []order.Transition
+}
+
+func (f *fakeFulfilment) ApplyTransition(ctx context.Context, o *order.Order, t order.Transition) error {
+	f.applied = append(f.applied, t)
+	return nil
+}
+
+func TestUpdateOrderStatus_CancelRestoresStock(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+	customerID := uuid.New()
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), customerID, f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 3},
+	))
+	require.NoError(t, err)
+	require.Equal(t, 2, f.products.stock(id))
+
+	cancelled, err := f.uc.UpdateOrderStatus(context.Background(), o.ID, customerID, order.ActorCustomer, order.Cancelled)
+
+	require.NoError(t, err)
+	require.Equal(t, order.Cancelled, cancelled.Status)
+	require.Equal(t, 5, f.products.stock(id))
+}
+
+func TestUpdateOrderStatus_IllegalTransition(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+	customerID := uuid.New()
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), customerID, f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+	require.NoError(t, err)
+
+	_, err = f.uc.UpdateOrderStatus(context.Background(), o.ID, uuid.Nil, order.ActorAdmin, order.Delivered)
+
+	var terr *order.TransitionError
+	require.ErrorAs(t, err, &terr)
+	require.ErrorIs(t, err, order.ErrorIllegalTransition)
+	require.Equal(t, order.Pending, terr.From)
+}
+
+func TestUpdateOrderStatus_OnlyOwnOrders(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+	require.NoError(t, err)
+
+	_, err = f.uc.UpdateOrderStatus(context.Background(), o.ID, uuid.New(), order.ActorCustomer, order.Cancelled)
+	require.ErrorIs(t, err, order.ErrorNotOrderParticipant)
+
+	_, err = f.uc.UpdateOrderStatus(context.Background(), o.ID, uuid.New(), order.ActorDriver, order.Assigned)
+	require.ErrorIs(t, err, order.ErrorTransitionForbidden)
+}
+
+func TestUpdateOrderStatus_FulfilmentStaff(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+	packer, editor := uuid.New(), uuid.New()
+	f.stores.staff[packer] = store.MemberFulfilment
+	f.stores.staff[editor] = store.MemberCatalogEditor
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+	require.NoError(t, err)
+
+	_, err = f.uc.GetOrderTimeline(context.Background(), o.ID, editor, order.ActorMerchant)
+	require.ErrorIs(t, err, order.ErrorNotOrderParticipant)
+
+	_, err = f.uc.GetOrderTimeline(context.Background(), o.ID, packer, order.ActorMerchant)
+	require.NoError(t, err)
+}
+
+func TestTransitionOrder_AppliesFulfilmentEffects(t *testing.T) {
+	f := newFixture()
+	ful := &fakeFulfilment{}
+	f.uc.SetFulfilment(ful)
+	id := f.addProduct(10, 5)
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+	require.NoError(t, err)
+
+	_, err = f.uc.TransitionOrder(context.Background(), o.ID, order.Assigned, order.ActorSystem, uuid.Nil)
+	require.NoError(t, err)
+	require.Empty(t, ful.applied, "assignment has no delivery effects")
+
+	_, err = f.uc.TransitionOrder(context.Background(), o.ID, order.InTransit, order.ActorDriver, uuid.New())
+	require.NoError(t, err)
+	require.Len(t, ful.applied, 1)
+	require.True(t, ful.applied[0].Has(order.EffectPickUpDelivery))
+}
+
+func TestExpireOrder_ReleasesStaleReservations(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+
+	stale, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 2},
+	))
+	require.NoError(t, err)
+	fresh, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+	require.NoError(t, err)
+	f.orders.created[0].CreatedAt = time.Now().Add(-time.Hour)
+	f.orders.created[1].CreatedAt = time.Now()
+
+	expired, err := f.uc.ListExpiredPendingOrders(context.Background(), 30*time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	require.Equal(t, stale.ID, expired[0].ID)
+
+	o, err := f.uc.ExpireOrder(context.Background(), stale.ID)
+	require.NoError(t, err)
+	require.Equal(t, order.Cancelled, o.Status)
+	require.Equal(t, 4, f.products.stock(id), "only the stale order's stock comes back")
+
+	f.orders.created[1].Status = order.Assigned
+	_, err = f.uc.ExpireOrder(context.Background(), fresh.ID)
+	require.ErrorIs(t, err, order.ErrorStatusConflict, "orders that moved on are left alone")
+}
+
+func TestExpireOrder_SkipsOrderPaidAfterListing(t *testing.T) {
+	f := newFixture()
+	id := f.addProduct(10, 5)
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), uuid.New(), f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 2},
+	))
+	require.NoError(t, err)
+	f.orders.created[0].CreatedAt = time.Now().Add(-time.Hour)
+
+	expired, err := f.uc.ListExpiredPendingOrders(context.Background(), 30*time.Minute, 10)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+
+	f.orders.paid = map[uuid.UUID]bool{o.ID: true}
+	_, err = f.uc.ExpireOrder(context.Background(), o.ID)
+	require.ErrorIs(t, err, order.ErrorStatusConflict)
+	require.Equal(t, order.Pending, f.orders.created[0].Status)
+	require.Equal(t, 3, f.products.stock(id), "the paid order keeps its stock")
+}
+
+func TestListOrdersFiltered_Scoping(t *testing.T) {
+	f := newFixture()
+	customerID := uuid.New()
+	otherStore := uuid.New()
+	fulfiler, editor := uuid.New(), uuid.New()
+	f.stores.staff[fulfiler] = store.MemberFulfilment
+	f.stores.staff[editor] = store.MemberCatalogEditor
+
+	tests := []struct {
+		name     string
+		callerID uuid.UUID
+		actor    order.Actor
+		filter   order.ListFilter
+		err      error
+		check    func(t *testing.T, got order.ListFilter)
+	}{
+		{
+			name: "customer is pinned to own orders", callerID: customerID, actor: order.ActorCustomer,
+			check: func(t *testing.T, got order.ListFilter) { require.Equal(t, customerID, *got.CustomerID) },
+		},
+		{
+			name: "customer cannot list someone else", callerID: customerID, actor: order.ActorCustomer,
+			filter: order.ListFilter{CustomerID: &otherStore}, err: order.ErrorListForbidden,
+		},
+		{
+			name: "merchant without store sees own orders", callerID: f.store.OwnerID, actor: order.ActorMerchant,
+			check: func(t *testing.T, got order.ListFilter) { require.Equal(t, f.store.OwnerID, *got.MerchantID) },
+		},
+		{
+			name: "merchant lists owned store", callerID: f.store.OwnerID, actor: order.ActorMerchant,
+			filter: order.ListFilter{StoreID: &f.store.ID},
+			check:  func(t *testing.T, got order.ListFilter) { require.Equal(t, f.store.ID, *got.StoreID) },
+		},
+		{
+			name: "fulfilment staff list the store", callerID: fulfiler, actor: order.ActorMerchant,
+			filter: order.ListFilter{StoreID: &f.store.ID},
+			check:  func(t *testing.T, got order.ListFilter) { require.Nil(t, got.MerchantID) },
+		},
+		{
+			name: "catalog editors cannot list the store", callerID: editor, actor: order.ActorMerchant,
+			filter: order.ListFilter{StoreID: &f.store.ID}, err: order.ErrorListForbidden,
+		},
+		{
+			name: "merchant cannot list foreign store", callerID: f.store.OwnerID, actor: order.ActorMerchant,
+			filter: order.ListFilter{StoreID: &otherStore}, err: order.ErrorListForbidden,
+		},
+		{
+			name: "drivers cannot list orders", callerID: uuid.New(), actor: order.ActorDriver,
+			err: order.ErrorListForbidden,
+		},
+		{
+			name: "admin filters freely", callerID: uuid.New(), actor: order.ActorAdmin,
+			filter: order.ListFilter{StoreID: &otherStore},
+			check: func(t *testing.T, got order.ListFilter) {
+				require.Nil(t, got.CustomerID)
+				require.Nil(t, got.MerchantID)
+			},
+		},
+		{
+			name: "unknown sort is rejected", callerID: uuid.New(), actor: order.ActorAdmin,
+			filter: order.ListFilter{Sort: "user_id"}, err: order.ErrorInvalidSort,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.uc.ListOrdersFiltered(context.Background(), tt.callerID, tt.actor, tt.filter)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, f.orders.lastFilter)
+		})
+	}
+}
+
+func TestListOrdersFiltered_Pagination(t *testing.T) {
+	f := newFixture()
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		f.orders.created = append(f.orders.created, &order.Order{
+			ID: uuid.New(), CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	var seen []uuid.UUID
+	filter := order.ListFilter{Limit: 2}
+	for {
+		page, err := f.uc.ListOrdersFiltered(context.Background(), uuid.New(), order.ActorAdmin, filter)
+		require.NoError(t, err)
+		for _, o := range page.Orders {
+			seen = append(seen, o.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor, err = order.DecodeCursor(page.NextCursor)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, seen, 5)
+	for i, o := range f.orders.created {
+		require.Equal(t, o.ID, seen[i])
+	}
+}
+
+func TestGetOrderTimeline_RecordsWhoChangedWhat(t *testing.T) {
+	f := newFixture()
+	f.uc.SetFulfilment(&fakeFulfilment{})
+	id := f.addProduct(10, 5)
+	customerID := uuid.New()
+	driverID := uuid.New()
+
+	o, err := f.uc.CreatePendingOrder(context.Background(), customerID, f.request(
+		order.CreateOrderItem{ProductID: id, Quantity: 1},
+	))
+	require.NoError(t, err)
+
+	_, err = f.uc.TransitionOrder(context.Background(), o.ID, order.Assigned, order.ActorSystem, uuid.Nil)
+	require.NoError(t, err)
+	_, err = f.uc.TransitionOrder(context.Background(), o.ID, order.InTransit, order.ActorDriver, driverID)
+	require.NoError(t, err)
+
+	events, err := f.uc.GetOrderTimeline(context.Background(), o.ID, customerID, order.ActorCustomer)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	require.Nil(t, events[0].ActorID, "system changes have no actor")
+	require.Equal(t, order.ActorSystem, events[0].ActorRole)
+	require.Equal(t, "pending", *events[0].OldValue)
+	require.Equal(t, "assigned", *events[0].NewValue)
+
+	require.Equal(t, driverID, *events[1].ActorID)
+	require.Equal(t, order.ActorDriver, events[1].ActorRole)
+	require.Equal(t, "in_transit", *events[1].NewValue)
+
+	_, err = f.uc.GetOrderTimeline(context.Background(), o.ID, uuid.New(), order.ActorCustomer)
+	require.ErrorIs(t, err, order.ErrorNotOrderParticipant)
+}
+
+func TestAssignOrderToDriver_RecordsDesignatedDriver(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	o := &order.Order{ID: uuid.New(), Status: order.Pending}
+	f.orders.created = append(f.orders.created, o)
+	driverID := uuid.New()
+
+	require.NoError(t, f.uc.AssignOrderToDriver(ctx, o.ID, driverID))
+
+	events, err := f.orders.ListEvents(ctx, o.ID)
+	require.NoError(t, err)
+	var assigned *order.Event
+	for _, e := range events {
+		if e.Type == order.EventDriverAssigned {
+			assigned = e
+		}
+	}
+	require.NotNil(t, assigned)
+	require.Equal(t, driverID.String(), *assigned.NewValue)
+}
+
+func TestCancelOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		status order.OrderStatus
+		actor  order.Actor
+		err    error
+	}{
+		{"customer cancels pending order", order.Pending, order.ActorCustomer, nil},
+		{"customer cancels assigned order", order.Assigned, order.ActorCustomer, nil},
+		{"customer cannot cancel in transit", order.InTransit, order.ActorCustomer, order.ErrorTransitionForbidden},
+		{"admin cancels in transit", order.InTransit, order.ActorAdmin, nil},
+		{"delivered orders stay delivered", order.Delivered, order.ActorAdmin, order.ErrorIllegalTransition},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			ful := &fakeFulfilment{}
+			f.uc.SetFulfilment(ful)
+			id := f.addProduct(10, 5)
+			customerID := uuid.New()
+
+			req := f.request(order.CreateOrderItem{ProductID: id, Quantity: 2})
+			req.PromoCode = "KARIBU"
+			o, err := f.uc.CreatePendingOrder(context.Background(), customerID, req)
+			require.NoError(t, err)
+			f.orders.created[0].Status = tt.status
+
+			_, err = f.uc.CancelOrder(context.Background(), o.ID, customerID, tt.actor, "changed my mind")
+
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				require.Equal(t, 3, f.products.stock(id), "stock must stay reserved")
+				require.Empty(t, f.refunds.reasons)
+				require.Contains(t, f.promos.redeemed, o.ID, "promotion must stay redeemed")
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 5, f.products.stock(id))
+			require.NotContains(t, f.promos.redeemed, o.ID, "promotion must be released")
+			require.Equal(t, "changed my mind", f.refunds.reasons[o.ID])
+			if tt.status != order.Pending {
+				require.Len(t, ful.applied, 1)
+				require.True(t, ful.applied[0].Has(order.EffectFailDelivery))
+				require.True(t, ful.applied[0].Has(order.EffectReleaseDriver))
+			}
+		})
+	}
+}
+
+func TestCancelOrder_RequiresReason(t *testing.T) {
+	f := newFixture()
+
+	_, err := f.uc.CancelOrder(context.Background(), uuid.New(), uuid.New(), order.ActorCustomer, "  ")
+	require.ErrorIs(t, err, order.ErrorCancelReasonMissing)
+}
+
+func TestUpdateOrder_RejectsStatusColumn(t *testing.T) {
+	f := newFixture()
+
+	st := order.Delivered
+	err := f.uc.UpdateOrder(context.Background(), uuid.New(), uuid.New(), order.ActorAdmin, &order.UpdateOrderRequest{Status: &st})
+	require.ErrorIs(t, err, order.ErrorStatusNotUpdatable)
+}
+
+func TestUpdateOrder_RecordsEachEdit(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	o := &order.Order{
+		ID:              uuid.New(),
+		Status:          order.Pending,
+		PickupAddress:   "Moi Avenue",
+		PickupPoint:     postgis.PointS{SRID: 4326, X: 36.8167, Y: -1.2833},
+		DeliveryAddress: "Ngong Road",
+		DeliveryPoint:   postgis.PointS{SRID: 4326, X: 36.7834, Y: -1.3000},
+	}
+	f.orders.created = append(f.orders.created, o)
+	adminID := uuid.New()
+
+	addr := "Kilimani"
+	err := f.uc.UpdateOrder(ctx, o.ID, adminID, order.ActorAdmin, &order.UpdateOrderRequest{DeliveryAddress: &addr})
+	require.NoError(t, err)
+
+	events, err := f.orders.ListEvents(ctx, o.ID)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, order.EventEdited, events[0].Type)
+	require.Equal(t, "delivery_address", events[0].Field)
+	require.Equal(t, "Ngong Road", *events[0].OldValue)
+	require.Equal(t, addr, *events[0].NewValue)
+	require.Equal(t, adminID, *events[0].ActorID)
+
+	blank := " "
+	err = f.uc.UpdateOrder(ctx, o.ID, adminID, order.ActorAdmin, &order.UpdateOrderRequest{PickupAddress: &blank})
+	require.ErrorIs(t, err, order.ErrorInvalidAddress)
+	err = f.uc.UpdateOrder(ctx, o.ID, adminID, order.ActorAdmin, &order.UpdateOrderRequest{})
+	require.ErrorIs(t, err, order.ErrorNothingToUpdate)
+}
+
+func TestUpdateOrder_RelocatesAndReprices(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	addressID := uuid.New()
+	o := &order.Order{
+		ID:              uuid.New(),
+		Status:          order.Pending,
+		Subtotal:        1000,
+		Discount:        100,
+		Total:           900,
+		PickupAddress:   "Moi Avenue",
+		PickupPoint:     postgis.PointS{SRID: 4326, X: 36.8167, Y: -1.2833},
+		DeliveryAddress: "Ngong Road",
+		DeliveryPoint:   postgis.PointS{SRID: 4326, X: 36.7834, Y: -1.3000},
+		AddressID:       &addressID,
+	}
+	f.orders.created = append(f.orders.created, o)
+
+	addr := "Kilimani"
+	err := f.uc.UpdateOrder(ctx, o.ID, uuid.New(), order.ActorAdmin, &order.UpdateOrderRequest{DeliveryAddress: &addr})
+	require.NoError(t, err)
+
+	got, err := f.orders.GetByID(ctx, o.ID)
+	require.NoError(t, err)
+	require.Equal(t, addr, got.DeliveryAddress)
+	require.Equal(t, 36.7856, got.DeliveryPoint.X, "geocoded again")
+	require.Equal(t, -1.2906, got.DeliveryPoint.Y)
+	require.Nil(t, got.AddressID)
+	require.Equal(t, o.PickupPoint, got.PickupPoint)
+
+	// Re-quoted for the new route, the checkout discount is kept
+	require.EqualValues(t, fakeDeliveryFee, got.DeliveryFee)
+	require.EqualValues(t, 100, got.Discount)
+	require.EqualValues(t, 120, got.Tax)
+	require.EqualValues(t, 1000+fakeDeliveryFee-100+120, got.Total)
+
+	unknown := "Nowhere Street"
+	err = f.uc.UpdateOrder(ctx, o.ID, uuid.New(), order.ActorAdmin, &order.UpdateOrderRequest{DeliveryAddress: &unknown})
+	require.ErrorIs(t, err, geocoding.ErrorNoMatch)
+}
+
+func TestUpdateOrder_OnlyWhilePending(t *testing.T) {
+	f := newFixture()
+	ctx := context.Background()
+	o := &order.Order{ID: uuid.New(), Status: order.Assigned, DeliveryAddress: "Ngong Road"}
+	f.orders.created = append(f.orders.created, o)
+
+	addr := "Kilimani"
+	err := f.uc.UpdateOrder(ctx, o.ID, uuid.New(), order.ActorAdmin, &order.UpdateOrderRequest{DeliveryAddress: &addr})
+	require.ErrorIs(t, err, order.ErrorOrderNotEditable)
+
+	got, err := f.orders.GetByID(ctx, o.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Ngong Road", got.DeliveryAddress)
+	events, err := f.orders.ListEvents(ctx, o.ID)
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
+
+// --- unused methods (minimal stubs) ---
+func (f *fakeOrderRepo) ListByCustomer(context.Context, uuid.UUID) ([]*order.Order, error) {
+	return nil, nil
+}
+func (f *fakeOrderRepo) ListByStatus(context.Context, order.OrderStatus) ([]*order.Order, error) {
+	return nil, nil
+}
+func (f *fakeOrderRepo) List(context.Context) ([]*order.Order, error) {
+	return nil, nil
+}
+func (f *fakeOrderRepo) Delete(context.Context, uuid.UUID) error {
+	return nil
+}
+func (f *fakeOrderRepo) GetPickupPoint(context.Context, uuid.UUID) (postgis.PointS, error) {
+	return postgis.PointS{}, nil
+}
+func (f *fakeOrderRepo) GetDeliveryPoint(context.Context, uuid.UUID) (postgis.PointS, error) {
+	return postgis.PointS{}, nil
+}
@@ -2,7 +2,10 @@ package payment
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"backend/internal/domain/order"
 	domain "backend/internal/domain/payment"
 	"backend/internal/usecase/common"
 
@@ -12,18 +15,28 @@ import (
 type UseCase struct {
 	repo      domain.Repository
 	txManager common.TxManager
+	events    domain.OrderEventWriter
 }
 
-func NewUseCase(repo domain.Repository, txm common.TxManager) *UseCase {
-	return &UseCase{repo: repo, txManager: txm}
+func NewUseCase(repo domain.Repository, txm common.TxManager, events domain.OrderEventWriter) *UseCase {
+	return &UseCase{repo: repo, txManager: txm, events: events}
 }
 
-func (uc *UseCase) CreatePayment(ctx context.Context, p *domain.Payment) error {
+// CreatePayment records a payment and adds it to the order timeline as made
+// by the caller.
+func (uc *UseCase) CreatePayment(ctx context.Context, p *domain.Payment, callerID uuid.UUID, actor order.Actor) error {
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		if err := uc.repo.Create(txCtx, p); err != nil {
 			return fmt.Errorf("create payment failed: %w", err)
 		}
 
+		e := order.NewEvent(p.OrderID, order.EventPayment, "payment_status", actor, callerID).
+			Change("", string(p.Status)).
+			WithNote(fmt.Sprintf("%s payment of %d %s", p.Method, p.Amount, p.Currency))
+		if err := uc.events.AddEvent(txCtx, e); err != nil {
+			return fmt.Errorf("record payment event: %w", err)
+		}
+
 		return nil
 	})
 }
@@ -49,3 +62,49 @@ func (uc *UseCase) DeletePayment(ctx context.Context, id uuid.UUID) error {
 		return nil
 	})
 }
+
+// RequestRefund opens a refund for the completed payment of an order.
+// Orders that were never paid have nothing to refund.
+func (uc *UseCase) RequestRefund(ctx context.Context, orderID uuid.UUID, reason string) (*domain.Refund, error) {
+	var rf *domain.Refund
+
+	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		p, err := uc.repo.GetByOrder(txCtx, orderID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return fmt.Errorf("fetch payment: %w", err)
+		}
+
+		if p.Status != domain.StatusCompleted {
+			return nil
+		}
+
+		rf = &domain.Refund{
+			PaymentID: p.ID,
+			OrderID:   orderID,
+			Amount:    p.Amount,
+			Currency:  p.Currency,
+			Reason:    reason,
+			Status:    domain.RefundPending,
+		}
+		if err := uc.repo.CreateRefund(txCtx, rf); err != nil {
+			return fmt.Errorf("create refund failed: %w", err)
+		}
+
+		e := order.NewEvent(orderID, order.EventRefund, "refund_status", order.ActorSystem, uuid.Nil).
+			Change("", string(rf.Status)).
+			WithNote(reason)
+		if err := uc.events.AddEvent(txCtx, e); err != nil {
+			return fmt.Errorf("record refund event: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
@@ -0,0 +1,57 @@
+package payment
+
+import (
+	"backend/internal/domain/order"
+	domain "backend/internal/domain/payment"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type fakeRepo struct {
+	domain.Repository // unused methods panic
+
+	payments []*domain.Payment
+}
+
+func (f *fakeRepo) Create(ctx context.Context, p *domain.Payment) error {
+	p.ID = uuid.New()
+	f.payments = append(f.payments, p)
+	return nil
+}
+
+type fakeEvents struct {
+	events []*order.Event
+}
+
+func (f *fakeEvents) AddEvent(ctx context.Context, e *order.Event) error {
+	f.events = append(f.events, e)
+	return nil
+}
+
+func TestCreatePayment_RecordsCaller(t *testing.T) {
+	repo, events := &fakeRepo{}, &fakeEvents{}
+	uc := NewUseCase(repo, fakeTxManager{}, events)
+	merchantID := uuid.New()
+
+	req := &domain.CreatePaymentRequest{OrderID: uuid.New(), Amount: 150000, Currency: "KES", Method: domain.MethodCashOnDelivery}
+	p := req.ToPayment()
+	require.NoError(t, uc.CreatePayment(context.Background(), p, merchantID, order.ActorMerchant))
+
+	require.Len(t, repo.payments, 1)
+	require.Len(t, events.events, 1)
+	e := events.events[0]
+	require.Equal(t, order.EventPayment, e.Type)
+	require.Equal(t, p.OrderID, e.OrderID)
+	require.Equal(t, order.ActorMerchant, e.ActorRole)
+	require.NotNil(t, e.ActorID)
+	require.Equal(t, merchantID, *e.ActorID)
+}
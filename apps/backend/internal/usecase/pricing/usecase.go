@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	domain "backend/internal/domain/pricing"
+	"backend/internal/domain/store"
+	"backend/internal/usecase/common"
+	"context"
+	"fmt"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+)
+
+type UseCase struct {
+	repo       domain.Repository
+	storeRepo  domain.StoreReader
+	txManager  common.TxManager
+	taxRateBps int
+}
+
+// NewUseCase builds the pricing usecase. taxRateBps is the tax charged on
+// every order in basis points, e.g. 1600 for 16%.
+func NewUseCase(repo domain.Repository, storeRepo domain.StoreReader, txm common.TxManager, taxRateBps int) *UseCase {
+	return &UseCase{repo: repo, storeRepo: storeRepo, txManager: txm, taxRateBps: taxRateBps}
+}
+
+// Quote prices an order: the delivery fee comes from the distance between
+// pickup and delivery and the tariff of the store, or the global tariff if
+// the store has none.
+func (uc *UseCase) Quote(ctx context.Context, storeID uuid.UUID, subtotal int64, currency string, pickup, delivery postgis.PointS) (*domain.Breakdown, error) {
+	tariff, err := uc.GetTariff(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	distance, err := uc.repo.Distance(ctx, pickup, delivery)
+	if err != nil {
+		return nil, fmt.Errorf("measure delivery distance: %w", err)
+	}
+
+	fee, err := tariff.FeeFor(distance)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &domain.Breakdown{
+		Currency:    currency,
+		DistanceM:   distance,
+		Subtotal:    subtotal,
+		DeliveryFee: fee,
+		TaxRateBps:  uc.taxRateBps,
+	}
+	b.Recalculate()
+
+	return b, nil
+}
+
+// GetTariff returns the bands that apply to a store.
+func (uc *UseCase) GetTariff(ctx context.Context, storeID uuid.UUID) (domain.Tariff, error) {
+	bands, err := uc.repo.ListBands(ctx, &storeID)
+	if err != nil {
+		return nil, fmt.Errorf("load store tariff: %w", err)
+	}
+	if len(bands) > 0 {
+		return bands, nil
+	}
+
+	return uc.GetGlobalTariff(ctx)
+}
+
+// GetGlobalTariff returns the bands used by stores without their own.
+func (uc *UseCase) GetGlobalTariff(ctx context.Context) (domain.Tariff, error) {
+	bands, err := uc.repo.ListBands(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load global tariff: %w", err)
+	}
+	return bands, nil
+}
+
+// ReplaceTariff swaps the bands of a store, or the global bands when storeID
+// is nil. Only admins may change the global tariff; merchants may change the
+// tariff of stores they own or manage.
+func (uc *UseCase) ReplaceTariff(ctx context.Context, callerID uuid.UUID, isAdmin bool, storeID *uuid.UUID, bands domain.Tariff) error {
+	if err := bands.Validate(); err != nil {
+		return err
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if !isAdmin {
+			if storeID == nil {
+				return domain.ErrorNotStoreOwner
+			}
+			role, err := uc.storeRepo.GetMemberRole(txCtx, *storeID, callerID)
+			if err != nil {
+				return fmt.Errorf("check store role: %w", err)
+			}
+			if !role.Can(store.PermManageStore) {
+				return domain.ErrorNotStoreOwner
+			}
+		}
+
+		if err := uc.repo.ReplaceBands(txCtx, storeID, bands); err != nil {
+			return fmt.Errorf("replace tariff failed: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,149 @@
+package promotion
+
+import (
+	domain "backend/internal/domain/promotion"
+	"backend/internal/domain/store"
+	"backend/internal/usecase/common"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UseCase struct {
+	repo      domain.Repository
+	storeRepo domain.StoreReader
+	prodRepo  domain.ProductReader
+	txManager common.TxManager
+}
+
+func NewUseCase(repo domain.Repository, storeRepo domain.StoreReader, prodRepo domain.ProductReader, txm common.TxManager) *UseCase {
+	return &UseCase{repo: repo, storeRepo: storeRepo, prodRepo: prodRepo, txManager: txm}
+}
+
+// CreatePromotion saves a new promotion. Merchants may create promotions
+// for stores they own; platform-wide promotions are reserved to admins.
+func (uc *UseCase) CreatePromotion(ctx context.Context, callerID uuid.UUID, isAdmin bool, p *domain.Promotion) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := uc.authorize(txCtx, callerID, isAdmin, p.StoreID); err != nil {
+			return err
+		}
+
+		for _, id := range p.ProductIDs {
+			prod, err := uc.prodRepo.GetProductByID(txCtx, id)
+			if err != nil {
+				return fmt.Errorf("product not found: %w", err)
+			}
+			if prod.StoreID != *p.StoreID {
+				return domain.ErrorProductNotInStore
+			}
+		}
+
+		if err := uc.repo.Create(txCtx, p); err != nil {
+			return fmt.Errorf("create promotion failed: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListPromotions returns the promotions of a store to its managers, or the
+// platform-wide ones to admins when storeID is nil. Codes and their limits
+// aren't public, customers learn a code from whoever hands it out.
+func (uc *UseCase) ListPromotions(ctx context.Context, callerID uuid.UUID, isAdmin bool, storeID *uuid.UUID) ([]*domain.Promotion, error) {
+	if err := uc.authorize(ctx, callerID, isAdmin, storeID); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListByStore(ctx, storeID)
+}
+
+// SetPromotionActive switches a promotion on or off.
+func (uc *UseCase) SetPromotionActive(ctx context.Context, callerID uuid.UUID, isAdmin bool, id uuid.UUID, active bool) error {
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		p, err := uc.repo.GetByID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("fetch promotion: %w", err)
+		}
+		if err := uc.authorize(txCtx, callerID, isAdmin, p.StoreID); err != nil {
+			return err
+		}
+		return uc.repo.SetActive(txCtx, id, active)
+	})
+}
+
+func (uc *UseCase) authorize(ctx context.Context, callerID uuid.UUID, isAdmin bool, storeID *uuid.UUID) error {
+	if isAdmin {
+		return nil
+	}
+	if storeID == nil {
+		return domain.ErrorNotStoreOwner
+	}
+	role, err := uc.storeRepo.GetMemberRole(ctx, *storeID, callerID)
+	if err != nil {
+		return fmt.Errorf("check store role: %w", err)
+	}
+	if !role.Can(store.PermManageStore) {
+		return domain.ErrorNotStoreOwner
+	}
+	return nil
+}
+
+// ApplyPromotion validates a code against a basket and returns the discount
+// it grants. Called inside the checkout transaction it locks the promotion
+// until commit, so concurrent checkouts can't exceed its limits.
+func (uc *UseCase) ApplyPromotion(ctx context.Context, code string, b domain.Basket) (*domain.Application, error) {
+	p, err := uc.repo.GetByCodeForUpdate(ctx, domain.NormalizeCode(code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrorPromotionNotFound
+		}
+		return nil, fmt.Errorf("fetch promotion: %w", err)
+	}
+
+	if err := p.CheckWindow(time.Now()); err != nil {
+		return nil, err
+	}
+
+	if p.MaxRedemptions != nil && p.Redemptions >= *p.MaxRedemptions {
+		return nil, domain.ErrorPromotionExhausted
+	}
+
+	if p.MaxPerCustomer != nil {
+		used, err := uc.repo.CountCustomerRedemptions(ctx, p.ID, b.CustomerID)
+		if err != nil {
+			return nil, fmt.Errorf("count redemptions: %w", err)
+		}
+		if used >= *p.MaxPerCustomer {
+			return nil, domain.ErrorCustomerLimitReached
+		}
+	}
+
+	discount, err := p.DiscountFor(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Application{PromotionID: p.ID, Code: p.Code, Discount: discount}, nil
+}
+
+// RecordRedemption stores the use of an applied promotion by an order.
+func (uc *UseCase) RecordRedemption(ctx context.Context, a *domain.Application, orderID, customerID uuid.UUID) error {
+	return uc.repo.CreateRedemption(ctx, &domain.Redemption{
+		PromotionID: a.PromotionID,
+		OrderID:     orderID,
+		CustomerID:  customerID,
+		Amount:      a.Discount,
+	})
+}
+
+// ReleaseRedemption gives back the promotion used by a cancelled order, so
+// it counts neither against the promotion's limit nor the customer's.
+func (uc *UseCase) ReleaseRedemption(ctx context.Context, orderID uuid.UUID) error {
+	return uc.repo.DeleteRedemption(ctx, orderID)
+}
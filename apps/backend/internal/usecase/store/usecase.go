@@ -1,30 +1,112 @@
 package store
 
 import (
+	"backend/internal/domain/address"
+	"backend/internal/domain/geocoding"
 	"backend/internal/domain/store"
 	"backend/internal/usecase/common"
+	"backend/internal/utils"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/cridenour/go-postgis"
 	"github.com/google/uuid"
 )
 
 type UseCase struct {
 	repo      store.Repository
 	txManager common.TxManager
+	geocoder  store.Geocoder
 }
 
-func NewUseCase(repo store.Repository, txm common.TxManager) *UseCase {
-	return &UseCase{repo: repo, txManager: txm}
+func NewUseCase(repo store.Repository, txm common.TxManager, geocoder store.Geocoder) *UseCase {
+	return &UseCase{repo: repo, txManager: txm, geocoder: geocoder}
+}
+
+// locate finds a store location on the map. Stores without a location, or
+// with one the geocoder doesn't know, get no point and are left out of
+// nearby searches until their location is resolved.
+func (uc *UseCase) locate(ctx context.Context, location string) (*postgis.PointS, error) {
+	if strings.TrimSpace(location) == "" {
+		return nil, nil
+	}
+
+	p, err := uc.geocoder.Geocode(ctx, location)
+	if errors.Is(err, geocoding.ErrorNoMatch) || errors.Is(err, geocoding.ErrorEmptyQuery) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("geocode store location: %w", err)
+	}
+
+	point := address.NewPoint(p.Lat, p.Lng)
+	return &point, nil
+}
+
+func validServiceRadius(m int) bool {
+	return m > 0 && m <= store.MaxServiceRadiusM
 }
 
 func (uc *UseCase) CreateStore(ctx context.Context, s *store.Store) error {
 	s.NameNormalized = NormalizeName(s.Name)
 
-	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
-		return uc.repo.Create(txCtx, s)
-	})
+	if s.ServiceRadiusM == 0 {
+		s.ServiceRadiusM = store.DefaultServiceRadiusM
+	}
+	if !validServiceRadius(s.ServiceRadiusM) {
+		return store.ErrInvalidServiceRadius
+	}
+
+	point, err := uc.locate(ctx, s.Location)
+	if err != nil {
+		return err
+	}
+	s.Point = point
+
+	base := utils.GenerateSlug(s.NameNormalized)
+	if base == "" {
+		base = "store"
+	}
+
+	// Another store may take the slug between reading the taken ones and
+	// saving; pick again when that happens
+	for attempt := 1; ; attempt++ {
+		err = uc.txManager.Do(ctx, func(txCtx context.Context) error {
+			taken, err := uc.repo.ListSlugs(txCtx, base)
+			if err != nil {
+				return err
+			}
+			s.Slug = nextSlug(base, append(taken, reservedSlugs...))
+
+			return uc.repo.Create(txCtx, s)
+		})
+		if !errors.Is(err, store.ErrSlugTaken) || attempt == maxSlugAttempts {
+			return err
+		}
+	}
+}
+
+// maxSlugAttempts bounds the retries of CreateStore on slug collisions.
+const maxSlugAttempts = 3
+
+// reservedSlugs are /public/stores paths that a store slug would shadow.
+var reservedSlugs = []string{"nearby"}
+
+// nextSlug returns base, or base with the lowest numeric suffix from 2 up
+// that is not taken yet.
+func nextSlug(base string, taken []string) string {
+	used := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		used[t] = true
+	}
+
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug
 }
 
 func (uc *UseCase) GetStoreByID(ctx context.Context, id uuid.UUID) (*store.Store, error) {
@@ -39,21 +121,42 @@ func (uc *UseCase) GetStoreSummary(ctx context.Context, storeID uuid.UUID) (*sto
 	return uc.repo.GetStoreSummary(ctx, storeID)
 }
 
-func (uc *UseCase) UpdateStore(ctx context.Context, storeID uuid.UUID, ownerID uuid.UUID, req *store.UpdateStoreRequest) error {
+// UpdateStore changes a store's details for its owner or a manager.
+func (uc *UseCase) UpdateStore(ctx context.Context, storeID uuid.UUID, callerID uuid.UUID, req *store.UpdateStoreRequest) error {
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 
-		owned, err := uc.repo.IsOwnedBy(txCtx, storeID, ownerID)
-		if err != nil {
-			return fmt.Errorf("%w", err)
+		if err := uc.permit(txCtx, storeID, callerID, store.PermManageStore); err != nil {
+			return err
 		}
-		if !owned {
-			return store.ErrNotOwner
+
+		if req.ServiceRadiusM != 0 && !validServiceRadius(req.ServiceRadiusM) {
+			return store.ErrInvalidServiceRadius
+		}
+
+		var point *postgis.PointS
+		if req.Location != "" {
+			var err error
+			if point, err = uc.locate(txCtx, req.Location); err != nil {
+				return err
+			}
 		}
 
 		if err := uc.repo.UpdateStoreDetails(txCtx, storeID, req.Name, req.Logo, req.Location); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
+		// A new location replaces the old point even when it isn't on the map
+		if req.Location != "" {
+			if err := uc.repo.SetPoint(txCtx, storeID, point); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+		}
+		if req.ServiceRadiusM != 0 {
+			if err := uc.repo.UpdateServiceArea(txCtx, storeID, nil, req.ServiceRadiusM); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+		}
+
 		return nil
 	})
 }
@@ -62,6 +165,8 @@ func (uc *UseCase) ListAllStores(ctx context.Context) ([]*store.Store, error) {
 	return uc.repo.ListStores(ctx)
 }
 
+// ListOwnerStores returns the stores a merchant owns and those they are staff
+// of, each with their role in it.
 func (uc *UseCase) ListOwnerStores(ctx context.Context, ownerID uuid.UUID) ([]*store.MyStores, error) {
 	return uc.repo.ListStoresByOwner(ctx, ownerID)
 }
@@ -70,16 +175,33 @@ func (uc *UseCase) ListStoresPaged(ctx context.Context, filter store.StoreFilter
 	return uc.repo.ListStoresPaged(ctx, filter)
 }
 
+// ListNearbyStores returns the stores that deliver to a customer at lat/lng
+// within filter.RadiusM metres, closest first.
+func (uc *UseCase) ListNearbyStores(ctx context.Context, filter store.NearbyFilter) ([]*store.StoreSummary, error) {
+	if !address.ValidCoordinates(filter.Lat, filter.Lng) {
+		return nil, store.ErrInvalidCoordinates
+	}
+
+	switch {
+	case filter.RadiusM == 0:
+		filter.RadiusM = store.DefaultNearbyRadiusM
+	case filter.RadiusM < 0 || filter.RadiusM > store.MaxNearbyRadiusM:
+		return nil, store.ErrInvalidSearchRadius
+	}
+
+	return uc.repo.ListNearby(ctx, filter)
+}
+
 func (uc *UseCase) DeleteStore(ctx context.Context, storeID uuid.UUID, ownerID uuid.UUID) (string, error) {
 	var storeName string
 
 	err := uc.txManager.Do(ctx, func(txCtx context.Context) error {
 
-		owned, err := uc.repo.IsOwnedBy(txCtx, storeID, ownerID)
+		role, err := uc.repo.GetMemberRole(txCtx, storeID, ownerID)
 		if err != nil {
 			return fmt.Errorf("%w", err)
 		}
-		if !owned {
+		if !role.Can(store.PermDeleteStore) {
 			return store.ErrNotOwner
 		}
 
@@ -104,6 +226,51 @@ func (uc *UseCase) IsStoreOwnedBy(ctx context.Context, storeID uuid.UUID, ownerI
 	return uc.repo.IsOwnedBy(ctx, storeID, ownerID)
 }
 
+// ListMembers returns the staff of a store to those who manage it.
+func (uc *UseCase) ListMembers(ctx context.Context, storeID, callerID uuid.UUID) ([]*store.Member, error) {
+	if err := uc.permit(ctx, storeID, callerID, store.PermManageStaff); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListMembers(ctx, storeID)
+}
+
+// UpdateMemberRole gives a staff member of a store another staff role.
+func (uc *UseCase) UpdateMemberRole(ctx context.Context, storeID, callerID, userID uuid.UUID, role store.MemberRole) error {
+	if !role.IsStaff() {
+		return store.ErrInvalidMemberRole
+	}
+
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := uc.permit(txCtx, storeID, callerID, store.PermManageStaff); err != nil {
+			return err
+		}
+		return uc.repo.UpdateMemberRole(txCtx, storeID, userID, role)
+	})
+}
+
+// RemoveMember takes a user off the staff of a store. Their account stays.
+func (uc *UseCase) RemoveMember(ctx context.Context, storeID, callerID, userID uuid.UUID) error {
+	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
+		if err := uc.permit(txCtx, storeID, callerID, store.PermManageStaff); err != nil {
+			return err
+		}
+		return uc.repo.RemoveMember(txCtx, storeID, userID)
+	})
+}
+
+// permit returns store.ErrNotPermitted unless the user's role in the store
+// grants perm.
+func (uc *UseCase) permit(ctx context.Context, storeID, userID uuid.UUID, perm store.Permission) error {
+	role, err := uc.repo.GetMemberRole(ctx, storeID, userID)
+	if err != nil {
+		return fmt.Errorf("get store role: %w", err)
+	}
+	if !role.Can(perm) {
+		return store.ErrNotPermitted
+	}
+	return nil
+}
+
 func NormalizeName(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
 }
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"backend/internal/domain/geocoding"
+	"backend/internal/domain/store"
+	"backend/internal/usecase/common"
+	"context"
+	"testing"
+
+	"github.com/cridenour/go-postgis"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTxManager struct{}
+
+func (fakeTxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(common.MarkTx(ctx))
+}
+
+// fakeStoreRepo records writes; the methods these tests don't reach come
+// from the nil embedded interface.
+type fakeStoreRepo struct {
+	store.Repository
+	created  *store.Store
+	owner    uuid.UUID
+	staff    map[uuid.UUID]store.MemberRole // user -> role, in every store
+	point    *postgis.PointS
+	pointSet bool
+	radiusM  int
+	nearby   *store.NearbyFilter
+
+	slugs []string
+	// racedSlugs is how many creates lose their slug to another store
+	racedSlugs int
+}
+
+func (f *fakeStoreRepo) Create(ctx context.Context, s *store.Store) error {
+	if f.racedSlugs > 0 {
+		f.racedSlugs--
+		f.slugs = append(f.slugs, s.Slug)
+		return store.ErrSlugTaken
+	}
+	s.ID = uuid.New()
+	f.created = s
+	f.slugs = append(f.slugs, s.Slug)
+	return nil
+}
+
+func (f *fakeStoreRepo) ListSlugs(ctx context.Context, base string) ([]string, error) {
+	return f.slugs, nil
+}
+
+func (f *fakeStoreRepo) GetMemberRole(ctx context.Context, storeID, userID uuid.UUID) (store.MemberRole, error) {
+	if userID == f.owner {
+		return store.MemberOwner, nil
+	}
+	return f.staff[userID], nil
+}
+
+func (f *fakeStoreRepo) UpdateMemberRole(ctx context.Context, storeID, userID uuid.UUID, role store.MemberRole) error {
+	if _, ok := f.staff[userID]; !ok {
+		return store.ErrMemberNotFound
+	}
+	f.staff[userID] = role
+	return nil
+}
+
+func (f *fakeStoreRepo) RemoveMember(ctx context.Context, storeID, userID uuid.UUID) error {
+	if _, ok := f.staff[userID]; !ok {
+		return store.ErrMemberNotFound
+	}
+	delete(f.staff, userID)
+	return nil
+}
+
+func (f *fakeStoreRepo) UpdateStoreDetails(ctx context.Context, storeID uuid.UUID, name, logo, location string) error {
+	return nil
+}
+
+func (f *fakeStoreRepo) UpdateServiceArea(ctx context.Context, storeID uuid.UUID, point *postgis.PointS, radiusM int) error {
+	if point != nil {
+		f.point = point
+	}
+	if radiusM != 0 {
+		f.radiusM = radiusM
+	}
+	return nil
+}
+
+func (f *fakeStoreRepo) SetPoint(ctx context.Context, storeID uuid.UUID, point *postgis.PointS) error {
+	f.point, f.pointSet = point, true
+	return nil
+}
+
+func (f *fakeStoreRepo) ListNearby(ctx context.Context, filter store.NearbyFilter) ([]*store.StoreSummary, error) {
+	f.nearby = &filter
+	return []*store.StoreSummary{}, nil
+}
+
+type fakeGeocoder struct{}
+
+func (fakeGeocoder) Geocode(ctx context.Context, query string) (*geocoding.Place, error) {
+	if query != "Westlands, Nairobi" {
+		return nil, geocoding.ErrorNoMatch
+	}
+	return &geocoding.Place{Name: "Westlands", Lat: -1.2676, Lng: 36.8108}, nil
+}
+
+func TestCreateStore_RecordsServiceArea(t *testing.T) {
+	repo := &fakeStoreRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	ctx := context.Background()
+
+	s := &store.Store{Name: " Mama Mboga ", Location: "Westlands, Nairobi"}
+	require.NoError(t, uc.CreateStore(ctx, s))
+	require.Equal(t, "mama mboga", repo.created.NameNormalized)
+	require.Equal(t, store.DefaultServiceRadiusM, repo.created.ServiceRadiusM)
+	require.Equal(t, &postgis.PointS{SRID: 4326, X: 36.8108, Y: -1.2676}, repo.created.Point)
+
+	tooFar := &store.Store{Name: "Far", Location: "Westlands, Nairobi", ServiceRadiusM: store.MaxServiceRadiusM + 1}
+	require.ErrorIs(t, uc.CreateStore(ctx, tooFar), store.ErrInvalidServiceRadius)
+
+	// Stores are created without a point when their location isn't on the map
+	lost := &store.Store{Name: "Lost", Location: "Atlantis"}
+	require.NoError(t, uc.CreateStore(ctx, lost))
+	require.Nil(t, repo.created.Point)
+
+	nowhere := &store.Store{Name: "Nowhere"}
+	require.NoError(t, uc.CreateStore(ctx, nowhere))
+	require.Nil(t, repo.created.Point)
+}
+
+func TestCreateStore_UniqueSlug(t *testing.T) {
+	repo := &fakeStoreRepo{}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	ctx := context.Background()
+
+	create := func(name string) (*store.Store, error) {
+		s := &store.Store{Name: name, Location: "Westlands, Nairobi"}
+		return s, uc.CreateStore(ctx, s)
+	}
+
+	first, err := create("Duka  la Mama")
+	require.NoError(t, err)
+	require.Equal(t, "duka-la-mama", first.Slug)
+
+	second, err := create("Duka la Mama")
+	require.NoError(t, err)
+	require.Equal(t, "duka-la-mama-2", second.Slug)
+
+	repo.racedSlugs = 1
+	third, err := create("DUKA LA MAMA")
+	require.NoError(t, err)
+	require.Equal(t, "duka-la-mama-4", third.Slug, "-3 was taken in the meantime")
+
+	repo.racedSlugs = maxSlugAttempts
+	_, err = create("Duka la Mama")
+	require.ErrorIs(t, err, store.ErrSlugTaken)
+
+	reserved, err := create("Nearby")
+	require.NoError(t, err)
+	require.Equal(t, "nearby-2", reserved.Slug)
+
+	unnamed, err := create("???")
+	require.NoError(t, err)
+	require.Equal(t, "store", unnamed.Slug)
+}
+
+func TestNextSlug(t *testing.T) {
+	require.Equal(t, "duka", nextSlug("duka", nil))
+	require.Equal(t, "duka-2", nextSlug("duka", []string{"duka"}))
+	require.Equal(t, "duka-3", nextSlug("duka", []string{"duka", "duka-2", "duka-4"}))
+	require.Equal(t, "duka", nextSlug("duka", []string{"duka-2"}))
+}
+
+func TestUpdateStore_RelocatesStore(t *testing.T) {
+	owner := uuid.New()
+	repo := &fakeStoreRepo{owner: owner}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	ctx := context.Background()
+
+	require.NoError(t, uc.UpdateStore(ctx, uuid.New(), owner, &store.UpdateStoreRequest{Name: "Renamed"}))
+	require.False(t, repo.pointSet, "the service area is left alone")
+	require.Nil(t, repo.point)
+
+	require.NoError(t, uc.UpdateStore(ctx, uuid.New(), owner, &store.UpdateStoreRequest{Location: "Westlands, Nairobi", ServiceRadiusM: 3000}))
+	require.NotNil(t, repo.point)
+	require.Equal(t, 3000, repo.radiusM)
+
+	require.NoError(t, uc.UpdateStore(ctx, uuid.New(), owner, &store.UpdateStoreRequest{Location: "Atlantis"}))
+	require.Nil(t, repo.point, "the old point doesn't outlive the location")
+	require.Equal(t, 3000, repo.radiusM)
+
+	require.ErrorIs(t, uc.UpdateStore(ctx, uuid.New(), owner, &store.UpdateStoreRequest{ServiceRadiusM: -1}), store.ErrInvalidServiceRadius)
+	require.ErrorIs(t, uc.UpdateStore(ctx, uuid.New(), uuid.New(), &store.UpdateStoreRequest{Name: "Mine"}), store.ErrNotPermitted)
+}
+
+func TestUpdateStore_StaffRoles(t *testing.T) {
+	manager, editor := uuid.New(), uuid.New()
+	repo := &fakeStoreRepo{owner: uuid.New(), staff: map[uuid.UUID]store.MemberRole{
+		manager: store.MemberManager,
+		editor:  store.MemberCatalogEditor,
+	}}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	ctx := context.Background()
+
+	require.NoError(t, uc.UpdateStore(ctx, uuid.New(), manager, &store.UpdateStoreRequest{Name: "Renamed"}))
+	require.ErrorIs(t, uc.UpdateStore(ctx, uuid.New(), editor, &store.UpdateStoreRequest{Name: "Renamed"}), store.ErrNotPermitted)
+
+	_, err := uc.DeleteStore(ctx, uuid.New(), manager)
+	require.ErrorIs(t, err, store.ErrNotOwner, "only owners delete stores")
+}
+
+func TestMembers(t *testing.T) {
+	owner, manager, editor := uuid.New(), uuid.New(), uuid.New()
+	repo := &fakeStoreRepo{owner: owner, staff: map[uuid.UUID]store.MemberRole{
+		manager: store.MemberManager,
+		editor:  store.MemberCatalogEditor,
+	}}
+	uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+	ctx := context.Background()
+	storeID := uuid.New()
+
+	require.NoError(t, uc.UpdateMemberRole(ctx, storeID, owner, editor, store.MemberFulfilment))
+	require.Equal(t, store.MemberFulfilment, repo.staff[editor])
+
+	require.ErrorIs(t, uc.UpdateMemberRole(ctx, storeID, owner, editor, store.MemberOwner), store.ErrInvalidMemberRole)
+	require.ErrorIs(t, uc.UpdateMemberRole(ctx, storeID, manager, editor, store.MemberManager), store.ErrNotPermitted, "managers don't manage staff")
+	require.ErrorIs(t, uc.UpdateMemberRole(ctx, storeID, owner, uuid.New(), store.MemberManager), store.ErrMemberNotFound)
+
+	require.ErrorIs(t, uc.RemoveMember(ctx, storeID, editor, manager), store.ErrNotPermitted)
+	require.NoError(t, uc.RemoveMember(ctx, storeID, owner, manager))
+	require.NotContains(t, repo.staff, manager)
+}
+
+func TestListNearbyStores(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  store.NearbyFilter
+		radiusM int
+		err     error
+	}{
+		{"default radius", store.NearbyFilter{Lat: -1.28, Lng: 36.82}, store.DefaultNearbyRadiusM, nil},
+		{"own radius", store.NearbyFilter{Lat: -1.28, Lng: 36.82, RadiusM: 1500}, 1500, nil},
+		{"radius too large", store.NearbyFilter{Lat: -1.28, Lng: 36.82, RadiusM: store.MaxNearbyRadiusM + 1}, 0, store.ErrInvalidSearchRadius},
+		{"negative radius", store.NearbyFilter{Lat: -1.28, Lng: 36.82, RadiusM: -5}, 0, store.ErrInvalidSearchRadius},
+		{"unset coordinates", store.NearbyFilter{}, 0, store.ErrInvalidCoordinates},
+		{"off the globe", store.NearbyFilter{Lat: 91, Lng: 36.82}, 0, store.ErrInvalidCoordinates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeStoreRepo{}
+			uc := NewUseCase(repo, fakeTxManager{}, fakeGeocoder{})
+
+			_, err := uc.ListNearbyStores(context.Background(), tt.filter)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				require.Nil(t, repo.nearby)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.radiusM, repo.nearby.RadiusM)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package storefront
+
+import (
+	"backend/internal/domain/storefront"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// UseCase serves the public, unauthenticated pages of stores.
+type UseCase struct {
+	repo     storefront.Repository
+	products storefront.ProductReader
+}
+
+// NewUseCase creates a new storefront UseCase.
+func NewUseCase(repo storefront.Repository, products storefront.ProductReader) *UseCase {
+	return &UseCase{repo: repo, products: products}
+}
+
+// GetStore returns the public page of the store with the given slug.
+func (uc *UseCase) GetStore(ctx context.Context, slug string) (*storefront.Store, error) {
+	return uc.repo.GetStoreBySlug(ctx, strings.ToLower(strings.TrimSpace(slug)))
+}
+
+// ListProducts returns a page of the products of a store. A limit outside
+// 1..MaxPageSize falls back to DefaultPageSize or is capped.
+func (uc *UseCase) ListProducts(ctx context.Context, slug string, limit, offset int) (*storefront.ProductPage, error) {
+	s, err := uc.GetStore(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = storefront.DefaultPageSize
+	}
+	if limit > storefront.MaxPageSize {
+		limit = storefront.MaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	products, total, err := uc.repo.ListProducts(ctx, s.ID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list store products: %w", err)
+	}
+
+	return &storefront.ProductPage{Products: products, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// GetProduct returns a product of the store with its variants and options.
+// Products of other stores are reported as not found.
+func (uc *UseCase) GetProduct(ctx context.Context, slug string, productID uuid.UUID) (*storefront.ProductDetail, error) {
+	s, err := uc.GetStore(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := uc.products.GetFullProductByID(ctx, productID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storefront.ErrorProductNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get product: %w", err)
+	}
+	if p.StoreID != s.ID {
+		return nil, storefront.ErrorProductNotFound
+	}
+
+	return storefront.NewProductDetail(p), nil
+}
+
+// GetRatingSummary returns how customers rated the orders of a store.
+func (uc *UseCase) GetRatingSummary(ctx context.Context, slug string) (*storefront.RatingSummary, error) {
+	s, err := uc.GetStore(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return uc.repo.GetRatingSummary(ctx, s.ID)
+}
@@ -0,0 +1,123 @@
+package storefront
+
+import (
+	"backend/internal/domain/product"
+	"backend/internal/domain/storefront"
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	stores map[string]*storefront.Store
+
+	limit, offset int
+}
+
+func (f *fakeRepo) GetStoreBySlug(ctx context.Context, slug string) (*storefront.Store, error) {
+	s, ok := f.stores[slug]
+	if !ok {
+		return nil, storefront.ErrorStoreNotFound
+	}
+	return s, nil
+}
+
+func (f *fakeRepo) ListProducts(ctx context.Context, storeID uuid.UUID, limit, offset int) ([]storefront.Product, int, error) {
+	f.limit, f.offset = limit, offset
+	return []storefront.Product{}, 0, nil
+}
+
+func (f *fakeRepo) GetRatingSummary(ctx context.Context, storeID uuid.UUID) (*storefront.RatingSummary, error) {
+	return storefront.SummarizeRatings(map[int]int{5: 1}), nil
+}
+
+type fakeProducts map[uuid.UUID]*product.Product
+
+func (f fakeProducts) GetFullProductByID(ctx context.Context, id uuid.UUID) (*product.Product, error) {
+	p, ok := f[id]
+	if !ok {
+		return nil, fmt.Errorf("get base product: %w", sql.ErrNoRows)
+	}
+	return p, nil
+}
+
+func newUseCase() (*UseCase, *fakeRepo, *storefront.Store, fakeProducts) {
+	s := &storefront.Store{ID: uuid.New(), Slug: "mama-mboga", Name: "Mama Mboga"}
+	repo := &fakeRepo{stores: map[string]*storefront.Store{s.Slug: s}}
+	products := fakeProducts{}
+	return NewUseCase(repo, products), repo, s, products
+}
+
+func TestGetStore(t *testing.T) {
+	uc, _, s, _ := newUseCase()
+
+	got, err := uc.GetStore(context.Background(), " Mama-Mboga ")
+	require.NoError(t, err)
+	require.Equal(t, s.ID, got.ID)
+
+	_, err = uc.GetStore(context.Background(), "nope")
+	require.ErrorIs(t, err, storefront.ErrorStoreNotFound)
+}
+
+func TestListProducts_Paging(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit, offset int
+		wantLimit     int
+		wantOffset    int
+	}{
+		{"defaults", 0, 0, storefront.DefaultPageSize, 0},
+		{"as asked", 5, 10, 5, 10},
+		{"capped", 1000, 0, storefront.MaxPageSize, 0},
+		{"negative", -1, -3, storefront.DefaultPageSize, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, repo, _, _ := newUseCase()
+
+			page, err := uc.ListProducts(context.Background(), "mama-mboga", tt.limit, tt.offset)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantLimit, repo.limit)
+			require.Equal(t, tt.wantOffset, repo.offset)
+			require.Equal(t, tt.wantLimit, page.Limit)
+			require.NotNil(t, page.Products)
+		})
+	}
+
+	uc, _, _, _ := newUseCase()
+	_, err := uc.ListProducts(context.Background(), "nope", 0, 0)
+	require.ErrorIs(t, err, storefront.ErrorStoreNotFound)
+}
+
+func TestGetProduct_OnlyFromThatStore(t *testing.T) {
+	uc, _, s, products := newUseCase()
+	ours := &product.Product{ID: uuid.New(), StoreID: s.ID, Name: "Sukuma wiki", Price: 30}
+	theirs := &product.Product{ID: uuid.New(), StoreID: uuid.New(), Name: "Mchele", Price: 200}
+	products[ours.ID], products[theirs.ID] = ours, theirs
+
+	d, err := uc.GetProduct(context.Background(), s.Slug, ours.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Sukuma wiki", d.Name)
+
+	_, err = uc.GetProduct(context.Background(), s.Slug, theirs.ID)
+	require.ErrorIs(t, err, storefront.ErrorProductNotFound)
+
+	_, err = uc.GetProduct(context.Background(), s.Slug, uuid.New())
+	require.ErrorIs(t, err, storefront.ErrorProductNotFound)
+}
+
+func TestGetRatingSummary(t *testing.T) {
+	uc, _, s, _ := newUseCase()
+
+	summary, err := uc.GetRatingSummary(context.Background(), s.Slug)
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.Count)
+
+	_, err = uc.GetRatingSummary(context.Background(), "nope")
+	require.ErrorIs(t, err, storefront.ErrorStoreNotFound)
+}
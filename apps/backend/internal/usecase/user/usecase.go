@@ -40,7 +40,7 @@ func (uc *UseCase) RegisterUser(ctx context.Context, u *domain.User) error {
 
 		// 2. insert user to DB
 		if err := uc.repo.Create(txCtx, u); err != nil {
-			return fmt.Errorf("%w", err)
+			return fmt.Errorf("could not create user: %w", err)
 		}
 
 		// 3. if role is driver, insert into drivers table
@@ -141,20 +141,24 @@ func (uc *UseCase) UpdateStatus(ctx context.Context, id uuid.UUID, req *domain.U
 	})
 }
 
-// PATCH method for user details
+// UpdateUser applies a partial update to a user's name and contact details.
 func (uc *UseCase) UpdateUser(ctx context.Context, userID uuid.UUID, req *domain.UpdateUserRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
 	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
 		user, err := uc.repo.GetByID(txCtx, userID)
 		if err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
-		if err := uc.repo.UpdateColumn(txCtx, userID, req.Column, req.Value); err != nil {
+		if err := uc.repo.Update(txCtx, userID, req); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
 		go func() {
-			msg := fmt.Sprintf("ℹ️ Your account column '%s' was updated.", req.Column)
+			msg := fmt.Sprintf("ℹ️ Your account %s was updated.", strings.Join(req.Fields(), ", "))
 			_ = uc.notify(ctx, user.ID, msg)
 		}()
 
@@ -162,31 +166,9 @@ func (uc *UseCase) UpdateUser(ctx context.Context, userID uuid.UUID, req *domain
 	})
 }
 
-func (uc *UseCase) ChangePassword(ctx context.Context, userID uuid.UUID, req *domain.ChangePasswordRequest) error {
-	return uc.txManager.Do(ctx, func(txCtx context.Context) error {
-		user, err := uc.repo.GetByID(txCtx, userID)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
-
-		// 1. verify old password
-		if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)) != nil {
-			return domain.ErrInvalidCurrentPassword
-		}
-
-		// 2. hash new password
-		hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
-
-		// 3. save
-		if err := uc.repo.UpdateColumn(txCtx, userID, "password_hash", string(hashed)); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-
-		return nil
-	})
+// RecordLogin stamps the time of a user's last sign-in.
+func (uc *UseCase) RecordLogin(ctx context.Context, userID uuid.UUID) error {
+	return uc.repo.UpdateLastLogin(ctx, userID, time.Now())
 }
 
 func (uc *UseCase) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
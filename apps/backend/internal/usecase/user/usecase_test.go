@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
@@ -138,13 +139,19 @@ func (f *fakeUserRepo) GetByID(context.Context, uuid.UUID) (*user.User, error) {
 func (f *fakeUserRepo) GetByEmail(context.Context, string) (*user.User, error) {
 	return nil, nil
 }
+func (f *fakeUserRepo) GetCustomerByVerifiedPhone(context.Context, string) (*user.User, error) {
+	return nil, nil
+}
 func (f *fakeUserRepo) List(context.Context) ([]*user.User, error) {
 	return nil, nil
 }
 func (f *fakeUserRepo) GetAllCustomers(context.Context) ([]user.AllCustomers, error) {
 	return nil, nil
 }
-func (f *fakeUserRepo) UpdateColumn(context.Context, uuid.UUID, string, any) error {
+func (f *fakeUserRepo) Update(context.Context, uuid.UUID, *user.UpdateUserRequest) error {
+	return nil
+}
+func (f *fakeUserRepo) UpdateLastLogin(context.Context, uuid.UUID, time.Time) error {
 	return nil
 }
 func (f *fakeUserRepo) UpdateUserStatus(context.Context, uuid.UUID, user.UserStatus) error {
@@ -158,4 +165,10 @@ func (f *fakeUserRepo) UpdateUserProfile(context.Context, uuid.UUID, string, str
 }
 func (f *fakeUserRepo) Delete(context.Context, uuid.UUID) error {
 	return nil
+}
+func (f *fakeUserRepo) UpdatePassword(context.Context, uuid.UUID, string) error {
+	return nil
+}
+func (f *fakeUserRepo) MarkEmailVerified(context.Context, uuid.UUID) error {
+	return nil
 }
\ No newline at end of file
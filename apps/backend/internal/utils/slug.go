@@ -5,14 +5,24 @@ import (
 	"unicode"
 )
 
+// GenerateSlug lowercases input, turns whitespace and dashes into single
+// dashes and drops everything else that is not a letter or a digit, e.g.
+// "Kevin's  Electronics" becomes "kevins-electronics".
 func GenerateSlug(input string) string {
 	slug := strings.ToLower(strings.TrimSpace(input))
-	slug = strings.ReplaceAll(slug, " ", "-")
 
 	var cleaned strings.Builder
+	dash := false
 	for _, r := range slug {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && cleaned.Len() > 0 {
+				cleaned.WriteRune('-')
+			}
+			dash = false
 			cleaned.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			dash = true
 		}
 	}
 	return cleaned.String()
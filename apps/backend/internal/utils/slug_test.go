@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSlug(t *testing.T) {
+	tests := map[string]string{
+		"Mama Mboga":              "mama-mboga",
+		"  Kevin's  Electronics ": "kevins-electronics",
+		"Duka la Wanjiru - Thika": "duka-la-wanjiru-thika",
+		"-- Café 24/7 --":         "café-247",
+		"!!!":                     "",
+	}
+	for in, want := range tests {
+		require.Equal(t, want, GenerateSlug(in), in)
+	}
+}
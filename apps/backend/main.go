@@ -1,30 +1,51 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 
 	"backend/handlers"
+	addressadapter "backend/internal/adapters/address"
+	authadapter "backend/internal/adapters/auth"
+	cartadapter "backend/internal/adapters/cart"
 	deliveryadapter "backend/internal/adapters/delivery"
 	driveradapter "backend/internal/adapters/driver"
 	notificationadapter "backend/internal/adapters/notification"
 	orderadapter "backend/internal/adapters/order"
+	paymentadapter "backend/internal/adapters/payment"
+	pricingadapter "backend/internal/adapters/pricing"
 	productadapter "backend/internal/adapters/product"
+	promotionadapter "backend/internal/adapters/promotion"
 	storeadapter "backend/internal/adapters/store"
+	storefrontadapter "backend/internal/adapters/storefront"
 	useradapter "backend/internal/adapters/user"
+	"backend/internal/domain/auth"
+	"backend/internal/domain/geocoding"
 	"backend/internal/domain/mpesa"
+	"backend/internal/domain/notification"
 	"backend/internal/repository/postgres"
 	"backend/internal/router"
+	addressUsecase "backend/internal/usecase/address"
+	apiKeyUsecase "backend/internal/usecase/apikey"
+	authUsecase "backend/internal/usecase/auth"
+	authzUsecase "backend/internal/usecase/authz"
+	cartUsecase "backend/internal/usecase/cart"
 	deliveryUsecase "backend/internal/usecase/delivery"
 	driverUsecase "backend/internal/usecase/driver"
 	feedbackUsecase "backend/internal/usecase/feedback"
+	geocodingUsecase "backend/internal/usecase/geocoding"
 	inviteUsecase "backend/internal/usecase/invite"
 	notificationUsecase "backend/internal/usecase/notification"
 	orderUsecase "backend/internal/usecase/order"
 	paymentUsecase "backend/internal/usecase/payment"
+	pricingUsecase "backend/internal/usecase/pricing"
 	productUsecase "backend/internal/usecase/product"
+	promotionUsecase "backend/internal/usecase/promotion"
 	storeUsecase "backend/internal/usecase/store"
+	storefrontUsecase "backend/internal/usecase/storefront"
 	userUsecase "backend/internal/usecase/user"
 
 	"backend/internal/application"
@@ -58,6 +79,19 @@ func main() {
 		log.Fatal("PUBLIC_API_BASE_URL not set")
 	}
 
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		log.Fatal("JWT_SECRET not set")
+	}
+
+	reservationTTL := durationFromEnv("ORDER_RESERVATION_TTL", 30*time.Minute)
+	expiryInterval := durationFromEnv("ORDER_EXPIRY_INTERVAL", time.Minute)
+	taxRateBps := intFromEnv("ORDER_TAX_RATE_BPS", 0)
+	geocodingProvider := os.Getenv("GEOCODING_PROVIDER")
+	accessTokenTTL := durationFromEnv("ACCESS_TOKEN_TTL", auth.DefaultAccessTokenTTL)
+	refreshTokenTTL := durationFromEnv("REFRESH_TOKEN_TTL", auth.DefaultRefreshTokenTTL)
+	appBaseURL := os.Getenv("APP_BASE_URL")
+
 	db := waitForPostgres(dbUrl, 10, 5*time.Second)
 
 	txm := application.NewTxManager(db)
@@ -73,17 +107,46 @@ func main() {
 	inviteRepo := postgres.NewInviteRepository(db)
 	storeRepo := postgres.NewStoreRepository(db)
 	productRepo := postgres.NewProductRepository(db)
+	idempotencyRepo := postgres.NewIdempotencyRepository(db)
+	pricingRepo := postgres.NewPricingRepository(db)
+	promotionRepo := postgres.NewPromotionRepository(db)
+	cartRepo := postgres.NewCartRepository(db)
+	addressRepo := postgres.NewAddressRepository(db)
+	geocodingRepo := postgres.NewGeocodingRepository(db)
+	storefrontRepo := postgres.NewStorefrontRepository(db)
+	authRepo := postgres.NewAuthRepository(db)
+	apiKeyRepo := postgres.NewAPIKeyRepository(db)
+
+	geocoder := newGeocoder(geocodingProvider, geocodingRepo)
+	mailer := newEmailSender()
+	sms := newSMSSender()
 
 	// Set up usecase
 	// Individual
-	inviteUC := inviteUsecase.NewUseCase(inviteRepo, txm)
 	driverUC := driverUsecase.NewUseCase(driverRepo, txm, notificationRepo)
+	paymentUC := paymentUsecase.NewUseCase(paymentRepo, txm, orderRepo)
+	pricingUC := pricingUsecase.NewUseCase(pricingRepo, storeRepo, txm, taxRateBps)
+	addressUC := addressUsecase.NewUseCase(addressRepo, txm, geocoder)
+	promotionUC := promotionUsecase.NewUseCase(promotionRepo, storeRepo, productRepo, txm)
 	userUC := userUsecase.NewUseCase(userRepo, driverUC, txm, notificationRepo)
-	orderUC := orderUsecase.NewUseCase(orderRepo, &useradapter.UseCaseAdapter{UseCase: userUC}, driverRepo, txm, notificationRepo, productRepo, storeRepo)
+	inviteUC := inviteUsecase.NewUseCase(inviteRepo, userUC, storeRepo, txm, mailer, appBaseURL)
+	orderUC := orderUsecase.NewUseCase(orderRepo, &useradapter.UseCaseAdapter{UseCase: userUC}, driverRepo, txm, notificationRepo, productRepo, storeRepo, &paymentadapter.UseCaseAdapter{UseCase: paymentUC}, &pricingadapter.UseCaseAdapter{UseCase: pricingUC}, &promotionadapter.UseCaseAdapter{UseCase: promotionUC}, &addressadapter.UseCaseAdapter{UseCase: addressUC}, geocoder)
 	deliveryUC := deliveryUsecase.NewUseCase(deliveryRepo, &orderadapter.UseCaseAdapter{UseCase: orderUC}, &driveradapter.UseCaseAdapter{UseCase: driverUC}, txm, notificationRepo)
+	orderUC.SetFulfilment(&deliveryadapter.UseCaseAdapter{UseCase: deliveryUC})
+	cartUC := cartUsecase.NewUseCase(cartRepo, productRepo, &orderadapter.UseCaseAdapter{UseCase: orderUC}, txm)
 	notificationUC := notificationUsecase.NewUseCase(notificationRepo, txm)
-	storeUC := storeUsecase.NewUseCase(storeRepo, txm)
+	storeUC := storeUsecase.NewUseCase(storeRepo, txm, geocoder)
 	productUC := productUsecase.NewUseCase(productRepo, txm)
+	storefrontUC := storefrontUsecase.NewUseCase(storefrontRepo, productRepo)
+	apiKeyUC := apiKeyUsecase.NewUseCase(apiKeyRepo, userRepo, storeRepo)
+	authzUC := authzUsecase.NewUseCase(storeRepo, productRepo, orderRepo, deliveryRepo, inviteRepo)
+	authUC := authUsecase.NewUseCase(authRepo, userRepo, txm, mailer, sms, auth.Config{
+		Secret:          []byte(jwtSecret),
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+		AppBaseURL:      appBaseURL,
+		TOTPIssuer:      os.Getenv("TOTP_ISSUER"),
+	})
 
 	// Combined cross-domain service
 	orderService := application.NewOrderService(
@@ -94,10 +157,18 @@ func main() {
 		&notificationadapter.UseCaseAdapter{UseCase: notificationUC},
 		&productadapter.UseCaseAdapter{UseCase: productUC},
 		&storeadapter.UseCaseAdapter{UseCase: storeUC},
+		&pricingadapter.UseCaseAdapter{UseCase: pricingUC},
+		&promotionadapter.UseCaseAdapter{UseCase: promotionUC},
+		&cartadapter.UseCaseAdapter{UseCase: cartUC},
+		&addressadapter.UseCaseAdapter{UseCase: addressUC},
+		&storefrontadapter.UseCaseAdapter{UseCase: storefrontUC},
+		&authadapter.UseCaseAdapter{UseCase: authUC},
 	)
 
+	// Release stock held by orders that were never paid
+	go orderService.RunOrderExpiry(context.Background(), reservationTTL, expiryInterval)
+
 	// Other usecases
-	paymentUC := paymentUsecase.NewUseCase(paymentRepo, txm)
 	feedbackUC := feedbackUsecase.NewUseCase(feedbackRepo, txm)
 
 	// Set up Handlers
@@ -111,6 +182,12 @@ func main() {
 	inviteHandler := handlers.NewInviteHandler(inviteUC)
 	storeHandler := handlers.NewStoreHandler(orderService)
 	productHandler := handlers.NewProductHandler(orderService)
+	pricingHandler := handlers.NewPricingHandler(orderService)
+	promotionHandler := handlers.NewPromotionHandler(orderService)
+	cartHandler := handlers.NewCartHandler(orderService)
+	addressHandler := handlers.NewAddressHandler(orderService)
+	storefrontHandler := handlers.NewStorefrontHandler(orderService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyUC)
 
 	// Start server
 	r := router.NewRouter(
@@ -126,6 +203,16 @@ func main() {
 		storeHandler,
 		productHandler,
 		db,
+		idempotencyRepo,
+		pricingHandler,
+		promotionHandler,
+		cartHandler,
+		addressHandler,
+		storefrontHandler,
+		apiKeyHandler,
+		authzUC,
+		authUC,
+		apiKeyUC,
 	)
 
 	log.Println("Server starting at :8080")
@@ -156,3 +243,75 @@ func waitForPostgres(dsn string, maxRetries int, delay time.Duration) *sqlx.DB {
 	log.Fatalf("❌ Could not connect to Postgres after %d attempts: %v", maxRetries, err)
 	return nil
 }
+
+// durationFromEnv reads a duration such as "30m" from the environment,
+// falling back to def when the variable is unset
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s must be a positive duration, got %q", key, v)
+	}
+	return d
+}
+
+// intFromEnv reads an integer from the environment, falling back to def
+// when the variable is unset
+func intFromEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a non-negative integer, got %q", key, v)
+	}
+	return n
+}
+
+// newGeocoder builds the geocoding provider named by GEOCODING_PROVIDER,
+// behind the Postgres cache. The offline gazetteer is the default.
+func newGeocoder(name string, repo geocoding.Repository) geocoding.Provider {
+	var provider geocoding.Provider
+	switch name {
+	case "", "gazetteer":
+		provider = geocodingUsecase.NewGazetteer(repo)
+	default:
+		log.Fatalf("GEOCODING_PROVIDER %q is not supported", name)
+	}
+	return geocodingUsecase.NewCached(provider, repo)
+}
+
+// newEmailSender delivers through the SMTP relay named by SMTP_HOST, or only
+// logs emails when none is set.
+func newEmailSender() notification.EmailSender {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Println("SMTP_HOST not set, emails are logged instead of sent")
+		return notificationUsecase.LogSender{}
+	}
+
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		log.Fatal("SMTP_FROM not set")
+	}
+	return notificationUsecase.NewSMTPSender(notificationUsecase.SMTPConfig{
+		Host:     host,
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     from,
+	})
+}
+
+// newSMSSender returns the sender of texts like sign-in codes. Until an SMS
+// gateway is integrated texts are only logged, which suits dev runs.
+func newSMSSender() notification.SMSSender {
+	log.Println("no SMS gateway configured, texts are logged instead of sent")
+	return notificationUsecase.LogSMSSender{}
+}
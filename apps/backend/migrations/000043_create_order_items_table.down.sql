@@ -0,0 +1,30 @@
+ALTER TABLE orders
+ALTER COLUMN total TYPE NUMERIC(10,2);
+
+ALTER TABLE orders
+ADD COLUMN product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+ADD COLUMN variant_id UUID NULL REFERENCES variants(id) ON DELETE SET NULL,
+ADD COLUMN quantity INTEGER CHECK (quantity > 0),
+ADD COLUMN unit_price NUMERIC(10,2),
+ADD COLUMN product_name TEXT,
+ADD COLUMN variant_name TEXT,
+ADD COLUMN image_url TEXT;
+
+-- Restore the first line of every order onto the header
+UPDATE orders o
+SET product_id = oi.product_id,
+    variant_id = oi.variant_id,
+    quantity = oi.quantity,
+    unit_price = oi.unit_price,
+    product_name = oi.product_name,
+    variant_name = oi.variant_name,
+    image_url = oi.image_url
+FROM (
+    SELECT DISTINCT ON (order_id) *
+    FROM order_items
+    ORDER BY order_id, created_at
+) oi
+WHERE oi.order_id = o.id;
+
+DROP INDEX IF EXISTS idx_order_items_order_id;
+DROP TABLE IF EXISTS order_items;
@@ -0,0 +1,42 @@
+-- Line items for the order aggregate: one order, many lines
+CREATE TABLE order_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID NULL REFERENCES variants(id) ON DELETE SET NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price BIGINT NOT NULL CHECK (unit_price >= 0), -- cents
+    total BIGINT NOT NULL CHECK (total >= 0),           -- cents
+    product_name TEXT NOT NULL,
+    variant_name TEXT,
+    image_url TEXT,
+    created_at TIMESTAMPTZ DEFAULT now()
+);
+
+CREATE INDEX idx_order_items_order_id ON order_items(order_id);
+
+-- Move existing single-line orders into order_items
+INSERT INTO order_items (
+    order_id, product_id, variant_id, quantity,
+    unit_price, total, product_name, variant_name, image_url
+)
+SELECT
+    id, product_id, variant_id, quantity,
+    unit_price::BIGINT, total::BIGINT, product_name, variant_name, image_url
+FROM orders
+WHERE product_id IS NOT NULL;
+
+-- Orders now only hold the header
+ALTER TABLE orders
+DROP COLUMN product_id,
+DROP COLUMN variant_id,
+DROP COLUMN quantity,
+DROP COLUMN unit_price,
+DROP COLUMN product_name,
+DROP COLUMN variant_name,
+DROP COLUMN image_url;
+
+-- Order total is the sum of its lines, stored in cents like payments.amount
+ALTER TABLE orders
+ALTER COLUMN total TYPE BIGINT
+USING total::BIGINT;
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_deliveries_order_id;
+
+ALTER TABLE drivers
+DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE deliveries
+DROP COLUMN IF EXISTS updated_at;
+
+ALTER TABLE orders
+DROP CONSTRAINT IF EXISTS orders_status_check;
+
+UPDATE orders SET status = 'in-transit' WHERE status = 'in_transit';
+
+ALTER TABLE orders
+ADD CONSTRAINT orders_status_check
+CHECK (status IN ('pending', 'assigned', 'in-transit', 'delivered', 'cancelled'));
@@ -0,0 +1,18 @@
+-- Align the orders status check with the status values used by the code
+ALTER TABLE orders
+DROP CONSTRAINT IF EXISTS orders_status_check;
+
+UPDATE orders SET status = 'in_transit' WHERE status = 'in-transit';
+
+ALTER TABLE orders
+ADD CONSTRAINT orders_status_check
+CHECK (status IN ('pending', 'assigned', 'in_transit', 'delivered', 'cancelled'));
+
+-- Status transitions touch deliveries and drivers, whose updates set updated_at
+ALTER TABLE deliveries
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+ALTER TABLE drivers
+ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_order_id ON deliveries(order_id);
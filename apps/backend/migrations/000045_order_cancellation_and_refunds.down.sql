@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS refunds;
+
+ALTER TABLE orders
+DROP COLUMN IF EXISTS cancelled_at,
+DROP COLUMN IF EXISTS cancel_reason;
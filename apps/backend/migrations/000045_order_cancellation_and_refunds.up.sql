@@ -0,0 +1,18 @@
+ALTER TABLE orders
+ADD COLUMN cancel_reason TEXT,
+ADD COLUMN cancelled_at TIMESTAMPTZ;
+
+-- Refund requests raised against completed payments, e.g. on cancellation
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL UNIQUE REFERENCES payments(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount BIGINT NOT NULL CHECK (amount >= 0), -- cents
+    currency VARCHAR(3) NOT NULL DEFAULT 'KES',
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed', 'rejected')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    processed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_refunds_order_id ON refunds(order_id);
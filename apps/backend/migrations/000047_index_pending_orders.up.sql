@@ -0,0 +1,2 @@
+-- Lets the expiry sweep find stale pending orders without scanning the table
+CREATE INDEX IF NOT EXISTS idx_orders_pending_created_at ON orders(created_at) WHERE status = 'pending';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_user_created_at_id;
+DROP INDEX IF EXISTS idx_orders_merchant_created_at_id;
+DROP INDEX IF EXISTS idx_orders_store_created_at_id;
+DROP INDEX IF EXISTS idx_orders_created_at_id;
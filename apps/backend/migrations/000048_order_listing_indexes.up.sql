@@ -0,0 +1,5 @@
+-- Keyset pagination indexes for the filtered order listing
+CREATE INDEX IF NOT EXISTS idx_orders_created_at_id ON orders(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_store_created_at_id ON orders(store_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_merchant_created_at_id ON orders(merchant_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_created_at_id ON orders(user_id, created_at, id);
@@ -0,0 +1,15 @@
+-- Append-only audit history of every change made to an order
+CREATE TABLE order_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('status_changed', 'driver_assigned', 'payment', 'refund', 'edited')),
+    field TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    note TEXT,
+    actor_id UUID,
+    actor_role TEXT NOT NULL CHECK (actor_role IN ('customer', 'merchant', 'driver', 'admin', 'system')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_order_events_order_id_created_at ON order_events(order_id, created_at);
@@ -0,0 +1,7 @@
+ALTER TABLE orders
+DROP COLUMN IF EXISTS tax,
+DROP COLUMN IF EXISTS discount,
+DROP COLUMN IF EXISTS delivery_fee,
+DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS delivery_tariff_bands;
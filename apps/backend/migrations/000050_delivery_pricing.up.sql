@@ -0,0 +1,27 @@
+-- Distance bands for the delivery fee. Rows without a store form the global
+-- tariff, used by stores that have none of their own.
+CREATE TABLE delivery_tariff_bands (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    store_id UUID REFERENCES stores(id) ON DELETE CASCADE,
+    max_distance_m INT NOT NULL CHECK (max_distance_m > 0),
+    fee BIGINT NOT NULL CHECK (fee >= 0), -- cents
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_delivery_tariff_bands_store_distance
+    ON delivery_tariff_bands (COALESCE(store_id, '00000000-0000-0000-0000-000000000000'), max_distance_m);
+
+INSERT INTO delivery_tariff_bands (store_id, max_distance_m, fee) VALUES
+    (NULL, 3000, 15000),
+    (NULL, 7000, 25000),
+    (NULL, 15000, 40000),
+    (NULL, 30000, 70000);
+
+-- Pricing breakdown; total becomes the grand total
+ALTER TABLE orders
+ADD COLUMN subtotal BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN delivery_fee BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN discount BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN tax BIGINT NOT NULL DEFAULT 0;
+
+UPDATE orders SET subtotal = total;
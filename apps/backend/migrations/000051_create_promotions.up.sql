@@ -0,0 +1,36 @@
+-- Discount codes. Without a store a promotion is platform-wide; a non-empty
+-- product_ids limits the discount to those products.
+CREATE TABLE promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    store_id UUID REFERENCES stores(id) ON DELETE CASCADE,
+    product_ids UUID[] NOT NULL DEFAULT '{}',
+    type TEXT NOT NULL CHECK (type IN ('percentage', 'fixed', 'free_delivery')),
+    value BIGINT NOT NULL DEFAULT 0 CHECK (value >= 0),
+    min_subtotal BIGINT NOT NULL DEFAULT 0 CHECK (min_subtotal >= 0), -- cents
+    max_redemptions INT CHECK (max_redemptions > 0),
+    max_per_customer INT CHECK (max_per_customer > 0),
+    redemption_count INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (type <> 'percentage' OR value BETWEEN 1 AND 100),
+    CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at)
+);
+
+CREATE INDEX idx_promotions_store_id ON promotions(store_id);
+
+CREATE TABLE promotion_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL REFERENCES users(id),
+    amount BIGINT NOT NULL CHECK (amount >= 0), -- cents
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_promotion_redemptions_promotion_customer ON promotion_redemptions(promotion_id, customer_id);
+
+ALTER TABLE orders ADD COLUMN promo_code TEXT;
@@ -0,0 +1,24 @@
+-- One cart per customer per store. Lines keep product, variant and quantity
+-- only; prices and stock are read from the catalog whenever a cart is shown.
+CREATE TABLE carts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    customer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (customer_id, store_id)
+);
+
+CREATE TABLE cart_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cart_id UUID NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id UUID REFERENCES variants(id) ON DELETE CASCADE,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- A product (or variant) appears once per cart; adding it again adds to the line
+CREATE UNIQUE INDEX idx_cart_items_line ON cart_items (
+    cart_id, product_id, COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid)
+);
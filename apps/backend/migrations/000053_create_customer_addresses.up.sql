@@ -0,0 +1,18 @@
+-- Saved delivery locations of customers; at most one default per customer.
+CREATE TABLE customer_addresses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label TEXT NOT NULL CHECK (char_length(label) BETWEEN 1 AND 50),
+    address TEXT NOT NULL,
+    point GEOGRAPHY(POINT, 4326) NOT NULL,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_customer_addresses_user_id ON customer_addresses(user_id);
+CREATE UNIQUE INDEX idx_customer_addresses_one_default ON customer_addresses(user_id) WHERE is_default;
+
+-- Orders placed to a saved address keep a reference to it; the address and
+-- point are still copied onto the order so later edits don't change it.
+ALTER TABLE orders ADD COLUMN address_id UUID REFERENCES customer_addresses(id) ON DELETE SET NULL;
@@ -0,0 +1,163 @@
+-- Offline gazetteer of Kenyan towns and estates used by the built-in
+-- geocoder, so dev and test runs resolve places without network access.
+-- Coordinates are approximate centres.
+CREATE TABLE gazetteer (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    name_normalized TEXT GENERATED ALWAYS AS (
+        btrim(regexp_replace(regexp_replace(lower(name), '[^a-z0-9'' ]+', ' ', 'g'), '\s+', ' ', 'g'))
+    ) STORED,
+    kind TEXT NOT NULL CHECK (kind IN ('town', 'estate')),
+    town TEXT, -- parent town of an estate
+    county TEXT NOT NULL,
+    point GEOGRAPHY(POINT, 4326) NOT NULL,
+    CHECK ((kind = 'estate') = (town IS NOT NULL)),
+    UNIQUE (name, town)
+);
+
+CREATE INDEX idx_gazetteer_name_normalized ON gazetteer(name_normalized);
+CREATE INDEX idx_gazetteer_point ON gazetteer USING GIST(point);
+
+INSERT INTO gazetteer (name, kind, town, county, point) VALUES
+    ('Nairobi', 'town', NULL, 'Nairobi', ST_SetSRID(ST_MakePoint(36.8172, -1.2864), 4326)),
+    ('Mombasa', 'town', NULL, 'Mombasa', ST_SetSRID(ST_MakePoint(39.6682, -4.0435), 4326)),
+    ('Kisumu', 'town', NULL, 'Kisumu', ST_SetSRID(ST_MakePoint(34.7680, -0.0917), 4326)),
+    ('Nakuru', 'town', NULL, 'Nakuru', ST_SetSRID(ST_MakePoint(36.0800, -0.3031), 4326)),
+    ('Eldoret', 'town', NULL, 'Uasin Gishu', ST_SetSRID(ST_MakePoint(35.2698, 0.5143), 4326)),
+    ('Thika', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(37.0693, -1.0333), 4326)),
+    ('Malindi', 'town', NULL, 'Kilifi', ST_SetSRID(ST_MakePoint(40.1169, -3.2192), 4326)),
+    ('Kitale', 'town', NULL, 'Trans Nzoia', ST_SetSRID(ST_MakePoint(35.0062, 1.0157), 4326)),
+    ('Garissa', 'town', NULL, 'Garissa', ST_SetSRID(ST_MakePoint(39.6461, -0.4532), 4326)),
+    ('Kakamega', 'town', NULL, 'Kakamega', ST_SetSRID(ST_MakePoint(34.7519, 0.2827), 4326)),
+    ('Nyeri', 'town', NULL, 'Nyeri', ST_SetSRID(ST_MakePoint(36.9476, -0.4201), 4326)),
+    ('Machakos', 'town', NULL, 'Machakos', ST_SetSRID(ST_MakePoint(37.2634, -1.5177), 4326)),
+    ('Meru', 'town', NULL, 'Meru', ST_SetSRID(ST_MakePoint(37.6559, 0.0463), 4326)),
+    ('Kericho', 'town', NULL, 'Kericho', ST_SetSRID(ST_MakePoint(35.2863, -0.3689), 4326)),
+    ('Embu', 'town', NULL, 'Embu', ST_SetSRID(ST_MakePoint(37.4596, -0.5388), 4326)),
+    ('Naivasha', 'town', NULL, 'Nakuru', ST_SetSRID(ST_MakePoint(36.4333, -0.7167), 4326)),
+    ('Nanyuki', 'town', NULL, 'Laikipia', ST_SetSRID(ST_MakePoint(37.0667, 0.0167), 4326)),
+    ('Nyahururu', 'town', NULL, 'Laikipia', ST_SetSRID(ST_MakePoint(36.3636, 0.0380), 4326)),
+    ('Kiambu', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(36.8356, -1.1714), 4326)),
+    ('Ruiru', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(36.9609, -1.1466), 4326)),
+    ('Juja', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(37.0144, -1.1027), 4326)),
+    ('Kikuyu', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(36.6629, -1.2463), 4326)),
+    ('Limuru', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(36.6422, -1.1136), 4326)),
+    ('Ruaka', 'town', NULL, 'Kiambu', ST_SetSRID(ST_MakePoint(36.7770, -1.2048), 4326)),
+    ('Kitengela', 'town', NULL, 'Kajiado', ST_SetSRID(ST_MakePoint(36.9598, -1.4731), 4326)),
+    ('Ongata Rongai', 'town', NULL, 'Kajiado', ST_SetSRID(ST_MakePoint(36.7600, -1.3966), 4326)),
+    ('Ngong', 'town', NULL, 'Kajiado', ST_SetSRID(ST_MakePoint(36.6699, -1.3527), 4326)),
+    ('Kajiado', 'town', NULL, 'Kajiado', ST_SetSRID(ST_MakePoint(36.7768, -1.8524), 4326)),
+    ('Athi River', 'town', NULL, 'Machakos', ST_SetSRID(ST_MakePoint(36.9780, -1.4560), 4326)),
+    ('Syokimau', 'town', NULL, 'Machakos', ST_SetSRID(ST_MakePoint(36.9336, -1.3742), 4326)),
+    ('Kilifi', 'town', NULL, 'Kilifi', ST_SetSRID(ST_MakePoint(39.8499, -3.6305), 4326)),
+    ('Mtwapa', 'town', NULL, 'Kilifi', ST_SetSRID(ST_MakePoint(39.7450, -3.9400), 4326)),
+    ('Watamu', 'town', NULL, 'Kilifi', ST_SetSRID(ST_MakePoint(40.0240, -3.3540), 4326)),
+    ('Ukunda', 'town', NULL, 'Kwale', ST_SetSRID(ST_MakePoint(39.5661, -4.2875), 4326)),
+    ('Kwale', 'town', NULL, 'Kwale', ST_SetSRID(ST_MakePoint(39.4521, -4.1737), 4326)),
+    ('Lamu', 'town', NULL, 'Lamu', ST_SetSRID(ST_MakePoint(40.9020, -2.2717), 4326)),
+    ('Voi', 'town', NULL, 'Taita Taveta', ST_SetSRID(ST_MakePoint(38.5561, -3.3961), 4326)),
+    ('Bungoma', 'town', NULL, 'Bungoma', ST_SetSRID(ST_MakePoint(34.5606, 0.5635), 4326)),
+    ('Busia', 'town', NULL, 'Busia', ST_SetSRID(ST_MakePoint(34.1115, 0.4608), 4326)),
+    ('Siaya', 'town', NULL, 'Siaya', ST_SetSRID(ST_MakePoint(34.2881, 0.0607), 4326)),
+    ('Homa Bay', 'town', NULL, 'Homa Bay', ST_SetSRID(ST_MakePoint(34.4571, -0.5273), 4326)),
+    ('Migori', 'town', NULL, 'Migori', ST_SetSRID(ST_MakePoint(34.4731, -1.0634), 4326)),
+    ('Kisii', 'town', NULL, 'Kisii', ST_SetSRID(ST_MakePoint(34.7667, -0.6817), 4326)),
+    ('Bomet', 'town', NULL, 'Bomet', ST_SetSRID(ST_MakePoint(35.3416, -0.7813), 4326)),
+    ('Narok', 'town', NULL, 'Narok', ST_SetSRID(ST_MakePoint(35.8711, -1.0876), 4326)),
+    ('Murang''a', 'town', NULL, 'Murang''a', ST_SetSRID(ST_MakePoint(37.1526, -0.7210), 4326)),
+    ('Kerugoya', 'town', NULL, 'Kirinyaga', ST_SetSRID(ST_MakePoint(37.2803, -0.4989), 4326)),
+    ('Karatina', 'town', NULL, 'Nyeri', ST_SetSRID(ST_MakePoint(37.1333, -0.4833), 4326)),
+    ('Chuka', 'town', NULL, 'Tharaka Nithi', ST_SetSRID(ST_MakePoint(37.6500, -0.3333), 4326)),
+    ('Isiolo', 'town', NULL, 'Isiolo', ST_SetSRID(ST_MakePoint(37.5822, 0.3546), 4326)),
+    ('Marsabit', 'town', NULL, 'Marsabit', ST_SetSRID(ST_MakePoint(37.9899, 2.3284), 4326)),
+    ('Lodwar', 'town', NULL, 'Turkana', ST_SetSRID(ST_MakePoint(35.5973, 3.1191), 4326)),
+    ('Wajir', 'town', NULL, 'Wajir', ST_SetSRID(ST_MakePoint(40.0573, 1.7471), 4326)),
+    ('Mandera', 'town', NULL, 'Mandera', ST_SetSRID(ST_MakePoint(41.8670, 3.9366), 4326)),
+    ('Kitui', 'town', NULL, 'Kitui', ST_SetSRID(ST_MakePoint(38.0106, -1.3670), 4326)),
+    ('Wote', 'town', NULL, 'Makueni', ST_SetSRID(ST_MakePoint(37.6333, -1.7833), 4326)),
+    ('Kapsabet', 'town', NULL, 'Nandi', ST_SetSRID(ST_MakePoint(35.1050, 0.2039), 4326)),
+    ('Iten', 'town', NULL, 'Elgeyo Marakwet', ST_SetSRID(ST_MakePoint(35.5081, 0.6703), 4326)),
+    ('Kabarnet', 'town', NULL, 'Baringo', ST_SetSRID(ST_MakePoint(35.7430, 0.4919), 4326)),
+    ('Eldama Ravine', 'town', NULL, 'Baringo', ST_SetSRID(ST_MakePoint(35.7167, 0.0500), 4326)),
+    ('Maralal', 'town', NULL, 'Samburu', ST_SetSRID(ST_MakePoint(36.6981, 1.0968), 4326)),
+    ('Molo', 'town', NULL, 'Nakuru', ST_SetSRID(ST_MakePoint(35.7322, -0.2486), 4326));
+
+INSERT INTO gazetteer (name, kind, town, county, point) VALUES
+    ('CBD', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8233, -1.2841), 4326)),
+    ('Westlands', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8108, -1.2676), 4326)),
+    ('Parklands', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8196, -1.2623), 4326)),
+    ('Kilimani', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7856, -1.2906), 4326)),
+    ('Kileleshwa', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7824, -1.2807), 4326)),
+    ('Lavington', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7687, -1.2795), 4326)),
+    ('Hurlingham', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7990, -1.2960), 4326)),
+    ('Upper Hill', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8143, -1.2986), 4326)),
+    ('Karen', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7073, -1.3194), 4326)),
+    ('Langata', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7620, -1.3365), 4326)),
+    ('South B', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8378, -1.3089), 4326)),
+    ('South C', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8262, -1.3199), 4326)),
+    ('Madaraka', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8180, -1.3060), 4326)),
+    ('Nairobi West', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8200, -1.3080), 4326)),
+    ('Industrial Area', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8520, -1.3050), 4326)),
+    ('Embakasi', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8960, -1.3195), 4326)),
+    ('Pipeline', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.9033, -1.3172), 4326)),
+    ('Utawala', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.9610, -1.2890), 4326)),
+    ('Eastleigh', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8505, -1.2741), 4326)),
+    ('Ngara', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8269, -1.2716), 4326)),
+    ('Pangani', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8370, -1.2704), 4326)),
+    ('Buruburu', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8753, -1.2843), 4326)),
+    ('Donholm', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8880, -1.2962), 4326)),
+    ('Umoja', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8987, -1.2822), 4326)),
+    ('Kayole', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.9141, -1.2757), 4326)),
+    ('Mathare', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8590, -1.2600), 4326)),
+    ('Kariobangi', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8850, -1.2520), 4326)),
+    ('Kasarani', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8987, -1.2217), 4326)),
+    ('Roysambu', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8868, -1.2186), 4326)),
+    ('Zimmerman', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8954, -1.2091), 4326)),
+    ('Githurai', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.9149, -1.2007), 4326)),
+    ('Kahawa West', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.9030, -1.1864), 4326)),
+    ('Kahawa Sukari', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.9330, -1.1900), 4326)),
+    ('Muthaiga', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8323, -1.2473), 4326)),
+    ('Runda', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8072, -1.2176), 4326)),
+    ('Gigiri', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.8017, -1.2333), 4326)),
+    ('Spring Valley', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7917, -1.2583), 4326)),
+    ('Loresho', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7500, -1.2550), 4326)),
+    ('Kawangware', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7500, -1.2833), 4326)),
+    ('Dagoretti', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7407, -1.2987), 4326)),
+    ('Kibera', 'estate', 'Nairobi', 'Nairobi', ST_SetSRID(ST_MakePoint(36.7892, -1.3133), 4326)),
+    ('Nyali', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.7147, -4.0225), 4326)),
+    ('Bamburi', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.7230, -3.9980), 4326)),
+    ('Shanzu', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.7470, -3.9530), 4326)),
+    ('Kisauni', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.7050, -4.0060), 4326)),
+    ('Tudor', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.6850, -4.0430), 4326)),
+    ('Kizingo', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.6700, -4.0640), 4326)),
+    ('Old Town', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.6790, -4.0620), 4326)),
+    ('Likoni', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.6630, -4.0830), 4326)),
+    ('Changamwe', 'estate', 'Mombasa', 'Mombasa', ST_SetSRID(ST_MakePoint(39.6300, -4.0230), 4326)),
+    ('Milimani', 'estate', 'Kisumu', 'Kisumu', ST_SetSRID(ST_MakePoint(34.7530, -0.1040), 4326)),
+    ('Nyalenda', 'estate', 'Kisumu', 'Kisumu', ST_SetSRID(ST_MakePoint(34.7620, -0.1120), 4326)),
+    ('Kondele', 'estate', 'Kisumu', 'Kisumu', ST_SetSRID(ST_MakePoint(34.7730, -0.0880), 4326)),
+    ('Manyatta', 'estate', 'Kisumu', 'Kisumu', ST_SetSRID(ST_MakePoint(34.7700, -0.0950), 4326)),
+    ('Mamboleo', 'estate', 'Kisumu', 'Kisumu', ST_SetSRID(ST_MakePoint(34.7900, -0.0750), 4326)),
+    ('Milimani', 'estate', 'Nakuru', 'Nakuru', ST_SetSRID(ST_MakePoint(36.0680, -0.2880), 4326)),
+    ('Section 58', 'estate', 'Nakuru', 'Nakuru', ST_SetSRID(ST_MakePoint(36.0870, -0.2950), 4326)),
+    ('Lanet', 'estate', 'Nakuru', 'Nakuru', ST_SetSRID(ST_MakePoint(36.1480, -0.3010), 4326)),
+    ('Shabab', 'estate', 'Nakuru', 'Nakuru', ST_SetSRID(ST_MakePoint(36.0850, -0.2760), 4326)),
+    ('Elgon View', 'estate', 'Eldoret', 'Uasin Gishu', ST_SetSRID(ST_MakePoint(35.2800, 0.5050), 4326)),
+    ('Langas', 'estate', 'Eldoret', 'Uasin Gishu', ST_SetSRID(ST_MakePoint(35.2780, 0.4870), 4326)),
+    ('Kapsoya', 'estate', 'Eldoret', 'Uasin Gishu', ST_SetSRID(ST_MakePoint(35.3030, 0.5320), 4326)),
+    ('Makongeni', 'estate', 'Thika', 'Kiambu', ST_SetSRID(ST_MakePoint(37.0950, -1.0440), 4326)),
+    ('Section 9', 'estate', 'Thika', 'Kiambu', ST_SetSRID(ST_MakePoint(37.0800, -1.0330), 4326));
+
+-- Results of forward (normalized query) and reverse (rounded lat,lng)
+-- lookups, whichever provider answered them.
+CREATE TABLE geocode_cache (
+    kind TEXT NOT NULL CHECK (kind IN ('forward', 'reverse')),
+    key TEXT NOT NULL,
+    name TEXT NOT NULL,
+    address TEXT NOT NULL,
+    lat DOUBLE PRECISION NOT NULL,
+    lng DOUBLE PRECISION NOT NULL,
+    source TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (kind, key)
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_stores_point;
+
+ALTER TABLE stores
+    DROP COLUMN IF EXISTS service_radius_m,
+    DROP COLUMN IF EXISTS point;
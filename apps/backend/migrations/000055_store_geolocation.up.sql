@@ -0,0 +1,21 @@
+-- Geocoded store location and how far the store delivers. The point is
+-- NULL for stores whose location could not be resolved yet.
+ALTER TABLE stores
+    ADD COLUMN point GEOGRAPHY(POINT, 4326),
+    ADD COLUMN service_radius_m INT NOT NULL DEFAULT 10000
+        CHECK (service_radius_m BETWEEN 1 AND 100000);
+
+CREATE INDEX idx_stores_point ON stores USING GIST (point);
+
+-- Backfill stores whose location is a plain gazetteer name, preferring a
+-- town over an estate of the same name. The rest are located when their
+-- location is next updated.
+UPDATE stores s
+SET point = g.point
+FROM (
+    SELECT DISTINCT ON (name_normalized) name_normalized, point
+    FROM gazetteer
+    ORDER BY name_normalized, kind DESC
+) g
+WHERE s.point IS NULL
+  AND g.name_normalized = lower(btrim(s.location));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uniq_stores_slug;
+ALTER TABLE stores DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,36 @@
+-- Unique public name of a store, used by the storefront URLs. Existing
+-- stores get the slug of their normalized name, oldest first, the way
+-- CreateStore picks one: the bare slug if it is free, otherwise the lowest
+-- free numeric suffix from 2 up. A store named "Shop 2" thus doesn't clash
+-- with the second "Shop". "nearby" is a route of its own and never free.
+ALTER TABLE stores ADD COLUMN slug TEXT;
+CREATE UNIQUE INDEX uniq_stores_slug ON stores(slug);
+
+DO $$
+DECLARE
+    s RECORD;
+    candidate TEXT;
+    n INT;
+BEGIN
+    FOR s IN
+        SELECT id,
+            COALESCE(NULLIF(btrim(regexp_replace(
+                regexp_replace(lower(btrim(name_normalized)), '[^[:alnum:][:space:]-]', '', 'g'),
+                '[[:space:]-]+', '-', 'g'
+            ), '-'), ''), 'store') AS base
+        FROM stores
+        ORDER BY created_at, id
+    LOOP
+        candidate := s.base;
+        n := 2;
+        WHILE candidate = 'nearby' OR EXISTS (SELECT 1 FROM stores WHERE slug = candidate) LOOP
+            candidate := s.base || '-' || n;
+            n := n + 1;
+        END LOOP;
+
+        UPDATE stores SET slug = candidate WHERE id = s.id;
+    END LOOP;
+END
+$$;
+
+ALTER TABLE stores ALTER COLUMN slug SET NOT NULL;
@@ -0,0 +1,25 @@
+-- One row per sign-in. Access tokens carry the session ID, so revoking the
+-- session ends them together with its refresh tokens.
+CREATE TABLE auth_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ,
+    revoked_reason TEXT
+);
+
+CREATE INDEX idx_auth_sessions_user_id ON auth_sessions(user_id);
+
+-- Refresh tokens are stored as SHA-256 hashes. Each is exchanged once for a
+-- new one of the same session; used_at marks the exchange so a replay of the
+-- old token is recognised.
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES auth_sessions(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
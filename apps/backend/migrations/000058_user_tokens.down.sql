@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_tokens;
+
+ALTER TABLE users ALTER COLUMN must_change_password DROP NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
@@ -0,0 +1,18 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+
+UPDATE users SET must_change_password = FALSE WHERE must_change_password IS NULL;
+ALTER TABLE users ALTER COLUMN must_change_password SET NOT NULL;
+
+-- Single-use tokens mailed to users, e.g. to verify their email address or
+-- reset a forgotten password. Only the SHA-256 hash of a token is stored.
+CREATE TABLE user_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('email_verification', 'password_reset')),
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_user_tokens_user_purpose ON user_tokens(user_id, purpose) WHERE used_at IS NULL;
@@ -0,0 +1,15 @@
+DROP TABLE IF EXISTS store_members;
+
+ALTER TABLE invites
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS accepted_by,
+    DROP COLUMN IF EXISTS accepted_at,
+    DROP COLUMN IF EXISTS store_id;
+
+DELETE FROM invites WHERE role = 'merchant';
+ALTER TABLE invites DROP CONSTRAINT IF EXISTS invites_role_check;
+ALTER TABLE invites ADD CONSTRAINT invites_role_check
+    CHECK (role IN ('guest', 'customer', 'driver', 'admin'));
+
+-- Hashes can't be turned back into tokens, so the rename keeps them as is
+ALTER TABLE invites RENAME COLUMN token_hash TO token;
@@ -0,0 +1,25 @@
+-- Invite tokens were stored as given; keep only their SHA-256 hash, like
+-- the other single-use tokens.
+ALTER TABLE invites RENAME COLUMN token TO token_hash;
+UPDATE invites SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+ALTER TABLE invites DROP CONSTRAINT IF EXISTS invites_role_check;
+ALTER TABLE invites ADD CONSTRAINT invites_role_check
+    CHECK (role IN ('guest', 'customer', 'driver', 'merchant', 'admin'));
+
+-- Store staff invites name the store the invitee joins on acceptance
+ALTER TABLE invites
+    ADD COLUMN store_id UUID REFERENCES stores(id) ON DELETE CASCADE,
+    ADD COLUMN accepted_at TIMESTAMP,
+    ADD COLUMN accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN revoked_at TIMESTAMP;
+
+CREATE TABLE store_members (
+    store_id UUID NOT NULL REFERENCES stores(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_id UUID REFERENCES invites(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (store_id, user_id)
+);
+
+CREATE INDEX idx_store_members_user ON store_members(user_id);
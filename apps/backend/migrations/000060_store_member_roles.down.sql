@@ -0,0 +1,4 @@
+ALTER TABLE invites DROP CONSTRAINT IF EXISTS invites_store_role_check;
+ALTER TABLE invites DROP COLUMN IF EXISTS store_role;
+
+ALTER TABLE store_members DROP COLUMN IF EXISTS role;
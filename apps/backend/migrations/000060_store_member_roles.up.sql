@@ -0,0 +1,13 @@
+-- Staff roles decide what a member may do in a store; members who joined
+-- before roles existed become managers.
+ALTER TABLE store_members
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'manager'
+    CHECK (role IN ('manager', 'catalog_editor', 'fulfilment'));
+ALTER TABLE store_members ALTER COLUMN role DROP DEFAULT;
+
+-- Store invites name the role the invitee joins with
+ALTER TABLE invites
+    ADD COLUMN store_role TEXT CHECK (store_role IN ('manager', 'catalog_editor', 'fulfilment'));
+UPDATE invites SET store_role = 'manager' WHERE store_id IS NOT NULL;
+ALTER TABLE invites ADD CONSTRAINT invites_store_role_check
+    CHECK ((store_id IS NULL) = (store_role IS NULL));
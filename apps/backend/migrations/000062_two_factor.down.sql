@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS login_challenges;
+DROP TABLE IF EXISTS two_factor_requirements;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS user_two_factor;
@@ -0,0 +1,11 @@
+-- Recent failed password attempts per account email and per client IP. A run
+-- of failures slows further attempts down and locks an account for a while;
+-- the count starts over after a quiet period.
+CREATE TABLE login_throttles (
+    scope TEXT NOT NULL CHECK (scope IN ('account', 'ip')),
+    key TEXT NOT NULL,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (scope, key)
+);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_users_customer_phone;
+DROP INDEX IF EXISTS users_guest_phone_key;
+
+-- Fails while guest customers without an email address remain
+DROP INDEX IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+DROP TABLE IF EXISTS phone_codes;
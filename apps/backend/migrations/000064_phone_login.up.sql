@@ -0,0 +1,27 @@
+-- Codes texted to customers signing in by phone. Only the last code sent to
+-- a number is valid; older rows are kept for the rate limits.
+CREATE TABLE phone_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    phone TEXT NOT NULL,
+    code_hash TEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX idx_phone_codes_phone ON phone_codes(phone, created_at);
+CREATE INDEX idx_phone_codes_ip ON phone_codes(ip, created_at);
+
+-- Guest customers created by their first sign-in by phone have no email
+-- address (nor password) until they add one, so only real addresses need to
+-- be unique. Each number gets at most one guest.
+ALTER TABLE users DROP CONSTRAINT users_email_key;
+CREATE UNIQUE INDEX users_email_key ON users(email) WHERE email <> '';
+CREATE UNIQUE INDEX users_guest_phone_key ON users(phone) WHERE role = 'customer' AND email = '';
+
+-- Customers are found by their number however it was typed in, normalised to
+-- +2547XXXXXXXX like user.NormalizePhone does.
+CREATE INDEX idx_users_customer_phone ON users (
+    ('+' || regexp_replace(regexp_replace(phone, '\D', '', 'g'), '^0?([17]\d{8})$', '254\1'))
+) WHERE role = 'customer';
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS users_verified_phone_key;
+
+CREATE INDEX idx_users_customer_phone ON users (
+    ('+' || regexp_replace(regexp_replace(phone, '\D', '', 'g'), '^0?([17]\d{8})$', '254\1'))
+) WHERE role = 'customer';
+CREATE UNIQUE INDEX users_guest_phone_key ON users(phone) WHERE role = 'customer' AND email = '';
+
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified_at;
@@ -0,0 +1,11 @@
+-- Signing in by phone only reaches the customer who proved the number with a
+-- texted code. Numbers typed into a profile are never trusted for sign-in.
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMPTZ;
+
+-- Guests only ever came from a sign-in by phone
+UPDATE users SET phone_verified_at = created_at WHERE role = 'customer' AND email = '';
+
+-- A verified number, stored normalised, belongs to one customer
+DROP INDEX IF EXISTS users_guest_phone_key;
+DROP INDEX IF EXISTS idx_users_customer_phone;
+CREATE UNIQUE INDEX users_verified_phone_key ON users(phone) WHERE role = 'customer' AND phone_verified_at IS NOT NULL;
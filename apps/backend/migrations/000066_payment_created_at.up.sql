@@ -0,0 +1,5 @@
+-- When a payment was started, the tie-break for an order's latest payment
+-- among those never paid. Older rows only have paid_at to go on.
+ALTER TABLE payments ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+UPDATE payments SET created_at = paid_at WHERE paid_at IS NOT NULL;
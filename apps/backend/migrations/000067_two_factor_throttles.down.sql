@@ -0,0 +1,4 @@
+DELETE FROM login_throttles WHERE scope = 'two_factor';
+ALTER TABLE login_throttles DROP CONSTRAINT login_throttles_scope_check;
+ALTER TABLE login_throttles ADD CONSTRAINT login_throttles_scope_check
+    CHECK (scope IN ('account', 'ip'));
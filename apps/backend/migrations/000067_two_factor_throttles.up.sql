@@ -0,0 +1,5 @@
+-- Wrong codes a signed-in user gives to turn two-factor authentication off
+-- or replace their recovery codes, keyed by user ID
+ALTER TABLE login_throttles DROP CONSTRAINT login_throttles_scope_check;
+ALTER TABLE login_throttles ADD CONSTRAINT login_throttles_scope_check
+    CHECK (scope IN ('account', 'ip', 'two_factor'));